@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/PinataCloud/ipfs-cli/internal/api"
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// outputFormat is set from the global --output flag. It starts from the
+// environment so errors raised before the flags are parsed honour it too.
+var outputFormat = os.Getenv("PINATA_OUTPUT")
+
+// errorEnvelope is written to stderr for failures when --output json is set
+type errorEnvelope struct {
+	Error errorDetail `json:"error"`
+}
+
+type errorDetail struct {
+	Code      string `json:"code"`
+	Status    int    `json:"status,omitempty"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// exitWithError reports err on stderr and exits with the code for its category
+func exitWithError(err error) {
+	if outputFormat == outputJSON {
+		data, marshalErr := json.Marshal(newErrorEnvelope(err))
+		if marshalErr == nil {
+			fmt.Fprintln(os.Stderr, string(data))
+			os.Exit(exitcode.For(err))
+		}
+	}
+	log.Println(err)
+	os.Exit(exitcode.For(err))
+}
+
+// newErrorEnvelope describes err for --output json
+func newErrorEnvelope(err error) errorEnvelope {
+	detail := errorDetail{
+		Code:    exitcode.CodeOf(err),
+		Message: err.Error(),
+	}
+	var apiErr *api.Error
+	if errors.As(err, &apiErr) {
+		detail.Status = apiErr.Status
+		detail.RequestID = apiErr.RequestID
+	}
+	return errorEnvelope{Error: detail}
+}
+
+// classifyError marks the errors raised by the CLI framework itself
+func classifyError(err error) error {
+	// urfave/cli doesn't export the type of its missing flag error
+	if err != nil && strings.HasPrefix(err.Error(), "Required flag") {
+		return exitcode.AsInvalidInput(err)
+	}
+	return err
+}
+
+// onUsageError marks flag parsing errors as invalid input. It prints the same
+// help as urfave/cli does without a handler, unless the output is JSON.
+func onUsageError(ctx *cli.Context, err error, isSubcommand bool) error {
+	if outputFormat != outputJSON {
+		fmt.Fprintf(ctx.App.Writer, "Incorrect Usage: %s\n\n", err)
+		if lineage := ctx.Lineage(); isSubcommand && len(lineage) > 1 {
+			_ = cli.ShowCommandHelp(lineage[1], ctx.Command.Name)
+		} else {
+			_ = cli.ShowAppHelp(ctx)
+		}
+	}
+	return exitcode.AsInvalidInput(err)
+}
+
+// setUsageErrorHandler installs onUsageError on every command
+func setUsageErrorHandler(commands []*cli.Command) {
+	for _, command := range commands {
+		command.OnUsageError = onUsageError
+		setUsageErrorHandler(command.Subcommands)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+)
+
+func TestErrorEnvelope(t *testing.T) {
+	notFound := &pinata.Error{Status: http.StatusNotFound, Message: "File not found", RequestID: "req-1"}
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			"API error",
+			fmt.Errorf("failed to get file: %w", notFound),
+			`{"error":{"code":"not_found","status":404,"message":"failed to get file: server returned an error 404 Not Found: File not found (request ID req-1)","request_id":"req-1"}}`,
+		},
+		{
+			"API error without an ID",
+			&pinata.Error{Status: http.StatusServiceUnavailable},
+			`{"error":{"code":"server_error","status":503,"message":"server returned an error 503 Service Unavailable"}}`,
+		},
+		{
+			"invalid input",
+			exitcode.InvalidInputf("--parallel must be at least 1"),
+			`{"error":{"code":"invalid_input","message":"--parallel must be at least 1"}}`,
+		},
+		{
+			"plain",
+			errors.New("failed"),
+			`{"error":{"code":"error","message":"failed"}}`,
+		},
+		{
+			"interrupted",
+			fmt.Errorf("upload: %w", context.Canceled),
+			`{"error":{"code":"interrupted","message":"upload: context canceled"}}`,
+		},
+		{
+			"joined",
+			errors.Join(errors.New("a.txt: failed"), notFound),
+			`{"error":{"code":"not_found","status":404,"message":"a.txt: failed\nserver returned an error 404 Not Found: File not found (request ID req-1)","request_id":"req-1"}}`,
+		},
+	}
+	for _, tt := range tests {
+		data, err := json.Marshal(newErrorEnvelope(tt.err))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tt.want {
+			t.Errorf("%s:\n got %s\nwant %s", tt.name, data, tt.want)
+		}
+	}
+}
+
+func TestCLIErrorEnvelope(t *testing.T) {
+	c := newCLI(t)
+	tests := []struct {
+		args   []string
+		code   string
+		status int
+		exit   int
+	}{
+		{[]string{"--output", "json", "files", "get", "e59d6704-093c-454c-9774-86afc5bec00b"}, exitcode.CodeNotFound, http.StatusNotFound, exitcode.NotFound},
+		{[]string{"--output", "json", "upload", "--parallel", "0", writeTestFile(t, "a.txt", []byte("a"))}, exitcode.CodeInvalidInput, 0, exitcode.InvalidInput},
+		{[]string{"--output", "json", "files", "list", "--no-such-flag"}, exitcode.CodeInvalidInput, 0, exitcode.InvalidInput},
+	}
+	for _, tt := range tests {
+		stdout, stderr, exit := c.run(tt.args...)
+		args := strings.Join(tt.args, " ")
+		if exit != tt.exit {
+			t.Errorf("pinata %s exited with %d, want %d", args, exit, tt.exit)
+		}
+		// stderr holds the envelope alone, so it can be parsed as is
+		var envelope errorEnvelope
+		decoder := json.NewDecoder(strings.NewReader(stderr))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&envelope); err != nil || decoder.More() {
+			t.Errorf("pinata %s wrote %q to stderr, want one envelope", args, stderr)
+			continue
+		}
+		detail := envelope.Error
+		if detail.Code != tt.code || detail.Status != tt.status || detail.Message == "" {
+			t.Errorf("pinata %s gave %+v", args, detail)
+		}
+		// Only API errors have a request ID
+		if (detail.RequestID != "") != (tt.status != 0) {
+			t.Errorf("pinata %s gave request ID %q", args, detail.RequestID)
+		}
+		if tt.code == exitcode.CodeInvalidInput && stdout != "" {
+			t.Errorf("pinata %s printed usage %q with --output json", args, stdout)
+		}
+	}
+}
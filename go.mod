@@ -1,4 +1,4 @@
-module pinata
+module github.com/PinataCloud/ipfs-cli
 
 go 1.25.0
 
@@ -4,31 +4,30 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
+	"github.com/PinataCloud/ipfs-cli/internal/api"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
 )
 
 // ListAgents retrieves all agents for the authenticated user.
-func ListAgents() ([]Agent, error) {
-	var response AgentListResponse
-	err := doJSON(http.MethodGet, "", nil, &response)
+func ListAgents() ([]pinata.Agent, error) {
+	agents, err := api.Client().ListAgents(api.Context())
 	if err != nil {
 		return nil, err
 	}
 
-	formattedJSON, err := json.MarshalIndent(response.Agents, "", "    ")
+	formattedJSON, err := json.MarshalIndent(agents, "", "    ")
 	if err != nil {
 		return nil, errors.New("failed to format JSON")
 	}
 
 	fmt.Println(string(formattedJSON))
 
-	return response.Agents, nil
+	return agents, nil
 }
 
 // CreateAgent creates a new agent with the specified parameters.
-func CreateAgent(name, description, vibe, emoji, templateID string, skillCids, secretIds []string) (*CreateAgentResponse, error) {
-	body := CreateAgentBody{
+func CreateAgent(name, description, vibe, emoji, templateID string, skillCids, secretIds []string) (*pinata.CreateAgentResponse, error) {
+	body := pinata.CreateAgentBody{
 		Name:        name,
 		Description: description,
 		Vibe:        vibe,
@@ -38,8 +37,7 @@ func CreateAgent(name, description, vibe, emoji, templateID string, skillCids, s
 		TemplateID:  templateID,
 	}
 
-	var response CreateAgentResponse
-	err := doJSON(http.MethodPost, "", body, &response)
+	response, err := api.Client().CreateAgent(api.Context(), body)
 	if err != nil {
 		return nil, err
 	}
@@ -51,13 +49,12 @@ func CreateAgent(name, description, vibe, emoji, templateID string, skillCids, s
 
 	fmt.Println(string(formattedJSON))
 
-	return &response, nil
+	return response, nil
 }
 
 // GetAgent retrieves detailed information about a specific agent.
-func GetAgent(agentID string) (*AgentDetailResponse, error) {
-	var response AgentDetailResponse
-	err := doJSON(http.MethodGet, "/"+agentID, nil, &response)
+func GetAgent(agentID string) (*pinata.AgentDetailResponse, error) {
+	response, err := api.Client().GetAgent(api.Context(), agentID)
 	if err != nil {
 		return nil, err
 	}
@@ -69,13 +66,12 @@ func GetAgent(agentID string) (*AgentDetailResponse, error) {
 
 	fmt.Println(string(formattedJSON))
 
-	return &response, nil
+	return response, nil
 }
 
 // DeleteAgent deletes an agent by ID.
 func DeleteAgent(agentID string) error {
-	var response DeleteAgentResponse
-	err := doJSON(http.MethodDelete, "/"+agentID, nil, &response)
+	_, err := api.Client().DeleteAgent(api.Context(), agentID)
 	if err != nil {
 		return err
 	}
@@ -86,9 +82,8 @@ func DeleteAgent(agentID string) error {
 }
 
 // RestartAgent restarts an agent by ID.
-func RestartAgent(agentID string) (*RestartResponse, error) {
-	var response RestartResponse
-	err := doJSON(http.MethodPost, "/"+agentID+"/restart", nil, &response)
+func RestartAgent(agentID string) (*pinata.RestartResponse, error) {
+	response, err := api.Client().RestartAgent(api.Context(), agentID)
 	if err != nil {
 		return nil, err
 	}
@@ -100,29 +95,17 @@ func RestartAgent(agentID string) (*RestartResponse, error) {
 
 	fmt.Println(string(formattedJSON))
 
-	return &response, nil
+	return response, nil
 }
 
 // GetAgentLogs retrieves logs for a specific agent.
 func GetAgentLogs(agentID string) (string, error) {
-	resp, err := doRequest(http.MethodGet, "/"+agentID+"/logs", nil)
+	logs, err := api.Client().AgentLogs(api.Context(), agentID)
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("server returned error %d: %s", resp.StatusCode, string(bodyBytes))
-	}
-
-	var response LogsResponse
-	err = json.NewDecoder(resp.Body).Decode(&response)
-	if err != nil {
-		return "", errors.Join(err, errors.New("failed to decode response"))
-	}
-
-	fmt.Println(response.Logs)
+	fmt.Println(logs)
 
-	return response.Logs, nil
+	return logs, nil
 }
@@ -2,8 +2,8 @@ package agents
 
 import (
 	"fmt"
-	"net/http"
-	"pinata/internal/utils"
+	"github.com/PinataCloud/ipfs-cli/internal/api"
+	"github.com/PinataCloud/ipfs-cli/internal/utils"
 )
 
 // CredentialLogin prompts the user for a credential and stores it as a secret.
@@ -22,17 +22,7 @@ func CredentialLogin(prompt, secretName string) error {
 }
 
 
-// upsertSecret creates or updates a secret by name
+// UpsertSecret creates or updates a secret by name
 func UpsertSecret(name, value string) error {
-	var list SecretListResponse
-	if err := doSecretsJSON(http.MethodGet, "", nil, &list); err != nil {
-		return fmt.Errorf("failed to list secrets: %w", err)
-	}
-	for _, s := range list.Secrets {
-		if s.Name == name {
-			return doSecretsJSON(http.MethodPut, "/"+s.ID, UpdateSecretBody{Value: value}, nil)
-		}
-	}
-	
-	return doSecretsJSON(http.MethodPost, "", CreateSecretBody{Name: name, Value: value}, nil)
+	return api.Client().UpsertSecret(api.Context(), name, value)
 }
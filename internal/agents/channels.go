@@ -4,13 +4,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
+	"github.com/PinataCloud/ipfs-cli/internal/api"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
 )
 
 // GetChannelStatus retrieves the channel configuration status for an agent.
-func GetChannelStatus(agentID string) (*ChannelStatusResponse, error) {
-	var response ChannelStatusResponse
-	err := doJSON(http.MethodGet, "/"+agentID+"/channels", nil, &response)
+func GetChannelStatus(agentID string) (*pinata.ChannelStatusResponse, error) {
+	response, err := api.Client().ChannelStatus(api.Context(), agentID)
 	if err != nil {
 		return nil, err
 	}
@@ -22,21 +22,20 @@ func GetChannelStatus(agentID string) (*ChannelStatusResponse, error) {
 
 	fmt.Println(string(formattedJSON))
 
-	return &response, nil
+	return response, nil
 }
 
 // ConfigureChannel configures a specific channel for an agent.
 // channel must be one of: telegram, slack, discord, whatsapp
 func ConfigureChannel(agentID, channel string, botToken, appToken, dmPolicy string, allowFrom []string) error {
-	body := ConfigureChannelBody{
+	body := pinata.ConfigureChannelBody{
 		BotToken:  botToken,
 		AppToken:  appToken,
 		DmPolicy:  dmPolicy,
 		AllowFrom: allowFrom,
 	}
 
-	var response ConfigureChannelResponse
-	err := doJSON(http.MethodPost, "/"+agentID+"/channels/"+channel, body, &response)
+	response, err := api.Client().ConfigureChannel(api.Context(), agentID, channel, body)
 	if err != nil {
 		return err
 	}
@@ -54,7 +53,7 @@ func ConfigureChannel(agentID, channel string, botToken, appToken, dmPolicy stri
 // RemoveChannel removes a channel configuration from an agent.
 // channel must be one of: telegram, slack, discord, whatsapp
 func RemoveChannel(agentID, channel string) error {
-	err := doJSON(http.MethodDelete, "/"+agentID+"/channels/"+channel, nil, nil)
+	err := api.Client().RemoveChannel(api.Context(), agentID, channel)
 	if err != nil {
 		return err
 	}
@@ -2,15 +2,12 @@ package chat
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"hash/fnv"
-	"net/http"
 	"os"
 	"strings"
 
-	"pinata/internal/common"
-	"pinata/internal/config"
+	"github.com/PinataCloud/ipfs-cli/internal/api"
 
 	"charm.land/bubbles/v2/spinner"
 	"charm.land/bubbles/v2/viewport"
@@ -974,46 +971,9 @@ func getEnv(key, fallback string) string {
 
 // fetchAgentInfo fetches agent details from the API.
 func fetchAgentInfo(agentID string) (*AgentInfo, error) {
-	jwt, err := common.FindToken()
+	result, err := api.Client().GetAgent(api.Context(), agentID)
 	if err != nil {
-		return nil, fmt.Errorf("not authenticated: %w", err)
-	}
-
-	url := fmt.Sprintf("https://%s/v0/agents/%s", config.GetAgentsHost(), agentID)
-
-	req, err := http.NewRequest(http.MethodGet, url, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Set("Authorization", "Bearer "+string(jwt))
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to get agent: status %d", resp.StatusCode)
-	}
-
-	// Parse response
-	var result struct {
-		Agent struct {
-			AgentID      string  `json:"agentId"`
-			Name         string  `json:"name"`
-			Description  *string `json:"description"`
-			Emoji        *string `json:"emoji"`
-			GatewayToken string  `json:"gatewayToken"`
-			Status       string  `json:"status"`
-		} `json:"agent"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+		return nil, fmt.Errorf("failed to get agent: %w", err)
 	}
 
 	info := &AgentInfo{
@@ -13,6 +13,9 @@ import (
 	"os/exec"
 	"runtime"
 	"time"
+
+	"github.com/PinataCloud/ipfs-cli/internal/api"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
 )
 
 const (
@@ -91,17 +94,16 @@ func exchangeCodexToken(code, verifier string) (*codexTokenResponse, error) {
 		"client_id":     {codexClientID},
 		"code_verifier": {verifier},
 	}
-	resp, err := http.PostForm(codexTokenURL, params)
-	if err != nil {
-		return nil, fmt.Errorf("token exchange request failed: %w", err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("token exchange failed with status %d", resp.StatusCode)
-	}
 	var tokens codexTokenResponse
-	if err := json.NewDecoder(resp.Body).Decode(&tokens); err != nil {
-		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	err := api.Do(api.Context(), &api.Request{
+		Method:      http.MethodPost,
+		URL:         codexTokenURL,
+		Body:        []byte(params.Encode()),
+		ContentType: "application/x-www-form-urlencoded",
+		NoAuth:      true,
+	}, &tokens)
+	if err != nil {
+		return nil, fmt.Errorf("token exchange failed: %w", err)
 	}
 	return &tokens, nil
 }
@@ -116,7 +118,7 @@ type codexBundle struct {
 
 // CodexOAuthLogin runs the PKCE browser flow, stores the full OAuth bundle in
 // the agents API (access token + refresh token + expiry), and caches it locally.
-func CodexOAuthLogin() (*CreateSecretResponse, error) {
+func CodexOAuthLogin() (*pinata.CreateSecretResponse, error) {
 	verifier, err := generateCodeVerifier()
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate PKCE verifier: %w", err)
@@ -4,13 +4,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
+	"github.com/PinataCloud/ipfs-cli/internal/api"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
 )
 
 // GetConfig retrieves the openclaw config for a specific agent.
-func GetConfig(agentID string) (*ConfigResponse, error) {
-	var response ConfigResponse
-	err := doJSON(http.MethodGet, "/"+agentID+"/config", nil, &response)
+func GetConfig(agentID string) (*pinata.ConfigResponse, error) {
+	response, err := api.Client().AgentConfig(api.Context(), agentID)
 	if err != nil {
 		return nil, err
 	}
@@ -22,17 +22,12 @@ func GetConfig(agentID string) (*ConfigResponse, error) {
 
 	fmt.Println(string(formattedJSON))
 
-	return &response, nil
+	return response, nil
 }
 
 // SetConfig updates the openclaw config for a specific agent.
 func SetConfig(agentID string, configData interface{}) error {
-	body := UpdateConfigBody{
-		Config: configData,
-	}
-
-	var response map[string]interface{}
-	err := doJSON(http.MethodPut, "/"+agentID+"/config", body, &response)
+	response, err := api.Client().SetAgentConfig(api.Context(), agentID, configData)
 	if err != nil {
 		return err
 	}
@@ -48,9 +43,8 @@ func SetConfig(agentID string, configData interface{}) error {
 }
 
 // ValidateConfig validates the openclaw config for a specific agent.
-func ValidateConfig(agentID string) (*ValidateConfigResponse, error) {
-	var response ValidateConfigResponse
-	err := doJSON(http.MethodPost, "/"+agentID+"/config/validate", nil, &response)
+func ValidateConfig(agentID string) (*pinata.ValidateConfigResponse, error) {
+	response, err := api.Client().ValidateAgentConfig(api.Context(), agentID)
 	if err != nil {
 		return nil, err
 	}
@@ -62,13 +56,12 @@ func ValidateConfig(agentID string) (*ValidateConfigResponse, error) {
 
 	fmt.Println(string(formattedJSON))
 
-	return &response, nil
+	return response, nil
 }
 
 // CheckUpdate checks if there's an openclaw update available for an agent.
-func CheckUpdate(agentID string) (*UpdateCheckResponse, error) {
-	var response UpdateCheckResponse
-	err := doJSON(http.MethodGet, "/"+agentID+"/update", nil, &response)
+func CheckUpdate(agentID string) (*pinata.UpdateCheckResponse, error) {
+	response, err := api.Client().CheckAgentUpdate(api.Context(), agentID)
 	if err != nil {
 		return nil, err
 	}
@@ -80,18 +73,12 @@ func CheckUpdate(agentID string) (*UpdateCheckResponse, error) {
 
 	fmt.Println(string(formattedJSON))
 
-	return &response, nil
+	return response, nil
 }
 
 // ApplyUpdate applies an openclaw update to an agent.
-func ApplyUpdate(agentID, tag string) (*UpdateApplyResponse, error) {
-	var body *UpdateApplyBody
-	if tag != "" {
-		body = &UpdateApplyBody{Tag: tag}
-	}
-
-	var response UpdateApplyResponse
-	err := doJSON(http.MethodPost, "/"+agentID+"/update", body, &response)
+func ApplyUpdate(agentID, tag string) (*pinata.UpdateApplyResponse, error) {
+	response, err := api.Client().ApplyAgentUpdate(api.Context(), agentID, tag)
 	if err != nil {
 		return nil, err
 	}
@@ -103,13 +90,12 @@ func ApplyUpdate(agentID, tag string) (*UpdateApplyResponse, error) {
 
 	fmt.Println(string(formattedJSON))
 
-	return &response, nil
+	return response, nil
 }
 
 // GetAvailableVersions retrieves available agent versions.
-func GetAvailableVersions(agentID string) (*VersionsResponse, error) {
-	var response VersionsResponse
-	err := doJSON(http.MethodGet, "/"+agentID+"/available-agent-versions", nil, &response)
+func GetAvailableVersions(agentID string) (*pinata.VersionsResponse, error) {
+	response, err := api.Client().AgentVersions(api.Context(), agentID)
 	if err != nil {
 		return nil, err
 	}
@@ -121,5 +107,5 @@ func GetAvailableVersions(agentID string) (*VersionsResponse, error) {
 
 	fmt.Println(string(formattedJSON))
 
-	return &response, nil
+	return response, nil
 }
@@ -4,20 +4,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
+	"github.com/PinataCloud/ipfs-cli/internal/api"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
 )
 
 // ExecCommand executes a command on the agent's console.
-func ExecCommand(agentID, command, cwd string) (*ExecResponse, error) {
-	body := ExecRequest{
-		Command: command,
-		Cwd:     cwd,
-	}
-
-	var response ExecResponse
-	err := doJSON(http.MethodPost, "/"+agentID+"/console/exec", body, &response)
+func ExecCommand(agentID, command, cwd string) (*pinata.ExecResponse, error) {
+	response, err := api.Client().ExecCommand(api.Context(), agentID, command, cwd)
 	if err != nil {
 		return nil, err
 	}
@@ -29,27 +22,15 @@ func ExecCommand(agentID, command, cwd string) (*ExecResponse, error) {
 
 	fmt.Println(string(formattedJSON))
 
-	return &response, nil
+	return response, nil
 }
 
 // ReadFile reads a file from the agent's filesystem.
 func ReadFile(agentID, filePath string) ([]byte, error) {
-	encodedPath := url.QueryEscape(filePath)
-	resp, err := doRequest(http.MethodGet, "/"+agentID+"/files?path="+encodedPath, nil)
+	content, err := api.Client().ReadAgentFile(api.Context(), agentID, filePath)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("server returned error %d: %s", resp.StatusCode, string(bodyBytes))
-	}
-
-	content, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, errors.Join(err, errors.New("failed to read response body"))
-	}
 
 	fmt.Println(string(content))
 
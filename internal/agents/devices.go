@@ -4,13 +4,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
+	"github.com/PinataCloud/ipfs-cli/internal/api"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
 )
 
 // ListDevices retrieves all devices for a specific agent.
-func ListDevices(agentID string) (*DeviceListResponse, error) {
-	var response DeviceListResponse
-	err := doJSON(http.MethodGet, "/"+agentID+"/devices", nil, &response)
+func ListDevices(agentID string) (*pinata.DeviceListResponse, error) {
+	response, err := api.Client().ListDevices(api.Context(), agentID)
 	if err != nil {
 		return nil, err
 	}
@@ -22,13 +22,12 @@ func ListDevices(agentID string) (*DeviceListResponse, error) {
 
 	fmt.Println(string(formattedJSON))
 
-	return &response, nil
+	return response, nil
 }
 
 // ApproveDevice approves a pending device pairing request.
 func ApproveDevice(agentID, requestID string) error {
-	var response ApproveDeviceResponse
-	err := doJSON(http.MethodPost, "/"+agentID+"/devices/"+requestID+"/approve", nil, &response)
+	_, err := api.Client().ApproveDevice(api.Context(), agentID, requestID)
 	if err != nil {
 		return err
 	}
@@ -39,9 +38,8 @@ func ApproveDevice(agentID, requestID string) error {
 }
 
 // ApproveAllDevices approves all pending device pairing requests.
-func ApproveAllDevices(agentID string) (*ApproveAllResponse, error) {
-	var response ApproveAllResponse
-	err := doJSON(http.MethodPost, "/"+agentID+"/devices/approve-all", nil, &response)
+func ApproveAllDevices(agentID string) (*pinata.ApproveAllResponse, error) {
+	response, err := api.Client().ApproveAllDevices(api.Context(), agentID)
 	if err != nil {
 		return nil, err
 	}
@@ -53,5 +51,5 @@ func ApproveAllDevices(agentID string) (*ApproveAllResponse, error) {
 
 	fmt.Println(string(formattedJSON))
 
-	return &response, nil
+	return response, nil
 }
@@ -4,13 +4,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
+	"github.com/PinataCloud/ipfs-cli/internal/api"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
 )
 
 // ListDomains retrieves all custom domains for a specific agent.
-func ListDomains(agentID string) (*CustomDomainListResponse, error) {
-	var response CustomDomainListResponse
-	err := doJSON(http.MethodGet, "/"+agentID+"/domains", nil, &response)
+func ListDomains(agentID string) (*pinata.CustomDomainListResponse, error) {
+	response, err := api.Client().ListDomains(api.Context(), agentID)
 	if err != nil {
 		return nil, err
 	}
@@ -22,20 +22,19 @@ func ListDomains(agentID string) (*CustomDomainListResponse, error) {
 
 	fmt.Println(string(formattedJSON))
 
-	return &response, nil
+	return response, nil
 }
 
 // CreateDomain registers a new custom domain for an agent.
-func CreateDomain(agentID string, subdomain, customDomain string, targetPort int, protected bool) (*CreateCustomDomainResponse, error) {
-	body := CreateCustomDomainBody{
+func CreateDomain(agentID string, subdomain, customDomain string, targetPort int, protected bool) (*pinata.CreateCustomDomainResponse, error) {
+	body := pinata.CreateCustomDomainBody{
 		Subdomain:    subdomain,
 		CustomDomain: customDomain,
 		TargetPort:   targetPort,
 		Protected:    protected,
 	}
 
-	var response CreateCustomDomainResponse
-	err := doJSON(http.MethodPost, "/"+agentID+"/domains", body, &response)
+	response, err := api.Client().CreateDomain(api.Context(), agentID, body)
 	if err != nil {
 		return nil, err
 	}
@@ -47,20 +46,19 @@ func CreateDomain(agentID string, subdomain, customDomain string, targetPort int
 
 	fmt.Println(string(formattedJSON))
 
-	return &response, nil
+	return response, nil
 }
 
 // UpdateDomain updates an existing custom domain mapping.
-func UpdateDomain(agentID, domainID string, subdomain, customDomain string, targetPort *int, protected *bool) (*UpdateCustomDomainResponse, error) {
-	body := UpdateCustomDomainBody{
+func UpdateDomain(agentID, domainID string, subdomain, customDomain string, targetPort *int, protected *bool) (*pinata.UpdateCustomDomainResponse, error) {
+	body := pinata.UpdateCustomDomainBody{
 		Subdomain:    subdomain,
 		CustomDomain: customDomain,
 		TargetPort:   targetPort,
 		Protected:    protected,
 	}
 
-	var response UpdateCustomDomainResponse
-	err := doJSON(http.MethodPut, "/"+agentID+"/domains/"+domainID, body, &response)
+	response, err := api.Client().UpdateDomain(api.Context(), agentID, domainID, body)
 	if err != nil {
 		return nil, err
 	}
@@ -72,13 +70,12 @@ func UpdateDomain(agentID, domainID string, subdomain, customDomain string, targ
 
 	fmt.Println(string(formattedJSON))
 
-	return &response, nil
+	return response, nil
 }
 
 // DeleteDomain removes a custom domain mapping.
-func DeleteDomain(agentID, domainID string) (*DeleteCustomDomainResponse, error) {
-	var response DeleteCustomDomainResponse
-	err := doJSON(http.MethodDelete, "/"+agentID+"/domains/"+domainID, nil, &response)
+func DeleteDomain(agentID, domainID string) (*pinata.DeleteCustomDomainResponse, error) {
+	response, err := api.Client().DeleteDomain(api.Context(), agentID, domainID)
 	if err != nil {
 		return nil, err
 	}
@@ -90,5 +87,5 @@ func DeleteDomain(agentID, domainID string) (*DeleteCustomDomainResponse, error)
 
 	fmt.Println(string(formattedJSON))
 
-	return &response, nil
+	return response, nil
 }
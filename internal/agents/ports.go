@@ -4,13 +4,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
+	"github.com/PinataCloud/ipfs-cli/internal/api"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
 )
 
 // ListPorts retrieves the port forwarding rules for a specific agent.
-func ListPorts(agentID string) (*PortForwardingResponse, error) {
-	var response PortForwardingResponse
-	err := doJSON(http.MethodGet, "/"+agentID+"/port-forwarding", nil, &response)
+func ListPorts(agentID string) (*pinata.PortForwardingResponse, error) {
+	response, err := api.Client().ListPorts(api.Context(), agentID)
 	if err != nil {
 		return nil, err
 	}
@@ -22,17 +22,12 @@ func ListPorts(agentID string) (*PortForwardingResponse, error) {
 
 	fmt.Println(string(formattedJSON))
 
-	return &response, nil
+	return response, nil
 }
 
 // UpdatePorts updates the port forwarding rules for a specific agent.
-func UpdatePorts(agentID string, mappings []PortForwarding) (*UpdatePortForwardingResponse, error) {
-	body := UpdatePortForwardingBody{
-		Mappings: mappings,
-	}
-
-	var response UpdatePortForwardingResponse
-	err := doJSON(http.MethodPut, "/"+agentID+"/port-forwarding", body, &response)
+func UpdatePorts(agentID string, mappings []pinata.PortForwarding) (*pinata.UpdatePortForwardingResponse, error) {
+	response, err := api.Client().UpdatePorts(api.Context(), agentID, mappings)
 	if err != nil {
 		return nil, err
 	}
@@ -44,5 +39,5 @@ func UpdatePorts(agentID string, mappings []PortForwarding) (*UpdatePortForwardi
 
 	fmt.Println(string(formattedJSON))
 
-	return &response, nil
+	return response, nil
 }
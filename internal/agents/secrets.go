@@ -1,105 +1,33 @@
 package agents
 
 import (
-	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"pinata/internal/common"
-	"pinata/internal/config"
+	"github.com/PinataCloud/ipfs-cli/internal/api"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
 )
 
-// buildSecretsURL constructs the full URL for a Secrets API endpoint
-func buildSecretsURL(path string) string {
-	return fmt.Sprintf("https://%s/%s/secrets%s", config.GetAgentsHost(), apiVersion, path)
-}
-
-// doSecretsRequest makes an authenticated HTTP request to the Secrets API
-func doSecretsRequest(method, path string, body interface{}) (*http.Response, error) {
-	jwt, err := common.FindToken()
-	if err != nil {
-		return nil, err
-	}
-
-	var reqBody io.Reader
-	if body != nil {
-		jsonPayload, err := json.Marshal(body)
-		if err != nil {
-			return nil, errors.Join(err, errors.New("failed to marshal request body"))
-		}
-		reqBody = bytes.NewBuffer(jsonPayload)
-	}
-
-	url := buildSecretsURL(path)
-	req, err := http.NewRequest(method, url, reqBody)
-	if err != nil {
-		return nil, errors.Join(err, errors.New("failed to create the request"))
-	}
-
-	req.Header.Set("Authorization", "Bearer "+string(jwt))
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, errors.Join(err, errors.New("failed to send the request"))
-	}
-
-	return resp, nil
-}
-
-// doSecretsJSON makes an authenticated request to the Secrets API and decodes the JSON response
-func doSecretsJSON(method, path string, body interface{}, result interface{}) error {
-	resp, err := doSecretsRequest(method, path, body)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("server returned error %d: %s", resp.StatusCode, string(bodyBytes))
-	}
-
-	if result != nil {
-		err = json.NewDecoder(resp.Body).Decode(result)
-		if err != nil {
-			return errors.Join(err, errors.New("failed to decode response"))
-		}
-	}
-
-	return nil
-}
-
 // ListSecrets retrieves all secrets for the authenticated user.
-func ListSecrets() ([]SecretWithAgents, error) {
-	var response SecretListResponse
-	err := doSecretsJSON(http.MethodGet, "", nil, &response)
+func ListSecrets() ([]pinata.SecretWithAgents, error) {
+	secrets, err := api.Client().ListSecrets(api.Context())
 	if err != nil {
 		return nil, err
 	}
 
-	formattedJSON, err := json.MarshalIndent(response.Secrets, "", "    ")
+	formattedJSON, err := json.MarshalIndent(secrets, "", "    ")
 	if err != nil {
 		return nil, errors.New("failed to format JSON")
 	}
 
 	fmt.Println(string(formattedJSON))
 
-	return response.Secrets, nil
+	return secrets, nil
 }
 
 // CreateSecret creates a new secret with the specified name and value.
-func CreateSecret(name, value string) (*CreateSecretResponse, error) {
-	body := CreateSecretBody{
-		Name:  name,
-		Value: value,
-	}
-
-	var response CreateSecretResponse
-	err := doSecretsJSON(http.MethodPost, "", body, &response)
+func CreateSecret(name, value string) (*pinata.CreateSecretResponse, error) {
+	response, err := api.Client().CreateSecret(api.Context(), name, value)
 	if err != nil {
 		return nil, err
 	}
@@ -111,16 +39,12 @@ func CreateSecret(name, value string) (*CreateSecretResponse, error) {
 
 	fmt.Println(string(formattedJSON))
 
-	return &response, nil
+	return response, nil
 }
 
 // UpdateSecret updates an existing secret's value.
 func UpdateSecret(secretID, value string) error {
-	body := UpdateSecretBody{
-		Value: value,
-	}
-
-	err := doSecretsJSON(http.MethodPut, "/"+secretID, body, nil)
+	err := api.Client().UpdateSecret(api.Context(), secretID, value)
 	if err != nil {
 		return err
 	}
@@ -132,7 +56,7 @@ func UpdateSecret(secretID, value string) error {
 
 // DeleteSecret deletes a secret by ID.
 func DeleteSecret(secretID string) error {
-	err := doSecretsJSON(http.MethodDelete, "/"+secretID, nil, nil)
+	err := api.Client().DeleteSecret(api.Context(), secretID)
 	if err != nil {
 		return err
 	}
@@ -144,12 +68,7 @@ func DeleteSecret(secretID string) error {
 
 // AttachSecrets attaches secrets to an agent.
 func AttachSecrets(agentID string, secretIds []string) error {
-	body := AddSecretsBody{
-		SecretIds: secretIds,
-	}
-
-	var response AddSecretsResponse
-	err := doJSON(http.MethodPost, "/"+agentID+"/secrets", body, &response)
+	response, err := api.Client().AttachSecrets(api.Context(), agentID, secretIds)
 	if err != nil {
 		return err
 	}
@@ -166,7 +85,7 @@ func AttachSecrets(agentID string, secretIds []string) error {
 
 // DetachSecret detaches a secret from an agent.
 func DetachSecret(agentID, secretID string) error {
-	err := doJSON(http.MethodDelete, "/"+agentID+"/secrets/"+secretID, nil, nil)
+	err := api.Client().DetachSecret(api.Context(), agentID, secretID)
 	if err != nil {
 		return err
 	}
@@ -4,13 +4,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
+	"github.com/PinataCloud/ipfs-cli/internal/api"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
 )
 
 // ListSnapshots retrieves all snapshots for a specific agent.
-func ListSnapshots(agentID string) (*AgentSnapshotsResponse, error) {
-	var response AgentSnapshotsResponse
-	err := doJSON(http.MethodGet, "/"+agentID+"/snapshots", nil, &response)
+func ListSnapshots(agentID string) (*pinata.AgentSnapshotsResponse, error) {
+	response, err := api.Client().ListSnapshots(api.Context(), agentID)
 	if err != nil {
 		return nil, err
 	}
@@ -22,13 +22,12 @@ func ListSnapshots(agentID string) (*AgentSnapshotsResponse, error) {
 
 	fmt.Println(string(formattedJSON))
 
-	return &response, nil
+	return response, nil
 }
 
 // CreateSnapshot triggers a snapshot sync for a specific agent.
-func CreateSnapshot(agentID string) (*StorageSyncResponse, error) {
-	var response StorageSyncResponse
-	err := doJSON(http.MethodPost, "/"+agentID+"/snapshots/sync", nil, &response)
+func CreateSnapshot(agentID string) (*pinata.StorageSyncResponse, error) {
+	response, err := api.Client().CreateSnapshot(api.Context(), agentID)
 	if err != nil {
 		return nil, err
 	}
@@ -40,13 +39,12 @@ func CreateSnapshot(agentID string) (*StorageSyncResponse, error) {
 
 	fmt.Println(string(formattedJSON))
 
-	return &response, nil
+	return response, nil
 }
 
 // GetSyncStatus retrieves the storage sync status for a specific agent.
-func GetSyncStatus(agentID string) (*StorageStatusResponse, error) {
-	var response StorageStatusResponse
-	err := doJSON(http.MethodGet, "/"+agentID+"/snapshots/sync", nil, &response)
+func GetSyncStatus(agentID string) (*pinata.StorageStatusResponse, error) {
+	response, err := api.Client().SyncStatus(api.Context(), agentID)
 	if err != nil {
 		return nil, err
 	}
@@ -58,17 +56,12 @@ func GetSyncStatus(agentID string) (*StorageStatusResponse, error) {
 
 	fmt.Println(string(formattedJSON))
 
-	return &response, nil
+	return response, nil
 }
 
 // ResetSnapshot resets the agent to a specific snapshot.
-func ResetSnapshot(agentID, snapshotCid string) (*ResetSnapshotResponse, error) {
-	body := ResetSnapshotBody{
-		SnapshotCid: snapshotCid,
-	}
-
-	var response ResetSnapshotResponse
-	err := doJSON(http.MethodPost, "/"+agentID+"/snapshots/reset", body, &response)
+func ResetSnapshot(agentID, snapshotCid string) (*pinata.ResetSnapshotResponse, error) {
+	response, err := api.Client().ResetSnapshot(api.Context(), agentID, snapshotCid)
 	if err != nil {
 		return nil, err
 	}
@@ -80,5 +73,5 @@ func ResetSnapshot(agentID, snapshotCid string) (*ResetSnapshotResponse, error)
 
 	fmt.Println(string(formattedJSON))
 
-	return &response, nil
+	return response, nil
 }
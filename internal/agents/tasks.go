@@ -4,16 +4,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
+	"github.com/PinataCloud/ipfs-cli/internal/api"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
 )
 
 // ListTasks retrieves all tasks (cron jobs) for a specific agent.
 // Set includeDisabled to true to include disabled tasks in the response.
 func ListTasks(agentID string, includeDisabled bool) (interface{}, error) {
-	path := fmt.Sprintf("/%s/tasks?includeDisabled=%t", agentID, includeDisabled)
-
-	var result interface{}
-	err := doJSON(http.MethodGet, path, nil, &result)
+	result, err := api.Client().ListTasks(api.Context(), agentID, includeDisabled)
 	if err != nil {
 		return nil, err
 	}
@@ -29,11 +27,8 @@ func ListTasks(agentID string, includeDisabled bool) (interface{}, error) {
 }
 
 // CreateTask creates a new task (cron job) for a specific agent.
-func CreateTask(agentID string, body CreateTaskBody) (interface{}, error) {
-	path := fmt.Sprintf("/%s/tasks", agentID)
-
-	var result interface{}
-	err := doJSON(http.MethodPost, path, body, &result)
+func CreateTask(agentID string, body pinata.CreateTaskBody) (interface{}, error) {
+	result, err := api.Client().CreateTask(api.Context(), agentID, body)
 	if err != nil {
 		return nil, err
 	}
@@ -49,11 +44,8 @@ func CreateTask(agentID string, body CreateTaskBody) (interface{}, error) {
 }
 
 // UpdateTask updates an existing task (cron job) for a specific agent.
-func UpdateTask(agentID, jobID string, body UpdateTaskBody) (interface{}, error) {
-	path := fmt.Sprintf("/%s/tasks/%s", agentID, jobID)
-
-	var result interface{}
-	err := doJSON(http.MethodPut, path, body, &result)
+func UpdateTask(agentID, jobID string, body pinata.UpdateTaskBody) (interface{}, error) {
+	result, err := api.Client().UpdateTask(api.Context(), agentID, jobID, body)
 	if err != nil {
 		return nil, err
 	}
@@ -70,9 +62,7 @@ func UpdateTask(agentID, jobID string, body UpdateTaskBody) (interface{}, error)
 
 // DeleteTask deletes a task (cron job) from a specific agent.
 func DeleteTask(agentID, jobID string) error {
-	path := fmt.Sprintf("/%s/tasks/%s", agentID, jobID)
-
-	err := doJSON(http.MethodDelete, path, nil, nil)
+	err := api.Client().DeleteTask(api.Context(), agentID, jobID)
 	if err != nil {
 		return err
 	}
@@ -84,13 +74,7 @@ func DeleteTask(agentID, jobID string) error {
 
 // ToggleTask enables or disables a task (cron job) for a specific agent.
 func ToggleTask(agentID, jobID string, enabled bool) error {
-	path := fmt.Sprintf("/%s/tasks/%s/toggle", agentID, jobID)
-
-	body := ToggleTaskBody{
-		Enabled: enabled,
-	}
-
-	err := doJSON(http.MethodPost, path, body, nil)
+	err := api.Client().ToggleTask(api.Context(), agentID, jobID, enabled)
 	if err != nil {
 		return err
 	}
@@ -106,9 +90,7 @@ func ToggleTask(agentID, jobID string, enabled bool) error {
 
 // RunTask manually triggers a task (cron job) to run immediately.
 func RunTask(agentID, jobID string) error {
-	path := fmt.Sprintf("/%s/tasks/%s/run", agentID, jobID)
-
-	err := doJSON(http.MethodPost, path, nil, nil)
+	err := api.Client().RunTask(api.Context(), agentID, jobID)
 	if err != nil {
 		return err
 	}
@@ -121,10 +103,7 @@ func RunTask(agentID, jobID string) error {
 // GetTaskHistory retrieves the run history for a specific task.
 // The limit parameter controls how many history entries to return.
 func GetTaskHistory(agentID, jobID string, limit int) (interface{}, error) {
-	path := fmt.Sprintf("/%s/tasks/%s/runs?limit=%d", agentID, jobID, limit)
-
-	var result interface{}
-	err := doJSON(http.MethodGet, path, nil, &result)
+	result, err := api.Client().TaskHistory(api.Context(), agentID, jobID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -4,54 +4,16 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
-
-	"pinata/internal/config"
+	"github.com/PinataCloud/ipfs-cli/internal/api"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
 )
 
-// Templates API uses a different base URL than agents
-func doTemplatesJSON(method, path string, body interface{}, result interface{}) error {
-	host := config.GetAgentsHost()
-	url := fmt.Sprintf("https://%s/v0/templates%s", host, path)
-
-	resp, err := doRequestURL(method, url, body)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		var errResp ErrorResponse
-		if decodeErr := json.NewDecoder(resp.Body).Decode(&errResp); decodeErr == nil && errResp.Error != "" {
-			return fmt.Errorf("server error: %s", errResp.Error)
-		}
-		return fmt.Errorf("server returned status %d", resp.StatusCode)
-	}
-
-	if result != nil {
-		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
-			return errors.Join(err, errors.New("failed to decode response"))
-		}
-	}
-
-	return nil
-}
-
 // ListTemplates retrieves all published templates, optionally filtered.
-func ListTemplates(category string, featured bool) (*TemplateListResponse, error) {
-	path := ""
-	sep := "?"
-
-	if category != "" {
-		path += sep + "category=" + category
-		sep = "&"
-	}
-	if featured {
-		path += sep + "featured=true"
-	}
-
-	var response TemplateListResponse
-	err := doTemplatesJSON(http.MethodGet, path, nil, &response)
+func ListTemplates(category string, featured bool) (*pinata.TemplateListResponse, error) {
+	response, err := api.Client().ListTemplates(api.Context(), pinata.ListTemplatesOptions{
+		Category: category,
+		Featured: featured,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -63,13 +25,12 @@ func ListTemplates(category string, featured bool) (*TemplateListResponse, error
 
 	fmt.Println(string(formattedJSON))
 
-	return &response, nil
+	return response, nil
 }
 
 // GetTemplate retrieves a template by its slug.
-func GetTemplate(slug string) (*TemplateDetailResponse, error) {
-	var response TemplateDetailResponse
-	err := doTemplatesJSON(http.MethodGet, "/"+slug, nil, &response)
+func GetTemplate(slug string) (*pinata.TemplateDetailResponse, error) {
+	response, err := api.Client().GetTemplate(api.Context(), slug)
 	if err != nil {
 		return nil, err
 	}
@@ -81,13 +42,14 @@ func GetTemplate(slug string) (*TemplateDetailResponse, error) {
 
 	fmt.Println(string(formattedJSON))
 
-	return &response, nil
+	return response, nil
 }
 
 // ListTemplatesBySubmitter retrieves templates submitted by the authenticated user.
-func ListTemplatesBySubmitter() (*TemplateListResponse, error) {
-	var response TemplateListResponse
-	err := doTemplatesJSON(http.MethodGet, "?submittedBy=me", nil, &response)
+func ListTemplatesBySubmitter() (*pinata.TemplateListResponse, error) {
+	response, err := api.Client().ListTemplates(api.Context(), pinata.ListTemplatesOptions{
+		SubmittedByMe: true,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -99,18 +61,14 @@ func ListTemplatesBySubmitter() (*TemplateListResponse, error) {
 
 	fmt.Println(string(formattedJSON))
 
-	return &response, nil
+	return response, nil
 }
 
 // ValidateTemplate validates a git repo for template submission.
-func ValidateTemplate(gitURL, branch string) (*ValidateTemplateResponse, error) {
-	body := SubmitTemplateBody{GitURL: gitURL}
-	if branch != "" {
-		body.Branch = branch
-	}
+func ValidateTemplate(gitURL, branch string) (*pinata.ValidateTemplateResponse, error) {
+	body := pinata.SubmitTemplateBody{GitURL: gitURL, Branch: branch}
 
-	var response ValidateTemplateResponse
-	err := doTemplatesJSON(http.MethodPost, "/validate", body, &response)
+	response, err := api.Client().ValidateTemplate(api.Context(), body)
 	if err != nil {
 		return nil, err
 	}
@@ -122,18 +80,14 @@ func ValidateTemplate(gitURL, branch string) (*ValidateTemplateResponse, error)
 
 	fmt.Println(string(formattedJSON))
 
-	return &response, nil
+	return response, nil
 }
 
 // SubmitTemplate submits a new template from a git repo URL.
-func SubmitTemplate(gitURL, branch string) (*SubmitTemplateResponse, error) {
-	body := SubmitTemplateBody{GitURL: gitURL}
-	if branch != "" {
-		body.Branch = branch
-	}
+func SubmitTemplate(gitURL, branch string) (*pinata.SubmitTemplateResponse, error) {
+	body := pinata.SubmitTemplateBody{GitURL: gitURL, Branch: branch}
 
-	var response SubmitTemplateResponse
-	err := doTemplatesJSON(http.MethodPost, "", body, &response)
+	response, err := api.Client().SubmitTemplate(api.Context(), body)
 	if err != nil {
 		return nil, err
 	}
@@ -145,21 +99,14 @@ func SubmitTemplate(gitURL, branch string) (*SubmitTemplateResponse, error) {
 
 	fmt.Println(string(formattedJSON))
 
-	return &response, nil
+	return response, nil
 }
 
 // UpdateTemplate updates an existing template submission by re-pulling from the repo.
-func UpdateTemplate(templateID, gitURL, branch string) (*SubmitTemplateResponse, error) {
-	body := SubmitTemplateBody{}
-	if gitURL != "" {
-		body.GitURL = gitURL
-	}
-	if branch != "" {
-		body.Branch = branch
-	}
+func UpdateTemplate(templateID, gitURL, branch string) (*pinata.SubmitTemplateResponse, error) {
+	body := pinata.SubmitTemplateBody{GitURL: gitURL, Branch: branch}
 
-	var response SubmitTemplateResponse
-	err := doTemplatesJSON(http.MethodPut, "/"+templateID, body, &response)
+	response, err := api.Client().UpdateTemplate(api.Context(), templateID, body)
 	if err != nil {
 		return nil, err
 	}
@@ -171,13 +118,12 @@ func UpdateTemplate(templateID, gitURL, branch string) (*SubmitTemplateResponse,
 
 	fmt.Println(string(formattedJSON))
 
-	return &response, nil
+	return response, nil
 }
 
 // DeleteTemplate archives a template submission.
-func DeleteTemplate(templateID string) (*DeleteTemplateResponse, error) {
-	var response DeleteTemplateResponse
-	err := doTemplatesJSON(http.MethodDelete, "/"+templateID, nil, &response)
+func DeleteTemplate(templateID string) (*pinata.DeleteTemplateResponse, error) {
+	response, err := api.Client().DeleteTemplate(api.Context(), templateID)
 	if err != nil {
 		return nil, err
 	}
@@ -189,15 +135,12 @@ func DeleteTemplate(templateID string) (*DeleteTemplateResponse, error) {
 
 	fmt.Println(string(formattedJSON))
 
-	return &response, nil
+	return response, nil
 }
 
 // ListBranches lists branches for a public git repository.
-func ListBranches(gitURL string) (*BranchesResponse, error) {
-	body := BranchesBody{GitURL: gitURL}
-
-	var response BranchesResponse
-	err := doTemplatesJSON(http.MethodPost, "/branches", body, &response)
+func ListBranches(gitURL string) (*pinata.BranchesResponse, error) {
+	response, err := api.Client().ListBranches(api.Context(), gitURL)
 	if err != nil {
 		return nil, err
 	}
@@ -209,5 +152,5 @@ func ListBranches(gitURL string) (*BranchesResponse, error) {
 
 	fmt.Println(string(formattedJSON))
 
-	return &response, nil
+	return response, nil
 }
@@ -0,0 +1,104 @@
+// Package api configures the SDK client shared by every command from the
+// CLI's flags, environment and profile, and adds debugging and transport
+// options on top of it.
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/PinataCloud/ipfs-cli/internal/common"
+	"github.com/PinataCloud/ipfs-cli/internal/config"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+)
+
+const (
+	DefaultTimeout = pinata.DefaultTimeout
+	DefaultRetries = pinata.DefaultRetries
+)
+
+// The SDK types are used as is
+type (
+	Request      = pinata.Request
+	Error        = pinata.Error
+	NetworkError = pinata.NetworkError
+)
+
+var (
+	defaultClient = newClient()
+	baseContext   = context.Background()
+)
+
+func newClient() *pinata.Client {
+	client := pinata.New(pinata.TokenFunc(func(context.Context) (string, error) {
+		jwt, err := common.FindToken()
+		return string(jwt), err
+	}))
+	client.OnRetry = func(err error, wait time.Duration, attempt, attempts int) {
+		fmt.Fprintf(os.Stderr, "%s, retrying in %s (attempt %d of %d)\n", err, wait.Round(100*time.Millisecond), attempt, attempts)
+	}
+	client.TraceWebSocket = func(url string, header http.Header, resp *http.Response, started time.Time, err error) pinata.FrameTracer {
+		if trace := TraceWebSocket(url, header, resp, started, err); trace != nil {
+			return trace
+		}
+		return nil
+	}
+	return client
+}
+
+// Client returns the SDK client used by the CLI commands
+func Client() *pinata.Client {
+	return defaultClient
+}
+
+// Configure sets the timeout and retries of the default client and points it
+// at the hosts of the active profile
+func Configure(timeout time.Duration, retries int) {
+	if retries < 0 {
+		retries = 0
+	}
+	defaultClient.Timeout = timeout
+	defaultClient.Retries = retries
+	defaultClient.APIHost = config.GetAPIHost()
+	defaultClient.UploadsHost = config.GetUploadsHost()
+	defaultClient.AgentsHost = config.GetAgentsHost()
+}
+
+// SetContext sets the context commands run under, so an interrupt cancels
+// any request in flight
+func SetContext(ctx context.Context) {
+	if ctx != nil {
+		baseContext = ctx
+	}
+}
+
+// Context returns the context commands run under
+func Context() context.Context {
+	return baseContext
+}
+
+// HTTPClient returns the transport of the default client, for libraries
+// that make their own requests
+func HTTPClient() *http.Client {
+	return defaultClient.HTTPClient
+}
+
+// Do sends a request with the default client and decodes the JSON response
+// into result when it isn't nil
+func Do(ctx context.Context, req *Request, result interface{}) error {
+	return defaultClient.Do(ctx, req, result)
+}
+
+// Send sends a request with the default client and returns the response
+func Send(ctx context.Context, req *Request) (*http.Response, error) {
+	return defaultClient.Send(ctx, req)
+}
+
+// StatusCode returns the HTTP status of an *Error anywhere in err's chain, or
+// 0 if there is none
+func StatusCode(err error) int {
+	return pinata.StatusCode(err)
+}
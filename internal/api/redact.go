@@ -0,0 +1,156 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PinataCloud/ipfs-cli/internal/config"
+)
+
+// sensitiveNames are matched against header names, query parameters and
+// JSON keys, ignoring case, punctuation and position in the name
+var sensitiveNames = []string{"authorization", "jwt", "token", "secret", "password", "passphrase", "signature", "apikey", "cookie"}
+
+// notSensitive are names that match sensitiveNames but only carry pagination
+// state, which is useful when debugging
+var notSensitive = []string{"pagetoken"}
+
+// sensitiveExact are only sensitive as a whole name. Secrets are sent as
+// {"name": ..., "value": ...}.
+var sensitiveExact = []string{"value"}
+
+var (
+	jwtPattern    = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)
+	bearerPattern = regexp.MustCompile(`(?i)(bearer\s+)[^\s"',]+`)
+)
+
+func isSensitive(name string) bool {
+	normalized := strings.NewReplacer("-", "", "_", "", ".", "").Replace(strings.ToLower(name))
+	for _, allowed := range notSensitive {
+		if strings.Contains(normalized, allowed) {
+			return false
+		}
+	}
+	for _, exact := range sensitiveExact {
+		if normalized == exact {
+			return true
+		}
+	}
+	for _, sensitive := range sensitiveNames {
+		if strings.Contains(normalized, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactHeaders returns a copy of the headers with credentials masked
+func RedactHeaders(header http.Header) http.Header {
+	redacted := make(http.Header, len(header))
+	for name, values := range header {
+		for _, value := range values {
+			if isSensitive(name) {
+				value = maskValue(value)
+			}
+			redacted.Add(name, value)
+		}
+	}
+	return redacted
+}
+
+// RedactURL masks credentials in the query string of a URL, such as the
+// signature of a private download link
+func RedactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return RedactText(rawURL)
+	}
+	if u.User != nil {
+		u.User = url.User(u.User.Username())
+	}
+	query := u.Query()
+	changed := false
+	for name, values := range query {
+		if isSensitive(name) {
+			for i := range values {
+				values[i] = maskValue(values[i])
+			}
+			changed = true
+		}
+	}
+	if changed {
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
+
+// RedactBody masks credentials in a request or response body. JSON bodies have
+// the values of sensitive keys masked and the query strings of URLs redacted,
+// anything else has JWTs and bearer tokens masked.
+func RedactBody(data []byte) string {
+	var parsed interface{}
+	if err := json.Unmarshal(data, &parsed); err == nil {
+		var redacted strings.Builder
+		encoder := json.NewEncoder(&redacted)
+		// Keep the & of redacted links readable
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(redactJSON(parsed)); err == nil {
+			return RedactText(strings.TrimSuffix(redacted.String(), "\n"))
+		}
+	}
+	return RedactText(string(data))
+}
+
+// RedactText masks anything that looks like a JWT or bearer token
+func RedactText(text string) string {
+	text = jwtPattern.ReplaceAllStringFunc(text, config.MaskSecret)
+	return bearerPattern.ReplaceAllStringFunc(text, func(match string) string {
+		parts := bearerPattern.FindStringSubmatch(match)
+		return parts[1] + config.MaskSecret(strings.TrimPrefix(match, parts[1]))
+	})
+}
+
+func redactJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, inner := range v {
+			// Containers such as "secrets" are walked rather than masked so
+			// only the values inside them are hidden
+			switch scalar := inner.(type) {
+			case map[string]interface{}, []interface{}, nil:
+				v[key] = redactJSON(inner)
+			case string:
+				if isSensitive(key) {
+					v[key] = config.MaskSecret(scalar)
+				} else {
+					v[key] = redactJSON(scalar)
+				}
+			default:
+				if isSensitive(key) {
+					v[key] = "****"
+				}
+			}
+		}
+	case []interface{}:
+		for i, inner := range v {
+			v[i] = redactJSON(inner)
+		}
+	case string:
+		// Signed links carry their signature in the query string
+		if strings.HasPrefix(v, "http://") || strings.HasPrefix(v, "https://") {
+			return RedactURL(v)
+		}
+	}
+	return value
+}
+
+// maskValue masks a header or query value, keeping an auth scheme visible
+func maskValue(value string) string {
+	if scheme, credential, ok := strings.Cut(value, " "); ok && !strings.Contains(credential, " ") {
+		return scheme + " " + config.MaskSecret(credential)
+	}
+	return config.MaskSecret(value)
+}
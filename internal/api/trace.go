@@ -0,0 +1,449 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+)
+
+const (
+	// debugBodyLimit is how much of a body --debug prints
+	debugBodyLimit = 2048
+	// traceBodyLimit is how much of a body is kept in the trace file
+	traceBodyLimit = 64 * 1024
+)
+
+// tracer logs requests to stderr and records them for the trace file. Every
+// value it stores or prints has been redacted.
+type tracer struct {
+	mu      sync.Mutex
+	debug   bool
+	path    string
+	entries []*harEntry
+}
+
+// activeTracer is nil unless --debug or --trace-file is set
+var activeTracer *tracer
+
+// EnableTracing logs every request to stderr when debug is set, and records
+// them for FlushTrace when traceFile is set
+func EnableTracing(debug bool, traceFile string) {
+	if !debug && traceFile == "" {
+		return
+	}
+	activeTracer = &tracer{debug: debug, path: traceFile}
+	base := defaultClient.HTTPClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	defaultClient.HTTPClient.Transport = &traceTransport{base: base}
+}
+
+// FlushTrace writes the recorded requests to the trace file as HAR
+func FlushTrace() error {
+	t := activeTracer
+	if t == nil || t.path == "" {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entries := make([]harEntry, len(t.entries))
+	for i, entry := range t.entries {
+		entries[i] = *entry
+	}
+	data, err := json.MarshalIndent(harFile{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "pinata-cli", Version: "1"},
+		Entries: entries,
+	}}, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(t.path, data, 0600)
+}
+
+func (t *tracer) logf(format string, args ...any) {
+	if t.debug {
+		fmt.Fprintf(os.Stderr, "[debug] "+format+"\n", args...)
+	}
+}
+
+func (t *tracer) logHeaders(header http.Header) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		t.logf("  %s: %s", name, strings.Join(header[name], ", "))
+	}
+}
+
+func (t *tracer) logBody(label string, body []byte, size int64) {
+	if len(body) == 0 {
+		t.logf("  %s: %d bytes", label, size)
+		return
+	}
+	text := RedactBody(body)
+	if len(text) > debugBodyLimit {
+		text = truncateText(text, debugBodyLimit) + "..."
+	}
+	t.logf("  %s: %d bytes: %s", label, size, text)
+}
+
+// truncateText cuts text to at most limit bytes without splitting a UTF-8
+// character
+func truncateText(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	for limit > 0 && !utf8.RuneStart(text[limit]) {
+		limit--
+	}
+	return text[:limit]
+}
+
+// isFileUpload reports whether a request sends file content, which is left
+// out of the trace
+func isFileUpload(req *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	switch mediaType {
+	case "multipart/form-data", "application/offset+octet-stream", "application/octet-stream":
+		return true
+	}
+	return false
+}
+
+func (t *tracer) add(entry *harEntry) {
+	if t.path == "" {
+		return
+	}
+	t.mu.Lock()
+	t.entries = append(t.entries, entry)
+	t.mu.Unlock()
+}
+
+// traceTransport wraps the client's transport to trace each request
+type traceTransport struct {
+	base http.RoundTripper
+}
+
+func (tt *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t := activeTracer
+	started := time.Now()
+
+	// Uploads only log their size, since the files may be binary or private
+	upload := isFileUpload(req)
+	var reqBody []byte
+	if !upload {
+		reqBody = peekRequestBody(req)
+	}
+	headers := RedactHeaders(req.Header)
+	t.logf("→ %s %s", req.Method, RedactURL(req.URL.String()))
+	t.logHeaders(headers)
+	t.logBody("body", reqBody, max(req.ContentLength, 0))
+
+	entry := newHAREntry(started, req.Method, req.URL.String(), headers)
+	entry.Request.BodySize = max(req.ContentLength, 0)
+	switch {
+	case upload && req.ContentLength != 0:
+		entry.Request.PostData = &harPostData{
+			MimeType: req.Header.Get("Content-Type"),
+			Text:     fmt.Sprintf("[file upload of %d bytes not recorded]", max(req.ContentLength, 0)),
+		}
+	case len(reqBody) > 0:
+		entry.Request.PostData = &harPostData{MimeType: req.Header.Get("Content-Type"), Text: RedactBody(reqBody)}
+	}
+
+	resp, err := tt.base.RoundTrip(req)
+	elapsed := time.Since(started)
+	if err != nil {
+		t.logf("✗ %s %s failed after %s: %s", req.Method, RedactURL(req.URL.String()), elapsed.Round(time.Millisecond), RedactText(err.Error()))
+		entry.Time = float64(elapsed.Milliseconds())
+		entry.Response.StatusText = RedactText(err.Error())
+		t.add(entry)
+		return nil, err
+	}
+
+	t.logf("← %s in %s", resp.Status, elapsed.Round(time.Millisecond))
+	if id := pinata.RequestID(resp.Header); id != "" {
+		t.logf("  request ID: %s", id)
+	}
+	entry.Time = float64(elapsed.Milliseconds())
+	entry.Timings.Wait = entry.Time
+	entry.Response.Status = resp.StatusCode
+	entry.Response.StatusText = http.StatusText(resp.StatusCode)
+	entry.Response.Headers = harHeaders(RedactHeaders(resp.Header))
+	entry.Response.Content.MimeType = resp.Header.Get("Content-Type")
+	t.add(entry)
+
+	// The body is logged once the caller has finished reading it
+	resp.Body = &traceBody{ReadCloser: resp.Body, tracer: t, entry: entry}
+	return resp, nil
+}
+
+// peekRequestBody returns a copy of a small replayable request body
+func peekRequestBody(req *http.Request) []byte {
+	if req.Body == nil || req.GetBody == nil || req.ContentLength > traceBodyLimit {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil
+	}
+	defer body.Close()
+	data, _ := io.ReadAll(io.LimitReader(body, traceBodyLimit))
+	return data
+}
+
+// traceBody captures the start of a response body as it's read
+type traceBody struct {
+	io.ReadCloser
+	tracer *tracer
+	entry  *harEntry
+	buf    bytes.Buffer
+	size   int64
+	done   bool
+}
+
+func (b *traceBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.size += int64(n)
+	if room := traceBodyLimit - b.buf.Len(); room > 0 {
+		b.buf.Write(p[:min(n, room)])
+	}
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *traceBody) Close() error {
+	b.finish()
+	return b.ReadCloser.Close()
+}
+
+func (b *traceBody) finish() {
+	if b.done {
+		return
+	}
+	b.done = true
+	b.tracer.logBody("response body", b.buf.Bytes(), b.size)
+
+	b.tracer.mu.Lock()
+	defer b.tracer.mu.Unlock()
+	b.entry.Response.BodySize = b.size
+	b.entry.Response.Content.Size = b.size
+	if b.buf.Len() > 0 {
+		b.entry.Response.Content.Text = RedactBody(b.buf.Bytes())
+	}
+}
+
+// WebSocketTrace records a WebSocket connection and its frames. A nil
+// *WebSocketTrace is valid and records nothing, so callers don't need to
+// check whether tracing is on.
+type WebSocketTrace struct {
+	tracer *tracer
+	entry  *harEntry
+}
+
+// TraceWebSocket records a WebSocket handshake. It returns nil when tracing
+// is off.
+func TraceWebSocket(rawURL string, header http.Header, resp *http.Response, started time.Time, err error) *WebSocketTrace {
+	t := activeTracer
+	if t == nil {
+		return nil
+	}
+	elapsed := time.Since(started)
+	headers := RedactHeaders(header)
+
+	t.logf("→ WebSocket %s", RedactURL(rawURL))
+	t.logHeaders(headers)
+
+	entry := newHAREntry(started, http.MethodGet, rawURL, headers)
+	entry.Time = float64(elapsed.Milliseconds())
+	entry.Timings.Wait = entry.Time
+	if resp != nil {
+		t.logf("← %s in %s", resp.Status, elapsed.Round(time.Millisecond))
+		entry.Response.Status = resp.StatusCode
+		entry.Response.StatusText = http.StatusText(resp.StatusCode)
+		entry.Response.Headers = harHeaders(RedactHeaders(resp.Header))
+	}
+	if err != nil {
+		t.logf("✗ WebSocket handshake failed after %s: %s", elapsed.Round(time.Millisecond), RedactText(err.Error()))
+		if resp == nil {
+			entry.Response.StatusText = RedactText(err.Error())
+		}
+	}
+	t.add(entry)
+	return &WebSocketTrace{tracer: t, entry: entry}
+}
+
+// Sent records a frame written to the connection
+func (w *WebSocketTrace) Sent(data []byte) {
+	w.frame("send", "⇢", data)
+}
+
+// Received records a frame read from the connection
+func (w *WebSocketTrace) Received(data []byte) {
+	w.frame("receive", "⇠", data)
+}
+
+func (w *WebSocketTrace) frame(direction, arrow string, data []byte) {
+	if w == nil {
+		return
+	}
+	text := RedactBody(data)
+	logged := text
+	if len(logged) > debugBodyLimit {
+		logged = truncateText(logged, debugBodyLimit) + "..."
+	}
+	w.tracer.logf("%s frame %d bytes: %s", arrow, len(data), logged)
+
+	text = truncateText(text, traceBodyLimit)
+	w.tracer.mu.Lock()
+	defer w.tracer.mu.Unlock()
+	w.entry.WebSocketMessages = append(w.entry.WebSocketMessages, harWebSocketMessage{
+		Type:   direction,
+		Time:   float64(time.Now().UnixNano()) / 1e9,
+		Opcode: 1,
+		Data:   text,
+	})
+}
+
+// HAR 1.2, with WebSocket frames in the _webSocketMessages extension used by
+// browser developer tools
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime   string                `json:"startedDateTime"`
+	Time              float64               `json:"time"`
+	Request           harRequest            `json:"request"`
+	Response          harResponse           `json:"response"`
+	Cache             struct{}              `json:"cache"`
+	Timings           harTimings            `json:"timings"`
+	WebSocketMessages []harWebSocketMessage `json:"_webSocketMessages,omitempty"`
+}
+
+type harRequest struct {
+	Method      string       `json:"method"`
+	URL         string       `json:"url"`
+	HTTPVersion string       `json:"httpVersion"`
+	Headers     []harNameVal `json:"headers"`
+	QueryString []harNameVal `json:"queryString"`
+	Cookies     []harNameVal `json:"cookies"`
+	PostData    *harPostData `json:"postData,omitempty"`
+	HeadersSize int          `json:"headersSize"`
+	BodySize    int64        `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int          `json:"status"`
+	StatusText  string       `json:"statusText"`
+	HTTPVersion string       `json:"httpVersion"`
+	Headers     []harNameVal `json:"headers"`
+	Cookies     []harNameVal `json:"cookies"`
+	Content     harContent   `json:"content"`
+	RedirectURL string       `json:"redirectURL"`
+	HeadersSize int          `json:"headersSize"`
+	BodySize    int64        `json:"bodySize"`
+}
+
+type harNameVal struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+type harWebSocketMessage struct {
+	Type   string  `json:"type"`
+	Time   float64 `json:"time"`
+	Opcode int     `json:"opcode"`
+	Data   string  `json:"data"`
+}
+
+func newHAREntry(started time.Time, method, rawURL string, headers http.Header) *harEntry {
+	redactedURL := RedactURL(rawURL)
+	entry := &harEntry{
+		StartedDateTime: started.UTC().Format(time.RFC3339Nano),
+		Request: harRequest{
+			Method:      method,
+			URL:         redactedURL,
+			HTTPVersion: "HTTP/1.1",
+			Headers:     harHeaders(headers),
+			QueryString: []harNameVal{},
+			Cookies:     []harNameVal{},
+			HeadersSize: -1,
+		},
+		Response: harResponse{
+			HTTPVersion: "HTTP/1.1",
+			Headers:     []harNameVal{},
+			Cookies:     []harNameVal{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+	}
+	if i := strings.Index(redactedURL, "?"); i >= 0 {
+		for _, pair := range strings.Split(redactedURL[i+1:], "&") {
+			name, value, _ := strings.Cut(pair, "=")
+			entry.Request.QueryString = append(entry.Request.QueryString, harNameVal{Name: name, Value: value})
+		}
+	}
+	return entry
+}
+
+func harHeaders(header http.Header) []harNameVal {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	values := []harNameVal{}
+	for _, name := range names {
+		for _, value := range header[name] {
+			values = append(values, harNameVal{Name: name, Value: value})
+		}
+	}
+	return values
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateText(t *testing.T) {
+	tests := []struct {
+		text  string
+		limit int
+		want  string
+	}{
+		{"short", 10, "short"},
+		{"exactly", 7, "exactly"},
+		{"abcdef", 3, "abc"},
+		// é is 2 bytes, and isn't split
+		{"aéb", 2, "a"},
+		{"aéb", 3, "aé"},
+		// 😀 is 4 bytes
+		{"😀😀", 7, "😀"},
+		{"😀", 3, ""},
+	}
+	for _, tt := range tests {
+		got := truncateText(tt.text, tt.limit)
+		if got != tt.want || !utf8.ValidString(got) {
+			t.Errorf("truncateText(%q, %d) = %q, want %q", tt.text, tt.limit, got, tt.want)
+		}
+	}
+
+	long := "a" + strings.Repeat("é", debugBodyLimit)
+	if got := truncateText(long, debugBodyLimit); !utf8.ValidString(got) || len(got) != debugBodyLimit-1 {
+		t.Errorf("cut %d bytes of a long body, valid: %v", len(got), utf8.ValidString(got))
+	}
+}
+
+// readTrace flushes the trace and returns the HAR entries
+func readTrace(t *testing.T, path string) []harEntry {
+	t.Helper()
+	if err := FlushTrace(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var har harFile
+	if err := json.Unmarshal(data, &har); err != nil {
+		t.Fatal(err)
+	}
+	return har.Log.Entries
+}
+
+func TestTraceLeavesOutUploads(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		io.WriteString(w, `{"data": {"name": "héllo"}}`)
+	}))
+	t.Cleanup(server.Close)
+
+	path := filepath.Join(t.TempDir(), "trace.har")
+	saved := activeTracer
+	activeTracer = &tracer{path: path}
+	t.Cleanup(func() { activeTracer = saved })
+	client := &http.Client{Transport: &traceTransport{base: http.DefaultTransport}}
+
+	const secret = "the quarterly numbers"
+	var form bytes.Buffer
+	writer := multipart.NewWriter(&form)
+	part, _ := writer.CreateFormFile("file", "report.txt")
+	io.WriteString(part, secret)
+	writer.Close()
+
+	requests := []struct {
+		method, contentType string
+		body                []byte
+	}{
+		{http.MethodPost, writer.FormDataContentType(), form.Bytes()},
+		{http.MethodPatch, "application/offset+octet-stream", []byte(secret)},
+		{http.MethodPost, "application/json", []byte(`{"name": "report"}`)},
+	}
+	for _, r := range requests {
+		req, err := http.NewRequest(r.method, server.URL, bytes.NewReader(r.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", r.contentType)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+
+	entries := readTrace(t, path)
+	if len(entries) != len(requests) {
+		t.Fatalf("traced %d requests, want %d", len(entries), len(requests))
+	}
+	for i, entry := range entries[:2] {
+		post := entry.Request.PostData
+		want := "[file upload of " + strconv.Itoa(len(requests[i].body)) + " bytes not recorded]"
+		if post == nil || post.Text != want {
+			t.Errorf("upload %d recorded %+v, want %q", i, post, want)
+		}
+		if post != nil && post.MimeType != requests[i].contentType {
+			t.Errorf("upload %d recorded the type %q", i, post.MimeType)
+		}
+		if entry.Request.BodySize != int64(len(requests[i].body)) {
+			t.Errorf("upload %d recorded a size of %d", i, entry.Request.BodySize)
+		}
+	}
+	if post := entries[2].Request.PostData; post == nil || post.Text != `{"name":"report"}` {
+		t.Errorf("JSON body recorded as %+v", post)
+	}
+	if text := entries[2].Response.Content.Text; text != `{"data":{"name":"héllo"}}` {
+		t.Errorf("response recorded as %q", text)
+	}
+}
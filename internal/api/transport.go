@@ -0,0 +1,235 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+
+	"github.com/gorilla/websocket"
+)
+
+// TransportOptions controls how connections to the Pinata APIs are made
+type TransportOptions struct {
+	// Proxy is an http, https or socks5 URL. When it's empty the standard
+	// HTTPS_PROXY, HTTP_PROXY and NO_PROXY variables are used.
+	Proxy string
+	// CAFile is a PEM bundle trusted in addition to the system roots
+	CAFile string
+	// ClientCert and ClientKey are presented for mutual TLS. ClientKey may be
+	// left empty when the certificate file also holds the key.
+	ClientCert string
+	ClientKey  string
+}
+
+// wsHandshakeTimeout bounds the agent chat WebSocket handshake
+const wsHandshakeTimeout = 30 * time.Second
+
+// The proxy and TLS settings are shared by API requests and WebSockets
+var (
+	proxyFunc       = trackTLSProxies(http.ProxyFromEnvironment)
+	clientTLSConfig *tls.Config
+	// proxyTLSConfig is used for the TLS handshake with https proxies. It
+	// trusts the CA bundle but never presents the client certificate.
+	proxyTLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	// tlsProxies holds the addresses of the https proxies picked so far
+	tlsProxies sync.Map
+)
+
+// ConfigureTransport applies proxy and TLS settings to the default client's
+// requests and chat WebSockets
+func ConfigureTransport(opts TransportOptions) error {
+	proxy, err := parseProxy(opts.Proxy)
+	if err != nil {
+		return err
+	}
+	tlsConfig, err := buildTLSConfig(opts)
+	if err != nil {
+		return err
+	}
+
+	proxy = trackTLSProxies(proxy)
+	proxyConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+		proxyConfig.RootCAs = tlsConfig.RootCAs
+		// net/http would present the client certificate to https proxies too
+		if len(tlsConfig.Certificates) > 0 {
+			transport.DialTLSContext = dialTLS
+		}
+	}
+	defaultClient.HTTPClient.Transport = transport
+
+	proxyFunc = proxy
+	clientTLSConfig = tlsConfig
+	proxyTLSConfig = proxyConfig
+	defaultClient.Dialer = WebSocketDialer(wsHandshakeTimeout)
+	return nil
+}
+
+// WebSocketDialer returns a dialer that connects through the same proxy and
+// with the same TLS settings as API requests
+func WebSocketDialer(handshakeTimeout time.Duration) *websocket.Dialer {
+	dialer := &websocket.Dialer{
+		HandshakeTimeout: handshakeTimeout,
+		Proxy:            websocketProxy,
+		NetDialContext:   dialProxyHop,
+	}
+	if clientTLSConfig != nil {
+		dialer.TLSClientConfig = clientTLSConfig.Clone()
+	}
+	return dialer
+}
+
+func parseProxy(raw string) (func(*http.Request) (*url.URL, error), error) {
+	if raw == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return nil, exitcode.InvalidInputf("invalid proxy %q: must be a URL such as http://proxy:3128 or socks5://proxy:1080", raw)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, exitcode.InvalidInputf("invalid proxy %q: the scheme must be http, https or socks5", raw)
+	}
+	return http.ProxyURL(u), nil
+}
+
+func buildTLSConfig(opts TransportOptions) (*tls.Config, error) {
+	if opts.CAFile == "" && opts.ClientCert == "" && opts.ClientKey == "" {
+		return nil, nil
+	}
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if opts.CAFile != "" {
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, exitcode.InvalidInputf("failed to read the CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, exitcode.InvalidInputf("no PEM certificates found in the CA file %s", opts.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if opts.ClientCert != "" || opts.ClientKey != "" {
+		if opts.ClientCert == "" {
+			return nil, exitcode.InvalidInputf("a client key requires a client certificate")
+		}
+		keyFile := opts.ClientKey
+		if keyFile == "" {
+			keyFile = opts.ClientCert
+		}
+		cert, err := tls.LoadX509KeyPair(opts.ClientCert, keyFile)
+		if err != nil {
+			return nil, exitcode.InvalidInputf("failed to load the client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// trackTLSProxies wraps a proxy function to record the addresses of the
+// https proxies it picks, so their TLS handshake can be told apart from the
+// one with the API
+func trackTLSProxies(proxy func(*http.Request) (*url.URL, error)) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		proxyURL, err := proxy(req)
+		if err == nil && proxyURL != nil && proxyURL.Scheme == "https" {
+			tlsProxies.Store(proxyAddr(proxyURL), true)
+		}
+		return proxyURL, err
+	}
+}
+
+// proxyAddr returns the host and port of a proxy, with the scheme's default
+// port when it has none
+func proxyAddr(proxyURL *url.URL) string {
+	port := proxyURL.Port()
+	if port == "" {
+		switch proxyURL.Scheme {
+		case "https":
+			port = "443"
+		case "socks5", "socks5h":
+			port = "1080"
+		default:
+			port = "80"
+		}
+	}
+	return net.JoinHostPort(proxyURL.Hostname(), port)
+}
+
+// dialTLS opens a TLS connection for API requests. https proxies get
+// proxyTLSConfig, so the client certificate is only presented to the API.
+func dialTLS(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	var config *tls.Config
+	if _, ok := tlsProxies.Load(addr); ok {
+		config = proxyTLSConfig.Clone()
+	} else {
+		config = clientTLSConfig.Clone()
+		if len(config.NextProtos) == 0 {
+			config.NextProtos = []string{"h2", "http/1.1"}
+		}
+	}
+	config.ServerName = host
+	dialer := &tls.Dialer{Config: config}
+	return dialer.DialContext(ctx, network, addr)
+}
+
+// websocketProxy picks the proxy for a WebSocket. gorilla/websocket tunnels
+// through http and socks5 proxies itself, so an https proxy is handed over as
+// an http one that dialProxyHop reaches over TLS.
+func websocketProxy(req *http.Request) (*url.URL, error) {
+	proxyURL, err := proxyFunc(req)
+	if err != nil || proxyURL == nil {
+		return proxyURL, err
+	}
+	tunnel := *proxyURL
+	switch proxyURL.Scheme {
+	case "https":
+		tunnel.Scheme = "http"
+		tunnel.Host = proxyAddr(proxyURL)
+	case "socks5h":
+		// gorilla/websocket's socks5 already lets the proxy resolve names
+		tunnel.Scheme = "socks5"
+	}
+	return &tunnel, nil
+}
+
+// dialProxyHop opens a connection for a WebSocket, either to the API or to
+// its proxy. Connections to https proxies are wrapped in TLS here, the TLS
+// with the API is added by gorilla/websocket.
+func dialProxyHop(ctx context.Context, network, addr string) (net.Conn, error) {
+	if _, ok := tlsProxies.Load(addr); !ok {
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, network, addr)
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	config := proxyTLSConfig.Clone()
+	config.ServerName = host
+	dialer := &tls.Dialer{Config: config}
+	return dialer.DialContext(ctx, network, addr)
+}
@@ -0,0 +1,391 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+)
+
+// testAPIHost is the host requests are sent to. It isn't resolved: requests
+// reach it through a test proxy, or skip the proxy and fail.
+const testAPIHost = "api.pinata.test"
+
+// testCA issues certificates for test servers and clients
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	// file is the CA certificate as PEM
+	file string
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca := &testCA{cert: cert, key: key, file: filepath.Join(t.TempDir(), "ca.pem")}
+	writePEM(t, ca.file, "CERTIFICATE", der)
+	return ca
+}
+
+// issue returns a certificate for name, valid for servers and clients, and
+// the files holding it and its key
+func (ca *testCA) issue(t *testing.T, name string) (cert tls.Certificate, certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, name+".pem"), filepath.Join(dir, name+".key")
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+	cert, err = tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, certFile, keyFile
+}
+
+func (ca *testCA) pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	return pool
+}
+
+func writePEM(t *testing.T, path, kind string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newTLSServer starts an API server with a certificate from ca. With
+// clientCAs set it requires a client certificate they issued. It answers
+// with the common name of the client.
+func newTLSServer(t *testing.T, ca *testCA, clientCAs *x509.CertPool) *httptest.Server {
+	t.Helper()
+	cert, _, _ := ca.issue(t, testAPIHost)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) > 0 {
+			io.WriteString(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+		}
+	}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	// Handshakes the tests expect to fail would be logged
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	if clientCAs != nil {
+		server.TLS.ClientAuth = tls.RequireAndVerifyClientCert
+		server.TLS.ClientCAs = clientCAs
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server
+}
+
+// connectProxy tunnels CONNECT requests for testAPIHost to target and
+// records what it saw
+type connectProxy struct {
+	target string
+
+	mu      sync.Mutex
+	hosts   []string
+	clients []string
+}
+
+func (p *connectProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	p.hosts = append(p.hosts, r.Host)
+	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		p.clients = append(p.clients, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}
+	p.mu.Unlock()
+	if r.Method != http.MethodConnect || r.Host != testAPIHost+":443" {
+		http.Error(w, "unexpected request", http.StatusBadGateway)
+		return
+	}
+	upstream, err := net.Dial("tcp", p.target)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer upstream.Close()
+	conn, buf, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+	go io.Copy(upstream, buf)
+	io.Copy(conn, upstream)
+}
+
+func (p *connectProxy) seen() (hosts, clients []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.hosts, p.clients
+}
+
+// restoreTransport puts back the transport settings ConfigureTransport
+// changes once the test ends
+func restoreTransport(t *testing.T) {
+	t.Helper()
+	transport, dialer := defaultClient.HTTPClient.Transport, defaultClient.Dialer
+	proxy, client, proxyTLS := proxyFunc, clientTLSConfig, proxyTLSConfig
+	t.Cleanup(func() {
+		defaultClient.HTTPClient.Transport, defaultClient.Dialer = transport, dialer
+		proxyFunc, clientTLSConfig, proxyTLSConfig = proxy, client, proxyTLS
+	})
+}
+
+// get fetches url with the default client and returns the body
+func get(url string) (string, error) {
+	resp, err := defaultClient.HTTPClient.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return string(body), err
+}
+
+func TestCAFile(t *testing.T) {
+	restoreTransport(t)
+	ca := newTestCA(t)
+	server := newTLSServer(t, ca, nil)
+
+	if err := ConfigureTransport(TransportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	var unknown x509.UnknownAuthorityError
+	if _, err := get(server.URL); !errors.As(err, &unknown) {
+		t.Fatalf("a server signed by an unknown CA gave %v", err)
+	}
+
+	if err := ConfigureTransport(TransportOptions{CAFile: ca.file}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := get(server.URL); err != nil {
+		t.Errorf("a server signed by the CA file gave %v", err)
+	}
+}
+
+func TestClientCertificate(t *testing.T) {
+	restoreTransport(t)
+	ca := newTestCA(t)
+	server := newTLSServer(t, ca, ca.pool())
+	_, certFile, keyFile := ca.issue(t, "cli")
+
+	// A certificate file may also hold the key
+	bundle := filepath.Join(t.TempDir(), "bundle.pem")
+	certPEM, _ := os.ReadFile(certFile)
+	keyPEM, _ := os.ReadFile(keyFile)
+	if err := os.WriteFile(bundle, append(certPEM, keyPEM...), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, opts := range []TransportOptions{
+		{CAFile: ca.file, ClientCert: certFile, ClientKey: keyFile},
+		{CAFile: ca.file, ClientCert: bundle},
+	} {
+		if err := ConfigureTransport(opts); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := get(server.URL); err != nil || got != "cli" {
+			t.Errorf("%+v: the server saw %q, %v", opts, got, err)
+		}
+	}
+
+	if err := ConfigureTransport(TransportOptions{CAFile: ca.file}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := get(server.URL); err == nil {
+		t.Error("the server accepted a request without a client certificate")
+	}
+}
+
+func TestClientCertificateThroughHTTPSProxy(t *testing.T) {
+	restoreTransport(t)
+	ca := newTestCA(t)
+	server := newTLSServer(t, ca, ca.pool())
+	_, certFile, keyFile := ca.issue(t, "cli")
+
+	proxy := &connectProxy{target: server.Listener.Addr().String()}
+	proxyServer := httptest.NewUnstartedServer(proxy)
+	proxyCert, _, _ := ca.issue(t, "proxy")
+	proxyServer.TLS = &tls.Config{
+		Certificates: []tls.Certificate{proxyCert},
+		ClientAuth:   tls.RequestClientCert,
+	}
+	proxyServer.StartTLS()
+	t.Cleanup(proxyServer.Close)
+
+	err := ConfigureTransport(TransportOptions{
+		Proxy:      proxyServer.URL,
+		CAFile:     ca.file,
+		ClientCert: certFile,
+		ClientKey:  keyFile,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := get("https://" + testAPIHost + "/"); err != nil || got != "cli" {
+		t.Fatalf("the API saw %q, %v", got, err)
+	}
+	// The proxy is trusted through the CA file, and the client certificate
+	// is kept for the API
+	hosts, clients := proxy.seen()
+	if len(hosts) != 1 || len(clients) != 0 {
+		t.Errorf("the proxy saw hosts %v and client certificates %v", hosts, clients)
+	}
+}
+
+// proxyChildEnv runs TestEnvironmentProxy in a process of its own: net/http
+// reads the proxy variables once per process
+const proxyChildEnv = "PINATA_TEST_PROXY_CHILD"
+
+func TestEnvironmentProxy(t *testing.T) {
+	if os.Getenv(proxyChildEnv) == "" {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestEnvironmentProxy$", "-test.v")
+		cmd.Env = append(os.Environ(), proxyChildEnv+"=1")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("%v\n%s", err, out)
+		}
+		return
+	}
+
+	ca := newTestCA(t)
+	server := newTLSServer(t, ca, nil)
+	proxy := &connectProxy{target: server.Listener.Addr().String()}
+	proxyServer := httptest.NewServer(proxy)
+	t.Cleanup(proxyServer.Close)
+
+	for _, name := range []string{"HTTP_PROXY", "http_proxy", "https_proxy", "no_proxy", "REQUEST_METHOD"} {
+		os.Unsetenv(name)
+	}
+	t.Setenv("HTTPS_PROXY", proxyServer.URL)
+	t.Setenv("NO_PROXY", "uploads.pinata.test,.internal.test")
+	if err := ConfigureTransport(TransportOptions{CAFile: ca.file}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := get("https://" + testAPIHost + "/v3/files"); err != nil {
+		t.Fatalf("the request through HTTPS_PROXY failed: %v", err)
+	}
+	if hosts, _ := proxy.seen(); len(hosts) != 1 {
+		t.Errorf("the proxy saw %v, want the API", hosts)
+	}
+
+	transport := defaultClient.HTTPClient.Transport.(*http.Transport)
+	tests := []struct {
+		url   string
+		proxy bool
+	}{
+		{"https://" + testAPIHost + "/v3/files", true},
+		{"https://uploads.pinata.test/v3/files", false},
+		{"https://agents.internal.test/v0/agents", false},
+		// HTTP_PROXY isn't set
+		{"http://" + testAPIHost + "/", false},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+		got, err := transport.Proxy(req)
+		if err != nil || (got != nil) != tt.proxy {
+			t.Errorf("%s went through %v, %v; want a proxy: %v", tt.url, got, err, tt.proxy)
+		}
+	}
+
+	// Chat WebSockets pick the same proxy. gorilla/websocket asks for the
+	// proxy of wss URLs as https.
+	dialer := WebSocketDialer(time.Second)
+	for url, want := range map[string]bool{"https://" + testAPIHost + "/chat": true, "https://uploads.pinata.test/chat": false} {
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		got, err := dialer.Proxy(req)
+		if err != nil || (got != nil) != want {
+			t.Errorf("%s went through %v, %v; want a proxy: %v", url, got, err, want)
+		}
+	}
+}
+
+func TestTransportOptionsErrors(t *testing.T) {
+	restoreTransport(t)
+	ca := newTestCA(t)
+	_, certFile, keyFile := ca.issue(t, "cli")
+	notPEM := filepath.Join(t.TempDir(), "ca.txt")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts TransportOptions
+	}{
+		{"proxy without a host", TransportOptions{Proxy: "proxy:3128"}},
+		{"proxy scheme", TransportOptions{Proxy: "ftp://proxy:21"}},
+		{"missing CA file", TransportOptions{CAFile: filepath.Join(t.TempDir(), "missing.pem")}},
+		{"CA file without certificates", TransportOptions{CAFile: notPEM}},
+		{"key without a certificate", TransportOptions{ClientKey: keyFile}},
+		{"certificate without its key", TransportOptions{ClientCert: certFile}},
+		{"key that isn't a key", TransportOptions{ClientCert: certFile, ClientKey: certFile}},
+	}
+	for _, tt := range tests {
+		if err := ConfigureTransport(tt.opts); exitcode.For(err) != exitcode.InvalidInput {
+			t.Errorf("%s gave %v, want invalid input", tt.name, err)
+		}
+	}
+}
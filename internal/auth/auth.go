@@ -3,63 +3,122 @@ package auth
 import (
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
-	"path/filepath"
-	"pinata/internal/config"
-	"pinata/internal/gateways"
-	"pinata/internal/utils"
-	"time"
+	"strings"
+
+	"github.com/PinataCloud/ipfs-cli/internal/api"
+	"github.com/PinataCloud/ipfs-cli/internal/config"
+	"github.com/PinataCloud/ipfs-cli/internal/credentials"
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+	"github.com/PinataCloud/ipfs-cli/internal/gateways"
+	"github.com/PinataCloud/ipfs-cli/internal/utils"
 )
 
-func SaveJWT() error {
-	jwt, err := utils.GetInput("Enter your Pinata JWT", "Pinata JWT")
+// LoginOptions controls where SaveJWT reads the JWT from and which gateway it
+// sets, so it can run without a terminal
+type LoginOptions struct {
+	JWTStdin bool
+	JWTFile  string
+	Gateway  string
+}
+
+func SaveJWT(opts LoginOptions) error {
+	jwt, err := readJWT(opts)
 	if err != nil {
 		return err
 	}
 
 	if jwt == "" {
-		return errors.New("JWT cannot be empty")
+		return exitcode.InvalidInputf("JWT cannot be empty")
 	}
 
-	home, err := os.UserHomeDir()
+	err = VerifyJWT(jwt)
 	if err != nil {
 		return err
 	}
-	p := filepath.Join(home, ".pinata-files-cli")
-	err = os.WriteFile(p, []byte(jwt), 0600)
+	err = WriteJWT(jwt)
 	if err != nil {
 		return err
 	}
-	url := fmt.Sprintf("https://%s/data/testAuthentication", config.GetAPIHost())
-	req, err := http.NewRequest("GET", url, nil)
+
+	fmt.Println("Authentication Successful!")
+	if opts.Gateway != "" {
+		return gateways.SetGateway(opts.Gateway)
+	}
+	if !isInteractive() {
+		fmt.Println("Skipping gateway selection, set one with --gateway or 'pinata gateways set'")
+		return nil
+	}
+	err = gateways.SetGateway("")
 	if err != nil {
 		return err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+jwt)
+	return nil
+}
 
-	client := &http.Client{
-		Timeout: time.Duration(time.Second * 3),
-	}
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
+func readJWT(opts LoginOptions) (string, error) {
+	switch {
+	case opts.JWTStdin && opts.JWTFile != "":
+		return "", exitcode.InvalidInputf("use either --jwt-stdin or --jwt-file, not both")
+	case opts.JWTStdin:
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(data)), nil
+	case opts.JWTFile != "":
+		data, err := os.ReadFile(opts.JWTFile)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(data)), nil
 	}
 
-	defer resp.Body.Close()
-	status := resp.StatusCode
-	if status != 200 {
-		return errors.New("Authentication failed, make sure you are using the Pinata JWT")
+	if !isInteractive() {
+		return "", exitcode.InvalidInputf("no terminal available, pass the JWT with --jwt-stdin or --jwt-file")
 	}
+	return utils.GetInput("Enter your Pinata JWT", "Pinata JWT")
+}
 
-	fmt.Println("Authentication Successful!")
-	err = gateways.SetGateway("")
+func isInteractive() bool {
+	fi, err := os.Stdin.Stat()
 	if err != nil {
-		return err
+		return false
 	}
+	return (fi.Mode() & os.ModeCharDevice) != 0
+}
 
-	return nil
+// WriteJWT stores the JWT for the active profile. It goes into the encrypted
+// credential store once one exists or when encryption is required, and into
+// the config file otherwise.
+func WriteJWT(jwt string) error {
+	if credentials.Exists() || config.RequiresEncryptedCredentials() {
+		// A profile that doesn't exist yet is created by UpdateActiveProfile
+		name, _, err := config.ActiveProfile()
+		if err != nil && name == "" {
+			return err
+		}
+		err = credentials.Set(name, jwt)
+		if err != nil {
+			return err
+		}
+		jwt = ""
+	}
+	return config.UpdateActiveProfile(func(p *config.Profile) {
+		p.JWT = jwt
+	})
+}
+
+// VerifyJWT checks the JWT against the testAuthentication endpoint
+func VerifyJWT(jwt string) error {
+	err := api.Client().WithToken(jwt).TestAuthentication(api.Context())
+	if status := api.StatusCode(err); status == http.StatusUnauthorized || status == http.StatusForbidden {
+		return errors.Join(err, errors.New("Authentication failed, make sure you are using the Pinata JWT"))
+	}
+	return err
 }
 
 func GetHost() string {
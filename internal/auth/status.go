@@ -0,0 +1,206 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/PinataCloud/ipfs-cli/internal/common"
+	"github.com/PinataCloud/ipfs-cli/internal/config"
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+)
+
+// Claims are the fields of a Pinata JWT shown by Status
+type Claims struct {
+	UserID    string   `json:"user_id,omitempty"`
+	Email     string   `json:"email,omitempty"`
+	KeyID     string   `json:"key_id,omitempty"`
+	Type      string   `json:"type,omitempty"`
+	Scopes    []string `json:"scopes,omitempty"`
+	IssuedAt  string   `json:"issued_at,omitempty"`
+	ExpiresAt string   `json:"expires_at,omitempty"`
+	Expired   bool     `json:"expired"`
+}
+
+// StatusReport is the output of Status
+type StatusReport struct {
+	Profile     string  `json:"profile"`
+	Network     string  `json:"network"`
+	Gateway     string  `json:"gateway,omitempty"`
+	APIHost     string  `json:"api_host"`
+	TokenSource string  `json:"token_source"`
+	Claims      *Claims `json:"claims,omitempty"`
+	Valid       *bool   `json:"valid,omitempty"`
+	Error       string  `json:"error,omitempty"`
+}
+
+// Status decodes the active JWT locally and, unless offline, checks it against
+// the API. It returns an error when the JWT is missing, expired or rejected.
+func Status(offline bool, jsonOutput bool) (StatusReport, error) {
+	jwt, source, err := common.FindTokenWithSource()
+	if err != nil {
+		return StatusReport{}, err
+	}
+
+	name, _, err := config.ActiveProfile()
+	if err != nil {
+		return StatusReport{}, err
+	}
+	network, err := config.GetDefaultNetwork()
+	if err != nil {
+		return StatusReport{}, err
+	}
+	report := StatusReport{
+		Profile:     name,
+		Network:     network,
+		APIHost:     config.GetAPIHost(),
+		TokenSource: source,
+	}
+	if domain, err := common.FindGatewayDomain(); err == nil {
+		report.Gateway = string(domain)
+	}
+
+	var statusErr error
+	claims, err := DecodeClaims(string(jwt))
+	if err != nil {
+		statusErr = err
+	} else {
+		report.Claims = &claims
+		if claims.Expired {
+			statusErr = exitcode.Unauthorizedf("the JWT has expired")
+		}
+	}
+
+	if !offline && statusErr == nil {
+		err := VerifyJWT(string(jwt))
+		valid := err == nil
+		report.Valid = &valid
+		statusErr = err
+	}
+	if statusErr != nil {
+		report.Error = statusErr.Error()
+	}
+
+	if jsonOutput {
+		formattedJSON, err := json.MarshalIndent(report, "", "    ")
+		if err != nil {
+			return report, errors.New("failed to format JSON")
+		}
+		fmt.Println(string(formattedJSON))
+	} else {
+		printStatus(report)
+	}
+
+	return report, statusErr
+}
+
+// DecodeClaims reads the payload of a JWT without verifying its signature
+func DecodeClaims(jwt string) (Claims, error) {
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		return Claims{}, exitcode.Unauthorizedf("the stored credential is not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return Claims{}, fmt.Errorf("failed to decode the JWT payload: %w", err)
+	}
+
+	var raw struct {
+		UserInformation struct {
+			ID    string `json:"id"`
+			Email string `json:"email"`
+		} `json:"userInformation"`
+		AuthenticationType string          `json:"authenticationType"`
+		ScopedKeyKey       string          `json:"scopedKeyKey"`
+		Scopes             json.RawMessage `json:"scopes"`
+		IssuedAt           int64           `json:"iat"`
+		ExpiresAt          int64           `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &raw); err != nil {
+		return Claims{}, fmt.Errorf("failed to decode the JWT payload: %w", err)
+	}
+
+	claims := Claims{
+		UserID: raw.UserInformation.ID,
+		Email:  raw.UserInformation.Email,
+		KeyID:  raw.ScopedKeyKey,
+		Type:   raw.AuthenticationType,
+		Scopes: decodeScopes(raw.Scopes),
+	}
+	if raw.IssuedAt > 0 {
+		claims.IssuedAt = time.Unix(raw.IssuedAt, 0).UTC().Format(time.RFC3339)
+	}
+	if raw.ExpiresAt > 0 {
+		expires := time.Unix(raw.ExpiresAt, 0)
+		claims.ExpiresAt = expires.UTC().Format(time.RFC3339)
+		claims.Expired = time.Now().After(expires)
+	}
+	return claims, nil
+}
+
+// decodeScopes accepts scopes either as a list of names or as an object of
+// flags, and returns the names that are granted
+func decodeScopes(raw json.RawMessage) []string {
+	if len(raw) == 0 {
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil {
+		return list
+	}
+	var flags map[string]interface{}
+	if err := json.Unmarshal(raw, &flags); err != nil {
+		return nil
+	}
+	// Sorted, so the output doesn't change from run to run
+	var scopes []string
+	for _, name := range slices.Sorted(maps.Keys(flags)) {
+		if granted, ok := flags[name].(bool); ok && granted {
+			scopes = append(scopes, name)
+		}
+	}
+	return scopes
+}
+
+func printStatus(report StatusReport) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Profile:\t%s\n", report.Profile)
+	fmt.Fprintf(w, "Network:\t%s\n", report.Network)
+	fmt.Fprintf(w, "Gateway:\t%s\n", orNone(report.Gateway))
+	fmt.Fprintf(w, "API host:\t%s\n", report.APIHost)
+	fmt.Fprintf(w, "JWT source:\t%s\n", report.TokenSource)
+	if report.Claims != nil {
+		fmt.Fprintf(w, "Key:\t%s\n", orNone(report.Claims.KeyID))
+		fmt.Fprintf(w, "User:\t%s\n", orNone(report.Claims.Email))
+		fmt.Fprintf(w, "Scopes:\t%s\n", orNone(strings.Join(report.Claims.Scopes, ", ")))
+		fmt.Fprintf(w, "Issued:\t%s\n", orNone(report.Claims.IssuedAt))
+		expires := orNone(report.Claims.ExpiresAt)
+		if report.Claims.Expired {
+			expires += " (expired)"
+		}
+		fmt.Fprintf(w, "Expires:\t%s\n", expires)
+	}
+	switch {
+	case report.Error != "":
+		fmt.Fprintf(w, "Status:\tinvalid: %s\n", report.Error)
+	case report.Valid != nil:
+		fmt.Fprintf(w, "Status:\tvalid\n")
+	default:
+		fmt.Fprintf(w, "Status:\tnot checked (offline)\n")
+	}
+	w.Flush()
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return value
+}
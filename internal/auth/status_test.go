@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"encoding/base64"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+)
+
+// testJWT returns an unsigned JWT with a payload
+func testJWT(payload string) string {
+	encode := base64.RawURLEncoding.EncodeToString
+	return encode([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." + encode([]byte(payload)) + ".signature"
+}
+
+func TestDecodeClaims(t *testing.T) {
+	issued := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+	claims, err := DecodeClaims(testJWT(`{
+		"userInformation": {"id": "user-1", "email": "ada@example.com"},
+		"authenticationType": "scopedKey",
+		"scopedKeyKey": "key-1",
+		"scopes": {"files": true, "admin": false, "groups": true, "analytics": true, "gateways": "yes"},
+		"iat": ` + strconv.FormatInt(issued.Unix(), 10) + `,
+		"exp": ` + strconv.FormatInt(expires.Unix(), 10) + `
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Claims{
+		UserID:    "user-1",
+		Email:     "ada@example.com",
+		KeyID:     "key-1",
+		Type:      "scopedKey",
+		Scopes:    []string{"analytics", "files", "groups"},
+		IssuedAt:  "2026-01-02T03:04:05Z",
+		ExpiresAt: expires.UTC().Format(time.RFC3339),
+	}
+	if !reflect.DeepEqual(claims, want) {
+		t.Errorf("got %+v, want %+v", claims, want)
+	}
+}
+
+func TestDecodeScopesOrder(t *testing.T) {
+	jwt := testJWT(`{"scopes": {"pinning": true, "files": true, "keys": true, "groups": true, "gateways": true, "analytics": true}}`)
+	first, err := DecodeClaims(jwt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.IsSorted(first.Scopes) || len(first.Scopes) != 6 {
+		t.Fatalf("scopes are %v, want them sorted", first.Scopes)
+	}
+	// Maps are read in a random order, so a few runs would catch a
+	// change of order
+	for range 20 {
+		claims, _ := DecodeClaims(jwt)
+		if !slices.Equal(claims.Scopes, first.Scopes) {
+			t.Fatalf("scopes changed from %v to %v", first.Scopes, claims.Scopes)
+		}
+	}
+
+	// A list is kept in its order
+	claims, err := DecodeClaims(testJWT(`{"scopes": ["org:files:read", "org:files:write"]}`))
+	if err != nil || !slices.Equal(claims.Scopes, []string{"org:files:read", "org:files:write"}) {
+		t.Errorf("got %v, %v", claims.Scopes, err)
+	}
+}
+
+func TestDecodeClaimsExpired(t *testing.T) {
+	claims, err := DecodeClaims(testJWT(`{"exp": ` + strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10) + `}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !claims.Expired || claims.ExpiresAt == "" {
+		t.Errorf("got %+v, want it expired", claims)
+	}
+
+	// A JWT without an expiry never expires
+	claims, err = DecodeClaims(testJWT(`{"userInformation": {"id": "user-1"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Expired || claims.ExpiresAt != "" || claims.IssuedAt != "" || claims.Scopes != nil {
+		t.Errorf("got %+v, want no expiry", claims)
+	}
+}
+
+func TestDecodeClaimsMalformed(t *testing.T) {
+	tests := []struct {
+		name    string
+		jwt     string
+		message string
+	}{
+		{"empty", "", "not a JWT"},
+		{"API key", "3f2a9c1e7d21e4b0aa02", "not a JWT"},
+		{"two parts", "header.payload", "not a JWT"},
+		{"four parts", "a.b.c.d", "not a JWT"},
+		{"payload isn't base64", "header.!!!.signature", "failed to decode"},
+		{"payload isn't JSON", "header." + base64.RawURLEncoding.EncodeToString([]byte("not json")) + ".signature", "failed to decode"},
+		{"wrong claim type", testJWT(`{"exp": "tomorrow"}`), "failed to decode"},
+	}
+	for _, tt := range tests {
+		_, err := DecodeClaims(tt.jwt)
+		if err == nil || !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("%s gave %v, want an error mentioning %q", tt.name, err, tt.message)
+		}
+	}
+	if _, err := DecodeClaims("not-a-jwt"); exitcode.For(err) != exitcode.Unauthorized {
+		t.Errorf("a credential that isn't a JWT gave %v, want unauthorized", err)
+	}
+}
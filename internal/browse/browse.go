@@ -0,0 +1,596 @@
+// Package browse is a full-screen explorer over the files and groups of
+// either network, with the common file actions bound to keys.
+package browse
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/PinataCloud/ipfs-cli/internal/config"
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+)
+
+type tab int
+
+const (
+	tabFiles tab = iota
+	tabGroups
+)
+
+// mode is what keys currently do
+type mode int
+
+const (
+	modeList mode = iota
+	// modeSearch filters the list as you type
+	modeSearch
+	// modePrompt reads a value for the pending action
+	modePrompt
+	// modeConfirm waits for y before deleting
+	modeConfirm
+	modeHelp
+)
+
+// action is what a prompt's value is for
+type action int
+
+const (
+	actionRename action = iota
+	actionKeyValues
+	actionAddToGroup
+	actionFilter
+)
+
+// filter narrows the list, server side
+type filter struct {
+	name      string
+	cid       string
+	mimeType  string
+	keyValues map[string]string
+	// groupRef is the group as typed, groupID and groupName what it
+	// resolved to
+	groupRef  string
+	groupID   string
+	groupName string
+}
+
+func (f filter) empty() bool {
+	return f.name == "" && f.String() == ""
+}
+
+// String writes the filter as the filter prompt reads it. The name is left
+// out, it's the search.
+func (f filter) String() string {
+	var terms []string
+	if f.groupID != "" {
+		terms = append(terms, "group:"+f.groupName)
+	}
+	if f.mimeType != "" {
+		terms = append(terms, "mime:"+f.mimeType)
+	}
+	if f.cid != "" {
+		terms = append(terms, "cid:"+f.cid)
+	}
+	keys := make([]string, 0, len(f.keyValues))
+	for key := range f.keyValues {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		terms = append(terms, key+"="+f.keyValues[key])
+	}
+	return strings.Join(terms, " ")
+}
+
+// Model is the bubbletea model of the browser
+type Model struct {
+	tab     tab
+	mode    mode
+	action  action
+	network string
+	filter  filter
+
+	files     []pinata.File
+	groups    []pinata.Group
+	nextPage  string
+	loading   bool
+	loadedAll bool
+	// seq tells the pages of the current listing from those of one that was
+	// replaced while they were loading
+	seq int
+
+	// query filters the loaded items as you type, before the search is
+	// sent to the API
+	query  string
+	cursor int
+	offset int
+
+	groupNames map[string]string
+
+	input  textinput.Model
+	status string
+	err    error
+
+	width  int
+	height int
+}
+
+// NewModel returns a browser over the files of a network
+func NewModel(network string) Model {
+	input := textinput.New()
+	input.Prompt = ""
+	return Model{
+		network:    network,
+		input:      input,
+		groupNames: map[string]string{},
+		loading:    true,
+	}
+}
+
+// Run opens the browser on a network, or the default one
+func Run(network string) error {
+	if fi, err := os.Stdout.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return exitcode.InvalidInputf("browse needs a terminal, use 'files list' or 'groups list' in scripts")
+	}
+	network, err := config.GetNetworkParam(network)
+	if err != nil {
+		return err
+	}
+	_, err = tea.NewProgram(NewModel(network)).Run()
+	return err
+}
+
+func (m Model) Init() tea.Cmd {
+	return m.loadFiles("")
+}
+
+// reload lists the current tab again from the first page
+func (m Model) reload() (Model, tea.Cmd) {
+	m.seq++
+	m.files = nil
+	m.groups = nil
+	m.nextPage = ""
+	m.loadedAll = false
+	m.loading = true
+	m.cursor = 0
+	m.offset = 0
+	if m.tab == tabGroups {
+		return m, m.loadGroups("")
+	}
+	return m, m.loadFiles("")
+}
+
+// loadMore fetches the next page when the cursor nears the end of what's
+// loaded, or the loaded items don't fill the screen
+func (m Model) loadMore() (Model, tea.Cmd) {
+	if m.loading || m.loadedAll || m.cursor < m.visibleCount()-5 && m.visibleCount() >= m.listHeight() {
+		return m, nil
+	}
+	m.loading = true
+	if m.tab == tabGroups {
+		return m, m.loadGroups(m.nextPage)
+	}
+	return m, m.loadFiles(m.nextPage)
+}
+
+// visible returns the indexes of the loaded items matching the query
+func (m Model) visible() []int {
+	query := strings.ToLower(m.query)
+	var indexes []int
+	if m.tab == tabGroups {
+		for i, g := range m.groups {
+			if strings.Contains(strings.ToLower(g.Name), query) || strings.HasPrefix(g.Id, query) {
+				indexes = append(indexes, i)
+			}
+		}
+		return indexes
+	}
+	for i, f := range m.files {
+		if strings.Contains(strings.ToLower(f.Name), query) || strings.HasPrefix(strings.ToLower(f.Cid), query) {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+func (m Model) visibleCount() int {
+	return len(m.visible())
+}
+
+// selectedFile returns the file under the cursor
+func (m Model) selectedFile() (pinata.File, bool) {
+	visible := m.visible()
+	if m.tab != tabFiles || m.cursor >= len(visible) {
+		return pinata.File{}, false
+	}
+	return m.files[visible[m.cursor]], true
+}
+
+// selectedGroup returns the group under the cursor
+func (m Model) selectedGroup() (pinata.Group, bool) {
+	visible := m.visible()
+	if m.tab != tabGroups || m.cursor >= len(visible) {
+		return pinata.Group{}, false
+	}
+	return m.groups[visible[m.cursor]], true
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.input.SetWidth(max(m.width-20, 10))
+		return m.loadMore()
+
+	case filesMsg:
+		if msg.seq != m.seq {
+			return m, nil
+		}
+		m.loading = false
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.files = append(m.files, msg.list.Files...)
+		m.nextPage = msg.list.NextPageToken
+		m.loadedAll = m.nextPage == "" || len(msg.list.Files) == 0
+		m.clampCursor()
+		m, cmd := m.loadMore()
+		return m, tea.Batch(cmd, m.lookupGroupName())
+
+	case groupsMsg:
+		if msg.seq != m.seq {
+			return m, nil
+		}
+		m.loading = false
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.groups = append(m.groups, msg.list.Groups...)
+		for _, g := range msg.list.Groups {
+			m.groupNames[g.Id] = g.Name
+		}
+		m.nextPage = msg.list.NextPageToken
+		m.loadedAll = m.nextPage == "" || len(msg.list.Groups) == 0
+		m.clampCursor()
+		return m.loadMore()
+
+	case groupNameMsg:
+		m.groupNames[msg.id] = msg.name
+		return m, nil
+
+	case resolveGroupMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		msg.filter.name = m.filter.name
+		m.filter = msg.filter
+		return m.reload()
+
+	case linkMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		if msg.open {
+			m.setStatus("Opened " + msg.url)
+			return m, nil
+		}
+		m.setStatus("Copied link " + msg.url)
+		return m, tea.SetClipboard(msg.url)
+
+	case doneMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.setStatus(msg.status)
+		if msg.reload {
+			cursor := m.cursor
+			m, cmd := m.reload()
+			m.cursor = cursor
+			return m, cmd
+		}
+		return m, nil
+
+	case tea.KeyPressMsg:
+		switch m.mode {
+		case modeSearch:
+			return m.updateSearch(msg)
+		case modePrompt:
+			return m.updatePrompt(msg)
+		case modeConfirm:
+			return m.updateConfirm(msg)
+		case modeHelp:
+			m.mode = modeList
+			return m, nil
+		}
+		return m.updateList(msg)
+	}
+	return m, nil
+}
+
+func (m *Model) setStatus(status string) {
+	m.status = status
+	m.err = nil
+}
+
+// lookupGroupName fetches the name of the selected file's group when it
+// isn't known yet
+func (m Model) lookupGroupName() tea.Cmd {
+	f, ok := m.selectedFile()
+	if !ok || f.GroupId == nil {
+		return nil
+	}
+	if _, known := m.groupNames[*f.GroupId]; known {
+		return nil
+	}
+	m.groupNames[*f.GroupId] = ""
+	return m.loadGroupName(*f.GroupId)
+}
+
+func (m Model) updateList(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	m.status = ""
+	m.err = nil
+	count := m.visibleCount()
+
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return m, tea.Quit
+	case "?":
+		m.mode = modeHelp
+	case "up", "k":
+		m.cursor = max(m.cursor-1, 0)
+	case "down", "j":
+		m.cursor = min(m.cursor+1, max(count-1, 0))
+	case "pgup":
+		m.cursor = max(m.cursor-m.listHeight(), 0)
+	case "pgdown":
+		m.cursor = min(m.cursor+m.listHeight(), max(count-1, 0))
+	case "home":
+		m.cursor = 0
+	case "end":
+		m.cursor = max(count-1, 0)
+	case "tab":
+		if m.tab == tabFiles {
+			m.tab = tabGroups
+		} else {
+			m.tab = tabFiles
+		}
+		m.query = ""
+		m.filter = filter{}
+		return m.reload()
+	case "n":
+		if m.network == pinata.NetworkPublic {
+			m.network = pinata.NetworkPrivate
+		} else {
+			m.network = pinata.NetworkPublic
+		}
+		m.filter = filter{}
+		m.query = ""
+		m.groupNames = map[string]string{}
+		return m.reload()
+	case "R", "ctrl+r":
+		return m.reload()
+	case "/":
+		m.mode = modeSearch
+		m.input.SetValue(m.query)
+		m.input.CursorEnd()
+		return m, m.input.Focus()
+	case "esc":
+		if m.query == "" && m.filter.empty() {
+			return m, nil
+		}
+		m.query = ""
+		m.filter = filter{}
+		return m.reload()
+	case "f":
+		if m.tab == tabFiles {
+			return m.prompt(actionFilter, m.filter.String())
+		}
+	case "enter":
+		if g, ok := m.selectedGroup(); ok {
+			m.tab = tabFiles
+			m.query = ""
+			m.filter = filter{groupRef: g.Id, groupID: g.Id, groupName: g.Name}
+			return m.reload()
+		}
+	case "c":
+		if f, ok := m.selectedFile(); ok {
+			m.setStatus("Copied CID " + f.Cid)
+			return m, tea.SetClipboard(f.Cid)
+		}
+		if g, ok := m.selectedGroup(); ok {
+			m.setStatus("Copied group ID " + g.Id)
+			return m, tea.SetClipboard(g.Id)
+		}
+	case "l":
+		if f, ok := m.selectedFile(); ok {
+			return m, m.link(f.Cid, false)
+		}
+	case "o":
+		if f, ok := m.selectedFile(); ok {
+			return m, m.link(f.Cid, true)
+		}
+	case "r":
+		if f, ok := m.selectedFile(); ok {
+			return m.prompt(actionRename, f.Name)
+		}
+		if g, ok := m.selectedGroup(); ok {
+			return m.prompt(actionRename, g.Name)
+		}
+	case "e":
+		if f, ok := m.selectedFile(); ok {
+			return m.prompt(actionKeyValues, formatKeyValues(f.KeyValues))
+		}
+	case "a":
+		if _, ok := m.selectedFile(); ok {
+			return m.prompt(actionAddToGroup, "")
+		}
+	case "x":
+		if f, ok := m.selectedFile(); ok {
+			if f.GroupId == nil {
+				m.setStatus(f.Name + " isn't in a group")
+				return m, nil
+			}
+			return m, m.removeFromGroup(f)
+		}
+	case "d", "delete":
+		if count > 0 {
+			m.mode = modeConfirm
+		}
+	}
+
+	m.scroll()
+	m, cmd := m.loadMore()
+	return m, tea.Batch(cmd, m.lookupGroupName())
+}
+
+// clampCursor moves the cursor back on the list once all of it is loaded,
+// e.g. after the last item was deleted
+func (m *Model) clampCursor() {
+	if m.loadedAll && m.cursor >= m.visibleCount() {
+		m.cursor = max(m.visibleCount()-1, 0)
+	}
+	m.scroll()
+}
+
+// scroll keeps the cursor on screen
+func (m *Model) scroll() {
+	height := m.listHeight()
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+height {
+		m.offset = m.cursor - height + 1
+	}
+}
+
+// updateSearch filters the loaded items on every key, and lists matches
+// from the API on enter
+func (m Model) updateSearch(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode = modeList
+		m.input.Blur()
+		m.query = ""
+		if m.filter.name != "" {
+			m.filter.name = ""
+			return m.reload()
+		}
+		return m, nil
+	case "enter":
+		m.mode = modeList
+		m.input.Blur()
+		m.filter.name = m.query
+		m.query = ""
+		return m.reload()
+	case "up", "down":
+		return m.updateList(msg)
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	m.query = m.input.Value()
+	m.cursor = 0
+	m.offset = 0
+	return m, cmd
+}
+
+func (m Model) prompt(a action, value string) (tea.Model, tea.Cmd) {
+	m.mode = modePrompt
+	m.action = a
+	m.input.SetValue(value)
+	m.input.CursorEnd()
+	return m, m.input.Focus()
+}
+
+func (m Model) updatePrompt(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode = modeList
+		m.input.Blur()
+		return m, nil
+	case "enter":
+		m.mode = modeList
+		m.input.Blur()
+		return m.submit(strings.TrimSpace(m.input.Value()))
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// submit runs the pending action with the prompt's value
+func (m Model) submit(value string) (tea.Model, tea.Cmd) {
+	switch m.action {
+	case actionFilter:
+		f, err := parseFilter(value)
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		if f.groupRef != "" {
+			return m, m.resolveFilterGroup(f)
+		}
+		f.name = m.filter.name
+		m.filter = f
+		return m.reload()
+	case actionRename:
+		if value == "" {
+			return m, nil
+		}
+		if f, ok := m.selectedFile(); ok {
+			return m, m.renameFile(f, value)
+		}
+		if g, ok := m.selectedGroup(); ok {
+			return m, m.renameGroup(g, value)
+		}
+	case actionKeyValues:
+		if f, ok := m.selectedFile(); ok {
+			return m, m.setKeyValues(f, value)
+		}
+	case actionAddToGroup:
+		if f, ok := m.selectedFile(); ok && value != "" {
+			return m, m.addToGroup(f, value)
+		}
+	}
+	return m, nil
+}
+
+func (m Model) updateConfirm(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	m.mode = modeList
+	if msg.String() != "y" {
+		m.setStatus("Not deleted")
+		return m, nil
+	}
+	if f, ok := m.selectedFile(); ok {
+		return m, m.deleteFile(f)
+	}
+	if g, ok := m.selectedGroup(); ok {
+		return m, m.deleteGroup(g)
+	}
+	return m, nil
+}
+
+// formatKeyValues writes key-values as the key-values prompt reads them
+func formatKeyValues(keyValues map[string]interface{}) string {
+	keys := make([]string, 0, len(keyValues))
+	for key := range keyValues {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = fmt.Sprintf("%s=%v", key, keyValues[key])
+	}
+	return strings.Join(pairs, " ")
+}
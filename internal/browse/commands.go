@@ -0,0 +1,225 @@
+package browse
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/PinataCloud/ipfs-cli/internal/api"
+	"github.com/PinataCloud/ipfs-cli/internal/gateways"
+	"github.com/PinataCloud/ipfs-cli/internal/refs"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// pageSize is how many files or groups are loaded at a time
+const pageSize = 50
+
+// linkExpiry is how long copied links to private files stay valid, in seconds
+const linkExpiry = 3600
+
+// filesMsg delivers a page of files
+type filesMsg struct {
+	seq  int
+	list *pinata.FileList
+	err  error
+}
+
+// groupsMsg delivers a page of groups
+type groupsMsg struct {
+	seq  int
+	list *pinata.GroupList
+	err  error
+}
+
+// groupNameMsg delivers the name of a group shown in the detail pane
+type groupNameMsg struct {
+	id   string
+	name string
+}
+
+// doneMsg reports the outcome of an action. reload refreshes the list after
+// a change.
+type doneMsg struct {
+	status string
+	err    error
+	reload bool
+}
+
+// linkMsg delivers a link to copy or open
+type linkMsg struct {
+	url  string
+	open bool
+	err  error
+}
+
+func (m Model) loadFiles(pageToken string) tea.Cmd {
+	opts := pinata.ListFilesOptions{
+		Network:   m.network,
+		Name:      m.filter.name,
+		Cid:       m.filter.cid,
+		GroupId:   m.filter.groupID,
+		MimeType:  m.filter.mimeType,
+		KeyValues: m.filter.keyValues,
+		Limit:     pageSize,
+		PageToken: pageToken,
+	}
+	seq := m.seq
+	return func() tea.Msg {
+		list, err := api.Client().ListFiles(api.Context(), opts)
+		return filesMsg{seq: seq, list: list, err: err}
+	}
+}
+
+func (m Model) loadGroups(pageToken string) tea.Cmd {
+	opts := pinata.ListGroupsOptions{
+		Network:   m.network,
+		Name:      m.filter.name,
+		Limit:     pageSize,
+		PageToken: pageToken,
+	}
+	seq := m.seq
+	return func() tea.Msg {
+		list, err := api.Client().ListGroups(api.Context(), opts)
+		return groupsMsg{seq: seq, list: list, err: err}
+	}
+}
+
+func (m Model) loadGroupName(id string) tea.Cmd {
+	network := m.network
+	return func() tea.Msg {
+		group, err := api.Client().GetGroup(api.Context(), network, id)
+		if err != nil {
+			return groupNameMsg{id: id}
+		}
+		return groupNameMsg{id: id, name: group.Name}
+	}
+}
+
+func (m Model) link(cid string, open bool) tea.Cmd {
+	network := m.network
+	return func() tea.Msg {
+		url, err := gateways.AccessLink(cid, linkExpiry, network, gateways.LinkOptions{})
+		if err == nil && open {
+			err = gateways.OpenURL(url)
+		}
+		return linkMsg{url: url, open: open, err: err}
+	}
+}
+
+func (m Model) renameFile(f pinata.File, name string) tea.Cmd {
+	network := m.network
+	return func() tea.Msg {
+		_, err := api.Client().UpdateFile(api.Context(), network, f.Id, name)
+		return doneMsg{status: fmt.Sprintf("Renamed %s to %s", f.Name, name), err: err, reload: true}
+	}
+}
+
+func (m Model) renameGroup(g pinata.Group, name string) tea.Cmd {
+	network := m.network
+	return func() tea.Msg {
+		_, err := api.Client().UpdateGroup(api.Context(), network, g.Id, name)
+		return doneMsg{status: fmt.Sprintf("Renamed group %s to %s", g.Name, name), err: err, reload: true}
+	}
+}
+
+func (m Model) setKeyValues(f pinata.File, input string) tea.Cmd {
+	network := m.network
+	return func() tea.Msg {
+		keyValues, err := parseKeyValues(input)
+		if err == nil {
+			_, err = api.Client().SetFileKeyValues(api.Context(), network, f.Id, keyValues)
+		}
+		return doneMsg{status: fmt.Sprintf("Updated the key-values of %s", f.Name), err: err, reload: true}
+	}
+}
+
+// addToGroup adds a file to a group given by ID, name or unique prefix
+func (m Model) addToGroup(f pinata.File, ref string) tea.Cmd {
+	network := m.network
+	return func() tea.Msg {
+		groupID, err := refs.Resolve(api.Context(), refs.Group, ref, network)
+		if err == nil {
+			err = api.Client().AddFileToGroup(api.Context(), network, groupID, f.Id)
+		}
+		return doneMsg{status: fmt.Sprintf("Added %s to %s", f.Name, ref), err: err, reload: true}
+	}
+}
+
+func (m Model) removeFromGroup(f pinata.File) tea.Cmd {
+	network := m.network
+	return func() tea.Msg {
+		err := api.Client().RemoveFileFromGroup(api.Context(), network, *f.GroupId, f.Id)
+		return doneMsg{status: fmt.Sprintf("Removed %s from its group", f.Name), err: err, reload: true}
+	}
+}
+
+func (m Model) deleteFile(f pinata.File) tea.Cmd {
+	network := m.network
+	return func() tea.Msg {
+		err := api.Client().DeleteFile(api.Context(), network, f.Id)
+		return doneMsg{status: fmt.Sprintf("Deleted %s", f.Name), err: err, reload: true}
+	}
+}
+
+func (m Model) deleteGroup(g pinata.Group) tea.Cmd {
+	network := m.network
+	return func() tea.Msg {
+		err := api.Client().DeleteGroup(api.Context(), network, g.Id)
+		return doneMsg{status: fmt.Sprintf("Deleted group %s", g.Name), err: err, reload: true}
+	}
+}
+
+// parseKeyValues parses space separated key=value pairs
+func parseKeyValues(input string) (map[string]string, error) {
+	keyValues := map[string]string{}
+	for _, pair := range strings.Fields(input) {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid key-value %q, expected key=value", pair)
+		}
+		keyValues[key] = value
+	}
+	return keyValues, nil
+}
+
+// parseFilter parses the filter prompt: mime:<type>, cid:<cid>,
+// group:<ref> and key=value terms, separated by spaces
+func parseFilter(input string) (filter, error) {
+	var f filter
+	for _, term := range strings.Fields(input) {
+		switch {
+		case strings.HasPrefix(term, "mime:"):
+			f.mimeType = strings.TrimPrefix(term, "mime:")
+		case strings.HasPrefix(term, "cid:"):
+			f.cid = strings.TrimPrefix(term, "cid:")
+		case strings.HasPrefix(term, "group:"):
+			f.groupRef = term
+		case strings.Contains(term, "="):
+			key, value, _ := strings.Cut(term, "=")
+			if f.keyValues == nil {
+				f.keyValues = map[string]string{}
+			}
+			f.keyValues[key] = value
+		default:
+			return filter{}, fmt.Errorf("unknown filter %q, use mime:<type>, cid:<cid>, group:<name> or key=value", term)
+		}
+	}
+	return f, nil
+}
+
+// resolveGroupMsg delivers the group a filter refers to
+type resolveGroupMsg struct {
+	filter filter
+	err    error
+}
+
+func (m Model) resolveFilterGroup(f filter) tea.Cmd {
+	network := m.network
+	return func() tea.Msg {
+		id, err := refs.Resolve(api.Context(), refs.Group, f.groupRef, network)
+		f.groupID = id
+		f.groupName = strings.TrimPrefix(f.groupRef, "group:")
+		return resolveGroupMsg{filter: f, err: err}
+	}
+}
@@ -0,0 +1,294 @@
+package browse
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+)
+
+var (
+	titleStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("15")).Bold(true)
+	tabStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	activeStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("14")).Bold(true)
+	dimStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	selectedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("15")).Background(lipgloss.Color("8"))
+	labelStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Width(10)
+	errorStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	statusStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+	promptStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+)
+
+// listHeight is the number of rows the list shows
+func (m Model) listHeight() int {
+	return max(m.height-5, 1)
+}
+
+func (m Model) View() tea.View {
+	if m.width == 0 {
+		return tea.NewView("")
+	}
+
+	var sb strings.Builder
+	sb.WriteString(m.renderHeader())
+	sb.WriteString("\n")
+	divider := dimStyle.Render(strings.Repeat("─", m.width))
+	sb.WriteString(divider)
+	sb.WriteString("\n")
+
+	if m.mode == modeHelp {
+		sb.WriteString(lipgloss.NewStyle().Height(m.listHeight()).Render(helpText))
+	} else {
+		listWidth := m.width * 55 / 100
+		list := lipgloss.NewStyle().Width(listWidth).Height(m.listHeight()).MaxHeight(m.listHeight()).Render(m.renderList(listWidth))
+		detail := lipgloss.NewStyle().
+			Width(m.width - listWidth - 3).
+			Height(m.listHeight()).
+			MaxHeight(m.listHeight()).
+			Render(m.renderDetail())
+		separator := dimStyle.Render(strings.TrimSuffix(strings.Repeat(" │\n", m.listHeight()), "\n"))
+		sb.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, list, separator, " ", detail))
+	}
+	sb.WriteString("\n")
+	sb.WriteString(divider)
+	sb.WriteString("\n")
+	sb.WriteString(m.renderStatus())
+	sb.WriteString("\n")
+	sb.WriteString(dimStyle.Render(truncate(m.helpLine(), m.width)))
+
+	v := tea.NewView(sb.String())
+	v.AltScreen = true
+	return v
+}
+
+func (m Model) renderHeader() string {
+	tabs := []string{"Files", "Groups"}
+	for i, name := range tabs {
+		if tab(i) == m.tab {
+			tabs[i] = activeStyle.Render(name)
+		} else {
+			tabs[i] = tabStyle.Render(name)
+		}
+	}
+	header := titleStyle.Render("Pinata") + "  " + strings.Join(tabs, dimStyle.Render(" │ ")) + "  " + dimStyle.Render(m.network)
+	if m.filter.name != "" {
+		header += "  " + promptStyle.Render(fmt.Sprintf("search %q", m.filter.name))
+	}
+	if terms := m.filter.String(); terms != "" {
+		header += "  " + promptStyle.Render(terms)
+	}
+	if m.loading {
+		header += "  " + dimStyle.Render("loading…")
+	}
+	return header
+}
+
+func (m Model) renderList(width int) string {
+	visible := m.visible()
+	if len(visible) == 0 {
+		switch {
+		case m.loading:
+			return dimStyle.Render("Loading…")
+		case m.tab == tabGroups:
+			return dimStyle.Render("No groups")
+		}
+		return dimStyle.Render("No files")
+	}
+
+	var rows []string
+	end := min(m.offset+m.listHeight(), len(visible))
+	for i := m.offset; i < end; i++ {
+		var row string
+		if m.tab == tabGroups {
+			g := m.groups[visible[i]]
+			row = columns(width, g.Name, shortID(g.Id))
+		} else {
+			f := m.files[visible[i]]
+			row = columns(width, f.Name, formatSize(f.Size)+"  "+shortCID(f.Cid))
+		}
+		if i == m.cursor {
+			row = selectedStyle.Render(row)
+		}
+		rows = append(rows, row)
+	}
+	return strings.Join(rows, "\n")
+}
+
+// columns lays out a row with the name on the left, truncated to fit, and
+// the rest on the right
+func columns(width int, name, right string) string {
+	space := width - lipgloss.Width(right) - 2
+	if space < 1 {
+		return truncate(name, width)
+	}
+	name = truncate(name, space)
+	return name + strings.Repeat(" ", width-lipgloss.Width(name)-lipgloss.Width(right)) + right
+}
+
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	if width <= 1 {
+		return string(runes[:width])
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+func (m Model) renderDetail() string {
+	if f, ok := m.selectedFile(); ok {
+		return m.renderFile(f)
+	}
+	if g, ok := m.selectedGroup(); ok {
+		return strings.Join([]string{
+			field("Name", g.Name),
+			field("ID", g.Id),
+			field("Created", g.CreatedAt),
+			field("Network", m.network),
+			"",
+			dimStyle.Render("enter to show its files"),
+		}, "\n")
+	}
+	return ""
+}
+
+func (m Model) renderFile(f pinata.File) string {
+	group := dimStyle.Render("none")
+	if f.GroupId != nil {
+		group = *f.GroupId
+		if name := m.groupNames[*f.GroupId]; name != "" {
+			group = name + dimStyle.Render(" ("+*f.GroupId+")")
+		}
+	}
+	lines := []string{
+		field("Name", f.Name),
+		field("ID", f.Id),
+		field("CID", f.Cid),
+		field("Size", fmt.Sprintf("%s (%d bytes)", formatSize(f.Size), f.Size)),
+		field("Files", fmt.Sprint(f.NumberOfFiles)),
+		field("MIME type", f.MimeType),
+		field("Group", group),
+		field("Created", f.CreatedAt),
+		field("Network", m.network),
+		"",
+		labelStyle.Render("Keyvalues"),
+	}
+	if len(f.KeyValues) == 0 {
+		lines = append(lines, dimStyle.Render("  none"))
+	}
+	keys := make([]string, 0, len(f.KeyValues))
+	for key := range f.KeyValues {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		lines = append(lines, fmt.Sprintf("  %s = %v", key, f.KeyValues[key]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func field(label, value string) string {
+	return labelStyle.Render(label) + value
+}
+
+func (m Model) renderStatus() string {
+	switch m.mode {
+	case modeSearch:
+		return promptStyle.Render("/") + m.input.View()
+	case modePrompt:
+		return promptStyle.Render(promptLabels[m.action]+": ") + m.input.View()
+	case modeConfirm:
+		name := ""
+		if f, ok := m.selectedFile(); ok {
+			name = f.Name
+		} else if g, ok := m.selectedGroup(); ok {
+			name = "group " + g.Name
+		}
+		return errorStyle.Render(fmt.Sprintf("Delete %s? (y/N)", name))
+	}
+	if m.err != nil {
+		return errorStyle.Render(m.err.Error())
+	}
+	return statusStyle.Render(m.status)
+}
+
+var promptLabels = map[action]string{
+	actionRename:     "Name",
+	actionKeyValues:  "Key-values (key=value ...)",
+	actionAddToGroup: "Add to group (name or ID)",
+	actionFilter:     "Filter (mime:<type> cid:<cid> group:<name> key=value)",
+}
+
+func (m Model) helpLine() string {
+	switch m.mode {
+	case modeSearch:
+		return "type to filter · enter search all pages · esc cancel"
+	case modePrompt:
+		return "enter save · esc cancel"
+	case modeHelp:
+		return "any key to go back"
+	}
+	if m.tab == tabGroups {
+		return "↑/↓ move · enter files · / search · r rename · c copy ID · d delete · n network · tab files · ? help · q quit"
+	}
+	return "↑/↓ move · / search · f filter · c copy CID · l copy link · o open · r rename · e keyvalues · a/x group · d delete · ? help · q quit"
+}
+
+const helpText = `Navigation
+  ↑/k ↓/j pgup pgdown home end   move
+  tab                             switch between files and groups
+  n                               switch between the public and private network
+  /                               search by name, filtering as you type
+  f                               filter files by mime:<type>, cid:<cid>, group:<name> or key=value
+  enter                           show the files of a group
+  esc                             clear the search and filters
+  R                               reload
+
+Files
+  c   copy the CID
+  l   copy a gateway link, signed for an hour on the private network
+  o   open in the gateway
+  r   rename
+  e   add or change key-values
+  a   add to a group
+  x   remove from its group
+  d   delete, after confirming
+
+Groups
+  c   copy the ID
+  r   rename
+  d   delete, after confirming
+
+Copying uses the terminal clipboard (OSC 52), which some terminals don't support.`
+
+func shortCID(cid string) string {
+	if len(cid) <= 16 {
+		return cid
+	}
+	return cid[:8] + "…" + cid[len(cid)-6:]
+}
+
+func shortID(id string) string {
+	if len(id) <= 8 {
+		return id
+	}
+	return id[:8]
+}
+
+func formatSize(size int) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := unit, 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,126 @@
+package car
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+const (
+	// ChunkSize is the size of the raw leaves files are split into
+	ChunkSize = 256 << 10
+	// maxLinks is how many blocks a file node links to before the file gets
+	// another layer
+	maxLinks = 174
+	// maxBlockSize is the largest block gateways will send
+	maxBlockSize = 2 << 20
+)
+
+// Builder makes UnixFS DAGs out of files and directories, with CIDv1s and
+// raw leaves, and hands each block to a function such as Writer.Put
+type Builder struct {
+	put func(c CID, data []byte) error
+}
+
+// NewBuilder returns a builder that stores blocks with put
+func NewBuilder(put func(c CID, data []byte) error) *Builder {
+	return &Builder{put: put}
+}
+
+// AddFile chunks the contents of r into a balanced DAG and returns its root
+func (b *Builder) AddFile(r io.Reader) (Entry, error) {
+	var level []Entry
+	buf := make([]byte, ChunkSize)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 || len(level) == 0 {
+			chunk := append([]byte(nil), buf[:n]...)
+			c := Sum(CodecRaw, chunk)
+			if err := b.put(c, chunk); err != nil {
+				return Entry{}, err
+			}
+			level = append(level, Entry{Cid: c, Type: TypeFile, Size: uint64(n), tsize: uint64(n)})
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return Entry{}, err
+		}
+	}
+
+	for len(level) > 1 {
+		var parents []Entry
+		for start := 0; start < len(level); start += maxLinks {
+			parent, err := b.fileNode(level[start:min(start+maxLinks, len(level))])
+			if err != nil {
+				return Entry{}, err
+			}
+			parents = append(parents, parent)
+		}
+		level = parents
+	}
+	return level[0], nil
+}
+
+func (b *Builder) fileNode(children []Entry) (Entry, error) {
+	u := &unixfsData{Type: unixfsFile}
+	node := &pbNode{}
+	var tsize uint64
+	for _, child := range children {
+		u.FileSize += child.Size
+		u.BlockSizes = append(u.BlockSizes, child.Size)
+		node.Links = append(node.Links, pbLink{Hash: child.Cid, Tsize: child.tsize})
+		tsize += child.tsize
+	}
+	node.Data = u.encode()
+	return b.putNode(node, Entry{Type: TypeFile, Size: u.FileSize}, tsize)
+}
+
+// AddDirectory makes a directory of entries returned by the builder, named
+// with their Name. Large directories are sharded into a HAMT.
+func (b *Builder) AddDirectory(entries []Entry) (Entry, error) {
+	sorted := append([]Entry{}, entries...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	for i, entry := range sorted {
+		if !validName(entry.Name) {
+			return Entry{}, fmt.Errorf("invalid name %q in a directory", entry.Name)
+		}
+		if i > 0 && sorted[i-1].Name == entry.Name {
+			return Entry{}, fmt.Errorf("more than one entry named %q in a directory", entry.Name)
+		}
+	}
+	if shouldShard(sorted) {
+		return b.addShard(sorted, 0)
+	}
+
+	node := &pbNode{Data: (&unixfsData{Type: unixfsDirectory}).encode()}
+	var tsize uint64
+	for _, entry := range sorted {
+		node.Links = append(node.Links, pbLink{Hash: entry.Cid, Name: entry.Name, Tsize: entry.tsize})
+		tsize += entry.tsize
+	}
+	return b.putNode(node, Entry{Type: TypeDirectory}, tsize)
+}
+
+// AddSymlink makes a symlink to target
+func (b *Builder) AddSymlink(target string) (Entry, error) {
+	node := &pbNode{Data: (&unixfsData{Type: unixfsSymlink, Data: []byte(target)}).encode()}
+	return b.putNode(node, Entry{Type: TypeSymlink, Size: uint64(len(target)), Target: target}, 0)
+}
+
+func (b *Builder) putNode(node *pbNode, entry Entry, tsize uint64) (Entry, error) {
+	data := node.encode()
+	if len(data) > maxBlockSize {
+		return Entry{}, fmt.Errorf("a %s node of %d bytes is over the block limit of %d", entry.Type, len(data), maxBlockSize)
+	}
+	entry.Cid = Sum(CodecDagPB, data)
+	entry.tsize = tsize + uint64(len(data))
+	if err := b.put(entry.Cid, data); err != nil {
+		return Entry{}, err
+	}
+	return entry, nil
+}
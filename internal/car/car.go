@@ -0,0 +1,200 @@
+// Package car reads and writes CAR (content addressable archive) files of
+// UnixFS data, verifying every block against its CID. It implements just
+// enough of CIDs, dag-pb and UnixFS to list and extract files and folders
+// without an IPFS node.
+//
+// See https://ipld.io/specs/transport/car/ for the format.
+package car
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// MediaType is what gateways answer a trustless CAR request with
+const MediaType = "application/vnd.ipld.car"
+
+const (
+	// maxHeaderSize bounds the CAR header, which only lists the roots
+	maxHeaderSize = 1 << 20
+	// maxSectionSize bounds a block and its CID. Gateways send blocks of at
+	// most 2MiB.
+	maxSectionSize = 8 << 20
+	// v2HeaderSize is the fixed CARv2 header following the pragma
+	v2HeaderSize = 40
+)
+
+// Reader reads the blocks of a CARv1 or CARv2 file
+type Reader struct {
+	r *bufio.Reader
+	// Roots are the CIDs the archive is about
+	Roots []CID
+	// Version is 1 or 2
+	Version int
+}
+
+// NewReader reads the header of a CAR file
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReader(r)
+	header, err := readHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	if header.version == 1 {
+		return &Reader{r: br, Roots: header.roots, Version: 1}, nil
+	}
+
+	// A CARv2 wraps a CARv1 payload, optionally followed by an index
+	fixed := make([]byte, v2HeaderSize)
+	if _, err := io.ReadFull(br, fixed); err != nil {
+		return nil, fmt.Errorf("invalid CARv2 header: %w", err)
+	}
+	offset := binary.LittleEndian.Uint64(fixed[16:24])
+	size := binary.LittleEndian.Uint64(fixed[24:32])
+	consumed := uint64(header.size + v2HeaderSize)
+	if offset < consumed {
+		return nil, errors.New("invalid CARv2 header: the data starts inside the header")
+	}
+	if _, err := br.Discard(int(offset - consumed)); err != nil {
+		return nil, fmt.Errorf("invalid CARv2 header: %w", err)
+	}
+	inner := bufio.NewReader(io.LimitReader(br, int64(size)))
+	header, err = readHeader(inner)
+	if err != nil {
+		return nil, err
+	}
+	if header.version != 1 {
+		return nil, fmt.Errorf("invalid CARv2 payload: version %d", header.version)
+	}
+	return &Reader{r: inner, Roots: header.roots, Version: 2}, nil
+}
+
+// Next returns the next block after checking it against its CID. It returns
+// io.EOF after the last block.
+func (r *Reader) Next() (CID, []byte, error) {
+	length, err := binary.ReadUvarint(r.r)
+	if err == io.EOF {
+		return CID{}, nil, io.EOF
+	}
+	if err != nil {
+		return CID{}, nil, fmt.Errorf("invalid CAR section: %w", unexpectedEOF(err))
+	}
+	if length > maxSectionSize {
+		return CID{}, nil, fmt.Errorf("invalid CAR section: %d bytes is over the limit of %d", length, maxSectionSize)
+	}
+	section := make([]byte, length)
+	if _, err := io.ReadFull(r.r, section); err != nil {
+		return CID{}, nil, fmt.Errorf("invalid CAR section: %w", unexpectedEOF(err))
+	}
+	c, n, err := readCID(section)
+	if err != nil {
+		return CID{}, nil, fmt.Errorf("invalid CAR section: %w", err)
+	}
+	data := section[n:]
+	if err := c.Verify(data); err != nil {
+		return CID{}, nil, err
+	}
+	return c, data, nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+type carHeader struct {
+	version int
+	roots   []CID
+	// size is the number of bytes the header took, with its length
+	size int
+}
+
+func readHeader(r *bufio.Reader) (*carHeader, error) {
+	length, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CAR header: %w", unexpectedEOF(err))
+	}
+	if length == 0 || length > maxHeaderSize {
+		return nil, fmt.Errorf("invalid CAR header: %d bytes long", length)
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, fmt.Errorf("invalid CAR header: %w", unexpectedEOF(err))
+	}
+
+	value, rest, err := decodeCBOR(data, 0)
+	if err == nil && len(rest) > 0 {
+		err = errors.New("trailing bytes")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CAR header: %w", err)
+	}
+	fields, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("invalid CAR header: not a map")
+	}
+
+	header := &carHeader{size: int(length) + len(binary.AppendUvarint(nil, length))}
+	switch version := fields["version"]; version {
+	case uint64(1), uint64(2):
+		header.version = int(version.(uint64))
+	default:
+		return nil, fmt.Errorf("unsupported CAR version %v", version)
+	}
+	if header.version == 2 {
+		return header, nil
+	}
+
+	roots, ok := fields["roots"].([]interface{})
+	if !ok {
+		return nil, errors.New("invalid CAR header: no roots")
+	}
+	for _, root := range roots {
+		link, ok := root.(cborLink)
+		if !ok {
+			return nil, errors.New("invalid CAR header: a root isn't a CID")
+		}
+		header.roots = append(header.roots, CID(link))
+	}
+	return header, nil
+}
+
+// Writer writes a CARv1 file
+type Writer struct {
+	w io.Writer
+}
+
+// NewWriter writes the header of a CARv1 file with the given roots
+func NewWriter(w io.Writer, roots ...CID) (*Writer, error) {
+	// The DAG-CBOR map {"roots": [...], "version": 1}, with its keys in
+	// canonical order
+	header := appendCBORHead(nil, cborMap, 2)
+	header = appendCBORText(header, "roots")
+	header = appendCBORHead(header, cborArray, uint64(len(roots)))
+	for _, root := range roots {
+		header = appendCBORLink(header, root)
+	}
+	header = appendCBORText(header, "version")
+	header = appendCBORHead(header, cborUint, 1)
+
+	if _, err := w.Write(append(binary.AppendUvarint(nil, uint64(len(header))), header...)); err != nil {
+		return nil, err
+	}
+	return &Writer{w: w}, nil
+}
+
+// Put writes a block
+func (w *Writer) Put(c CID, data []byte) error {
+	section := binary.AppendUvarint(nil, uint64(len(c.raw)+len(data)))
+	section = append(section, c.raw...)
+	if _, err := w.w.Write(section); err != nil {
+		return err
+	}
+	_, err := w.w.Write(data)
+	return err
+}
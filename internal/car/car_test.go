@@ -0,0 +1,286 @@
+package car
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// cidOf builds a CIDv1 by hand with the given hash function and digest
+func cidOf(codec, hash uint64, digest []byte) CID {
+	raw := binary.AppendUvarint([]byte{1}, codec)
+	raw = binary.AppendUvarint(raw, hash)
+	raw = binary.AppendUvarint(raw, uint64(len(digest)))
+	return CID{raw: string(append(raw, digest...))}
+}
+
+func TestParseCID(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"QmUNLLsPACCz1vLxQVkXqqLX5R1X345qqfHbsf67hvA3Nn", "QmUNLLsPACCz1vLxQVkXqqLX5R1X345qqfHbsf67hvA3Nn"},
+		{"bafkreihdwdcefgh4dqkjv67uzcmw7ojee6xedzdetojuzjevtenxquvyku", "bafkreihdwdcefgh4dqkjv67uzcmw7ojee6xedzdetojuzjevtenxquvyku"},
+		{"BAFKREIHDWDCEFGH4DQKJV67UZCMW7OJEE6XEDZDETOJUZJEVTENXQUVYKU", "bafkreihdwdcefgh4dqkjv67uzcmw7ojee6xedzdetojuzjevtenxquvyku"},
+		{"f01551220e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", "bafkreihdwdcefgh4dqkjv67uzcmw7ojee6xedzdetojuzjevtenxquvyku"},
+	}
+	for _, tt := range tests {
+		c, err := ParseCID(tt.in)
+		if err != nil {
+			t.Errorf("ParseCID(%q): %v", tt.in, err)
+			continue
+		}
+		if got := c.String(); got != tt.want {
+			t.Errorf("ParseCID(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{
+		"",
+		"b",
+		"xafkrei",
+		"bafkreihdwdcefgh4dqkjv67uzcmw7ojee6xedzdetojuzjevtenxquvyk",
+		"bafkreihdwdcefgh4dqkjv67uzcmw7ojee6xedzdetojuzjevtenxquvykuaa",
+		"QmUNLLsPACCz1vLxQVkXqqLX5R1X345qqfHbsf67hvA3N0",
+	} {
+		if _, err := ParseCID(in); err == nil {
+			t.Errorf("ParseCID(%q) succeeded, want an error", in)
+		}
+	}
+}
+
+func TestCIDVersionAndCodec(t *testing.T) {
+	v0, err := ParseCID("QmUNLLsPACCz1vLxQVkXqqLX5R1X345qqfHbsf67hvA3Nn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v0.Version() != 0 || v0.Codec() != CodecDagPB {
+		t.Errorf("CIDv0 has version %d and codec 0x%x", v0.Version(), v0.Codec())
+	}
+	v1 := Sum(CodecRaw, nil)
+	if v1.Version() != 1 || v1.Codec() != CodecRaw {
+		t.Errorf("CIDv1 has version %d and codec 0x%x", v1.Version(), v1.Codec())
+	}
+	if got := v1.String(); got != "bafkreihdwdcefgh4dqkjv67uzcmw7ojee6xedzdetojuzjevtenxquvyku" {
+		t.Errorf("Sum of the empty block is %s", got)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	data := []byte("hello world")
+	sum := sha256.Sum256(data)
+
+	tests := []struct {
+		name string
+		cid  CID
+		data []byte
+		ok   bool
+	}{
+		{"matching", Sum(CodecRaw, data), data, true},
+		{"other data", Sum(CodecRaw, data), []byte("hello world!"), false},
+		{"identity", cidOf(CodecRaw, hashIdentity, data), data, true},
+		{"identity other data", cidOf(CodecRaw, hashIdentity, data), []byte("bye"), false},
+		{"truncated to the minimum", cidOf(CodecRaw, hashSHA2_256, sum[:minDigestLength]), data, true},
+		{"truncated below the minimum", cidOf(CodecRaw, hashSHA2_256, sum[:minDigestLength-1]), data, false},
+		{"empty digest", cidOf(CodecRaw, hashSHA2_256, nil), data, false},
+		{"longer than the hash", cidOf(CodecRaw, hashSHA2_256, append(sum[:], 0)), data, false},
+		{"unknown hash", cidOf(CodecRaw, 0x1e, sum[:]), data, false},
+	}
+	for _, tt := range tests {
+		err := tt.cid.Verify(tt.data)
+		if tt.ok && err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+		if !tt.ok && err == nil {
+			t.Errorf("%s: verified, want an error", tt.name)
+		}
+	}
+}
+
+// writeCAR writes a CARv1 of the given blocks, rooted at the first one
+func writeCAR(t *testing.T, blocks ...[]byte) ([]byte, []CID) {
+	t.Helper()
+	var cids []CID
+	for _, block := range blocks {
+		cids = append(cids, Sum(CodecRaw, block))
+	}
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, cids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, block := range blocks {
+		if err := w.Put(cids[i], block); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes(), cids
+}
+
+func readAll(t *testing.T, r *Reader) []CID {
+	t.Helper()
+	var cids []CID
+	for {
+		c, _, err := r.Next()
+		if err == io.EOF {
+			return cids
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		cids = append(cids, c)
+	}
+}
+
+func TestReaderV1(t *testing.T) {
+	data, cids := writeCAR(t, []byte("one"), []byte("two"))
+	r, err := NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Version != 1 || len(r.Roots) != 1 || r.Roots[0] != cids[0] {
+		t.Fatalf("got version %d and roots %v", r.Version, r.Roots)
+	}
+	got := readAll(t, r)
+	if len(got) != 2 || got[0] != cids[0] || got[1] != cids[1] {
+		t.Errorf("got blocks %v, want %v", got, cids)
+	}
+}
+
+func TestReaderRejectsTamperedBlock(t *testing.T) {
+	data, _ := writeCAR(t, []byte("one"), []byte("two"))
+	tampered := bytes.Replace(data, []byte("two"), []byte("tw0"), 1)
+	r, err := NewReader(bytes.NewReader(tampered))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := r.Next(); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = r.Next()
+	if err == nil || !strings.Contains(err.Error(), "doesn't match") {
+		t.Errorf("got %v, want a mismatch", err)
+	}
+}
+
+func TestReaderRejectsTruncatedSection(t *testing.T) {
+	data, _ := writeCAR(t, []byte("one"))
+	r, err := NewReader(bytes.NewReader(data[:len(data)-1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := r.Next(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("got %v, want an unexpected EOF", err)
+	}
+}
+
+func TestReaderRejectsBadHeaders(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"empty":          nil,
+		"zero length":    {0},
+		"short":          {10, 0xa1},
+		"not a map":      {1, 0x01},
+		"version 3":      {10, 0xa1, 0x67, 'v', 'e', 'r', 's', 'i', 'o', 'n', 0x03},
+		"over the limit": binary.AppendUvarint(nil, maxHeaderSize+1),
+	} {
+		if _, err := NewReader(bytes.NewReader(data)); err == nil {
+			t.Errorf("%s: read the header, want an error", name)
+		}
+	}
+}
+
+// wrapV2 wraps a CARv1 payload in a CARv2, with padding before the payload
+// and an index after it that the reader should skip
+func wrapV2(payload []byte, padding int) []byte {
+	pragma := []byte{0x0a, 0xa1, 0x67, 'v', 'e', 'r', 's', 'i', 'o', 'n', 0x02}
+	header := make([]byte, v2HeaderSize)
+	offset := uint64(len(pragma) + v2HeaderSize + padding)
+	binary.LittleEndian.PutUint64(header[16:24], offset)
+	binary.LittleEndian.PutUint64(header[24:32], uint64(len(payload)))
+	binary.LittleEndian.PutUint64(header[32:40], offset+uint64(len(payload)))
+
+	out := append(pragma, header...)
+	out = append(out, make([]byte, padding)...)
+	out = append(out, payload...)
+	return append(out, []byte("index bytes")...)
+}
+
+func TestReaderV2(t *testing.T) {
+	payload, cids := writeCAR(t, []byte("one"), []byte("two"))
+	for _, padding := range []int{0, 7} {
+		r, err := NewReader(bytes.NewReader(wrapV2(payload, padding)))
+		if err != nil {
+			t.Fatalf("padding %d: %v", padding, err)
+		}
+		if r.Version != 2 || len(r.Roots) != 1 || r.Roots[0] != cids[0] {
+			t.Fatalf("padding %d: got version %d and roots %v", padding, r.Version, r.Roots)
+		}
+		if got := readAll(t, r); len(got) != 2 {
+			t.Errorf("padding %d: got %d blocks, want 2", padding, len(got))
+		}
+	}
+}
+
+func TestReaderV2RejectsOverlappingOffset(t *testing.T) {
+	payload, _ := writeCAR(t, []byte("one"))
+	data := wrapV2(payload, 0)
+	binary.LittleEndian.PutUint64(data[11+16:11+24], 10)
+	if _, err := NewReader(bytes.NewReader(data)); err == nil {
+		t.Error("read a CARv2 whose data starts inside the header")
+	}
+}
+
+func TestBuilderRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := NewBuilder(w.Put)
+
+	// Big enough to need several leaves
+	content := bytes.Repeat([]byte("0123456789"), ChunkSize/4)
+	file, err := b.AddFile(bytes.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Name = "data.txt"
+	link, err := b.AddSymlink("data.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	link.Name = "link"
+	dir, err := b.AddDirectory([]Entry{file, link})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Load(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if missing, err := s.Missing(dir.Cid); err != nil || len(missing) > 0 {
+		t.Fatalf("got missing blocks %v, %v", missing, err)
+	}
+	entries, err := s.ReadDir(dir.Cid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Name != "data.txt" || entries[1].Type != TypeSymlink {
+		t.Fatalf("got entries %+v", entries)
+	}
+	if entries[0].Size != uint64(len(content)) {
+		t.Errorf("file size is %d, want %d", entries[0].Size, len(content))
+	}
+	var out bytes.Buffer
+	if err := s.WriteFile(file.Cid, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out.Bytes(), content) {
+		t.Error("extracted file differs from the original")
+	}
+}
@@ -0,0 +1,170 @@
+package car
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// CBOR major types
+const (
+	cborUint   = 0
+	cborNegint = 1
+	cborBytes  = 2
+	cborText   = 3
+	cborArray  = 4
+	cborMap    = 5
+	cborTag    = 6
+	cborSimple = 7
+)
+
+// cborTagLink is the tag of a CID in DAG-CBOR
+const cborTagLink = 42
+
+// maxCBORDepth bounds the nesting of a CAR header
+const maxCBORDepth = 16
+
+// cborLink is a CID decoded from DAG-CBOR
+type cborLink CID
+
+// decodeCBOR decodes the subset of DAG-CBOR found in CAR headers: integers,
+// strings, arrays, maps with string keys, links and simple values. It
+// returns the value and the bytes after it.
+func decodeCBOR(data []byte, depth int) (interface{}, []byte, error) {
+	if depth > maxCBORDepth {
+		return nil, nil, errors.New("nested too deep")
+	}
+	if len(data) == 0 {
+		return nil, nil, errors.New("unexpected end of data")
+	}
+	major, info := data[0]>>5, data[0]&0x1f
+	data = data[1:]
+
+	if major == cborSimple {
+		switch info {
+		case 20:
+			return false, data, nil
+		case 21:
+			return true, data, nil
+		case 22:
+			return nil, data, nil
+		}
+		return nil, nil, fmt.Errorf("unsupported simple value %d", info)
+	}
+
+	var arg uint64
+	switch {
+	case info < 24:
+		arg = uint64(info)
+	case info <= 27:
+		size := 1 << (info - 24)
+		if len(data) < size {
+			return nil, nil, errors.New("unexpected end of data")
+		}
+		for _, b := range data[:size] {
+			arg = arg<<8 | uint64(b)
+		}
+		data = data[size:]
+	default:
+		return nil, nil, errors.New("indefinite lengths aren't allowed")
+	}
+
+	switch major {
+	case cborUint:
+		return arg, data, nil
+	case cborNegint:
+		return -1 - int64(arg), data, nil
+	case cborBytes, cborText:
+		if arg > uint64(len(data)) {
+			return nil, nil, errors.New("unexpected end of data")
+		}
+		if major == cborText {
+			return string(data[:arg]), data[arg:], nil
+		}
+		return data[:arg], data[arg:], nil
+	case cborArray:
+		if arg > uint64(len(data)) {
+			return nil, nil, errors.New("unexpected end of data")
+		}
+		values := make([]interface{}, 0, arg)
+		for i := uint64(0); i < arg; i++ {
+			var value interface{}
+			var err error
+			if value, data, err = decodeCBOR(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+			values = append(values, value)
+		}
+		return values, data, nil
+	case cborMap:
+		if arg > uint64(len(data)) {
+			return nil, nil, errors.New("unexpected end of data")
+		}
+		fields := make(map[string]interface{}, arg)
+		for i := uint64(0); i < arg; i++ {
+			var key, value interface{}
+			var err error
+			if key, data, err = decodeCBOR(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+			name, ok := key.(string)
+			if !ok {
+				return nil, nil, errors.New("map keys must be strings")
+			}
+			if value, data, err = decodeCBOR(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+			fields[name] = value
+		}
+		return fields, data, nil
+	case cborTag:
+		if arg != cborTagLink {
+			return nil, nil, fmt.Errorf("unsupported tag %d", arg)
+		}
+		value, rest, err := decodeCBOR(data, depth+1)
+		if err != nil {
+			return nil, nil, err
+		}
+		// Links are byte strings holding the binary CID after a 0x00
+		// multibase prefix
+		raw, ok := value.([]byte)
+		if !ok || len(raw) == 0 || raw[0] != 0 {
+			return nil, nil, errors.New("invalid link")
+		}
+		c, n, err := readCID(raw[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		if n != len(raw)-1 {
+			return nil, nil, errors.New("invalid link: trailing bytes")
+		}
+		return cborLink(c), rest, nil
+	}
+	return nil, nil, fmt.Errorf("unsupported major type %d", major)
+}
+
+// appendCBORHead appends the smallest encoding of a major type and argument
+func appendCBORHead(b []byte, major byte, arg uint64) []byte {
+	major <<= 5
+	switch {
+	case arg < 24:
+		return append(b, major|byte(arg))
+	case arg <= 0xff:
+		return append(b, major|24, byte(arg))
+	case arg <= 0xffff:
+		return binary.BigEndian.AppendUint16(append(b, major|25), uint16(arg))
+	case arg <= 0xffffffff:
+		return binary.BigEndian.AppendUint32(append(b, major|26), uint32(arg))
+	}
+	return binary.BigEndian.AppendUint64(append(b, major|27), arg)
+}
+
+func appendCBORText(b []byte, s string) []byte {
+	return append(appendCBORHead(b, cborText, uint64(len(s))), s...)
+}
+
+func appendCBORLink(b []byte, c CID) []byte {
+	b = appendCBORHead(b, cborTag, cborTagLink)
+	b = appendCBORHead(b, cborBytes, uint64(len(c.raw)+1))
+	return append(append(b, 0), c.raw...)
+}
@@ -0,0 +1,276 @@
+package car
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha3"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Multicodecs of the blocks this package understands
+const (
+	CodecRaw   = 0x55
+	CodecDagPB = 0x70
+)
+
+// Multihash functions that can be verified
+const (
+	hashIdentity = 0x00
+	hashSHA2_256 = 0x12
+	hashSHA2_512 = 0x13
+	hashSHA3_512 = 0x14
+	hashSHA3_256 = 0x16
+)
+
+// minDigestLength is the shortest truncated digest accepted, as in go-verifcid
+const minDigestLength = 20
+
+var base32Lower = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// CID is a content identifier in its binary form. CIDs are comparable, so
+// they can be used as map keys.
+type CID struct {
+	raw string
+}
+
+// ParseCID parses a CIDv0 (Qm...) or a multibase encoded CIDv1
+func ParseCID(s string) (CID, error) {
+	if len(s) == 46 && strings.HasPrefix(s, "Qm") {
+		data, err := decodeBase58(s)
+		if err != nil {
+			return CID{}, fmt.Errorf("invalid CID %q: %w", s, err)
+		}
+		return castCID(s, data)
+	}
+	if len(s) < 2 {
+		return CID{}, fmt.Errorf("invalid CID %q", s)
+	}
+
+	var data []byte
+	var err error
+	switch s[0] {
+	case 'b':
+		data, err = base32Lower.DecodeString(s[1:])
+	case 'B':
+		data, err = base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(s[1:])
+	case 'z':
+		data, err = decodeBase58(s[1:])
+	case 'f', 'F':
+		data, err = hex.DecodeString(s[1:])
+	default:
+		return CID{}, fmt.Errorf("invalid CID %q: unsupported multibase prefix %q", s, s[0])
+	}
+	if err != nil {
+		return CID{}, fmt.Errorf("invalid CID %q: %w", s, err)
+	}
+	return castCID(s, data)
+}
+
+func castCID(s string, data []byte) (CID, error) {
+	c, n, err := readCID(data)
+	if err == nil && n != len(data) {
+		err = errors.New("trailing bytes")
+	}
+	if err != nil {
+		return CID{}, fmt.Errorf("invalid CID %q: %w", s, err)
+	}
+	return c, nil
+}
+
+// readCID reads a binary CID from the start of data and returns it with its
+// length
+func readCID(data []byte) (CID, int, error) {
+	// A CIDv0 is a bare sha2-256 multihash
+	if len(data) >= 2 && data[0] == hashSHA2_256 && data[1] == 32 {
+		if len(data) < 34 {
+			return CID{}, 0, errors.New("truncated multihash")
+		}
+		return CID{raw: string(data[:34])}, 34, nil
+	}
+
+	n := 0
+	next := func() (uint64, error) {
+		v, size := binary.Uvarint(data[n:])
+		if size <= 0 {
+			return 0, errors.New("truncated varint")
+		}
+		n += size
+		return v, nil
+	}
+	version, err := next()
+	if err != nil {
+		return CID{}, 0, err
+	}
+	if version != 1 {
+		return CID{}, 0, fmt.Errorf("unsupported CID version %d", version)
+	}
+	if _, err := next(); err != nil {
+		return CID{}, 0, err
+	}
+	if _, err := next(); err != nil {
+		return CID{}, 0, err
+	}
+	length, err := next()
+	if err != nil {
+		return CID{}, 0, err
+	}
+	if length > uint64(len(data)-n) {
+		return CID{}, 0, errors.New("truncated multihash")
+	}
+	n += int(length)
+	return CID{raw: string(data[:n])}, n, nil
+}
+
+// Sum returns the CIDv1 of a block, hashed with sha2-256
+func Sum(codec uint64, data []byte) CID {
+	sum := sha256.Sum256(data)
+	raw := binary.AppendUvarint([]byte{1}, codec)
+	raw = append(raw, hashSHA2_256, 32)
+	return CID{raw: string(append(raw, sum[:]...))}
+}
+
+// Defined reports whether c is set
+func (c CID) Defined() bool {
+	return c.raw != ""
+}
+
+// Version is 0 or 1
+func (c CID) Version() int {
+	if len(c.raw) == 34 && c.raw[0] == hashSHA2_256 {
+		return 0
+	}
+	return 1
+}
+
+// Codec is the multicodec of the block c addresses
+func (c CID) Codec() uint64 {
+	if c.Version() == 0 {
+		return CodecDagPB
+	}
+	_, n := binary.Uvarint([]byte(c.raw))
+	codec, _ := binary.Uvarint([]byte(c.raw[n:]))
+	return codec
+}
+
+// multihash returns the hash function code and the digest
+func (c CID) multihash() (uint64, []byte) {
+	data := []byte(c.raw)
+	if c.Version() == 1 {
+		for i := 0; i < 2; i++ {
+			_, n := binary.Uvarint(data)
+			data = data[n:]
+		}
+	}
+	code, n := binary.Uvarint(data)
+	data = data[n:]
+	_, n = binary.Uvarint(data)
+	return code, data[n:]
+}
+
+// Bytes is the binary form of the CID
+func (c CID) Bytes() []byte {
+	return []byte(c.raw)
+}
+
+// String encodes a CIDv0 in base58btc and a CIDv1 in base32, as gateways do
+func (c CID) String() string {
+	if !c.Defined() {
+		return ""
+	}
+	if c.Version() == 0 {
+		return encodeBase58([]byte(c.raw))
+	}
+	return "b" + base32Lower.EncodeToString([]byte(c.raw))
+}
+
+// Verify checks that data hashes to c
+func (c CID) Verify(data []byte) error {
+	code, digest := c.multihash()
+	var sum []byte
+	switch code {
+	case hashIdentity:
+		sum = data
+	case hashSHA2_256:
+		s := sha256.Sum256(data)
+		sum = s[:]
+	case hashSHA2_512:
+		s := sha512.Sum512(data)
+		sum = s[:]
+	case hashSHA3_256:
+		s := sha3.Sum256(data)
+		sum = s[:]
+	case hashSHA3_512:
+		s := sha3.Sum512(data)
+		sum = s[:]
+	default:
+		return fmt.Errorf("block %s uses hash function 0x%x, which can't be verified", c, code)
+	}
+	// Digests may be truncated, except identity ones which are the data, but
+	// not so far that another block could be made to match
+	if code != hashIdentity {
+		if len(digest) < minDigestLength {
+			return fmt.Errorf("block %s has a %d byte digest, shorter than the minimum of %d", c, len(digest), minDigestLength)
+		}
+		if len(digest) <= len(sum) {
+			sum = sum[:len(digest)]
+		}
+	}
+	if !bytes.Equal(sum, digest) {
+		return fmt.Errorf("block %s doesn't match its CID", c)
+	}
+	return nil
+}
+
+// inline returns the data embedded in an identity CID
+func (c CID) inline() ([]byte, bool) {
+	code, digest := c.multihash()
+	return digest, code == hashIdentity
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+func encodeBase58(data []byte) string {
+	n := new(big.Int).SetBytes(data)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		out = append(out, base58Alphabet[0])
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+func decodeBase58(s string) ([]byte, error) {
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for _, r := range s {
+		i := strings.IndexRune(base58Alphabet, r)
+		if i < 0 {
+			return nil, fmt.Errorf("invalid base58 character %q", r)
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(i)))
+	}
+	zeros := 0
+	for zeros < len(s) && s[zeros] == base58Alphabet[0] {
+		zeros++
+	}
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
@@ -0,0 +1,326 @@
+package car
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+)
+
+// PackResult describes a CAR written by Pack
+type PackResult struct {
+	Cid    string `json:"cid"`
+	Output string `json:"output"`
+	Blocks int    `json:"blocks"`
+	Size   int64  `json:"size"`
+}
+
+// UnpackResult describes what Unpack wrote
+type UnpackResult struct {
+	Cid    string `json:"cid"`
+	Output string `json:"output"`
+	Files  int    `json:"files"`
+	Size   uint64 `json:"size"`
+}
+
+// Inspection describes a CAR and the DAGs under its roots
+type Inspection struct {
+	Version int            `json:"version"`
+	Roots   []RootSummary  `json:"roots"`
+	Blocks  int            `json:"blocks"`
+	Size    int64          `json:"size"`
+	Codecs  map[string]int `json:"codecs"`
+	Largest *BlockSummary  `json:"largestBlock,omitempty"`
+}
+
+// RootSummary tells whether a root's DAG is complete in the CAR
+type RootSummary struct {
+	Cid      string   `json:"cid"`
+	Type     string   `json:"type,omitempty"`
+	Complete bool     `json:"complete"`
+	Missing  []string `json:"missing,omitempty"`
+}
+
+// BlockSummary is a block of a CAR
+type BlockSummary struct {
+	Cid  string `json:"cid"`
+	Size int    `json:"size"`
+}
+
+var codecNames = map[uint64]string{
+	CodecRaw:   "raw",
+	CodecDagPB: "dag-pb",
+	0x71:       "dag-cbor",
+	0x0129:     "dag-json",
+}
+
+// Pack builds the UnixFS DAG of a file or folder into a CARv1 at output, or
+// on stdout when output is -. Folders hold the same files an upload of them
+// sends, so the root is the CID the upload gets: symlinks to files are
+// followed and empty folders are left out.
+func Pack(path string, output string) (*PackResult, error) {
+	stats, err := os.Stat(path)
+	if err != nil {
+		return nil, exitcode.AsInvalidInput(err)
+	}
+	if output == "" {
+		output = filepath.Base(filepath.Clean(path)) + ".car"
+	}
+	if output != "-" && stats.IsDir() && inside(output, path) {
+		return nil, exitcode.InvalidInputf("the output %s can't be inside the folder being packed", output)
+	}
+
+	// Blocks are spooled until the root, which the header lists first, is
+	// known
+	spool, err := os.CreateTemp("", "pinata-*.car")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	blocks := 0
+	seen := map[CID]bool{}
+	spooled := &Writer{w: spool}
+	builder := NewBuilder(func(c CID, data []byte) error {
+		if seen[c] {
+			return nil
+		}
+		seen[c] = true
+		blocks++
+		return spooled.Put(c, data)
+	})
+
+	var root Entry
+	if stats.IsDir() {
+		var ok bool
+		root, ok, err = packDir(builder, path)
+		if err == nil && !ok {
+			err = exitcode.InvalidInputf("%s has no files to pack", path)
+		}
+	} else {
+		root, err = packFile(builder, path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var out io.Writer = os.Stdout
+	var file *os.File
+	if output != "-" {
+		if file, err = os.Create(output); err != nil {
+			return nil, exitcode.AsInvalidInput(err)
+		}
+		defer file.Close()
+		out = file
+	}
+	counter := &countingWriter{w: out}
+	if _, err := NewWriter(counter, root.Cid); err != nil {
+		return nil, err
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(counter, spool); err != nil {
+		return nil, err
+	}
+	if file != nil {
+		if err := file.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &PackResult{Cid: root.Cid.String(), Output: output, Blocks: blocks, Size: int64(counter.n)}
+	if output == "-" {
+		fmt.Fprintf(os.Stderr, "Packed %s: %d blocks\n", result.Cid, blocks)
+		return result, nil
+	}
+	return result, printJSON(result)
+}
+
+// packDir adds the files under a folder, and reports false when there are
+// none
+func packDir(b *Builder, dir string) (Entry, bool, error) {
+	items, err := os.ReadDir(dir)
+	if err != nil {
+		return Entry{}, false, err
+	}
+	var entries []Entry
+	for _, item := range items {
+		path := filepath.Join(dir, item.Name())
+		var entry Entry
+		if item.IsDir() {
+			var ok bool
+			if entry, ok, err = packDir(b, path); err != nil {
+				return Entry{}, false, err
+			}
+			if !ok {
+				continue
+			}
+		} else if entry, err = packFile(b, path); err != nil {
+			return Entry{}, false, err
+		}
+		entry.Name = item.Name()
+		entries = append(entries, entry)
+	}
+	if len(entries) == 0 {
+		return Entry{}, false, nil
+	}
+	entry, err := b.AddDirectory(entries)
+	return entry, true, err
+}
+
+func packFile(b *Builder, path string) (Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Entry{}, err
+	}
+	defer f.Close()
+	if stats, err := f.Stat(); err == nil && stats.IsDir() {
+		return Entry{}, exitcode.InvalidInputf("%s is a symlink to a folder, which uploads don't follow", path)
+	}
+	entry, err := b.AddFile(f)
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return entry, nil
+}
+
+// inside reports whether path is dir or somewhere under it
+func inside(path, dir string) bool {
+	absPath, err1 := filepath.Abs(path)
+	absDir, err2 := filepath.Abs(dir)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	rel, err := filepath.Rel(absDir, absPath)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// Unpack verifies a CAR, read from stdin when carPath is -, and writes the
+// file or folder under a root to output. root may be empty when the CAR has
+// a single root.
+func Unpack(carPath string, output string, root string) (*UnpackResult, error) {
+	store, err := load(carPath)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+
+	var c CID
+	switch {
+	case root != "":
+		if c, err = ParseCID(root); err != nil {
+			return nil, exitcode.AsInvalidInput(err)
+		}
+	case len(store.Roots) == 1:
+		c = store.Roots[0]
+	default:
+		return nil, exitcode.InvalidInputf("the CAR has %d roots, pick one with --root", len(store.Roots))
+	}
+	if !store.Has(c) {
+		return nil, exitcode.NotFoundf("the CAR doesn't hold %s", c)
+	}
+	if output == "" {
+		output = c.String()
+	}
+	if _, err := os.Lstat(output); err == nil {
+		return nil, exitcode.Conflictf("%s already exists", output)
+	}
+
+	result := &UnpackResult{Cid: c.String(), Output: output}
+	err = store.Extract(c, output, func(path string, e Entry) {
+		if e.Type == TypeFile {
+			result.Files++
+			result.Size += e.Size
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", output, err)
+	}
+	if entry, _ := store.Stat(c); entry.Type == TypeFile {
+		result.Files, result.Size = 1, entry.Size
+	}
+	return result, printJSON(result)
+}
+
+// Inspect verifies every block of a CAR, read from stdin when carPath is -,
+// and describes it. Verification fails on the first block that doesn't
+// match its CID, while roots whose DAG is partly missing are reported as
+// incomplete.
+func Inspect(carPath string) (*Inspection, error) {
+	store, err := load(carPath)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+
+	inspection := &Inspection{
+		Version: store.Version,
+		Roots:   []RootSummary{},
+		Blocks:  store.Len(),
+		Size:    store.Size(),
+		Codecs:  map[string]int{},
+	}
+	for _, c := range store.CIDs() {
+		name, ok := codecNames[c.Codec()]
+		if !ok {
+			name = fmt.Sprintf("0x%x", c.Codec())
+		}
+		inspection.Codecs[name]++
+
+		size := store.blocks[c].size
+		if inspection.Largest == nil || size > inspection.Largest.Size {
+			inspection.Largest = &BlockSummary{Cid: c.String(), Size: size}
+		}
+	}
+
+	for _, root := range store.Roots {
+		summary := RootSummary{Cid: root.String()}
+		if entry, err := store.Stat(root); err == nil {
+			summary.Type = entry.Type
+		}
+		missing, err := store.Missing(root)
+		if err != nil {
+			return nil, err
+		}
+		summary.Complete = len(missing) == 0
+		for _, c := range missing {
+			summary.Missing = append(summary.Missing, c.String())
+		}
+		inspection.Roots = append(inspection.Roots, summary)
+	}
+	return inspection, printJSON(inspection)
+}
+
+// load reads and verifies a CAR file, or stdin for -
+func load(carPath string) (*Store, error) {
+	var r io.Reader = os.Stdin
+	if carPath != "-" {
+		f, err := os.Open(carPath)
+		if err != nil {
+			return nil, exitcode.AsInvalidInput(err)
+		}
+		defer f.Close()
+		r = f
+	}
+	store, err := Load(r)
+	if err != nil {
+		return nil, exitcode.InvalidInputf("%s: %v", carPath, err)
+	}
+	return store, nil
+}
+
+func printJSON(v interface{}) error {
+	formattedJSON, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return errors.New("failed to format JSON")
+	}
+	fmt.Println(string(formattedJSON))
+	return nil
+}
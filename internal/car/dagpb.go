@@ -0,0 +1,232 @@
+package car
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// UnixFS node types
+const (
+	unixfsRaw       = 0
+	unixfsDirectory = 1
+	unixfsFile      = 2
+	unixfsMetadata  = 3
+	unixfsSymlink   = 4
+	unixfsHAMTShard = 5
+)
+
+// Protobuf wire types
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// pbLink is a link of a dag-pb node
+type pbLink struct {
+	Hash CID
+	Name string
+	// Tsize is the size of the whole DAG the link points to
+	Tsize uint64
+}
+
+// pbNode is a dag-pb block. Files and folders keep their UnixFS metadata in
+// Data.
+type pbNode struct {
+	Links []pbLink
+	Data  []byte
+}
+
+// unixfsData is the UnixFS message in the Data of a dag-pb node
+type unixfsData struct {
+	Type       uint64
+	Data       []byte
+	FileSize   uint64
+	BlockSizes []uint64
+	HashType   uint64
+	Fanout     uint64
+}
+
+func decodePBNode(data []byte) (*pbNode, error) {
+	node := &pbNode{}
+	err := readFields(data, func(field uint64, wire int, v uint64, b []byte) error {
+		switch {
+		case field == 1 && wire == wireBytes:
+			node.Data = b
+		case field == 2 && wire == wireBytes:
+			link, err := decodePBLink(b)
+			if err != nil {
+				return err
+			}
+			node.Links = append(node.Links, *link)
+		default:
+			return fmt.Errorf("unexpected field %d", field)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid dag-pb node: %w", err)
+	}
+	return node, nil
+}
+
+func decodePBLink(data []byte) (*pbLink, error) {
+	link := &pbLink{}
+	err := readFields(data, func(field uint64, wire int, v uint64, b []byte) error {
+		switch {
+		case field == 1 && wire == wireBytes:
+			c, n, err := readCID(b)
+			if err != nil {
+				return err
+			}
+			if n != len(b) {
+				return errors.New("trailing bytes after a link's CID")
+			}
+			link.Hash = c
+		case field == 2 && wire == wireBytes:
+			link.Name = string(b)
+		case field == 3 && wire == wireVarint:
+			link.Tsize = v
+		default:
+			return fmt.Errorf("unexpected link field %d", field)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !link.Hash.Defined() {
+		return nil, errors.New("a link has no CID")
+	}
+	return link, nil
+}
+
+// encode writes the node in the canonical dag-pb order, links first
+func (n *pbNode) encode() []byte {
+	var b []byte
+	for _, link := range n.Links {
+		var l []byte
+		l = appendBytesField(l, 1, link.Hash.Bytes())
+		l = appendBytesField(l, 2, []byte(link.Name))
+		l = appendVarintField(l, 3, link.Tsize)
+		b = appendBytesField(b, 2, l)
+	}
+	if n.Data != nil {
+		b = appendBytesField(b, 1, n.Data)
+	}
+	return b
+}
+
+func decodeUnixFS(data []byte) (*unixfsData, error) {
+	u := &unixfsData{}
+	err := readFields(data, func(field uint64, wire int, v uint64, b []byte) error {
+		switch {
+		case field == 1 && wire == wireVarint:
+			u.Type = v
+		case field == 2 && wire == wireBytes:
+			u.Data = b
+		case field == 3 && wire == wireVarint:
+			u.FileSize = v
+		case field == 4 && wire == wireVarint:
+			u.BlockSizes = append(u.BlockSizes, v)
+		case field == 4 && wire == wireBytes:
+			// Packed block sizes
+			for len(b) > 0 {
+				size, n := binary.Uvarint(b)
+				if n <= 0 {
+					return errors.New("invalid block sizes")
+				}
+				u.BlockSizes = append(u.BlockSizes, size)
+				b = b[n:]
+			}
+		case field == 5 && wire == wireVarint:
+			u.HashType = v
+		case field == 6 && wire == wireVarint:
+			u.Fanout = v
+		}
+		// Anything else, like the mode and mtime, isn't needed
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid UnixFS data: %w", err)
+	}
+	return u, nil
+}
+
+func (u *unixfsData) encode() []byte {
+	b := appendVarintField(nil, 1, u.Type)
+	if u.Data != nil {
+		b = appendBytesField(b, 2, u.Data)
+	}
+	if u.Type == unixfsFile || u.Type == unixfsRaw {
+		b = appendVarintField(b, 3, u.FileSize)
+	}
+	for _, size := range u.BlockSizes {
+		b = appendVarintField(b, 4, size)
+	}
+	if u.HashType != 0 {
+		b = appendVarintField(b, 5, u.HashType)
+	}
+	if u.Fanout != 0 {
+		b = appendVarintField(b, 6, u.Fanout)
+	}
+	return b
+}
+
+// readFields calls fn with each field of a protobuf message: the value of
+// varints, or the contents of length delimited fields
+func readFields(data []byte, fn func(field uint64, wire int, v uint64, b []byte) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errors.New("truncated field key")
+		}
+		data = data[n:]
+		field, wire := key>>3, int(key&7)
+
+		var v uint64
+		var b []byte
+		switch wire {
+		case wireVarint:
+			if v, n = binary.Uvarint(data); n <= 0 {
+				return errors.New("truncated varint")
+			}
+			data = data[n:]
+		case wireBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || length > uint64(len(data)-n) {
+				return errors.New("truncated field")
+			}
+			b = data[n : n+int(length)]
+			data = data[n+int(length):]
+		case wireFixed64, wireFixed32:
+			size := 8
+			if wire == wireFixed32 {
+				size = 4
+			}
+			if len(data) < size {
+				return errors.New("truncated field")
+			}
+			data = data[size:]
+		default:
+			return fmt.Errorf("unsupported wire type %d", wire)
+		}
+		if err := fn(field, wire, v, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func appendVarintField(b []byte, field uint64, v uint64) []byte {
+	b = binary.AppendUvarint(b, field<<3|wireVarint)
+	return binary.AppendUvarint(b, v)
+}
+
+func appendBytesField(b []byte, field uint64, v []byte) []byte {
+	b = binary.AppendUvarint(b, field<<3|wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
@@ -0,0 +1,144 @@
+package car
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"sort"
+)
+
+const (
+	// shardingThreshold is the estimated size of a directory's links at
+	// which it's split into a HAMT, as Kubo does
+	shardingThreshold = 256 << 10
+	// hamtFanout is the number of buckets in each HAMT shard
+	hamtFanout = 256
+	// hashMurmur3 is the multihash code of the hash HAMT names are bucketed by
+	hashMurmur3 = 0x22
+)
+
+// shouldShard reports whether a directory of entries is big enough to be a
+// HAMT
+func shouldShard(entries []Entry) bool {
+	size := 0
+	for _, entry := range entries {
+		size += len(entry.Name) + len(entry.Cid.raw)
+	}
+	return size >= shardingThreshold
+}
+
+// addShard makes the HAMT shard at depth holding entries. Each level of the
+// trie buckets names by the next byte of their hash.
+func (b *Builder) addShard(entries []Entry, depth int) (Entry, error) {
+	if depth >= 8 {
+		return Entry{}, fmt.Errorf("too many HAMT collisions for %q", entries[0].Name)
+	}
+	buckets := map[int][]Entry{}
+	for _, entry := range entries {
+		index := int(murmur3(entry.Name) >> (56 - 8*depth) & 0xff)
+		buckets[index] = append(buckets[index], entry)
+	}
+	indexes := make([]int, 0, len(buckets))
+	for index := range buckets {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	bitfield := make([]byte, hamtFanout/8)
+	node := &pbNode{}
+	var tsize uint64
+	for _, index := range indexes {
+		bitfield[len(bitfield)-1-index/8] |= 1 << (index % 8)
+		bucket := buckets[index]
+		prefix := fmt.Sprintf("%02X", index)
+		if len(bucket) == 1 {
+			node.Links = append(node.Links, pbLink{Hash: bucket[0].Cid, Name: prefix + bucket[0].Name, Tsize: bucket[0].tsize})
+			tsize += bucket[0].tsize
+			continue
+		}
+		child, err := b.addShard(bucket, depth+1)
+		if err != nil {
+			return Entry{}, err
+		}
+		node.Links = append(node.Links, pbLink{Hash: child.Cid, Name: prefix, Tsize: child.tsize})
+		tsize += child.tsize
+	}
+
+	// The bitfield is big-endian without leading zero bytes
+	for len(bitfield) > 0 && bitfield[0] == 0 {
+		bitfield = bitfield[1:]
+	}
+	node.Data = (&unixfsData{Type: unixfsHAMTShard, Data: bitfield, HashType: hashMurmur3, Fanout: hamtFanout}).encode()
+	return b.putNode(node, Entry{Type: TypeDirectory}, tsize)
+}
+
+// murmur3 returns the first 64 bits of the x64 128-bit MurmurHash3 of s
+// with a zero seed, the hash UnixFS HAMTs use
+func murmur3(s string) uint64 {
+	const (
+		c1 = 0x87c37b91114253d5
+		c2 = 0x4cf5ad432745937f
+	)
+	data := []byte(s)
+	var h1, h2 uint64
+
+	for len(data) >= 16 {
+		k1 := binary.LittleEndian.Uint64(data)
+		k2 := binary.LittleEndian.Uint64(data[8:])
+		data = data[16:]
+
+		k1 *= c1
+		k1 = bits.RotateLeft64(k1, 31)
+		k1 *= c2
+		h1 ^= k1
+		h1 = bits.RotateLeft64(h1, 27)
+		h1 += h2
+		h1 = h1*5 + 0x52dce729
+
+		k2 *= c2
+		k2 = bits.RotateLeft64(k2, 33)
+		k2 *= c1
+		h2 ^= k2
+		h2 = bits.RotateLeft64(h2, 31)
+		h2 += h1
+		h2 = h2*5 + 0x38495ab5
+	}
+
+	var k1, k2 uint64
+	for i := len(data) - 1; i >= 8; i-- {
+		k2 = k2<<8 | uint64(data[i])
+	}
+	if len(data) > 8 {
+		k2 *= c2
+		k2 = bits.RotateLeft64(k2, 33)
+		k2 *= c1
+		h2 ^= k2
+	}
+	for i := min(len(data), 8) - 1; i >= 0; i-- {
+		k1 = k1<<8 | uint64(data[i])
+	}
+	if len(data) > 0 {
+		k1 *= c1
+		k1 = bits.RotateLeft64(k1, 31)
+		k1 *= c2
+		h1 ^= k1
+	}
+
+	h1 ^= uint64(len(s))
+	h2 ^= uint64(len(s))
+	h1 += h2
+	h2 += h1
+	h1 = fmix64(h1)
+	h2 = fmix64(h2)
+	h1 += h2
+	return h1
+}
+
+func fmix64(k uint64) uint64 {
+	k ^= k >> 33
+	k *= 0xff51afd7ed558ccd
+	k ^= k >> 33
+	k *= 0xc4ceb9fe1a85ec53
+	k ^= k >> 33
+	return k
+}
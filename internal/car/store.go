@@ -0,0 +1,422 @@
+package car
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Entry types
+const (
+	TypeFile      = "file"
+	TypeDirectory = "directory"
+	TypeSymlink   = "symlink"
+)
+
+// ErrMissingBlock is returned when a block the DAG links to isn't in the CAR
+var ErrMissingBlock = errors.New("block is missing from the CAR")
+
+// Entry is a file, directory or symlink of a UnixFS DAG
+type Entry struct {
+	Name string `json:"name,omitempty"`
+	Cid  CID    `json:"cid"`
+	Type string `json:"type"`
+	// Size is the length of a file or of a symlink's target, and 0 for a
+	// directory
+	Size   uint64 `json:"size"`
+	Target string `json:"target,omitempty"`
+	// tsize is the size of the DAG under the entry, for links to it
+	tsize uint64
+}
+
+// MarshalText encodes the CID as its string form
+func (c CID) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// Store holds the verified blocks of a CAR. The blocks are spooled to a
+// temporary file so large DAGs aren't held in memory.
+type Store struct {
+	// Roots are the roots listed in the CAR header
+	Roots []CID
+	// Version is the CAR version, 1 or 2
+	Version int
+	file    *os.File
+	blocks  map[CID]blockRef
+	// order is the CIDs in the order they were read
+	order []CID
+	size  int64
+}
+
+type blockRef struct {
+	offset int64
+	size   int
+}
+
+// Load reads a whole CAR, checking every block against its CID
+func Load(r io.Reader) (*Store, error) {
+	reader, err := NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.CreateTemp("", "pinata-*.car")
+	if err != nil {
+		return nil, err
+	}
+	s := &Store{Roots: reader.Roots, Version: reader.Version, file: file, blocks: map[CID]blockRef{}}
+
+	var offset int64
+	for {
+		c, data, err := reader.Next()
+		if err == io.EOF {
+			return s, nil
+		}
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		if _, ok := s.blocks[c]; ok {
+			continue
+		}
+		if _, err := file.Write(data); err != nil {
+			s.Close()
+			return nil, err
+		}
+		s.blocks[c] = blockRef{offset: offset, size: len(data)}
+		s.order = append(s.order, c)
+		offset += int64(len(data))
+		s.size = offset
+	}
+}
+
+// Close removes the spooled blocks
+func (s *Store) Close() error {
+	s.file.Close()
+	return os.Remove(s.file.Name())
+}
+
+// Len is the number of distinct blocks
+func (s *Store) Len() int {
+	return len(s.blocks)
+}
+
+// Size is the total size of the distinct blocks
+func (s *Store) Size() int64 {
+	return s.size
+}
+
+// CIDs returns the CIDs of the distinct blocks in the order they were read
+func (s *Store) CIDs() []CID {
+	return append([]CID{}, s.order...)
+}
+
+// Missing returns the blocks the DAG under root links to that the store
+// doesn't hold. Only dag-pb blocks have links that are followed.
+func (s *Store) Missing(root CID) ([]CID, error) {
+	var missing []CID
+	seen := map[CID]bool{}
+	var walk func(c CID) error
+	walk = func(c CID) error {
+		if seen[c] {
+			return nil
+		}
+		seen[c] = true
+		if !s.Has(c) {
+			missing = append(missing, c)
+			return nil
+		}
+		if c.Codec() != CodecDagPB {
+			return nil
+		}
+		data, err := s.Get(c)
+		if err != nil {
+			return err
+		}
+		node, err := decodePBNode(data)
+		if err != nil {
+			return fmt.Errorf("block %s: %w", c, err)
+		}
+		for _, link := range node.Links {
+			if err := walk(link.Hash); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return missing, walk(root)
+}
+
+// Has reports whether the store holds the block for c
+func (s *Store) Has(c CID) bool {
+	if _, ok := c.inline(); ok {
+		return true
+	}
+	_, ok := s.blocks[c]
+	return ok
+}
+
+// Get returns the data of a block
+func (s *Store) Get(c CID) ([]byte, error) {
+	if data, ok := c.inline(); ok {
+		return data, nil
+	}
+	ref, ok := s.blocks[c]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrMissingBlock, c)
+	}
+	data := make([]byte, ref.size)
+	if _, err := s.file.ReadAt(data, ref.offset); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// node returns a dag-pb block with its UnixFS data
+func (s *Store) node(c CID) (*pbNode, *unixfsData, error) {
+	if c.Codec() != CodecDagPB {
+		return nil, nil, fmt.Errorf("block %s has codec 0x%x, only dag-pb and raw blocks can be read", c, c.Codec())
+	}
+	data, err := s.Get(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	node, err := decodePBNode(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("block %s: %w", c, err)
+	}
+	if node.Data == nil {
+		return nil, nil, fmt.Errorf("block %s has no UnixFS data", c)
+	}
+	u, err := decodeUnixFS(node.Data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("block %s: %w", c, err)
+	}
+	return node, u, nil
+}
+
+// Stat describes the file, directory or symlink at c
+func (s *Store) Stat(c CID) (Entry, error) {
+	if c.Codec() == CodecRaw {
+		data, err := s.Get(c)
+		if err != nil {
+			return Entry{}, err
+		}
+		return Entry{Cid: c, Type: TypeFile, Size: uint64(len(data))}, nil
+	}
+
+	_, u, err := s.node(c)
+	if err != nil {
+		return Entry{}, err
+	}
+	switch u.Type {
+	case unixfsFile, unixfsRaw:
+		return Entry{Cid: c, Type: TypeFile, Size: u.FileSize}, nil
+	case unixfsDirectory, unixfsHAMTShard:
+		return Entry{Cid: c, Type: TypeDirectory}, nil
+	case unixfsSymlink:
+		return Entry{Cid: c, Type: TypeSymlink, Size: uint64(len(u.Data)), Target: string(u.Data)}, nil
+	}
+	return Entry{}, fmt.Errorf("block %s has unsupported UnixFS type %d", c, u.Type)
+}
+
+// ReadDir lists a directory by name, following the shards of a HAMT
+// directory
+func (s *Store) ReadDir(c CID) ([]Entry, error) {
+	node, u, err := s.node(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var links []pbLink
+	switch u.Type {
+	case unixfsDirectory:
+		links = node.Links
+	case unixfsHAMTShard:
+		if links, err = s.shardLinks(node, u); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%s isn't a directory", c)
+	}
+
+	entries := make([]Entry, 0, len(links))
+	seen := map[string]bool{}
+	for _, link := range links {
+		if !validName(link.Name) {
+			return nil, fmt.Errorf("directory %s has an entry with the invalid name %q", c, link.Name)
+		}
+		if seen[link.Name] {
+			return nil, fmt.Errorf("directory %s has more than one entry named %q", c, link.Name)
+		}
+		seen[link.Name] = true
+
+		entry, err := s.Stat(link.Hash)
+		if err != nil {
+			return nil, err
+		}
+		entry.Name = link.Name
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+	return entries, nil
+}
+
+// shardLinks returns the entries of a HAMT shard and its sub-shards. Link
+// names start with the bucket in hex: a link that is only the bucket is a
+// sub-shard, and the rest of a longer name is the entry's name.
+func (s *Store) shardLinks(node *pbNode, u *unixfsData) ([]pbLink, error) {
+	if u.Fanout < 2 {
+		return nil, fmt.Errorf("HAMT shard has an invalid fanout of %d", u.Fanout)
+	}
+	prefix := len(fmt.Sprintf("%X", u.Fanout-1))
+
+	var links []pbLink
+	for _, link := range node.Links {
+		if len(link.Name) < prefix {
+			return nil, fmt.Errorf("HAMT shard has an invalid link name %q", link.Name)
+		}
+		if len(link.Name) > prefix {
+			link.Name = link.Name[prefix:]
+			links = append(links, link)
+			continue
+		}
+		child, childData, err := s.node(link.Hash)
+		if err != nil {
+			return nil, err
+		}
+		if childData.Type != unixfsHAMTShard {
+			return nil, fmt.Errorf("HAMT bucket %s isn't a shard", link.Hash)
+		}
+		sub, err := s.shardLinks(child, childData)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, sub...)
+	}
+	return links, nil
+}
+
+// validName rejects names that could escape the directory they're in
+func validName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, "/\x00")
+}
+
+// WriteFile writes the contents of the file at c to w
+func (s *Store) WriteFile(c CID, w io.Writer) error {
+	entry, err := s.Stat(c)
+	if err != nil {
+		return err
+	}
+	if entry.Type != TypeFile {
+		return fmt.Errorf("%s is a %s, not a file", c, entry.Type)
+	}
+	counter := &countingWriter{w: w}
+	if err := s.writeFile(c, counter); err != nil {
+		return err
+	}
+	if counter.n != entry.Size {
+		return fmt.Errorf("file %s has %d bytes, but its root says %d", c, counter.n, entry.Size)
+	}
+	return nil
+}
+
+func (s *Store) writeFile(c CID, w io.Writer) error {
+	if c.Codec() == CodecRaw {
+		data, err := s.Get(c)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+
+	node, u, err := s.node(c)
+	if err != nil {
+		return err
+	}
+	if u.Type != unixfsFile && u.Type != unixfsRaw {
+		return fmt.Errorf("block %s inside a file isn't file data", c)
+	}
+	if _, err := w.Write(u.Data); err != nil {
+		return err
+	}
+	for _, link := range node.Links {
+		if err := s.writeFile(link.Hash, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n uint64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += uint64(n)
+	return n, err
+}
+
+// Extract writes the file, directory or symlink at root to dest, which must
+// not exist. Nothing is ever written through an existing path, so entries
+// can't escape dest. onEntry, when set, is called with the slash separated
+// path of each entry below dest before it's written.
+func (s *Store) Extract(root CID, dest string, onEntry func(path string, e Entry)) error {
+	entry, err := s.Stat(root)
+	if err != nil {
+		return err
+	}
+	return s.extract(entry, dest, "", onEntry)
+}
+
+func (s *Store) extract(entry Entry, dest string, path string, onEntry func(string, Entry)) error {
+	if onEntry != nil && path != "" {
+		onEntry(path, entry)
+	}
+
+	switch entry.Type {
+	case TypeDirectory:
+		entries, err := s.ReadDir(entry.Cid)
+		if err != nil {
+			return err
+		}
+		if err := os.Mkdir(dest, 0o755); err != nil {
+			return err
+		}
+		for _, child := range entries {
+			// Names are already free of slashes, this catches what else the
+			// platform treats as special, like backslashes on Windows
+			if !filepath.IsLocal(child.Name) || filepath.Base(child.Name) != child.Name {
+				return fmt.Errorf("can't write %q in %s: the name isn't valid on this system", child.Name, dest)
+			}
+			childPath := child.Name
+			if path != "" {
+				childPath = path + "/" + child.Name
+			}
+			if err := s.extract(child, filepath.Join(dest, child.Name), childPath, onEntry); err != nil {
+				return err
+			}
+		}
+		return nil
+	case TypeSymlink:
+		return os.Symlink(entry.Target, dest)
+	}
+
+	f, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := s.WriteFile(entry.Cid, f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
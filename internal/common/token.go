@@ -3,41 +3,61 @@ package common
 import (
 	"errors"
 	"os"
-	"path/filepath"
+	"strings"
+
+	"github.com/PinataCloud/ipfs-cli/internal/config"
+	"github.com/PinataCloud/ipfs-cli/internal/credentials"
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+)
+
+const (
+	TokenSourceEnv         = "PINATA_JWT"
+	TokenSourceConfig      = "config file"
+	TokenSourceCredentials = "encrypted credential store"
 )
 
-// FindToken extracts the JWT token from the .pinata-files-cli file
+// FindToken extracts the JWT token for the active profile. PINATA_JWT takes
+// precedence, then the config file and the encrypted credential store.
 func FindToken() ([]byte, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return nil, err
+	jwt, _, err := FindTokenWithSource()
+	return jwt, err
+}
+
+// FindTokenWithSource is FindToken but also reports where the JWT came from
+func FindTokenWithSource() ([]byte, string, error) {
+	if env := strings.TrimSpace(os.Getenv("PINATA_JWT")); env != "" {
+		return []byte(env), TokenSourceEnv, nil
 	}
-	dotFilePath := filepath.Join(homeDir, ".pinata-files-cli")
-	JWT, err := os.ReadFile(dotFilePath)
+	name, profile, err := config.ActiveProfile()
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, errors.New("JWT not found. Please authorize first using the 'auth' command")
-		} else {
-			return nil, err
+		return nil, "", err
+	}
+	if profile.JWT != "" {
+		if config.RequiresEncryptedCredentials() {
+			return nil, "", exitcode.Unauthorizedf("profile %q has a plaintext JWT but encrypted credentials are required, run 'pinata auth encrypt'", name)
+		}
+		return []byte(profile.JWT), TokenSourceConfig, nil
+	}
+	if credentials.Exists() {
+		jwt, err := credentials.Get(name)
+		if err != nil {
+			return nil, "", err
+		}
+		if jwt != "" {
+			return []byte(jwt), TokenSourceCredentials, nil
 		}
 	}
-	return JWT, err
+	return nil, "", exitcode.Unauthorizedf("JWT not found. Please authorize first using the 'auth' command")
 }
 
-// FindGatewayDomain gets the gateway domain from the config file
+// FindGatewayDomain gets the gateway domain from the active profile
 func FindGatewayDomain() ([]byte, error) {
-	homeDir, err := os.UserHomeDir()
+	_, profile, err := config.ActiveProfile()
 	if err != nil {
 		return nil, err
 	}
-	dotFilePath := filepath.Join(homeDir, ".pinata-files-cli-gateway")
-	Domain, err := os.ReadFile(dotFilePath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, errors.New("Gateway domain not found. Please set a gateway first")
-		} else {
-			return nil, err
-		}
+	if profile.Gateway == "" {
+		return nil, errors.New("Gateway domain not found. Please set a gateway first")
 	}
-	return Domain, err
+	return []byte(profile.Gateway), nil
 }
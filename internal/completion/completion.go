@@ -0,0 +1,201 @@
+// Package completion generates shell completion scripts and completes the
+// IDs of agents, groups, files, secrets, skills, tasks and domains from the
+// API, with their names as descriptions where the shell can show them.
+package completion
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/PinataCloud/ipfs-cli/internal/api"
+	"github.com/PinataCloud/ipfs-cli/internal/config"
+	"github.com/PinataCloud/ipfs-cli/internal/refs"
+
+	"github.com/urfave/cli/v2"
+)
+
+// timeout bounds the API calls made while completing, so a slow network
+// doesn't freeze the prompt
+const timeout = 3 * time.Second
+
+// Values completed without the API, which arguments can be declared as with
+// refs.Install
+const (
+	Network refs.Kind = "network"
+	Profile refs.Kind = "profile"
+)
+
+// flagKinds maps flag names to what their values complete to
+var flagKinds = map[string]refs.Kind{
+	"network": Network,
+	"profile": Profile,
+}
+
+func init() {
+	for name, kind := range refs.Flags {
+		flagKinds[name] = kind
+	}
+}
+
+// Install enables completion on the app, after refs.Install has declared the
+// arguments of its commands. Commands whose arguments or flags take IDs
+// complete them, after setup has applied the global flags, such as
+// --profile, that completion skips.
+func Install(app *cli.App, setup func(*cli.Context) error) {
+	app.EnableBashCompletion = true
+	app.BashComplete = completer(nil, nil, setup)
+	install(app.Commands, setup)
+}
+
+func install(commands []*cli.Command, setup func(*cli.Context) error) {
+	for _, command := range commands {
+		if len(command.Subcommands) > 0 {
+			install(command.Subcommands, setup)
+			continue
+		}
+		args := refs.Declared(command)
+		if len(args) > 0 || hasFlagKind(command.Flags) {
+			command.BashComplete = completer(command, args, setup)
+		}
+	}
+}
+
+func hasFlagKind(flags []cli.Flag) bool {
+	for _, flag := range flags {
+		if flagKinds[flag.Names()[0]] != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func completer(command *cli.Command, args []refs.Arg, setup func(*cli.Context) error) cli.BashCompleteFunc {
+	return func(ctx *cli.Context) {
+		var flags []cli.Flag
+		if command != nil {
+			flags = command.Flags
+		} else {
+			flags = ctx.App.Flags
+		}
+
+		// The word before the one being completed, as the scripts send it
+		var last string
+		if len(os.Args) > 2 {
+			last = os.Args[len(os.Args)-2]
+		}
+		if strings.HasPrefix(last, "-") {
+			if kind := flagKind(flags, last); kind != "" {
+				complete(ctx, kind, "", setup)
+				return
+			}
+			cli.DefaultCompleteWithFlags(command)(ctx)
+			return
+		}
+
+		kind := refs.At(args, ctx.NArg())
+		if kind == "" {
+			if command == nil {
+				cli.DefaultAppComplete(ctx)
+			}
+			return
+		}
+		complete(ctx, kind, ctx.Args().First(), setup)
+	}
+}
+
+// flagKind returns what the value of the flag named by arg completes to
+func flagKind(flags []cli.Flag, arg string) refs.Kind {
+	name := strings.TrimLeft(arg, "-")
+	for _, flag := range flags {
+		for _, n := range flag.Names() {
+			if n == name {
+				if f, ok := flag.(cli.DocGenerationFlag); ok && !f.TakesValue() {
+					return ""
+				}
+				return flagKinds[flag.Names()[0]]
+			}
+		}
+	}
+	return ""
+}
+
+// complete prints the values of a kind. agentID is the command's first
+// argument, which scopes the kinds that belong to an agent. Errors are
+// ignored, there's nowhere to show them.
+func complete(ctx *cli.Context, kind refs.Kind, agentID string, setup func(*cli.Context) error) {
+	if err := setup(ctx); err != nil {
+		return
+	}
+	// A retry would only outlast the timeout
+	api.Client().Retries = 0
+
+	switch kind {
+	case Network:
+		fmt.Printf("%s\n%s\n", config.NetworkPublic, config.NetworkPrivate)
+		return
+	case Profile:
+		if file, err := config.Load(); err == nil {
+			for _, name := range file.ProfileNames() {
+				fmt.Println(name)
+			}
+		}
+		return
+	}
+	if kind.Scoped() {
+		if agentID == "" {
+			return
+		}
+		// The agent may be given by name too
+		var err error
+		if agentID, err = resolve(ctx, refs.Agent, agentID, ""); err != nil {
+			return
+		}
+	}
+
+	items, err := resolveList(ctx, kind, agentID)
+	if err != nil {
+		return
+	}
+	printItems(items)
+}
+
+func resolve(ctx *cli.Context, kind refs.Kind, ref, agentID string) (string, error) {
+	reqCtx, cancel := context.WithTimeout(ctx.Context, timeout)
+	defer cancel()
+	scope, err := refs.Scope(kind, ctx.String("network"), agentID)
+	if err != nil {
+		return "", err
+	}
+	return refs.Resolve(reqCtx, kind, ref, scope)
+}
+
+func resolveList(ctx *cli.Context, kind refs.Kind, agentID string) ([]refs.Item, error) {
+	reqCtx, cancel := context.WithTimeout(ctx.Context, timeout)
+	defer cancel()
+	scope, err := refs.Scope(kind, ctx.String("network"), agentID)
+	if err != nil {
+		return nil, err
+	}
+	return refs.List(reqCtx, kind, scope)
+}
+
+// printItems writes one completion per line, with the name as description in
+// the format of the shell named by SHELL
+func printItems(items []refs.Item) {
+	shell := filepath.Base(os.Getenv("SHELL"))
+	for _, it := range items {
+		name := strings.Join(strings.Fields(it.Name), " ")
+		switch {
+		case name == "" || (shell != "zsh" && shell != "fish"):
+			fmt.Println(it.ID)
+		case shell == "zsh":
+			fmt.Printf("%s:%s\n", strings.ReplaceAll(it.ID, ":", `\:`), name)
+		default:
+			fmt.Printf("%s\t%s\n", it.ID, name)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package completion
+
+import (
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+)
+
+// The scripts run the CLI with --generate-bash-completion appended to the
+// words typed so far, and a trailing flag prefix when one is being completed.
+// SHELL tells the CLI which format to answer in. Stdin is closed so nothing
+// can prompt, e.g. for the credential store passphrase.
+
+const bashScript = `# pinata bash completion
+_pinata_complete() {
+  local cur words cword
+  if declare -F _init_completion >/dev/null 2>&1; then
+    _init_completion -n "=:" || return
+  else
+    COMPREPLY=()
+    cur="${COMP_WORDS[COMP_CWORD]}"
+    words=("${COMP_WORDS[@]}")
+    cword=$COMP_CWORD
+  fi
+  local request=("${words[@]:0:$cword}")
+  if [[ "$cur" == -* ]]; then
+    request+=("$cur")
+  fi
+  local opts
+  opts=$(SHELL=bash "${request[@]}" --generate-bash-completion 2>/dev/null </dev/null)
+  COMPREPLY=($(compgen -W "${opts}" -- "${cur}"))
+}
+
+complete -o bashdefault -o default -F _pinata_complete pinata
+`
+
+const zshScript = `#compdef pinata
+
+_pinata() {
+  local -a opts request
+  local cur=${words[CURRENT]}
+  request=("${(@)words[1,CURRENT-1]}")
+  if [[ "$cur" == -* ]]; then
+    request+=("$cur")
+  fi
+  opts=("${(@f)$(SHELL=zsh ${request[@]} --generate-bash-completion 2>/dev/null </dev/null)}")
+
+  if [[ "${opts[1]}" != "" ]]; then
+    _describe 'values' opts
+  else
+    _files
+  fi
+}
+
+if [[ "$funcstack[1]" == "_pinata" ]]; then
+  _pinata "$@"
+else
+  compdef _pinata pinata
+fi
+`
+
+const fishScript = `# pinata fish completion
+function __pinata_complete
+    set -l request (commandline -opc)
+    set -l cur (commandline -ct)
+    if string match -q -- '-*' $cur
+        set -a request $cur
+    end
+    set -l opts (env SHELL=fish $request --generate-bash-completion 2>/dev/null </dev/null)
+    if test (count $opts) -gt 0
+        printf '%s\n' $opts
+    else
+        __fish_complete_path $cur
+    end
+end
+
+complete -c pinata -f -a '(__pinata_complete)'
+`
+
+// Script returns the completion script for bash, zsh or fish
+func Script(shell string) (string, error) {
+	switch shell {
+	case "bash":
+		return bashScript, nil
+	case "zsh":
+		return zshScript, nil
+	case "fish":
+		return fishScript, nil
+	}
+	return "", exitcode.InvalidInputf("unsupported shell %q: must be bash, zsh or fish", shell)
+}
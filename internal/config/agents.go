@@ -2,17 +2,16 @@ package config
 
 import (
 	"os"
-	"path/filepath"
 	"strings"
 )
 
-// GetAgentsHost returns the Agents API host URL from environment or default
+// GetAgentsHost returns the Agents API host URL from environment, the active profile or default
 func GetAgentsHost() string {
-	return getEnv("PINATA_AGENTS_HOST", "agents.pinata.cloud")
+	return getEnv("PINATA_AGENTS_HOST", profileOr(func(p *Profile) string { return p.AgentsHost }, "agents.pinata.cloud"))
 }
 
 // GetChatModel returns the chat model to use for agents
-// It checks PINATA_CHAT_MODEL env var first, then ~/.pinata-agents-model file,
+// It checks PINATA_CHAT_MODEL env var first, then the active profile,
 // then returns empty string if neither is set
 func GetChatModel() string {
 	// First check environment variable
@@ -21,17 +20,6 @@ func GetChatModel() string {
 		return model
 	}
 
-	// Then check config file
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return ""
-	}
-
-	p := filepath.Join(home, ".pinata-agents-model")
-	data, err := os.ReadFile(p)
-	if err != nil {
-		return ""
-	}
-
-	return strings.TrimSpace(string(data))
+	// Then check the active profile
+	return strings.TrimSpace(activeProfileValue(func(p *Profile) string { return p.ChatModel }))
 }
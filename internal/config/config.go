@@ -4,14 +4,44 @@ import (
 	"os"
 )
 
-// GetAPIHost returns the API host URL from environment or default
+// GetAPIHost returns the API host URL from environment, the active profile or default
 func GetAPIHost() string {
-	return getEnv("PINATA_API_HOST", "api.pinata.cloud")
+	return getEnv("PINATA_API_HOST", profileOr(func(p *Profile) string { return p.APIHost }, "api.pinata.cloud"))
 }
 
-// GetUploadsHost returns the uploads host URL from environment or default
+// GetUploadsHost returns the uploads host URL from environment, the active profile or default
 func GetUploadsHost() string {
-	return getEnv("PINATA_UPLOADS_HOST", "uploads.pinata.cloud")
+	return getEnv("PINATA_UPLOADS_HOST", profileOr(func(p *Profile) string { return p.UploadsHost }, "uploads.pinata.cloud"))
+}
+
+// GetProxy returns the proxy URL from environment or the active profile
+func GetProxy() string {
+	return getEnv("PINATA_PROXY", profileOr(func(p *Profile) string { return p.Proxy }, ""))
+}
+
+// GetCAFile returns the CA bundle path from environment or the active profile
+func GetCAFile() string {
+	return getEnv("PINATA_CA_FILE", profileOr(func(p *Profile) string { return p.CAFile }, ""))
+}
+
+// GetClientCert returns the client certificate path from environment or the active profile
+func GetClientCert() string {
+	return getEnv("PINATA_CLIENT_CERT", profileOr(func(p *Profile) string { return p.ClientCert }, ""))
+}
+
+// GetClientKey returns the client key path from environment or the active profile
+func GetClientKey() string {
+	return getEnv("PINATA_CLIENT_KEY", profileOr(func(p *Profile) string { return p.ClientKey }, ""))
+}
+
+// GetOnSuccess returns the command run after uploads from environment or the active profile
+func GetOnSuccess() string {
+	return getEnv("PINATA_ON_SUCCESS", profileOr(func(p *Profile) string { return p.OnSuccess }, ""))
+}
+
+// GetWebhook returns the URL notified of uploads from environment or the active profile
+func GetWebhook() string {
+	return getEnv("PINATA_WEBHOOK", profileOr(func(p *Profile) string { return p.Webhook }, ""))
 }
 
 // Helper function to get environment variable with fallback
@@ -22,3 +52,12 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+// Helper function to get a value from the active profile with fallback
+func profileOr(field func(*Profile) string, defaultValue string) string {
+	value := activeProfileValue(field)
+	if len(value) == 0 {
+		return defaultValue
+	}
+	return value
+}
@@ -3,8 +3,8 @@ package config
 import (
 	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
 )
 
 const (
@@ -16,16 +16,12 @@ const (
 func SetDefaultNetwork(network string) error {
 	// Validate network value
 	if network != NetworkPublic && network != NetworkPrivate {
-		return fmt.Errorf("invalid network: %s. Must be either 'public' or 'private'", network)
-	}
-
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return err
+		return exitcode.InvalidInputf("invalid network: %s. Must be either 'public' or 'private'", network)
 	}
 
-	p := filepath.Join(home, ".pinata-files-cli-network")
-	err = os.WriteFile(p, []byte(network), 0600)
+	err := UpdateActiveProfile(func(p *Profile) {
+		p.Network = network
+	})
 	if err != nil {
 		return err
 	}
@@ -34,25 +30,18 @@ func SetDefaultNetwork(network string) error {
 	return nil
 }
 
-// GetDefaultNetwork retrieves the user's preferred network from config
+// GetDefaultNetwork retrieves the user's preferred network from the active profile
 // If no preference is set, it returns "public" as the default
 func GetDefaultNetwork() (string, error) {
-	home, err := os.UserHomeDir()
+	_, profile, err := ActiveProfile()
 	if err != nil {
 		return "", err
 	}
 
-	p := filepath.Join(home, ".pinata-files-cli-network")
-	data, err := os.ReadFile(p)
-	if err != nil {
-		if os.IsNotExist(err) {
-			// If file doesn't exist, return default
-			return NetworkPublic, nil
-		}
-		return "", err
+	network := profile.Network
+	if network == "" {
+		return NetworkPublic, nil
 	}
-
-	network := string(data)
 	if network != NetworkPublic && network != NetworkPrivate {
 		return NetworkPublic, errors.New("invalid network in config file")
 	}
@@ -70,7 +59,7 @@ func GetNetworkParam(network string) (string, error) {
 
 	// Validate the provided network
 	if network != NetworkPublic && network != NetworkPrivate {
-		return "", fmt.Errorf("invalid network: %s. Must be either 'public' or 'private'", network)
+		return "", exitcode.InvalidInputf("invalid network: %s. Must be either 'public' or 'private'", network)
 	}
 
 	return network, nil
@@ -0,0 +1,397 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+)
+
+const DefaultProfile = "default"
+
+// Profile holds the credentials and preferences for one account or environment
+type Profile struct {
+	JWT         string `json:"jwt,omitempty"`
+	Network     string `json:"network,omitempty"`
+	Gateway     string `json:"gateway,omitempty"`
+	APIHost     string `json:"api_host,omitempty"`
+	UploadsHost string `json:"uploads_host,omitempty"`
+	AgentsHost  string `json:"agents_host,omitempty"`
+	ChatModel   string `json:"chat_model,omitempty"`
+	// Proxy is an http, https or socks5 URL used for every connection
+	Proxy string `json:"proxy,omitempty"`
+	// CAFile is a PEM bundle trusted in addition to the system roots
+	CAFile string `json:"ca_file,omitempty"`
+	// ClientCert and ClientKey are PEM files presented for mutual TLS
+	ClientCert string `json:"client_cert,omitempty"`
+	ClientKey  string `json:"client_key,omitempty"`
+	// OnSuccess is a command run after each upload, and Webhook a URL the
+	// upload response is POSTed to
+	OnSuccess string `json:"on_success,omitempty"`
+	Webhook   string `json:"webhook,omitempty"`
+}
+
+// File is the on-disk layout of the unified config file
+type File struct {
+	CurrentProfile string              `json:"current_profile"`
+	Profiles       map[string]*Profile `json:"profiles"`
+	// RequireEncryptedCredentials rejects JWTs stored in plaintext
+	RequireEncryptedCredentials bool `json:"require_encrypted_credentials,omitempty"`
+}
+
+// profileOverride is set by the global --profile flag
+var profileOverride string
+
+// SetProfileOverride selects the profile for the rest of the process,
+// taking precedence over PINATA_PROFILE and the current profile in the file
+func SetProfileOverride(name string) {
+	profileOverride = name
+}
+
+// Dir returns the directory holding the config file and CLI caches
+func Dir() (string, error) {
+	if dir := os.Getenv("PINATA_CONFIG_DIR"); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".pinata"), nil
+}
+
+func filePath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.json"), nil
+}
+
+// loaded is the config file read by Load, kept for the rest of the process
+// so every getter doesn't read it again. path tells a cached file apart when
+// PINATA_CONFIG_DIR changes.
+var loaded struct {
+	path string
+	file *File
+}
+
+// Load reads the config file, migrating the legacy dotfiles the first time.
+// The file is only read once per process, and each call returns a copy that
+// can be changed and saved.
+func Load() (*File, error) {
+	p, err := filePath()
+	if err != nil {
+		return nil, err
+	}
+	if loaded.file != nil && loaded.path == p {
+		return loaded.file.clone(), nil
+	}
+
+	data, err := os.ReadFile(p)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+		file, err := migrateLegacy()
+		if err != nil {
+			return nil, err
+		}
+		if len(file.Profiles) == 0 {
+			loaded.path, loaded.file = p, file.clone()
+			return file, nil
+		}
+		if err := file.Save(); err != nil {
+			return nil, err
+		}
+		return file, nil
+	}
+
+	var file File
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", p, err)
+	}
+	if file.Profiles == nil {
+		file.Profiles = map[string]*Profile{}
+	}
+	loaded.path, loaded.file = p, file.clone()
+	return &file, nil
+}
+
+// Save writes the config file with owner-only permissions
+func (f *File) Save() error {
+	p, err := filePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(f, "", "    ")
+	if err != nil {
+		return err
+	}
+	// Write to a temporary file first so an interrupted write never leaves a
+	// truncated config
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	loaded.path, loaded.file = p, f.clone()
+	return nil
+}
+
+// clone returns a copy of the file whose profiles can be changed without
+// changing f
+func (f *File) clone() *File {
+	c := *f
+	c.Profiles = make(map[string]*Profile, len(f.Profiles))
+	for name, profile := range f.Profiles {
+		copied := *profile
+		c.Profiles[name] = &copied
+	}
+	return &c
+}
+
+// ActiveProfileName returns the profile selected by --profile, PINATA_PROFILE
+// or the config file, in that order
+func (f *File) ActiveProfileName() string {
+	if profileOverride != "" {
+		return profileOverride
+	}
+	if env := os.Getenv("PINATA_PROFILE"); env != "" {
+		return env
+	}
+	if f.CurrentProfile != "" {
+		return f.CurrentProfile
+	}
+	return DefaultProfile
+}
+
+// ProfileNames returns the names of all profiles in sorted order
+func (f *File) ProfileNames() []string {
+	names := make([]string, 0, len(f.Profiles))
+	for name := range f.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ActiveProfile returns the name and settings of the active profile. A missing
+// profile is only an error when it was explicitly requested.
+func ActiveProfile() (string, *Profile, error) {
+	file, err := Load()
+	if err != nil {
+		return "", nil, err
+	}
+	name := file.ActiveProfileName()
+	profile, ok := file.Profiles[name]
+	if !ok {
+		if name != DefaultProfile && name != file.CurrentProfile {
+			return name, nil, exitcode.NotFoundf("profile %q not found, available profiles: %s", name, strings.Join(file.ProfileNames(), ", "))
+		}
+		profile = &Profile{}
+	}
+	return name, profile, nil
+}
+
+// RequiresEncryptedCredentials reports whether plaintext JWTs are rejected
+func RequiresEncryptedCredentials() bool {
+	file, err := Load()
+	if err != nil {
+		return false
+	}
+	return file.RequireEncryptedCredentials
+}
+
+// UpdateActiveProfile applies a change to the active profile and saves it,
+// creating the profile if it doesn't exist yet
+func UpdateActiveProfile(update func(*Profile)) error {
+	file, err := Load()
+	if err != nil {
+		return err
+	}
+	name := file.ActiveProfileName()
+	profile, ok := file.Profiles[name]
+	if !ok {
+		profile = &Profile{}
+		file.Profiles[name] = profile
+	}
+	update(profile)
+	if file.CurrentProfile == "" {
+		file.CurrentProfile = name
+	}
+	return file.Save()
+}
+
+// CreateProfile adds a new profile
+func CreateProfile(name string, profile Profile) error {
+	if name == "" || strings.ContainsAny(name, " /\\") {
+		return exitcode.InvalidInputf("invalid profile name %q", name)
+	}
+	if profile.Network != "" && profile.Network != NetworkPublic && profile.Network != NetworkPrivate {
+		return exitcode.InvalidInputf("invalid network: %s. Must be either 'public' or 'private'", profile.Network)
+	}
+	file, err := Load()
+	if err != nil {
+		return err
+	}
+	if _, ok := file.Profiles[name]; ok {
+		return exitcode.Conflictf("profile %q already exists", name)
+	}
+	file.Profiles[name] = &profile
+	if file.CurrentProfile == "" {
+		file.CurrentProfile = name
+	}
+	return file.Save()
+}
+
+// UseProfile makes a profile the current one
+func UseProfile(name string) error {
+	file, err := Load()
+	if err != nil {
+		return err
+	}
+	if _, ok := file.Profiles[name]; !ok {
+		return exitcode.NotFoundf("profile %q not found, available profiles: %s", name, strings.Join(file.ProfileNames(), ", "))
+	}
+	file.CurrentProfile = name
+	return file.Save()
+}
+
+// DeleteProfile removes a profile
+func DeleteProfile(name string) error {
+	file, err := Load()
+	if err != nil {
+		return err
+	}
+	if _, ok := file.Profiles[name]; !ok {
+		return exitcode.NotFoundf("profile %q not found", name)
+	}
+	if file.CurrentProfile == name {
+		return exitcode.InvalidInputf("cannot delete the current profile, switch to another profile first")
+	}
+	delete(file.Profiles, name)
+	return file.Save()
+}
+
+// ListProfiles prints the profile names, marking the active one
+func ListProfiles() error {
+	file, err := Load()
+	if err != nil {
+		return err
+	}
+	active := file.ActiveProfileName()
+	if len(file.Profiles) == 0 {
+		fmt.Println("No profiles yet, run 'pinata auth' to create the default profile")
+		return nil
+	}
+	for _, name := range file.ProfileNames() {
+		marker := "  "
+		if name == active {
+			marker = "* "
+		}
+		fmt.Println(marker + name)
+	}
+	return nil
+}
+
+// ShowProfile prints the settings of a profile with the JWT masked
+func ShowProfile(name string) error {
+	file, err := Load()
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		name = file.ActiveProfileName()
+	}
+	profile, ok := file.Profiles[name]
+	if !ok {
+		return exitcode.NotFoundf("profile %q not found", name)
+	}
+
+	masked := *profile
+	masked.JWT = MaskSecret(masked.JWT)
+	formattedJSON, err := json.MarshalIndent(struct {
+		Name string `json:"name"`
+		Profile
+	}{name, masked}, "", "    ")
+	if err != nil {
+		return errors.New("failed to format JSON")
+	}
+	fmt.Println(string(formattedJSON))
+	return nil
+}
+
+// MaskSecret hides all but the last few characters of a secret
+func MaskSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	if len(secret) <= 8 {
+		return "****"
+	}
+	return "****" + secret[len(secret)-4:]
+}
+
+// LegacyJWTPath returns the file earlier versions of the CLI kept the JWT in
+func LegacyJWTPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".pinata-files-cli"), nil
+}
+
+// migrateLegacy builds the default profile from the single-valued dotfiles
+// used by earlier versions of the CLI. The JWT is copied as it was, in
+// plaintext, until 'pinata auth encrypt' seals it.
+func migrateLegacy() (*File, error) {
+	file := &File{Profiles: map[string]*Profile{}}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return file, nil
+	}
+
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(home, name))
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(data))
+	}
+
+	profile := &Profile{
+		JWT:       read(".pinata-files-cli"),
+		Gateway:   read(".pinata-files-cli-gateway"),
+		Network:   read(".pinata-files-cli-network"),
+		ChatModel: read(".pinata-agents-model"),
+	}
+	if *profile != (Profile{}) {
+		file.CurrentProfile = DefaultProfile
+		file.Profiles[DefaultProfile] = profile
+	}
+
+	return file, nil
+}
+
+// activeProfileValue returns a field of the active profile, or "" if the
+// config can't be read
+func activeProfileValue(field func(*Profile) string) string {
+	_, profile, err := ActiveProfile()
+	if err != nil || profile == nil {
+		return ""
+	}
+	return field(profile)
+}
@@ -0,0 +1,206 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+)
+
+// useConfigDir points the config at an empty directory, with a home of its
+// own so no legacy dotfiles are migrated, and returns the directory
+func useConfigDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("PINATA_CONFIG_DIR", dir)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("PINATA_PROFILE", "")
+	SetProfileOverride("")
+	return dir
+}
+
+func TestProfiles(t *testing.T) {
+	dir := useConfigDir(t)
+
+	// The first profile becomes the current one
+	if err := CreateProfile("work", Profile{JWT: "work-jwt", Network: NetworkPrivate}); err != nil {
+		t.Fatal(err)
+	}
+	if err := CreateProfile("personal", Profile{JWT: "personal-jwt"}); err != nil {
+		t.Fatal(err)
+	}
+	name, profile, err := ActiveProfile()
+	if err != nil || name != "work" || profile.JWT != "work-jwt" || profile.Network != NetworkPrivate {
+		t.Errorf("active profile is %q %+v, %v, want work", name, profile, err)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("config file has mode %o, want 600", perm)
+	}
+
+	if err := UseProfile("personal"); err != nil {
+		t.Fatal(err)
+	}
+	if name, _, _ := ActiveProfile(); name != "personal" {
+		t.Errorf("active profile is %q after switching, want personal", name)
+	}
+	if err := DeleteProfile("personal"); exitcode.For(err) != exitcode.InvalidInput {
+		t.Errorf("deleting the current profile gave %v, want invalid input", err)
+	}
+	if err := DeleteProfile("work"); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := file.ProfileNames(); len(names) != 1 || names[0] != "personal" {
+		t.Errorf("profiles are %v, want personal", names)
+	}
+}
+
+func TestProfileErrors(t *testing.T) {
+	useConfigDir(t)
+	if err := CreateProfile("work", Profile{}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		code int
+	}{
+		{"duplicate", CreateProfile("work", Profile{}), exitcode.Conflict},
+		{"empty name", CreateProfile("", Profile{}), exitcode.InvalidInput},
+		{"name with a space", CreateProfile("my work", Profile{}), exitcode.InvalidInput},
+		{"name with a slash", CreateProfile("../work", Profile{}), exitcode.InvalidInput},
+		{"unknown network", CreateProfile("lab", Profile{Network: "internal"}), exitcode.InvalidInput},
+		{"use a missing profile", UseProfile("missing"), exitcode.NotFound},
+		{"delete a missing profile", DeleteProfile("missing"), exitcode.NotFound},
+	}
+	for _, tt := range tests {
+		if got := exitcode.For(tt.err); got != tt.code {
+			t.Errorf("%s gave %v with code %d, want %d", tt.name, tt.err, got, tt.code)
+		}
+	}
+}
+
+func TestActiveProfileName(t *testing.T) {
+	useConfigDir(t)
+	file := &File{CurrentProfile: "work"}
+	if got := (&File{}).ActiveProfileName(); got != DefaultProfile {
+		t.Errorf("got %q with no current profile, want %q", got, DefaultProfile)
+	}
+	if got := file.ActiveProfileName(); got != "work" {
+		t.Errorf("got %q, want the current profile", got)
+	}
+	t.Setenv("PINATA_PROFILE", "staging")
+	if got := file.ActiveProfileName(); got != "staging" {
+		t.Errorf("got %q, want PINATA_PROFILE", got)
+	}
+	SetProfileOverride("ci")
+	t.Cleanup(func() { SetProfileOverride("") })
+	if got := file.ActiveProfileName(); got != "ci" {
+		t.Errorf("got %q, want --profile", got)
+	}
+
+	// Only a profile asked for by name must exist
+	if _, _, err := ActiveProfile(); exitcode.For(err) != exitcode.NotFound {
+		t.Errorf("a missing --profile gave %v, want not found", err)
+	}
+	SetProfileOverride("")
+	t.Setenv("PINATA_PROFILE", "")
+	if name, profile, err := ActiveProfile(); err != nil || name != DefaultProfile || *profile != (Profile{}) {
+		t.Errorf("got %q %+v, %v, want an empty default profile", name, profile, err)
+	}
+}
+
+func TestConfigDir(t *testing.T) {
+	first := useConfigDir(t)
+	if err := CreateProfile("first", Profile{}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Dir(); err != nil || got != first {
+		t.Errorf("Dir() = %q, %v, want %q", got, err, first)
+	}
+
+	// A cached config isn't used for another directory
+	second := t.TempDir()
+	t.Setenv("PINATA_CONFIG_DIR", second)
+	file, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(file.Profiles) != 0 {
+		t.Errorf("a new directory has profiles %v", file.ProfileNames())
+	}
+	if err := CreateProfile("second", Profile{}); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("PINATA_CONFIG_DIR", first)
+	file, err = Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := file.ProfileNames(); len(names) != 1 || names[0] != "first" {
+		t.Errorf("profiles are %v, want first", names)
+	}
+
+	t.Setenv("PINATA_CONFIG_DIR", "")
+	home, _ := os.UserHomeDir()
+	if got, err := Dir(); err != nil || got != filepath.Join(home, ".pinata") {
+		t.Errorf("Dir() = %q, %v, want ~/.pinata", got, err)
+	}
+}
+
+func TestLoadCopies(t *testing.T) {
+	useConfigDir(t)
+	if err := CreateProfile("work", Profile{JWT: "saved"}); err != nil {
+		t.Fatal(err)
+	}
+	file, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Profiles["work"].JWT = "changed"
+
+	again, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Profiles["work"].JWT != "saved" {
+		t.Errorf("an unsaved change leaked into the cached config")
+	}
+}
+
+func TestMigrateLegacy(t *testing.T) {
+	dir := useConfigDir(t)
+	home, _ := os.UserHomeDir()
+	for name, value := range map[string]string{
+		".pinata-files-cli":         "legacy-jwt\n",
+		".pinata-files-cli-gateway": "example.mypinata.cloud",
+	} {
+		if err := os.WriteFile(filepath.Join(home, name), []byte(value), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	name, profile, err := ActiveProfile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The JWT stays in plaintext until 'pinata auth encrypt'
+	if name != DefaultProfile || profile.JWT != "legacy-jwt" || profile.Gateway != "example.mypinata.cloud" {
+		t.Errorf("migrated %q %+v", name, profile)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "config.json")); err != nil {
+		t.Errorf("the migrated config wasn't saved: %v", err)
+	}
+}
@@ -0,0 +1,332 @@
+// Package credentials stores profile JWTs in a passphrase-sealed file so they
+// are never written to disk in plaintext.
+package credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/PinataCloud/ipfs-cli/internal/config"
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+	"github.com/PinataCloud/ipfs-cli/internal/utils"
+)
+
+const (
+	storeVersion = 1
+	kdfName      = "pbkdf2-sha256"
+	kdfIter      = 600000
+	keyLength    = 32
+	saltLength   = 16
+)
+
+// sealedStore is the on-disk format of the credential store
+type sealedStore struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// The passphrase and decrypted tokens are kept for the rest of the process so
+// the user is prompted at most once per command
+var (
+	passphrase string
+	unsealed   map[string]string
+)
+
+func storePath() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "credentials.enc"), nil
+}
+
+// Exists reports whether an encrypted credential store has been created
+func Exists() bool {
+	p, err := storePath()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(p)
+	return err == nil
+}
+
+// Get returns the JWT stored for a profile, or "" if there is none
+func Get(profile string) (string, error) {
+	tokens, err := load()
+	if err != nil {
+		return "", err
+	}
+	return tokens[profile], nil
+}
+
+// Set stores the JWT for a profile, creating the store if needed
+func Set(profile string, jwt string) error {
+	tokens, err := load()
+	if err != nil {
+		return err
+	}
+	tokens[profile] = jwt
+	return save(tokens)
+}
+
+// Delete removes the JWT for a profile from the store
+func Delete(profile string) error {
+	if !Exists() {
+		return nil
+	}
+	tokens, err := load()
+	if err != nil {
+		return err
+	}
+	delete(tokens, profile)
+	return save(tokens)
+}
+
+// Migrate moves every plaintext JWT in the config file, including the legacy
+// ~/.pinata-files-cli file, into the encrypted store. A non-nil require
+// updates whether plaintext JWTs are rejected from now on.
+func Migrate(require *bool) error {
+	file, err := config.Load()
+	if err != nil {
+		return err
+	}
+	tokens, err := load()
+	if err != nil {
+		return err
+	}
+
+	migrated := 0
+	legacy, err := readLegacyJWT()
+	if err != nil {
+		return err
+	}
+	// The legacy JWT is usually in the default profile already, copied there
+	// when the config file was first made
+	if profile := file.Profiles[config.DefaultProfile]; legacy != "" && tokens[config.DefaultProfile] == "" && (profile == nil || profile.JWT == "") {
+		tokens[config.DefaultProfile] = legacy
+		migrated++
+	}
+	for name, profile := range file.Profiles {
+		if profile.JWT == "" {
+			continue
+		}
+		tokens[name] = profile.JWT
+		profile.JWT = ""
+		migrated++
+	}
+
+	// Save the store before the plaintext copies are removed
+	err = save(tokens)
+	if err != nil {
+		return err
+	}
+	if require != nil {
+		file.RequireEncryptedCredentials = *require
+	}
+	err = file.Save()
+	if err != nil {
+		return err
+	}
+
+	if err := removeLegacyJWT(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Encrypted %d JWT(s)\n", migrated)
+	if file.RequireEncryptedCredentials {
+		fmt.Println("Plaintext JWTs are rejected")
+	}
+	return nil
+}
+
+func load() (map[string]string, error) {
+	if unsealed != nil {
+		return unsealed, nil
+	}
+
+	p, err := storePath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			unsealed = map[string]string{}
+			return unsealed, nil
+		}
+		return nil, err
+	}
+
+	var store sealedStore
+	if err := json.Unmarshal(data, &store); err != nil {
+		return nil, fmt.Errorf("invalid credential store %s: %w", p, err)
+	}
+	if store.Version != storeVersion || store.KDF != kdfName {
+		return nil, fmt.Errorf("unsupported credential store %s", p)
+	}
+	// A store with fewer iterations is weaker than one this CLI writes, so it
+	// was tampered with
+	if store.Iterations < kdfIter {
+		return nil, fmt.Errorf("invalid credential store %s: %d iterations is below the minimum of %d", p, store.Iterations, kdfIter)
+	}
+
+	pass, err := getPassphrase(false)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newCipher(pass, store.Salt, store.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, store.Nonce, store.Ciphertext, nil)
+	if err != nil {
+		return nil, exitcode.Unauthorizedf("failed to unlock the credential store, check your passphrase")
+	}
+
+	tokens := map[string]string{}
+	if err := json.Unmarshal(plaintext, &tokens); err != nil {
+		return nil, fmt.Errorf("invalid credential store %s: %w", p, err)
+	}
+	unsealed = tokens
+	return unsealed, nil
+}
+
+func readLegacyJWT() (string, error) {
+	path, err := config.LegacyJWTPath()
+	if err != nil {
+		return "", nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func removeLegacyJWT() error {
+	path, err := config.LegacyJWTPath()
+	if err != nil {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove the plaintext JWT in %s: %w", path, err)
+	}
+	return nil
+}
+
+func save(tokens map[string]string) error {
+	p, err := storePath()
+	if err != nil {
+		return err
+	}
+
+	pass, err := getPassphrase(!Exists())
+	if err != nil {
+		return err
+	}
+
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	gcm, err := newCipher(pass, salt, kdfIter)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	plaintext, err := json.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(sealedStore{
+		Version:    storeVersion,
+		KDF:        kdfName,
+		Iterations: kdfIter,
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plaintext, nil),
+	}, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return err
+	}
+	// Write to a temporary file first so an interrupted write never leaves a
+	// store that can't be decrypted
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		return err
+	}
+	unsealed = tokens
+	return nil
+}
+
+func newCipher(pass string, salt []byte, iterations int) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, pass, salt, iterations, keyLength)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// getPassphrase reads the passphrase from PINATA_PASSPHRASE or prompts for it,
+// asking twice when a new store is being created
+func getPassphrase(confirm bool) (string, error) {
+	if passphrase != "" {
+		return passphrase, nil
+	}
+	if env := os.Getenv("PINATA_PASSPHRASE"); env != "" {
+		passphrase = env
+		return passphrase, nil
+	}
+	if fi, err := os.Stdin.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return "", exitcode.Unauthorizedf("set PINATA_PASSPHRASE to unlock the encrypted credential store")
+	}
+
+	pass, err := utils.GetInput("Enter the passphrase for the Pinata credential store", "Passphrase")
+	if err != nil {
+		return "", err
+	}
+	if pass == "" {
+		return "", exitcode.InvalidInputf("passphrase cannot be empty")
+	}
+	if confirm {
+		again, err := utils.GetInput("Confirm the passphrase", "Passphrase")
+		if err != nil {
+			return "", err
+		}
+		if again != pass {
+			return "", exitcode.InvalidInputf("passphrases do not match")
+		}
+	}
+	passphrase = pass
+	return passphrase, nil
+}
@@ -0,0 +1,265 @@
+package credentials
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/PinataCloud/ipfs-cli/internal/config"
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+)
+
+// useStore points the config at an empty directory and unlocks the store
+// with a passphrase, returning the path of the store
+func useStore(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("PINATA_CONFIG_DIR", dir)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("PINATA_PROFILE", "")
+	t.Setenv("PINATA_PASSPHRASE", "correct horse")
+	forget()
+	t.Cleanup(forget)
+	return filepath.Join(dir, "credentials.enc")
+}
+
+// forget drops the passphrase and tokens kept by the process, as if the next
+// call came from a new command
+func forget() {
+	passphrase = ""
+	unsealed = nil
+}
+
+func TestSetGet(t *testing.T) {
+	path := useStore(t)
+	if Exists() {
+		t.Fatal("a store exists before anything was set")
+	}
+	if jwt, err := Get("default"); err != nil || jwt != "" {
+		t.Errorf("Get before Set = %q, %v", jwt, err)
+	}
+
+	if err := Set("default", "default-jwt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Set("work", "work-jwt"); err != nil {
+		t.Fatal(err)
+	}
+	forget()
+
+	for profile, want := range map[string]string{"default": "default-jwt", "work": "work-jwt", "missing": ""} {
+		if jwt, err := Get(profile); err != nil || jwt != want {
+			t.Errorf("Get(%q) = %q, %v, want %q", profile, jwt, err, want)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("default-jwt")) {
+		t.Error("the store holds a JWT in plaintext")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("store has mode %o, want 600", perm)
+	}
+
+	if err := Delete("work"); err != nil {
+		t.Fatal(err)
+	}
+	forget()
+	if jwt, err := Get("work"); err != nil || jwt != "" {
+		t.Errorf("Get of a deleted JWT = %q, %v", jwt, err)
+	}
+}
+
+func TestDeleteWithoutStore(t *testing.T) {
+	useStore(t)
+	if err := Delete("default"); err != nil {
+		t.Errorf("deleting from no store gave %v", err)
+	}
+	if Exists() {
+		t.Error("deleting created a store")
+	}
+}
+
+func TestWrongPassphrase(t *testing.T) {
+	useStore(t)
+	if err := Set("default", "default-jwt"); err != nil {
+		t.Fatal(err)
+	}
+	forget()
+	t.Setenv("PINATA_PASSPHRASE", "wrong")
+
+	jwt, err := Get("default")
+	if exitcode.For(err) != exitcode.Unauthorized || jwt != "" {
+		t.Errorf("a wrong passphrase gave %q, %v, want unauthorized", jwt, err)
+	}
+}
+
+func TestNoPassphrase(t *testing.T) {
+	useStore(t)
+	if err := Set("default", "default-jwt"); err != nil {
+		t.Fatal(err)
+	}
+	forget()
+	t.Setenv("PINATA_PASSPHRASE", "")
+
+	// Without a terminal there's no one to prompt
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	stdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = stdin }()
+
+	_, err = Get("default")
+	if exitcode.For(err) != exitcode.Unauthorized || !strings.Contains(err.Error(), "PINATA_PASSPHRASE") {
+		t.Errorf("got %v, want a request for PINATA_PASSPHRASE", err)
+	}
+}
+
+func TestTamperedStore(t *testing.T) {
+	path := useStore(t)
+	if err := Set("default", "default-jwt"); err != nil {
+		t.Fatal(err)
+	}
+	original, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		tamper  func(*sealedStore)
+		message string
+	}{
+		{"flipped ciphertext", func(s *sealedStore) { s.Ciphertext[0] ^= 1 }, "check your passphrase"},
+		{"flipped tag", func(s *sealedStore) { s.Ciphertext[len(s.Ciphertext)-1] ^= 1 }, "check your passphrase"},
+		{"other nonce", func(s *sealedStore) { s.Nonce[0] ^= 1 }, "check your passphrase"},
+		{"other salt", func(s *sealedStore) { s.Salt[0] ^= 1 }, "check your passphrase"},
+		{"more iterations", func(s *sealedStore) { s.Iterations++ }, "check your passphrase"},
+		{"fewer iterations", func(s *sealedStore) { s.Iterations = 1 }, "below the minimum"},
+		{"newer version", func(s *sealedStore) { s.Version = storeVersion + 1 }, "unsupported"},
+		{"other kdf", func(s *sealedStore) { s.KDF = "scrypt" }, "unsupported"},
+	}
+	for _, tt := range tests {
+		var store sealedStore
+		if err := json.Unmarshal(original, &store); err != nil {
+			t.Fatal(err)
+		}
+		tt.tamper(&store)
+		data, err := json.Marshal(store)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		forget()
+
+		jwt, err := Get("default")
+		if err == nil || jwt != "" || !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("%s gave %q, %v, want an error mentioning %q", tt.name, jwt, err, tt.message)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte("not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	forget()
+	if _, err := Get("default"); err == nil || !strings.Contains(err.Error(), "invalid credential store") {
+		t.Errorf("a corrupt store gave %v", err)
+	}
+}
+
+// writeConfig saves a config file with profiles holding plaintext JWTs
+func writeConfig(t *testing.T, profiles map[string]*config.Profile) {
+	t.Helper()
+	file := &config.File{CurrentProfile: config.DefaultProfile, Profiles: profiles}
+	if err := file.Save(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeLegacyJWT(t *testing.T, jwt string) string {
+	t.Helper()
+	path, err := config.LegacyJWTPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(jwt+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestMigrate(t *testing.T) {
+	useStore(t)
+	writeConfig(t, map[string]*config.Profile{
+		config.DefaultProfile: {JWT: "default-jwt"},
+		"work":                {JWT: "work-jwt", Network: config.NetworkPrivate},
+		"empty":               {},
+	})
+	// The same JWT as the default profile, copied there by an earlier version
+	legacy := writeLegacyJWT(t, "default-jwt")
+
+	require := true
+	if err := Migrate(&require); err != nil {
+		t.Fatal(err)
+	}
+	forget()
+
+	file, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, profile := range file.Profiles {
+		if profile.JWT != "" {
+			t.Errorf("profile %s still has a plaintext JWT", name)
+		}
+	}
+	if file.Profiles["work"].Network != config.NetworkPrivate {
+		t.Errorf("the work profile lost its network")
+	}
+	if !file.RequireEncryptedCredentials {
+		t.Error("plaintext JWTs aren't required to be encrypted")
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Errorf("the legacy JWT file is still there: %v", err)
+	}
+
+	for profile, want := range map[string]string{config.DefaultProfile: "default-jwt", "work": "work-jwt", "empty": ""} {
+		if jwt, err := Get(profile); err != nil || jwt != want {
+			t.Errorf("Get(%q) = %q, %v, want %q", profile, jwt, err, want)
+		}
+	}
+}
+
+func TestMigrateLegacyJWT(t *testing.T) {
+	useStore(t)
+	writeConfig(t, map[string]*config.Profile{"work": {JWT: "work-jwt"}})
+	writeLegacyJWT(t, "legacy-jwt")
+
+	if err := Migrate(nil); err != nil {
+		t.Fatal(err)
+	}
+	forget()
+
+	if jwt, err := Get(config.DefaultProfile); err != nil || jwt != "legacy-jwt" {
+		t.Errorf("the default profile has %q, %v, want the legacy JWT", jwt, err)
+	}
+	if config.RequiresEncryptedCredentials() {
+		t.Error("Migrate(nil) changed whether encryption is required")
+	}
+}
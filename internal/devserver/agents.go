@@ -0,0 +1,324 @@
+package devserver
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+type storedSecret struct {
+	pinata.Secret
+	Value string
+}
+
+// findAgent returns an agent by ID. The caller holds s.mu.
+func (s *Server) findAgent(id string) *pinata.Agent {
+	for _, a := range s.agents {
+		if a.AgentID == id {
+			return a
+		}
+	}
+	return nil
+}
+
+func (s *Server) listAgents(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	agents := []pinata.Agent{}
+	for _, a := range s.agents {
+		agents = append(agents, *a)
+	}
+	writeJSON(w, http.StatusOK, pinata.AgentListResponse{Agents: agents})
+}
+
+func (s *Server) createAgent(w http.ResponseWriter, r *http.Request) {
+	var body pinata.CreateAgentBody
+	if !readJSON(w, r, &body) {
+		return
+	}
+	if body.Name == "" {
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "name is required")
+		return
+	}
+
+	agent := &pinata.Agent{
+		AgentID:      uuid.New().String()[:8],
+		UserID:       "dev-user",
+		Name:         body.Name,
+		Description:  optional(body.Description),
+		Vibe:         optional(body.Vibe),
+		Emoji:        optional(body.Emoji),
+		GatewayToken: hex.EncodeToString(randomBytes(16)),
+		CreatedAt:    now(),
+		Status:       "running",
+	}
+	s.mu.Lock()
+	s.agents = append(s.agents, agent)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, pinata.CreateAgentResponse{Success: true, Agent: *agent})
+}
+
+func (s *Server) getAgent(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	agent := s.findAgent(r.PathValue("id"))
+	if agent == nil {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "Agent not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, pinata.AgentDetailResponse{
+		Agent:         *agent,
+		ProcessStatus: agent.Status,
+		Skills:        []pinata.AgentSkill{},
+		Secrets:       []pinata.AgentSecret{},
+	})
+}
+
+func (s *Server) deleteAgent(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, a := range s.agents {
+		if a.AgentID == r.PathValue("id") {
+			s.agents = append(s.agents[:i], s.agents[i+1:]...)
+			writeJSON(w, http.StatusOK, pinata.DeleteAgentResponse{Success: true, Message: "Agent deleted", WasInRegistry: true})
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "NOT_FOUND", "Agent not found")
+}
+
+func (s *Server) restartAgent(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.findAgent(r.PathValue("id")) == nil {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "Agent not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, pinata.RestartResponse{Success: true, Message: "Agent restarted"})
+}
+
+func (s *Server) agentLogs(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	agent := s.findAgent(r.PathValue("id"))
+	if agent == nil {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "Agent not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, pinata.LogsResponse{
+		Logs: fmt.Sprintf("%s [dev-server] agent %s started\n", agent.CreatedAt, agent.AgentID),
+	})
+}
+
+func (s *Server) listSecrets(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	secrets := []pinata.SecretWithAgents{}
+	for _, secret := range s.secrets {
+		secrets = append(secrets, pinata.SecretWithAgents{Secret: secret.Secret, Agents: []pinata.SecretAgentRef{}})
+	}
+	writeJSON(w, http.StatusOK, pinata.SecretListResponse{Secrets: secrets})
+}
+
+func (s *Server) createSecret(w http.ResponseWriter, r *http.Request) {
+	var body pinata.CreateSecretBody
+	if !readJSON(w, r, &body) {
+		return
+	}
+	if body.Name == "" || body.Value == "" {
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "name and value are required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, secret := range s.secrets {
+		if secret.Name == body.Name {
+			writeError(w, http.StatusConflict, "CONFLICT", fmt.Sprintf("a secret named %s already exists", body.Name))
+			return
+		}
+	}
+	secret := &storedSecret{
+		Secret: pinata.Secret{
+			ID:        uuid.New().String(),
+			UserID:    "dev-user",
+			Name:      body.Name,
+			CreatedAt: now(),
+			UpdatedAt: now(),
+		},
+		Value: body.Value,
+	}
+	s.secrets = append(s.secrets, secret)
+	writeJSON(w, http.StatusOK, pinata.CreateSecretResponse{Success: true, Secret: secret.Secret})
+}
+
+func (s *Server) updateSecret(w http.ResponseWriter, r *http.Request) {
+	var body pinata.UpdateSecretBody
+	if !readJSON(w, r, &body) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, secret := range s.secrets {
+		if secret.ID == r.PathValue("id") {
+			secret.Value = body.Value
+			secret.UpdatedAt = now()
+			writeJSON(w, http.StatusOK, map[string]bool{"success": true})
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "NOT_FOUND", "Secret not found")
+}
+
+func (s *Server) deleteSecret(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, secret := range s.secrets {
+		if secret.ID == r.PathValue("id") {
+			s.secrets = append(s.secrets[:i], s.secrets[i+1:]...)
+			writeJSON(w, http.StatusOK, map[string]bool{"success": true})
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "NOT_FOUND", "Secret not found")
+}
+
+var upgrader = websocket.Upgrader{
+	// The CLI sends the production Origin
+	CheckOrigin: func(*http.Request) bool { return true },
+}
+
+// agentGateway speaks enough of the OpenClaw protocol for chat: the connect
+// handshake with the agent's gateway token, then chat.send, which is answered
+// by echoing the message back a word at a time.
+func (s *Server) agentGateway(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	agent := s.findAgent(r.PathValue("id"))
+	var token string
+	if agent != nil {
+		token = agent.GatewayToken
+	}
+	s.mu.Unlock()
+	if agent == nil {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "Agent not found")
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	send := func(msg pinata.OpenClawMessage) error {
+		return conn.WriteJSON(msg)
+	}
+	respond := func(id string, err string) error {
+		ok := err == ""
+		msg := pinata.OpenClawMessage{Type: "res", ID: id, OK: &ok, Payload: json.RawMessage("{}")}
+		if !ok {
+			msg.Error = &pinata.OpenClawError{Code: "UNAUTHORIZED", Message: err}
+		}
+		return send(msg)
+	}
+
+	err = send(event("connect.challenge", pinata.ConnectChallenge{
+		Nonce: hex.EncodeToString(randomBytes(16)),
+		TS:    time.Now().UnixMilli(),
+	}))
+	if err != nil {
+		return
+	}
+
+	connected := false
+	for {
+		var req pinata.OpenClawMessage
+		if err := conn.ReadJSON(&req); err != nil {
+			return
+		}
+		if req.Type != "req" {
+			continue
+		}
+
+		switch req.Method {
+		case "connect":
+			var params pinata.ConnectParams
+			json.Unmarshal(req.Params, &params)
+			if params.Auth.Token != token {
+				respond(req.ID, "invalid gateway token")
+				return
+			}
+			connected = true
+			if respond(req.ID, "") != nil {
+				return
+			}
+		case "chat.send":
+			if !connected {
+				respond(req.ID, "connect first")
+				return
+			}
+			var params pinata.ChatSendParams
+			json.Unmarshal(req.Params, &params)
+			if respond(req.ID, "") != nil || s.echo(send, params) != nil {
+				return
+			}
+		default:
+			if respond(req.ID, fmt.Sprintf("unknown method %s", req.Method)) != nil {
+				return
+			}
+		}
+	}
+}
+
+// echo streams a reply to a chat message as agent events
+func (s *Server) echo(send func(pinata.OpenClawMessage) error, params pinata.ChatSendParams) error {
+	runID := uuid.New().String()
+	seq := 0
+	agentEvent := func(stream string, data map[string]interface{}) error {
+		seq++
+		return send(event("agent", pinata.AgentEventPayload{
+			Stream:     stream,
+			RunID:      runID,
+			SessionKey: params.SessionKey,
+			Seq:        seq,
+			TS:         time.Now().UnixMilli(),
+			Data:       data,
+		}))
+	}
+
+	if err := agentEvent("lifecycle", map[string]interface{}{"phase": "start"}); err != nil {
+		return err
+	}
+	words := strings.Fields("You said: " + params.Message)
+	for i, word := range words {
+		if i > 0 {
+			word = " " + word
+		}
+		if err := agentEvent("assistant", map[string]interface{}{"delta": word}); err != nil {
+			return err
+		}
+	}
+	return agentEvent("lifecycle", map[string]interface{}{"phase": "end"})
+}
+
+func event(name string, payload interface{}) pinata.OpenClawMessage {
+	data, _ := json.Marshal(payload)
+	return pinata.OpenClawMessage{Type: "event", Event: name, Payload: data}
+}
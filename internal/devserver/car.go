@@ -0,0 +1,114 @@
+package devserver
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"github.com/PinataCloud/ipfs-cli/internal/car"
+)
+
+// folder is a directory of a folder upload
+type folder struct {
+	files map[string][]byte
+	dirs  map[string]*folder
+}
+
+// folderTree arranges a folder's files by path. When every path is under
+// the same directory, as with an uploaded folder, that directory is the
+// root.
+func folderTree(contents map[string][]byte) *folder {
+	root := &folder{files: map[string][]byte{}, dirs: map[string]*folder{}}
+	for p, data := range contents {
+		dir := root
+		parts := strings.Split(p, "/")
+		for _, part := range parts[:len(parts)-1] {
+			if dir.dirs[part] == nil {
+				dir.dirs[part] = &folder{files: map[string][]byte{}, dirs: map[string]*folder{}}
+			}
+			dir = dir.dirs[part]
+		}
+		dir.files[parts[len(parts)-1]] = data
+	}
+	if len(root.files) == 0 && len(root.dirs) == 1 {
+		for _, dir := range root.dirs {
+			return dir
+		}
+	}
+	return root
+}
+
+// buildFolder makes the UnixFS DAG of a folder and returns its root
+func buildFolder(contents map[string][]byte, put func(car.CID, []byte) error) (car.CID, error) {
+	root, err := folderTree(contents).build(car.NewBuilder(put))
+	return root.Cid, err
+}
+
+func (f *folder) build(b *car.Builder) (car.Entry, error) {
+	var entries []car.Entry
+	for name, data := range f.files {
+		entry, err := b.AddFile(bytes.NewReader(data))
+		if err != nil {
+			return car.Entry{}, err
+		}
+		entry.Name = name
+		entries = append(entries, entry)
+	}
+	for name, dir := range f.dirs {
+		entry, err := dir.build(b)
+		if err != nil {
+			return car.Entry{}, err
+		}
+		entry.Name = name
+		entries = append(entries, entry)
+	}
+	return b.AddDirectory(entries)
+}
+
+// wantsCAR reports whether a gateway request asks for a trustless CAR, with
+// ?format=car or the CAR media type in Accept
+func wantsCAR(r *http.Request) bool {
+	return r.URL.Query().Get("format") == "car" || strings.Contains(r.Header.Get("Accept"), car.MediaType)
+}
+
+// serveCAR sends the whole DAG of a stored file as a CARv1, with the blocks
+// in the order they were built
+func serveCAR(w http.ResponseWriter, f *storedFile) {
+	type block struct {
+		cid  car.CID
+		data []byte
+	}
+	var blocks []block
+	put := func(c car.CID, data []byte) error {
+		blocks = append(blocks, block{c, data})
+		return nil
+	}
+
+	var root car.CID
+	if data, ok := f.Contents[""]; ok {
+		root = car.Sum(car.CodecRaw, data)
+		put(root, data)
+	} else {
+		var err error
+		if root, err = buildFolder(f.Contents, put); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	// Parents are built after their children, send them first as gateways
+	// do, with the root leading
+	for i, j := 0, len(blocks)-1; i < j; i, j = i+1, j-1 {
+		blocks[i], blocks[j] = blocks[j], blocks[i]
+	}
+
+	w.Header().Set("Content-Type", car.MediaType+"; version=1")
+	writer, err := car.NewWriter(w, root)
+	if err != nil {
+		return
+	}
+	for _, b := range blocks {
+		if err := writer.Put(b.cid, b.data); err != nil {
+			return
+		}
+	}
+}
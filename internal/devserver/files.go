@@ -0,0 +1,515 @@
+package devserver
+
+import (
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/PinataCloud/ipfs-cli/internal/car"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageSize = 10
+	maxPageSize     = 1000
+	// maxUploadMemory is how much of a multipart upload is kept in memory
+	// before spilling to disk
+	maxUploadMemory = 32 << 20
+)
+
+// codecRaw is the multicodec of files stored as a single block
+const codecRaw = 0x55
+
+type storedFile struct {
+	pinata.File
+	Network string
+	// Contents is the file, or the folder's files by path
+	Contents map[string][]byte
+}
+
+// cid returns the CIDv1 of a file stored as a single raw block
+func cid(codec byte, data []byte) string {
+	sum := sha256.Sum256(data)
+	raw := append([]byte{0x01, codec, 0x12, 0x20}, sum[:]...)
+	return "b" + strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(raw))
+}
+
+// folderCID returns the root of the UnixFS DAG of a folder's files
+func folderCID(contents map[string][]byte) string {
+	root, _ := buildFolder(contents, func(car.CID, []byte) error { return nil })
+	return root.String()
+}
+
+// addFile stores an uploaded file, or returns the existing one and true when
+// the same content is already on the network
+func (s *Server) addFile(f *storedFile) (*storedFile, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.files {
+		if existing.Cid == f.Cid && existing.Network == f.Network {
+			return existing, true
+		}
+	}
+	f.Id = uuid.New().String()
+	f.CreatedAt = now()
+	if f.KeyValues == nil {
+		f.KeyValues = map[string]interface{}{}
+	}
+	s.files = append(s.files, f)
+	return f, false
+}
+
+// findFile returns the file with the given ID on a network. The caller holds
+// s.mu.
+func (s *Server) findFile(network, id string) *storedFile {
+	for _, f := range s.files {
+		if f.Id == id && f.Network == network {
+			return f
+		}
+	}
+	return nil
+}
+
+func (f *storedFile) uploadResult(duplicate bool) pinata.UploadResult {
+	keyvalues := map[string]string{}
+	for k, v := range f.KeyValues {
+		keyvalues[k] = fmt.Sprint(v)
+	}
+	return pinata.UploadResult{
+		Id:            f.Id,
+		Name:          f.Name,
+		Cid:           f.Cid,
+		Size:          f.Size,
+		CreatedAt:     f.CreatedAt,
+		NumberOfFiles: f.NumberOfFiles,
+		MimeType:      f.MimeType,
+		GroupId:       f.GroupId,
+		KeyValues:     keyvalues,
+		Network:       f.Network,
+		IsDuplicate:   duplicate,
+	}
+}
+
+// uploadFile handles single file uploads to the uploads host, and the start
+// of TUS uploads, which are sent to the same endpoint
+func (s *Server) uploadFile(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Tus-Resumable") != "" {
+		s.tusCreate(w, r)
+		return
+	}
+
+	err := r.ParseMultipartForm(maxUploadMemory)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "expected a multipart form: "+err.Error())
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "the file field is required")
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "failed to read the file: "+err.Error())
+		return
+	}
+
+	network := r.FormValue("network")
+	if network == "" {
+		network = pinata.NetworkPublic
+	}
+	if network != pinata.NetworkPublic && network != pinata.NetworkPrivate {
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", fmt.Sprintf("invalid network %q", network))
+		return
+	}
+	name := r.FormValue("name")
+	if name == "" {
+		name = header.Filename
+	}
+	keyvalues, ok := parseKeyValues(w, r.FormValue("keyvalues"))
+	if !ok {
+		return
+	}
+
+	f, duplicate := s.addFile(&storedFile{
+		File: pinata.File{
+			Name:          name,
+			Cid:           cid(codecRaw, data),
+			Size:          len(data),
+			NumberOfFiles: 1,
+			MimeType:      mimeType(header.Filename, data),
+			KeyValues:     keyvalues,
+			GroupId:       optional(r.FormValue("group_id")),
+		},
+		Network:  network,
+		Contents: map[string][]byte{"": data},
+	})
+	writeJSON(w, http.StatusOK, pinata.UploadResponse{Data: f.uploadResult(duplicate)})
+}
+
+// uploadFolder handles the pinning API upload the CLI uses for folders
+func (s *Server) uploadFolder(w http.ResponseWriter, r *http.Request) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "expected a multipart form: "+err.Error())
+		return
+	}
+
+	contents := map[string][]byte{}
+	var options pinata.PinataOptions
+	var metadata pinata.PinataMetadata
+	folder := ""
+	size := 0
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "invalid multipart form: "+err.Error())
+			return
+		}
+		data, err := io.ReadAll(part)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "failed to read the form: "+err.Error())
+			return
+		}
+
+		switch part.FormName() {
+		case "file":
+			// Part.FileName drops the folder, so read the path from the header
+			_, params, _ := mime.ParseMediaType(part.Header.Get("Content-Disposition"))
+			filePath := strings.TrimPrefix(path.Clean(strings.ReplaceAll(params["filename"], "\\", "/")), "/")
+			if first, _, ok := strings.Cut(filePath, "/"); ok && folder == "" {
+				folder = first
+			}
+			contents[filePath] = data
+			size += len(data)
+		case "pinataOptions":
+			json.Unmarshal(data, &options)
+		case "pinataMetadata":
+			json.Unmarshal(data, &metadata)
+		}
+	}
+	if len(contents) == 0 {
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "no files were uploaded")
+		return
+	}
+
+	name := metadata.Name
+	if name == "" {
+		name = folder
+	}
+	keyvalues := map[string]interface{}{}
+	for k, v := range metadata.KeyValues {
+		keyvalues[k] = v
+	}
+
+	f, duplicate := s.addFile(&storedFile{
+		File: pinata.File{
+			Name:          name,
+			Cid:           folderCID(contents),
+			Size:          size,
+			NumberOfFiles: len(contents),
+			MimeType:      "directory",
+			KeyValues:     keyvalues,
+			GroupId:       optional(options.GroupId),
+		},
+		Network:  pinata.NetworkPublic,
+		Contents: contents,
+	})
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"ID":            f.Id,
+		"Name":          f.Name,
+		"IpfsHash":      f.Cid,
+		"PinSize":       f.Size,
+		"Timestamp":     f.CreatedAt,
+		"NumberOfFiles": f.NumberOfFiles,
+		"MimeType":      f.MimeType,
+		"GroupId":       f.GroupId,
+		"Keyvalues":     f.uploadResult(false).KeyValues,
+		"isDuplicate":   duplicate,
+	})
+}
+
+func (s *Server) listFiles(w http.ResponseWriter, r *http.Request) {
+	network, ok := network(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	if query.Get("cidPending") == "true" {
+		// CIDs are computed as soon as a file is uploaded
+		writeJSON(w, http.StatusOK, pinata.ListResponse{Data: pinata.FileList{Files: []pinata.File{}}})
+		return
+	}
+
+	keyvalues := map[string]string{}
+	for key, values := range query {
+		if k, ok := strings.CutPrefix(key, "keyvalues["); ok && strings.HasSuffix(k, "]") {
+			keyvalues[strings.TrimSuffix(k, "]")] = values[0]
+		}
+	}
+
+	s.mu.Lock()
+	var matches []pinata.File
+	for _, f := range s.files {
+		if f.Network != network ||
+			!strings.Contains(f.Name, query.Get("name")) ||
+			!matchOptional(query.Get("cid"), f.Cid) ||
+			!matchOptional(query.Get("mimeType"), f.MimeType) ||
+			!matchOptional(query.Get("group"), deref(f.GroupId)) ||
+			!matchKeyValues(f.KeyValues, keyvalues) {
+			continue
+		}
+		matches = append(matches, f.File)
+	}
+	s.mu.Unlock()
+
+	page, next, ok := paginate(w, query, len(matches))
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, pinata.ListResponse{Data: pinata.FileList{
+		Files:         append([]pinata.File{}, matches[page.start:page.end]...),
+		NextPageToken: next,
+	}})
+}
+
+func (s *Server) getFile(w http.ResponseWriter, r *http.Request) {
+	network, ok := network(w, r)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f := s.findFile(network, r.PathValue("id"))
+	if f == nil {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "File not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, pinata.GetFileResponse{Data: f.File})
+}
+
+func (s *Server) updateFile(w http.ResponseWriter, r *http.Request) {
+	network, ok := network(w, r)
+	if !ok {
+		return
+	}
+	var body struct {
+		Name      *string                `json:"name"`
+		KeyValues map[string]interface{} `json:"keyvalues"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f := s.findFile(network, r.PathValue("id"))
+	if f == nil {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "File not found")
+		return
+	}
+	if body.Name != nil {
+		f.Name = *body.Name
+	}
+	for k, v := range body.KeyValues {
+		f.KeyValues[k] = v
+	}
+	writeJSON(w, http.StatusOK, pinata.GetFileResponse{Data: f.File})
+}
+
+func (s *Server) deleteFile(w http.ResponseWriter, r *http.Request) {
+	network, ok := network(w, r)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, f := range s.files {
+		if f.Id == r.PathValue("id") && f.Network == network {
+			s.files = append(s.files[:i], s.files[i+1:]...)
+			writeJSON(w, http.StatusOK, map[string]interface{}{"data": nil})
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "NOT_FOUND", "File not found")
+}
+
+func (s *Server) swapHistory(w http.ResponseWriter, r *http.Request) {
+	if _, ok := network(w, r); !ok {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	history, found := s.swaps[r.PathValue("cid")]
+	if !found {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "no swaps found for the CID")
+		return
+	}
+	writeJSON(w, http.StatusOK, pinata.GetSwapHistoryResponse{Data: history})
+}
+
+func (s *Server) addSwap(w http.ResponseWriter, r *http.Request) {
+	network, ok := network(w, r)
+	if !ok {
+		return
+	}
+	var body pinata.AddSwapBody
+	if !readJSON(w, r, &body) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Both CIDs have to be on the account
+	for _, c := range []string{r.PathValue("cid"), body.SwapCid} {
+		if !s.hasCID(network, c) {
+			writeError(w, http.StatusNotFound, "NOT_FOUND", fmt.Sprintf("CID %s not found on the %s network", c, network))
+			return
+		}
+	}
+	swap := pinata.Swap{MappedCid: body.SwapCid, CreatedAt: now()}
+	s.swaps[r.PathValue("cid")] = append([]pinata.Swap{swap}, s.swaps[r.PathValue("cid")]...)
+	writeJSON(w, http.StatusOK, pinata.AddSwapResponse{Data: swap})
+}
+
+func (s *Server) removeSwap(w http.ResponseWriter, r *http.Request) {
+	if _, ok := network(w, r); !ok {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, found := s.swaps[r.PathValue("cid")]; !found {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "no swaps found for the CID")
+		return
+	}
+	delete(s.swaps, r.PathValue("cid"))
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": nil})
+}
+
+// hasCID reports whether a file with the CID is on the network. The caller
+// holds s.mu.
+func (s *Server) hasCID(network, c string) bool {
+	for _, f := range s.files {
+		if f.Cid == c && f.Network == network {
+			return true
+		}
+	}
+	return false
+}
+
+type pageRange struct {
+	start, end int
+}
+
+// paginate reads the limit and pageToken of a list request. Page tokens are
+// the offset of the next page.
+func paginate(w http.ResponseWriter, query map[string][]string, total int) (pageRange, string, bool) {
+	get := func(key string) string {
+		if values := query[key]; len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	limit := defaultPageSize
+	if value := get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxPageSize {
+			writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", fmt.Sprintf("limit must be between 1 and %d", maxPageSize))
+			return pageRange{}, "", false
+		}
+		limit = n
+	}
+
+	start := 0
+	if token := get("pageToken"); token != "" {
+		decoded, err := base64.RawURLEncoding.DecodeString(token)
+		if err == nil {
+			start, err = strconv.Atoi(string(decoded))
+		}
+		if err != nil || start < 0 {
+			writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "invalid pageToken")
+			return pageRange{}, "", false
+		}
+	}
+
+	start = min(start, total)
+	end := min(start+limit, total)
+	next := ""
+	if end < total {
+		next = base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(end)))
+	}
+	return pageRange{start, end}, next, true
+}
+
+func mimeType(filename string, data []byte) string {
+	if t := mime.TypeByExtension(path.Ext(filename)); t != "" {
+		return t
+	}
+	return http.DetectContentType(data)
+}
+
+// parseKeyValues decodes the keyvalues of an upload, a JSON object of
+// strings, responding with a 400 when it's invalid
+func parseKeyValues(w http.ResponseWriter, value string) (map[string]interface{}, bool) {
+	keyvalues := map[string]interface{}{}
+	if value == "" {
+		return keyvalues, true
+	}
+	var decoded map[string]string
+	if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "keyvalues must be a JSON object of strings")
+		return nil, false
+	}
+	for k, v := range decoded {
+		keyvalues[k] = v
+	}
+	return keyvalues, true
+}
+
+func matchOptional(want, value string) bool {
+	return want == "" || want == value
+}
+
+func matchKeyValues(have map[string]interface{}, want map[string]string) bool {
+	for k, v := range want {
+		if fmt.Sprint(have[k]) != v {
+			return false
+		}
+	}
+	return true
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,141 @@
+package devserver
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+)
+
+// Query parameters of a signed link
+const (
+	paramDate      = "X-Date"
+	paramExpires   = "X-Expires"
+	paramMethod    = "X-Method"
+	paramSignature = "X-Signature"
+)
+
+func (s *Server) listGateways(w http.ResponseWriter, r *http.Request) {
+	var response pinata.GetGatewaysResponse
+	response.Data.Rows = []pinata.Gateway{{Domain: s.opts.GatewayDomain}}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// signURL signs a gateway link to a private file. The server's /files/<cid>
+// endpoint accepts the link until it expires, whatever host it was made for.
+func (s *Server) signURL(w http.ResponseWriter, r *http.Request) {
+	var body pinata.GetSignedURLBody
+	if !readJSON(w, r, &body) {
+		return
+	}
+	link, err := url.Parse(body.URL)
+	if err != nil || link.Path == "" {
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "url must be a gateway link")
+		return
+	}
+	if body.Expires <= 0 {
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "expires must be positive")
+		return
+	}
+	if body.Date == 0 {
+		body.Date = time.Now().Unix()
+	}
+	method := body.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	query := link.Query()
+	query.Set(paramDate, strconv.FormatInt(body.Date, 10))
+	query.Set(paramExpires, strconv.Itoa(body.Expires))
+	query.Set(paramMethod, method)
+	query.Set(paramSignature, s.signature(method, link.Path, query))
+	link.RawQuery = query.Encode()
+
+	writeJSON(w, http.StatusOK, pinata.GetSignedURLResponse{Data: link.String()})
+}
+
+// signature signs the method, path and query of a link, leaving out the
+// signature itself
+func (s *Server) signature(method, path string, query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		if k != paramSignature {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(method + "\n" + path + "\n"))
+	for _, k := range keys {
+		mac.Write([]byte(k + "=" + strings.Join(query[k], ",") + "\n"))
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// servePublic serves a public file, as a dedicated gateway does, or its
+// DAG as a CAR for ?format=car
+func (s *Server) servePublic(w http.ResponseWriter, r *http.Request) {
+	s.serveContent(w, r, pinata.NetworkPublic)
+}
+
+// servePrivate serves a private file when the link is signed and hasn't
+// expired
+func (s *Server) servePrivate(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if !hmac.Equal([]byte(query.Get(paramSignature)), []byte(s.signature(query.Get(paramMethod), r.URL.Path, query))) {
+		http.Error(w, "invalid signature", http.StatusForbidden)
+		return
+	}
+	date, _ := strconv.ParseInt(query.Get(paramDate), 10, 64)
+	expires, _ := strconv.ParseInt(query.Get(paramExpires), 10, 64)
+	if time.Now().Unix() > date+expires {
+		http.Error(w, "link expired", http.StatusForbidden)
+		return
+	}
+	s.serveContent(w, r, pinata.NetworkPrivate)
+}
+
+func (s *Server) serveContent(w http.ResponseWriter, r *http.Request, network string) {
+	s.mu.Lock()
+	var found *storedFile
+	for _, f := range s.files {
+		if f.Cid == r.PathValue("cid") && f.Network == network {
+			found = f
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	if found == nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	if wantsCAR(r) {
+		serveCAR(w, found)
+		return
+	}
+	data, ok := found.Contents[""]
+	if !ok {
+		// Folders list their files
+		paths := make([]string, 0, len(found.Contents))
+		for p := range found.Contents {
+			paths = append(paths, p)
+		}
+		sort.Strings(paths)
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(strings.Join(paths, "\n") + "\n"))
+		return
+	}
+	w.Header().Set("Content-Type", found.MimeType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Write(data)
+}
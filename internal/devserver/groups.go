@@ -0,0 +1,184 @@
+package devserver
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+
+	"github.com/google/uuid"
+)
+
+type storedGroup struct {
+	pinata.Group
+	Network string
+}
+
+// findGroup returns the group with the given ID on a network. The caller
+// holds s.mu.
+func (s *Server) findGroup(network, id string) *storedGroup {
+	for _, g := range s.groups {
+		if g.Id == id && g.Network == network {
+			return g
+		}
+	}
+	return nil
+}
+
+func (s *Server) listGroups(w http.ResponseWriter, r *http.Request) {
+	network, ok := network(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+
+	s.mu.Lock()
+	var matches []pinata.Group
+	for _, g := range s.groups {
+		if g.Network == network && strings.Contains(g.Name, query.Get("name")) {
+			matches = append(matches, g.Group)
+		}
+	}
+	s.mu.Unlock()
+
+	page, next, ok := paginate(w, query, len(matches))
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, pinata.GroupListResponse{Data: pinata.GroupList{
+		Groups:        append([]pinata.Group{}, matches[page.start:page.end]...),
+		NextPageToken: next,
+	}})
+}
+
+func (s *Server) createGroup(w http.ResponseWriter, r *http.Request) {
+	network, ok := network(w, r)
+	if !ok {
+		return
+	}
+	var body pinata.GroupCreateBody
+	if !readJSON(w, r, &body) {
+		return
+	}
+	if body.Name == "" {
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "name is required")
+		return
+	}
+
+	group := &storedGroup{
+		Group: pinata.Group{
+			Id:        uuid.New().String(),
+			Name:      body.Name,
+			CreatedAt: now(),
+		},
+		Network: network,
+	}
+	s.mu.Lock()
+	s.groups = append(s.groups, group)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, pinata.GroupResponse{Data: group.Group})
+}
+
+func (s *Server) getGroup(w http.ResponseWriter, r *http.Request) {
+	network, ok := network(w, r)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	group := s.findGroup(network, r.PathValue("id"))
+	if group == nil {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "Group not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, pinata.GroupResponse{Data: group.Group})
+}
+
+func (s *Server) updateGroup(w http.ResponseWriter, r *http.Request) {
+	network, ok := network(w, r)
+	if !ok {
+		return
+	}
+	var body pinata.GroupCreateBody
+	if !readJSON(w, r, &body) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	group := s.findGroup(network, r.PathValue("id"))
+	if group == nil {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "Group not found")
+		return
+	}
+	if body.Name != "" {
+		group.Name = body.Name
+	}
+	writeJSON(w, http.StatusOK, pinata.GroupResponse{Data: group.Group})
+}
+
+func (s *Server) deleteGroup(w http.ResponseWriter, r *http.Request) {
+	network, ok := network(w, r)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := r.PathValue("id")
+	for i, g := range s.groups {
+		if g.Id == id && g.Network == network {
+			s.groups = append(s.groups[:i], s.groups[i+1:]...)
+			// The files stay, outside of any group
+			for _, f := range s.files {
+				if deref(f.GroupId) == id {
+					f.GroupId = nil
+				}
+			}
+			writeJSON(w, http.StatusOK, map[string]interface{}{"data": nil})
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "NOT_FOUND", "Group not found")
+}
+
+func (s *Server) addToGroup(w http.ResponseWriter, r *http.Request) {
+	s.setFileGroup(w, r, true)
+}
+
+func (s *Server) removeFromGroup(w http.ResponseWriter, r *http.Request) {
+	s.setFileGroup(w, r, false)
+}
+
+func (s *Server) setFileGroup(w http.ResponseWriter, r *http.Request, add bool) {
+	network, ok := network(w, r)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	group := s.findGroup(network, r.PathValue("id"))
+	if group == nil {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "Group not found")
+		return
+	}
+	f := s.findFile(network, r.PathValue("file"))
+	if f == nil {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "File not found")
+		return
+	}
+
+	switch {
+	case add:
+		f.GroupId = optional(group.Id)
+	case deref(f.GroupId) == group.Id:
+		f.GroupId = nil
+	default:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "File is not in the group")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": nil})
+}
@@ -0,0 +1,112 @@
+package devserver
+
+import (
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+
+	"github.com/google/uuid"
+)
+
+type storedKey struct {
+	pinata.Key
+	JWT string
+}
+
+// keyByJWT returns the key a JWT belongs to. The caller holds s.mu.
+func (s *Server) keyByJWT(jwt string) *storedKey {
+	for _, k := range s.keys {
+		if k.JWT == jwt {
+			return k
+		}
+	}
+	return nil
+}
+
+func (s *Server) listKeys(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	offset := 0
+	if value := query.Get("offset"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "invalid offset")
+			return
+		}
+		offset = n
+	}
+
+	s.mu.Lock()
+	matches := []pinata.Key{}
+	for _, k := range s.keys {
+		exhausted := k.MaxUses > 0 && k.Uses >= k.MaxUses
+		if !strings.Contains(k.Name, query.Get("name")) ||
+			(query.Get("revoked") == "true" && !k.Revoked) ||
+			(query.Get("limitedUse") == "true" && k.MaxUses == 0) ||
+			(query.Get("exhausted") == "true" && !exhausted) {
+			continue
+		}
+		matches = append(matches, k.Key)
+	}
+	s.mu.Unlock()
+
+	offset = min(offset, len(matches))
+	end := min(offset+defaultPageSize, len(matches))
+	writeJSON(w, http.StatusOK, pinata.KeyList{
+		Keys:  matches[offset:end],
+		Count: end - offset,
+	})
+}
+
+func (s *Server) createKey(w http.ResponseWriter, r *http.Request) {
+	var body pinata.CreateKeyBody
+	if !readJSON(w, r, &body) {
+		return
+	}
+	if body.KeyName == "" {
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "keyName is required")
+		return
+	}
+
+	key := &storedKey{
+		Key: pinata.Key{
+			ID:        uuid.New().String(),
+			Name:      body.KeyName,
+			Key:       hex.EncodeToString(randomBytes(10)),
+			Secret:    hex.EncodeToString(randomBytes(32)),
+			MaxUses:   body.MaxUses,
+			UserID:    "dev-user",
+			Scopes:    body.Permissions,
+			CreatedAt: now(),
+			UpdatedAt: now(),
+		},
+		JWT: "dev." + hex.EncodeToString(randomBytes(24)),
+	}
+	s.mu.Lock()
+	s.keys = append(s.keys, key)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, pinata.CreateKeyResponse{
+		JWT:             key.JWT,
+		PinataAPIKey:    key.Key.Key,
+		PinataAPISecret: key.Secret,
+	})
+}
+
+// revokeKey revokes a key by its API key, as the keys API does
+func (s *Server) revokeKey(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, k := range s.keys {
+		if k.Key.Key == r.PathValue("id") {
+			k.Revoked = true
+			k.UpdatedAt = now()
+			writeJSON(w, http.StatusOK, "Revoked")
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "NOT_FOUND", "Key not found")
+}
@@ -0,0 +1,170 @@
+package devserver
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+
+	"github.com/google/uuid"
+)
+
+// pinSteps is how long a pin job spends in each state before its content is
+// pinned
+var pinSteps = []struct {
+	status string
+	until  time.Duration
+}{
+	{pinata.PinStatusPrechecking, time.Second},
+	{pinata.PinStatusSearching, 2 * time.Second},
+	{pinata.PinStatusRetrieving, 3 * time.Second},
+}
+
+type storedPinJob struct {
+	pinata.PinJob
+	queued time.Time
+}
+
+func (s *Server) pinByCID(w http.ResponseWriter, r *http.Request) {
+	var body pinata.PinByCIDBody
+	if !readJSON(w, r, &body) {
+		return
+	}
+	if body.Cid == "" {
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "cid is required")
+		return
+	}
+
+	keyvalues := map[string]interface{}{}
+	for k, v := range body.KeyValues {
+		keyvalues[k] = v
+	}
+	job := &storedPinJob{
+		PinJob: pinata.PinJob{
+			Id:         uuid.New().String(),
+			Cid:        body.Cid,
+			Name:       body.Name,
+			Status:     pinata.PinStatusPrechecking,
+			DateQueued: now(),
+			KeyValues:  keyvalues,
+			HostNodes:  body.HostNodes,
+			GroupId:    optional(body.GroupId),
+		},
+		queued: time.Now(),
+	}
+	s.mu.Lock()
+	s.pinJobs = append(s.pinJobs, job)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, pinata.PinByCIDResponse{Data: job.PinJob})
+}
+
+func (s *Server) listPinJobs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	s.mu.Lock()
+	s.advancePinJobs()
+	var matches []pinata.PinJob
+	for _, job := range s.pinJobs {
+		if matchOptional(query.Get("cid"), job.Cid) && matchOptional(query.Get("status"), job.Status) {
+			matches = append(matches, job.PinJob)
+		}
+	}
+	s.mu.Unlock()
+
+	if strings.EqualFold(query.Get("sort"), "DESC") {
+		for i, j := 0, len(matches)-1; i < j; i, j = i+1, j-1 {
+			matches[i], matches[j] = matches[j], matches[i]
+		}
+	}
+
+	page, next, ok := paginate(w, query, len(matches))
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, pinata.PinJobListResponse{Data: pinata.PinJobList{
+		Jobs:          append([]pinata.PinJob{}, matches[page.start:page.end]...),
+		NextPageToken: next,
+	}})
+}
+
+func (s *Server) cancelPinJob(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, job := range s.pinJobs {
+		if job.Id == r.PathValue("id") {
+			s.pinJobs = append(s.pinJobs[:i], s.pinJobs[i+1:]...)
+			writeJSON(w, http.StatusOK, map[string]interface{}{"data": "OK"})
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "NOT_FOUND", "pin job not found")
+}
+
+// advancePinJobs moves jobs along pinSteps by the time since they were
+// queued. Content that doesn't look like a CID fails as an invalid object,
+// the rest is pinned as an empty file on the public network, or as the file
+// with that CID already stored. The caller holds s.mu.
+func (s *Server) advancePinJobs() {
+	queue := s.pinJobs[:0]
+	for _, job := range s.pinJobs {
+		elapsed := time.Since(job.queued)
+		if job.Pending() {
+			job.Status = ""
+			for _, step := range pinSteps {
+				if elapsed < step.until {
+					job.Status = step.status
+					break
+				}
+			}
+			if job.Status == "" && !looksLikeCID(job.Cid) {
+				job.Status = pinata.PinStatusInvalidObject
+			}
+		}
+		if job.Status != "" {
+			queue = append(queue, job)
+			continue
+		}
+		s.pinContent(job)
+	}
+	s.pinJobs = queue
+}
+
+// pinContent stores the file a finished job pinned. The caller holds s.mu.
+func (s *Server) pinContent(job *storedPinJob) {
+	if s.hasCID(pinata.NetworkPublic, job.Cid) {
+		return
+	}
+	contents := map[string][]byte{"": {}}
+	for _, f := range s.files {
+		if f.Cid == job.Cid {
+			contents = f.Contents
+			break
+		}
+	}
+	size := 0
+	for _, data := range contents {
+		size += len(data)
+	}
+	s.files = append(s.files, &storedFile{
+		File: pinata.File{
+			Id:            uuid.New().String(),
+			Name:          job.Name,
+			Cid:           job.Cid,
+			Size:          size,
+			NumberOfFiles: len(contents),
+			MimeType:      "application/octet-stream",
+			KeyValues:     job.KeyValues,
+			GroupId:       job.GroupId,
+			CreatedAt:     now(),
+		},
+		Network:  pinata.NetworkPublic,
+		Contents: contents,
+	})
+}
+
+func looksLikeCID(c string) bool {
+	return strings.HasPrefix(c, "baf") && len(c) > 50 || strings.HasPrefix(c, "Qm") && len(c) == 46
+}
@@ -0,0 +1,255 @@
+// Package devserver is an in-memory stand-in for the Pinata APIs, so the CLI
+// and scripts built on it can run without reaching the real service.
+//
+// It serves the v3 files, groups, swaps, keys and gateway endpoints, regular,
+// folder and TUS uploads, including parallel ones, pinning by CID, signed
+// links to private files, trustless CAR responses from the gateway, and a
+// minimal agents API with an OpenClaw gateway that echoes chat messages back.
+// Everything is lost when the server stops.
+package devserver
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+)
+
+// DefaultAddr is the address the server listens on by default
+const DefaultAddr = "127.0.0.1:8787"
+
+// Options configures a Server
+type Options struct {
+	// JWT, when set, is the only token accepted besides the JWTs of keys
+	// created on the server. Any token is accepted otherwise.
+	JWT string
+	// GatewayDomain is the dedicated gateway the gateways endpoint reports
+	GatewayDomain string
+}
+
+// Server holds the state of the fake APIs
+type Server struct {
+	opts Options
+
+	mu      sync.Mutex
+	files   []*storedFile
+	groups  []*storedGroup
+	swaps   map[string][]pinata.Swap
+	keys    []*storedKey
+	tus     map[string]*tusUpload
+	agents  []*pinata.Agent
+	secrets []*storedSecret
+	pinJobs []*storedPinJob
+
+	// signingKey signs download links to private files
+	signingKey []byte
+}
+
+// New returns an empty server
+func New(opts Options) *Server {
+	if opts.GatewayDomain == "" {
+		opts.GatewayDomain = "dev-server"
+	}
+	return &Server{
+		opts:       opts,
+		swaps:      map[string][]pinata.Swap{},
+		tus:        map[string]*tusUpload{},
+		signingKey: randomBytes(32),
+	}
+}
+
+// Handler returns the handler for every API. The API, uploads and agents
+// hosts can all point at it.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	authed := func(pattern string, handler http.HandlerFunc) {
+		mux.Handle(pattern, s.authenticate(handler))
+	}
+
+	authed("GET /data/testAuthentication", s.testAuthentication)
+
+	authed("POST /v3/files", s.uploadFile)
+	mux.HandleFunc("OPTIONS /v3/files", s.tusOptions)
+	authed("HEAD /tus/{id}/upload", s.tusHead)
+	authed("PATCH /tus/{id}/upload", s.tusPatch)
+	authed("POST /pinning/pinFileToIPFS", s.uploadFolder)
+	authed("POST /v3/files/public/pin_by_cid", s.pinByCID)
+	authed("GET /v3/files/public/pin_by_cid", s.listPinJobs)
+	authed("DELETE /v3/files/public/pin_by_cid/{id}", s.cancelPinJob)
+
+	authed("GET /v3/files/{network}", s.listFiles)
+	authed("GET /v3/files/{network}/{id}", s.getFile)
+	authed("PUT /v3/files/{network}/{id}", s.updateFile)
+	authed("DELETE /v3/files/{network}/{id}", s.deleteFile)
+
+	authed("GET /v3/files/{network}/swap/{cid}", s.swapHistory)
+	authed("PUT /v3/files/{network}/swap/{cid}", s.addSwap)
+	authed("DELETE /v3/files/{network}/swap/{cid}", s.removeSwap)
+
+	authed("GET /v3/groups/{network}", s.listGroups)
+	authed("POST /v3/groups/{network}", s.createGroup)
+	authed("GET /v3/groups/{network}/{id}", s.getGroup)
+	authed("PUT /v3/groups/{network}/{id}", s.updateGroup)
+	authed("DELETE /v3/groups/{network}/{id}", s.deleteGroup)
+	authed("PUT /v3/groups/{network}/{id}/ids/{file}", s.addToGroup)
+	authed("DELETE /v3/groups/{network}/{id}/ids/{file}", s.removeFromGroup)
+
+	authed("GET /v3/pinata/keys", s.listKeys)
+	authed("POST /v3/pinata/keys", s.createKey)
+	authed("PUT /v3/pinata/keys/{id}", s.revokeKey)
+
+	authed("GET /v3/ipfs/gateways", s.listGateways)
+	authed("POST /v3/files/private/download_link", s.signURL)
+	mux.HandleFunc("GET /ipfs/{cid}", s.servePublic)
+	mux.HandleFunc("GET /files/{cid}", s.servePrivate)
+
+	authed("GET /v0/agents", s.listAgents)
+	authed("POST /v0/agents", s.createAgent)
+	authed("GET /v0/agents/{id}", s.getAgent)
+	authed("DELETE /v0/agents/{id}", s.deleteAgent)
+	authed("POST /v0/agents/{id}/restart", s.restartAgent)
+	authed("GET /v0/agents/{id}/logs", s.agentLogs)
+	authed("GET /v0/secrets", s.listSecrets)
+	authed("POST /v0/secrets", s.createSecret)
+	authed("PUT /v0/secrets/{id}", s.updateSecret)
+	authed("DELETE /v0/secrets/{id}", s.deleteSecret)
+	// The gateway checks the agent's gateway token instead of a JWT
+	mux.HandleFunc("GET /v0/agents/{id}/gateway", s.agentGateway)
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", fmt.Sprintf("no dev server endpoint for %s %s", r.Method, r.URL.Path))
+	})
+
+	return withRequestID(mux)
+}
+
+// ListenAndServe serves the APIs on addr until ctx is done. ready is called
+// with the base URL once the server is listening.
+func (s *Server) ListenAndServe(ctx context.Context, addr string, ready func(baseURL string)) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{Handler: s.Handler()}
+	stop := context.AfterFunc(ctx, func() {
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	})
+	defer stop()
+
+	if ready != nil {
+		ready("http://" + ln.Addr().String())
+	}
+	err = srv.Serve(ln)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// authenticate rejects requests without an accepted JWT and counts the uses
+// of keys created on the server
+func (s *Server) authenticate(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			writeError(w, http.StatusUnauthorized, "NO_AUTH", "no authentication provided")
+			return
+		}
+
+		s.mu.Lock()
+		key := s.keyByJWT(token)
+		switch {
+		case key != nil && key.Revoked:
+			s.mu.Unlock()
+			writeError(w, http.StatusUnauthorized, "INVALID_CREDENTIALS", "the key has been revoked")
+			return
+		case key != nil && key.MaxUses > 0 && key.Uses >= key.MaxUses:
+			s.mu.Unlock()
+			writeError(w, http.StatusForbidden, "INVALID_CREDENTIALS", "the key has reached its maximum uses")
+			return
+		case key != nil:
+			key.Uses++
+		case s.opts.JWT != "" && token != s.opts.JWT:
+			s.mu.Unlock()
+			writeError(w, http.StatusUnauthorized, "INVALID_CREDENTIALS", "the JWT is not valid")
+			return
+		}
+		s.mu.Unlock()
+
+		next(w, r)
+	})
+}
+
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", hex.EncodeToString(randomBytes(8)))
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) testAuthentication(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"message": "Congratulations! You are communicating with the Pinata API!",
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError responds in the {"error": {"reason", "details"}} format of the
+// Pinata APIs
+func writeError(w http.ResponseWriter, status int, reason, details string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error": map[string]string{
+			"reason":  reason,
+			"details": details,
+		},
+	})
+}
+
+// readJSON decodes a request body, responding with a 400 when it's invalid
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "invalid JSON body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// network returns the network in the path, responding with a 400 when it's
+// neither public nor private
+func network(w http.ResponseWriter, r *http.Request) (string, bool) {
+	network := r.PathValue("network")
+	if network != pinata.NetworkPublic && network != pinata.NetworkPrivate {
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", fmt.Sprintf("invalid network %q", network))
+		return "", false
+	}
+	return network, true
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	rand.Read(b)
+	return b
+}
+
+func now() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
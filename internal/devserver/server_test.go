@@ -0,0 +1,324 @@
+package devserver_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/PinataCloud/ipfs-cli/internal/car"
+	"github.com/PinataCloud/ipfs-cli/internal/devserver"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+)
+
+// testServer is a dev server under httptest that records the Upload-Concat
+// header of each TUS request
+type testServer struct {
+	*httptest.Server
+	mu     sync.Mutex
+	concat []string
+}
+
+func newTestServer(t *testing.T, opts devserver.Options) *testServer {
+	t.Helper()
+	ts := &testServer{}
+	handler := devserver.New(opts).Handler()
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if concat := r.Header.Get("Upload-Concat"); concat != "" {
+			ts.mu.Lock()
+			ts.concat = append(ts.concat, concat)
+			ts.mu.Unlock()
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func (ts *testServer) client(jwt string) *pinata.Client {
+	client := pinata.New(pinata.StaticToken(jwt))
+	client.APIHost = ts.URL
+	client.UploadsHost = ts.URL
+	client.AgentsHost = ts.URL
+	client.Retries = 0
+	return client
+}
+
+// get fetches a path of the server, such as a gateway link
+func (ts *testServer) get(t *testing.T, path string) (int, []byte) {
+	t.Helper()
+	resp, err := http.Get(ts.URL + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, body
+}
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestUploadListDelete(t *testing.T) {
+	ts := newTestServer(t, devserver.Options{})
+	client := ts.client("dev")
+	ctx := context.Background()
+
+	data := []byte("hello from the dev server\n")
+	path := writeFile(t, "hello.txt", data)
+	uploaded, err := client.Upload(ctx, path, pinata.UploadOptions{KeyValues: map[string]string{"env": "test"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := car.Sum(car.CodecRaw, data).String(); uploaded.Cid != want {
+		t.Errorf("CID is %s, want %s", uploaded.Cid, want)
+	}
+	if uploaded.Name != "hello.txt" || uploaded.Size != len(data) || uploaded.KeyValues["env"] != "test" {
+		t.Errorf("got %+v", uploaded)
+	}
+
+	// The same content is deduplicated
+	again, err := client.Upload(ctx, path, pinata.UploadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Id != uploaded.Id || !again.IsDuplicate {
+		t.Errorf("reupload gave %+v, want the existing file", again)
+	}
+
+	if status, body := ts.get(t, "/ipfs/"+uploaded.Cid); status != http.StatusOK || !bytes.Equal(body, data) {
+		t.Errorf("gateway gave %d %q", status, body)
+	}
+
+	list, err := client.ListFiles(ctx, pinata.ListFilesOptions{Name: "hello.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Files) != 1 || list.Files[0].Id != uploaded.Id {
+		t.Errorf("listed %+v", list.Files)
+	}
+
+	if err := client.DeleteFile(ctx, "public", uploaded.Id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetFile(ctx, "public", uploaded.Id); pinata.StatusCode(err) != http.StatusNotFound {
+		t.Errorf("getting a deleted file gave %v, want a 404", err)
+	}
+	if status, _ := ts.get(t, "/ipfs/"+uploaded.Cid); status != http.StatusNotFound {
+		t.Errorf("gateway served a deleted file with %d", status)
+	}
+}
+
+func TestListPages(t *testing.T) {
+	ts := newTestServer(t, devserver.Options{})
+	client := ts.client("dev")
+	ctx := context.Background()
+
+	for i := range 5 {
+		path := writeFile(t, "file.txt", []byte(strings.Repeat("x", i+1)))
+		if _, err := client.Upload(ctx, path, pinata.UploadOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	seen := map[string]bool{}
+	pageToken := ""
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatal("paging never ended")
+		}
+		list, err := client.ListFiles(ctx, pinata.ListFilesOptions{Limit: 2, PageToken: pageToken})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list.Files) > 2 {
+			t.Fatalf("got %d files in a page of 2", len(list.Files))
+		}
+		for _, f := range list.Files {
+			if seen[f.Id] {
+				t.Errorf("%s listed twice", f.Id)
+			}
+			seen[f.Id] = true
+		}
+		if list.NextPageToken == "" {
+			break
+		}
+		pageToken = list.NextPageToken
+	}
+	if len(seen) != 5 {
+		t.Errorf("listed %d files, want 5", len(seen))
+	}
+}
+
+func TestUploadTUS(t *testing.T) {
+	ts := newTestServer(t, devserver.Options{})
+	client := ts.client("dev")
+	ctx := context.Background()
+
+	data := bytes.Repeat([]byte("0123456789abcdef"), 4096)
+	path := writeFile(t, "big.bin", data)
+	want := car.Sum(car.CodecRaw, data).String()
+
+	var committed []int64
+	uploaded, err := client.Upload(ctx, path, pinata.UploadOptions{
+		TUSThreshold: 1024,
+		ChunkSize:    16 << 10,
+		Chunk:        func(c, total int64) { committed = append(committed, c) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uploaded.Cid != want || uploaded.Size != len(data) || uploaded.Name != "big.bin" {
+		t.Errorf("got %+v, want CID %s", uploaded, want)
+	}
+	if len(committed) != 4 || committed[3] != int64(len(data)) {
+		t.Errorf("committed %v, want 4 chunks", committed)
+	}
+	if len(ts.concat) != 0 {
+		t.Errorf("a sequential upload sent Upload-Concat %v", ts.concat)
+	}
+	if status, body := ts.get(t, "/ipfs/"+uploaded.Cid); status != http.StatusOK || !bytes.Equal(body, data) {
+		t.Errorf("gateway gave %d and %d bytes", status, len(body))
+	}
+}
+
+func TestUploadTUSParallel(t *testing.T) {
+	ts := newTestServer(t, devserver.Options{})
+	client := ts.client("dev")
+	ctx := context.Background()
+
+	// Not a multiple of the part size, so the last part is shorter
+	data := bytes.Repeat([]byte("parallel"), 10000)
+	path := writeFile(t, "parts.bin", data)
+
+	var mu sync.Mutex
+	var last int64
+	uploaded, err := client.Upload(ctx, path, pinata.UploadOptions{
+		Network:      "private",
+		TUSThreshold: 1024,
+		ChunkSize:    8 << 10,
+		Parallel:     3,
+		KeyValues:    map[string]string{"parts": "3"},
+		Progress: func(sent, total int64) {
+			mu.Lock()
+			last = sent
+			mu.Unlock()
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := car.Sum(car.CodecRaw, data).String(); uploaded.Cid != want {
+		t.Errorf("CID is %s, want %s", uploaded.Cid, want)
+	}
+	if uploaded.KeyValues["parts"] != "3" {
+		t.Errorf("got %+v", uploaded)
+	}
+	if _, err := client.GetFile(ctx, "private", uploaded.Id); err != nil {
+		t.Errorf("the file isn't on the private network: %v", err)
+	}
+	if last != int64(len(data)) {
+		t.Errorf("progress ended at %d, want %d", last, len(data))
+	}
+
+	var partial, final int
+	for _, concat := range ts.concat {
+		switch {
+		case concat == "partial":
+			partial++
+		case strings.HasPrefix(concat, "final;"):
+			final++
+			if got := len(strings.Fields(strings.TrimPrefix(concat, "final;"))); got != 3 {
+				t.Errorf("concatenated %d parts, want 3", got)
+			}
+		}
+	}
+	if partial != 3 || final != 1 {
+		t.Errorf("sent %d partial and %d final uploads, want 3 and 1", partial, final)
+	}
+}
+
+func TestSignedLinks(t *testing.T) {
+	ts := newTestServer(t, devserver.Options{})
+	client := ts.client("dev")
+	ctx := context.Background()
+
+	data := []byte("private report")
+	uploaded, err := client.Upload(ctx, writeFile(t, "report.txt", data), pinata.UploadOptions{Network: "private"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Private files aren't on the public gateway
+	if status, _ := ts.get(t, "/ipfs/"+uploaded.Cid); status != http.StatusNotFound {
+		t.Errorf("public gateway gave %d for a private file", status)
+	}
+	if status, _ := ts.get(t, "/files/"+uploaded.Cid); status != http.StatusForbidden {
+		t.Errorf("unsigned link gave %d, want 403", status)
+	}
+
+	signed, err := client.SignURL(ctx, "https://example.mypinata.cloud/files/"+uploaded.Cid+"?img-width=100", 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	link, err := url.Parse(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.Query().Get("img-width") != "100" || link.Query().Get("X-Signature") == "" {
+		t.Fatalf("signed link is %s", signed)
+	}
+	if status, body := ts.get(t, link.RequestURI()); status != http.StatusOK || !bytes.Equal(body, data) {
+		t.Errorf("signed link gave %d %q", status, body)
+	}
+
+	query := link.Query()
+	query.Set("X-Expires", "6000")
+	if status, _ := ts.get(t, link.Path+"?"+query.Encode()); status != http.StatusForbidden {
+		t.Errorf("a link with a changed expiry gave %d, want 403", status)
+	}
+
+	if _, err := client.SignURL(ctx, "https://example.mypinata.cloud/files/"+uploaded.Cid, 0); pinata.StatusCode(err) != http.StatusBadRequest {
+		t.Errorf("signing without an expiry gave %v, want a 400", err)
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	ts := newTestServer(t, devserver.Options{JWT: "secret"})
+	ctx := context.Background()
+
+	if err := ts.client("secret").TestAuthentication(ctx); err != nil {
+		t.Errorf("the configured JWT was refused: %v", err)
+	}
+	if err := ts.client("other").TestAuthentication(ctx); pinata.StatusCode(err) != http.StatusUnauthorized {
+		t.Errorf("another JWT gave %v, want a 401", err)
+	}
+
+	created, err := ts.client("secret").CreateKey(ctx, pinata.CreateKeyBody{KeyName: "ci", MaxUses: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := ts.client(created.JWT)
+	if err := key.TestAuthentication(ctx); err != nil {
+		t.Errorf("a created key was refused: %v", err)
+	}
+	if err := key.TestAuthentication(ctx); pinata.StatusCode(err) != http.StatusForbidden {
+		t.Errorf("a used up key gave %v, want a 403", err)
+	}
+}
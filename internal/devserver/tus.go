@@ -0,0 +1,259 @@
+package devserver
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+
+	"github.com/google/uuid"
+)
+
+const tusVersion = "1.0.0"
+
+// tusUpload is a TUS upload in progress. The file is stored under id once
+// every byte has arrived, unless the upload is a partial one waiting to be
+// concatenated.
+type tusUpload struct {
+	id        string
+	length    int64
+	partial   bool
+	metadata  map[string]string
+	keyvalues map[string]interface{}
+	data      bytes.Buffer
+}
+
+// tusOptions answers TUS discovery requests, which aren't authenticated
+func (s *Server) tusOptions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Tus-Resumable", tusVersion)
+	w.Header().Set("Tus-Version", tusVersion)
+	w.Header().Set("Tus-Extension", "creation,concatenation")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// tusCreate starts a TUS upload. The upload URL ends in /<file id>/upload,
+// which is where the SDK reads the file ID from.
+func (s *Server) tusCreate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Tus-Resumable", tusVersion)
+
+	concat := r.Header.Get("Upload-Concat")
+	if strings.HasPrefix(concat, "final;") {
+		s.tusConcatenate(w, r, strings.Fields(strings.TrimPrefix(concat, "final;")))
+		return
+	}
+	if concat != "" && concat != "partial" {
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "invalid Upload-Concat header")
+		return
+	}
+
+	length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "invalid Upload-Length header")
+		return
+	}
+	metadata, err := parseTUSMetadata(r.Header.Get("Upload-Metadata"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", err.Error())
+		return
+	}
+	if network := metadata["network"]; network != "" && network != pinata.NetworkPublic && network != pinata.NetworkPrivate {
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", fmt.Sprintf("invalid network %q", network))
+		return
+	}
+	keyvalues, ok := parseKeyValues(w, metadata["keyvalues"])
+	if !ok {
+		return
+	}
+
+	upload := &tusUpload{
+		id:        uuid.New().String(),
+		length:    length,
+		partial:   concat == "partial",
+		metadata:  metadata,
+		keyvalues: keyvalues,
+	}
+	s.mu.Lock()
+	s.tus[upload.id] = upload
+	s.mu.Unlock()
+
+	w.Header().Set("Location", "/tus/"+upload.id+"/upload")
+	w.WriteHeader(http.StatusCreated)
+}
+
+// tusConcatenate makes a file of completed partial uploads, given by their
+// URLs in order
+func (s *Server) tusConcatenate(w http.ResponseWriter, r *http.Request, locations []string) {
+	metadata, err := parseTUSMetadata(r.Header.Get("Upload-Metadata"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", err.Error())
+		return
+	}
+	if network := metadata["network"]; network != "" && network != pinata.NetworkPublic && network != pinata.NetworkPrivate {
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", fmt.Sprintf("invalid network %q", network))
+		return
+	}
+	keyvalues, ok := parseKeyValues(w, metadata["keyvalues"])
+	if !ok {
+		return
+	}
+	if len(locations) == 0 {
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "no partial uploads to concatenate")
+		return
+	}
+
+	upload := &tusUpload{
+		id:        uuid.New().String(),
+		metadata:  metadata,
+		keyvalues: keyvalues,
+	}
+	s.mu.Lock()
+	var parts []*tusUpload
+	for _, location := range locations {
+		// Locations look like /tus/<id>/upload, possibly with a host
+		segments := strings.Split(strings.TrimSuffix(location, "/"), "/")
+		part, found := s.tus[segments[max(len(segments)-2, 0)]]
+		if !found || !part.partial {
+			s.mu.Unlock()
+			writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", fmt.Sprintf("%s isn't a partial upload", location))
+			return
+		}
+		if int64(part.data.Len()) != part.length {
+			s.mu.Unlock()
+			writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", fmt.Sprintf("the partial upload %s isn't complete", location))
+			return
+		}
+		parts = append(parts, part)
+	}
+	for _, part := range parts {
+		upload.data.Write(part.data.Bytes())
+		delete(s.tus, part.id)
+	}
+	upload.length = int64(upload.data.Len())
+	s.mu.Unlock()
+
+	s.finishTUS(upload)
+	w.Header().Set("Location", "/tus/"+upload.id+"/upload")
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *Server) tusHead(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Tus-Resumable", tusVersion)
+	w.Header().Set("Cache-Control", "no-store")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	upload, found := s.tus[r.PathValue("id")]
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Upload-Offset", strconv.Itoa(upload.data.Len()))
+	w.Header().Set("Upload-Length", strconv.FormatInt(upload.length, 10))
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) tusPatch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Tus-Resumable", tusVersion)
+
+	if r.Header.Get("Content-Type") != "application/offset+octet-stream" {
+		writeError(w, http.StatusUnsupportedMediaType, "INVALID_ARGUMENT", "Content-Type must be application/offset+octet-stream")
+		return
+	}
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "invalid Upload-Offset header")
+		return
+	}
+	chunk, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "failed to read the chunk: "+err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	upload, found := s.tus[r.PathValue("id")]
+	if !found {
+		s.mu.Unlock()
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "upload not found")
+		return
+	}
+	if offset != int64(upload.data.Len()) {
+		s.mu.Unlock()
+		writeError(w, http.StatusConflict, "CONFLICT", fmt.Sprintf("offset %d doesn't match the upload offset %d", offset, upload.data.Len()))
+		return
+	}
+	if offset+int64(len(chunk)) > upload.length {
+		s.mu.Unlock()
+		writeError(w, http.StatusRequestEntityTooLarge, "INVALID_ARGUMENT", "the chunk goes past the Upload-Length")
+		return
+	}
+	upload.data.Write(chunk)
+	newOffset := upload.data.Len()
+	// Partial uploads wait to be concatenated
+	complete := int64(newOffset) == upload.length && !upload.partial
+	if complete {
+		delete(s.tus, upload.id)
+	}
+	s.mu.Unlock()
+
+	if complete {
+		s.finishTUS(upload)
+	}
+
+	w.Header().Set("Upload-Offset", strconv.Itoa(newOffset))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// finishTUS stores a completed upload under its upload ID
+func (s *Server) finishTUS(upload *tusUpload) {
+	data := upload.data.Bytes()
+	network := upload.metadata["network"]
+	if network == "" {
+		network = pinata.NetworkPublic
+	}
+
+	f := &storedFile{
+		File: pinata.File{
+			Id:            upload.id,
+			Name:          upload.metadata["filename"],
+			Cid:           cid(codecRaw, data),
+			Size:          len(data),
+			NumberOfFiles: 1,
+			MimeType:      mimeType(upload.metadata["filename"], data),
+			KeyValues:     upload.keyvalues,
+			GroupId:       optional(upload.metadata["group_id"]),
+			CreatedAt:     now(),
+		},
+		Network:  network,
+		Contents: map[string][]byte{"": data},
+	}
+
+	s.mu.Lock()
+	s.files = append(s.files, f)
+	s.mu.Unlock()
+}
+
+// parseTUSMetadata decodes an Upload-Metadata header, a comma separated list
+// of keys and base64 values
+func parseTUSMetadata(header string) (map[string]string, error) {
+	metadata := map[string]string{}
+	for _, pair := range strings.Split(header, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, encoded, _ := strings.Cut(pair, " ")
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Upload-Metadata value for %q", key)
+		}
+		metadata[key] = string(value)
+	}
+	return metadata, nil
+}
@@ -0,0 +1,141 @@
+// Package exitcode sorts errors into the categories scripts can rely on and
+// maps each one to a stable process exit code.
+package exitcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+)
+
+// Error codes reported in the JSON error envelope. They're the categories the
+// SDK reports for API errors, plus interrupted.
+const (
+	CodeError        = pinata.CodeError
+	CodeInvalidInput = pinata.CodeInvalidInput
+	CodeUnauthorized = pinata.CodeUnauthorized
+	CodeNotFound     = pinata.CodeNotFound
+	CodeRateLimited  = pinata.CodeRateLimited
+	CodeNetwork      = pinata.CodeNetwork
+	CodeServer       = pinata.CodeServer
+	CodeConflict     = pinata.CodeConflict
+	CodeInterrupted  = "interrupted"
+	// CodeCheckFailed is for checks such as keys audit that ran fine but
+	// found problems
+	CodeCheckFailed = "check_failed"
+)
+
+// Exit codes, documented in the README. Never renumber these.
+const (
+	OK           = 0
+	Failure      = 1
+	InvalidInput = 2
+	Unauthorized = 3
+	NotFound     = 4
+	RateLimited  = 5
+	Network      = 6
+	Server       = 7
+	Conflict     = 8
+	CheckFailed  = 9
+	Interrupted  = 130
+)
+
+var exitCodes = map[string]int{
+	CodeError:        Failure,
+	CodeInvalidInput: InvalidInput,
+	CodeUnauthorized: Unauthorized,
+	CodeNotFound:     NotFound,
+	CodeRateLimited:  RateLimited,
+	CodeNetwork:      Network,
+	CodeServer:       Server,
+	CodeConflict:     Conflict,
+	CodeCheckFailed:  CheckFailed,
+	CodeInterrupted:  Interrupted,
+}
+
+// Coder is implemented by errors that know their category, such as the
+// errors returned by the SDK
+type Coder interface {
+	ErrorCode() string
+}
+
+// Error attaches a category to an error without changing its message
+type Error struct {
+	Code string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) ErrorCode() string {
+	return e.Code
+}
+
+// InvalidInputf returns an error for invalid flags, arguments or files
+func InvalidInputf(format string, args ...any) error {
+	return &Error{Code: CodeInvalidInput, Err: fmt.Errorf(format, args...)}
+}
+
+// AsInvalidInput marks an existing error as invalid input
+func AsInvalidInput(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Code: CodeInvalidInput, Err: err}
+}
+
+// Unauthorizedf returns an error for missing or rejected credentials
+func Unauthorizedf(format string, args ...any) error {
+	return &Error{Code: CodeUnauthorized, Err: fmt.Errorf(format, args...)}
+}
+
+// NotFoundf returns an error for a resource that doesn't exist
+func NotFoundf(format string, args ...any) error {
+	return &Error{Code: CodeNotFound, Err: fmt.Errorf(format, args...)}
+}
+
+// Conflictf returns an error for a resource that already exists
+func Conflictf(format string, args ...any) error {
+	return &Error{Code: CodeConflict, Err: fmt.Errorf(format, args...)}
+}
+
+// CheckFailedf returns an error for a check that found problems
+func CheckFailedf(format string, args ...any) error {
+	return &Error{Code: CodeCheckFailed, Err: fmt.Errorf(format, args...)}
+}
+
+// CodeOf returns the category of err, or CodeError if it has none
+func CodeOf(err error) string {
+	if err == nil {
+		return ""
+	}
+	if errors.Is(err, context.Canceled) {
+		return CodeInterrupted
+	}
+	var coder Coder
+	if errors.As(err, &coder) {
+		if code := coder.ErrorCode(); code != "" {
+			return code
+		}
+	}
+	return CodeError
+}
+
+// For returns the process exit code for err
+func For(err error) int {
+	if err == nil {
+		return OK
+	}
+	if code, ok := exitCodes[CodeOf(err)]; ok {
+		return code
+	}
+	return Failure
+}
@@ -0,0 +1,81 @@
+package exitcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+)
+
+// TestFor pins the exit codes, which scripts rely on. The codes are written
+// out rather than named so renumbering a constant fails here.
+func TestFor(t *testing.T) {
+	apiError := func(status int) error {
+		return &pinata.Error{Method: http.MethodGet, URL: "https://api.pinata.cloud/v3/files", Status: status}
+	}
+	tests := []struct {
+		name string
+		err  error
+		code string
+		exit int
+	}{
+		{"nil", nil, "", 0},
+		{"plain", errors.New("failed"), CodeError, 1},
+		{"invalid input", InvalidInputf("bad flag %q", "--x"), CodeInvalidInput, 2},
+		{"marked invalid input", AsInvalidInput(errors.New("bad file")), CodeInvalidInput, 2},
+		{"unauthorized", Unauthorizedf("no credentials"), CodeUnauthorized, 3},
+		{"not found", NotFoundf("no file %s", "x"), CodeNotFound, 4},
+		{"conflict", Conflictf("exists"), CodeConflict, 8},
+		{"check failed", CheckFailedf("2 findings"), CodeCheckFailed, 9},
+		{"interrupted", context.Canceled, CodeInterrupted, 130},
+		{"timed out", context.DeadlineExceeded, CodeError, 1},
+		{"unknown category", &Error{Code: "teapot", Err: errors.New("short and stout")}, "teapot", 1},
+		{"empty category", &Error{Err: errors.New("no category")}, CodeError, 1},
+
+		{"400", apiError(http.StatusBadRequest), CodeInvalidInput, 2},
+		{"422", apiError(http.StatusUnprocessableEntity), CodeInvalidInput, 2},
+		{"401", apiError(http.StatusUnauthorized), CodeUnauthorized, 3},
+		{"403", apiError(http.StatusForbidden), CodeUnauthorized, 3},
+		{"404", apiError(http.StatusNotFound), CodeNotFound, 4},
+		{"429", apiError(http.StatusTooManyRequests), CodeRateLimited, 5},
+		{"network", &pinata.NetworkError{Method: http.MethodGet, URL: "https://api.pinata.cloud", Err: errors.New("connection refused")}, CodeNetwork, 6},
+		{"500", apiError(http.StatusInternalServerError), CodeServer, 7},
+		{"503", apiError(http.StatusServiceUnavailable), CodeServer, 7},
+		{"409", apiError(http.StatusConflict), CodeConflict, 8},
+		{"418", apiError(http.StatusTeapot), CodeError, 1},
+
+		{"wrapped", fmt.Errorf("failed to get file: %w", apiError(http.StatusNotFound)), CodeNotFound, 4},
+		{"wrapped twice", fmt.Errorf("keys rotate: %w", fmt.Errorf("verify: %w", Unauthorizedf("rejected"))), CodeUnauthorized, 3},
+		{"wrapped interruption", fmt.Errorf("upload: %w", context.Canceled), CodeInterrupted, 130},
+		// A request cancelled by Ctrl+C fails with a network error
+		{"interrupted request", &pinata.NetworkError{Method: http.MethodGet, URL: "https://api.pinata.cloud", Err: context.Canceled}, CodeInterrupted, 130},
+		{"category over cause", &Error{Code: CodeInvalidInput, Err: apiError(http.StatusNotFound)}, CodeInvalidInput, 2},
+
+		// The first error with a category wins
+		{"joined", errors.Join(errors.New("plain"), NotFoundf("missing"), Conflictf("exists")), CodeNotFound, 4},
+		{"joined without categories", errors.Join(errors.New("a"), errors.New("b")), CodeError, 1},
+		{"joined interruption", errors.Join(Conflictf("exists"), context.Canceled), CodeInterrupted, 130},
+	}
+	for _, tt := range tests {
+		if got := CodeOf(tt.err); got != tt.code {
+			t.Errorf("%s: CodeOf = %q, want %q", tt.name, got, tt.code)
+		}
+		if got := For(tt.err); got != tt.exit {
+			t.Errorf("%s: For = %d, want %d", tt.name, got, tt.exit)
+		}
+	}
+}
+
+func TestErrorKeepsMessage(t *testing.T) {
+	cause := errors.New("disk full")
+	err := AsInvalidInput(cause)
+	if err.Error() != "disk full" || !errors.Is(err, cause) {
+		t.Errorf("got %q, want the cause unchanged", err)
+	}
+	if AsInvalidInput(nil) != nil {
+		t.Error("AsInvalidInput(nil) isn't nil")
+	}
+}
@@ -1,46 +1,25 @@
 package files
 
 import (
-	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
-	"pinata/internal/common"
-	"pinata/internal/config"
-	"pinata/internal/gateways"
-	"pinata/internal/types"
-	"strings"
+	"github.com/PinataCloud/ipfs-cli/internal/api"
+	"github.com/PinataCloud/ipfs-cli/internal/config"
+	"github.com/PinataCloud/ipfs-cli/internal/gateways"
+	"github.com/PinataCloud/ipfs-cli/internal/utils"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
 )
 
 func DeleteFile(id string, network string) error {
-	jwt, err := common.FindToken()
-	if err != nil {
-		return err
-	}
 	networkParam, err := config.GetNetworkParam(network)
 	if err != nil {
 		return errors.Join(err, errors.New("Failed to fetch network preference"))
 	}
 
-	url := fmt.Sprintf("https://%s/v3/files/%s/%s", config.GetAPIHost(), networkParam, id)
-
-	req, err := http.NewRequest("DELETE", url, nil)
-	if err != nil {
-		return errors.Join(err, errors.New("failed to create the request"))
-	}
-	req.Header.Set("Authorization", "Bearer "+string(jwt))
-	req.Header.Set("content-type", "application/json")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	err = api.Client().DeleteFile(api.Context(), networkParam, id)
 	if err != nil {
-		return errors.Join(err, errors.New("failed to send the request"))
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("server Returned an error %d, check ID", resp.StatusCode)
+		return err
 	}
 
 	fmt.Println("File Deleted")
@@ -49,345 +28,143 @@ func DeleteFile(id string, network string) error {
 
 }
 
-func GetFile(id string, network string) (types.GetFileResponse, error) {
-	jwt, err := common.FindToken()
-	if err != nil {
-		return types.GetFileResponse{}, err
-	}
-
+func GetFile(id string, network string) (*pinata.File, error) {
 	networkParam, err := config.GetNetworkParam(network)
 	if err != nil {
-		return types.GetFileResponse{}, err
-	}
-
-	url := fmt.Sprintf("https://%s/v3/files/%s/%s", config.GetAPIHost(), networkParam, id)
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return types.GetFileResponse{}, errors.Join(err, errors.New("failed to create the request"))
-	}
-	req.Header.Set("Authorization", "Bearer "+string(jwt))
-	req.Header.Set("content-type", "application/json")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return types.GetFileResponse{}, errors.Join(err, errors.New("failed to send the request"))
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return types.GetFileResponse{}, fmt.Errorf("server Returned an error %d", resp.StatusCode)
+		return nil, err
 	}
-	var response types.GetFileResponse
 
-	err = json.NewDecoder(resp.Body).Decode(&response)
+	file, err := api.Client().GetFile(api.Context(), networkParam, id)
 	if err != nil {
-		return types.GetFileResponse{}, err
+		return nil, err
 	}
-	formattedJSON, err := json.MarshalIndent(response.Data, "", "    ")
+	formattedJSON, err := json.MarshalIndent(file, "", "    ")
 	if err != nil {
-		return types.GetFileResponse{}, errors.New("failed to format JSON")
+		return nil, errors.New("failed to format JSON")
 	}
 
 	fmt.Println(string(formattedJSON))
 
-	return response, nil
+	return file, nil
 
 }
 
-func UpdateFile(id string, name string, network string) (types.GetFileResponse, error) {
-	jwt, err := common.FindToken()
-	if err != nil {
-		return types.GetFileResponse{}, err
-	}
-	payload := types.FileUpdateBody{
-		Name: name,
-	}
-
-	jsonPayload, err := json.Marshal(payload)
-
-	if err != nil {
-		return types.GetFileResponse{}, errors.Join(err, errors.New("Failed to marshal paylod"))
-	}
-
+func UpdateFile(id string, name string, network string) (*pinata.File, error) {
 	networkParam, err := config.GetNetworkParam(network)
 	if err != nil {
-		return types.GetFileResponse{}, err
-	}
-
-	url := fmt.Sprintf("https://%s/v3/files/%s/%s", config.GetAPIHost(), networkParam, id)
-
-	req, err := http.NewRequest("PUT", url, bytes.NewBuffer(jsonPayload))
-	if err != nil {
-		return types.GetFileResponse{}, errors.Join(err, errors.New("failed to create the request"))
-	}
-	req.Header.Set("Authorization", "Bearer "+string(jwt))
-	req.Header.Set("content-type", "application/json")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return types.GetFileResponse{}, errors.Join(err, errors.New("failed to send the request"))
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return types.GetFileResponse{}, fmt.Errorf("server Returned an error %d", resp.StatusCode)
+		return nil, err
 	}
-	var response types.GetFileResponse
 
-	err = json.NewDecoder(resp.Body).Decode(&response)
+	file, err := api.Client().UpdateFile(api.Context(), networkParam, id, name)
 	if err != nil {
-		return types.GetFileResponse{}, err
+		return nil, err
 	}
-	formattedJSON, err := json.MarshalIndent(response.Data, "", "    ")
+	formattedJSON, err := json.MarshalIndent(file, "", "    ")
 	if err != nil {
-		return types.GetFileResponse{}, errors.New("failed to format JSON")
+		return nil, errors.New("failed to format JSON")
 	}
 
 	fmt.Println(string(formattedJSON))
 
-	return response, nil
+	return file, nil
 
 }
 
-func ListFiles(amount string, pageToken string, cidPending bool, name string, cid string, group string, mime_type string, keyvalues map[string]string, network string) (types.ListResponse, error) {
-	jwt, err := common.FindToken()
-	if err != nil {
-		return types.ListResponse{}, err
-	}
-
+func ListFiles(amount string, pageToken string, cidPending bool, name string, cid string, group string, mime_type string, keyvalues map[string]string, network string) (*pinata.FileList, error) {
 	networkParam, err := config.GetNetworkParam(network)
 	if err != nil {
-		return types.ListResponse{}, err
-	}
-
-	url := fmt.Sprintf("https://%s/v3/files/%s?", config.GetAPIHost(), networkParam)
-
-	params := []string{}
-
-	if name != "" {
-		params = append(params, "name="+name)
-	}
-
-	if cid != "" {
-		params = append(params, "cid="+cid)
-	}
-
-	if group != "" {
-		params = append(params, "group="+group)
-	}
-
-	if mime_type != "" {
-		params = append(params, "mimeType="+mime_type)
-	}
-
-	if amount != "" {
-		params = append(params, "limit="+amount)
-	}
-
-	if pageToken != "" {
-		params = append(params, "pageToken="+pageToken)
-	}
-
-	if cidPending {
-		params = append(params, "cidPending=true")
-	}
-
-	if len(keyvalues) > 0 {
-		for key, value := range keyvalues {
-			params = append(params, fmt.Sprintf("keyvalues[%s]=%s", key, value))
-		}
-	}
-
-	if len(params) > 0 {
-		url += strings.Join(params, "&")
-	}
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return types.ListResponse{}, errors.Join(err, errors.New("failed to create the request"))
+		return nil, err
 	}
-	req.Header.Set("Authorization", "Bearer "+string(jwt))
-	req.Header.Set("content-type", "application/json")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	limit, err := utils.ParseLimit(amount)
 	if err != nil {
-		return types.ListResponse{}, errors.Join(err, errors.New("failed to send the request"))
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		return types.ListResponse{}, fmt.Errorf("server Returned an error %d", resp.StatusCode)
-	}
-
-	var response types.ListResponse
-
-	err = json.NewDecoder(resp.Body).Decode(&response)
+	list, err := api.Client().ListFiles(api.Context(), pinata.ListFilesOptions{
+		Network:    networkParam,
+		Name:       name,
+		Cid:        cid,
+		GroupId:    group,
+		MimeType:   mime_type,
+		KeyValues:  keyvalues,
+		CidPending: cidPending,
+		Limit:      limit,
+		PageToken:  pageToken,
+	})
 	if err != nil {
-		return types.ListResponse{}, err
+		return nil, err
 	}
-	formattedJSON, err := json.MarshalIndent(response.Data, "", "    ")
+	formattedJSON, err := json.MarshalIndent(list, "", "    ")
 	if err != nil {
-		return types.ListResponse{}, errors.New("failed to format JSON")
+		return nil, errors.New("failed to format JSON")
 	}
 
 	fmt.Println(string(formattedJSON))
 
-	return response, nil
+	return list, nil
 
 }
 
-func GetSwapHistory(cid string, domain string, network string) (types.GetSwapHistoryResponse, error) {
-	jwt, err := common.FindToken()
-	if err != nil {
-		return types.GetSwapHistoryResponse{}, err
-	}
+func GetSwapHistory(cid string, domain string, network string) ([]pinata.Swap, error) {
 	networkParam, err := config.GetNetworkParam(network)
 	if err != nil {
-		return types.GetSwapHistoryResponse{}, err
+		return nil, err
 	}
-	url := fmt.Sprintf("https://%s/v3/files/%s/swap/%s?", config.GetAPIHost(), networkParam, cid)
-
-	params := []string{}
 
-	if domain != "" {
-		params = append(params, "domain="+domain)
-	} else {
+	if domain == "" {
 		internalDomain, err := gateways.FindGatewayDomain()
 		if err != nil {
-			return types.GetSwapHistoryResponse{}, err
+			return nil, err
 		}
-		params = append(params, "domain="+string(internalDomain))
-	}
-	if len(params) > 0 {
-		url += strings.Join(params, "&")
-	}
-
-	req, err := http.NewRequest("GET", url, nil)
-
-	if err != nil {
-		return types.GetSwapHistoryResponse{}, errors.Join(err, errors.New("failed to create the request"))
+		domain = string(internalDomain)
 	}
-	req.Header.Set("Authorization", "Bearer "+string(jwt))
-	req.Header.Set("content-type", "application/json")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	swaps, err := api.Client().SwapHistory(api.Context(), networkParam, cid, domain)
 	if err != nil {
-		return types.GetSwapHistoryResponse{}, errors.Join(err, errors.New("failed to send the request"))
+		return nil, err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return types.GetSwapHistoryResponse{}, fmt.Errorf("server Returned an error %d", resp.StatusCode)
-	}
-
-	var response types.GetSwapHistoryResponse
-
-	err = json.NewDecoder(resp.Body).Decode(&response)
+	formattedJSON, err := json.MarshalIndent(swaps, "", "    ")
 	if err != nil {
-		return types.GetSwapHistoryResponse{}, err
-	}
-	formattedJSON, err := json.MarshalIndent(response.Data, "", "    ")
-	if err != nil {
-		return types.GetSwapHistoryResponse{}, errors.New("failed to format JSON")
+		return nil, errors.New("failed to format JSON")
 	}
 
 	fmt.Println(string(formattedJSON))
 
-	return response, nil
+	return swaps, nil
 
 }
 
-func AddSwap(cid string, swapCid string, network string) (types.AddSwapResponse, error) {
-	jwt, err := common.FindToken()
-	if err != nil {
-		return types.AddSwapResponse{}, err
-	}
+func AddSwap(cid string, swapCid string, network string) (*pinata.Swap, error) {
 	networkParam, err := config.GetNetworkParam(network)
 	if err != nil {
-		return types.AddSwapResponse{}, err
-	}
-
-	url := fmt.Sprintf("https://%s/v3/files/%s/swap/%s", config.GetAPIHost(), networkParam, cid)
-
-	payload := types.AddSwapBody{
-		SwapCid: swapCid,
-	}
-
-	jsonPayload, err := json.Marshal(payload)
-
-	if err != nil {
-		return types.AddSwapResponse{}, errors.Join(err, errors.New("Failed to marshal paylod"))
-	}
-
-	req, err := http.NewRequest("PUT", url, bytes.NewBuffer(jsonPayload))
-
-	if err != nil {
-		return types.AddSwapResponse{}, errors.Join(err, errors.New("failed to create the request"))
-	}
-	req.Header.Set("Authorization", "Bearer "+string(jwt))
-	req.Header.Set("content-type", "application/json")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return types.AddSwapResponse{}, errors.Join(err, errors.New("failed to send the request"))
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return types.AddSwapResponse{}, fmt.Errorf("server Returned an error %d", resp.StatusCode)
+		return nil, err
 	}
 
-	var response types.AddSwapResponse
-
-	err = json.NewDecoder(resp.Body).Decode(&response)
+	swap, err := api.Client().AddSwap(api.Context(), networkParam, cid, swapCid)
 	if err != nil {
-		return types.AddSwapResponse{}, err
+		return nil, err
 	}
-	formattedJSON, err := json.MarshalIndent(response.Data, "", "    ")
+	formattedJSON, err := json.MarshalIndent(swap, "", "    ")
 	if err != nil {
-		return types.AddSwapResponse{}, errors.New("failed to format JSON")
+		return nil, errors.New("failed to format JSON")
 	}
 
 	fmt.Println(string(formattedJSON))
 
-	return response, nil
+	return swap, nil
 
 }
 
 func RemoveSwap(cid string, network string) error {
-	jwt, err := common.FindToken()
-	if err != nil {
-		return err
-	}
 	networkParam, err := config.GetNetworkParam(network)
 	if err != nil {
 		return errors.Join(err, errors.New("Failed to get network preference"))
 	}
-	url := fmt.Sprintf("https://%s/v3/files/%s/swap/%s", config.GetAPIHost(), networkParam, cid)
 
-	req, err := http.NewRequest("DELETE", url, nil)
-
-	if err != nil {
-		return errors.Join(err, errors.New("failed to create the request"))
-	}
-	req.Header.Set("Authorization", "Bearer "+string(jwt))
-	req.Header.Set("content-type", "application/json")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	err = api.Client().RemoveSwap(api.Context(), networkParam, cid)
 	if err != nil {
-		return errors.Join(err, errors.New("failed to send the request"))
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("server Returned an error %d", resp.StatusCode)
+		return err
 	}
 
 	fmt.Println("Swap deleted")
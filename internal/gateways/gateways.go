@@ -1,74 +1,29 @@
 package gateways
 
 import (
-	"bytes"
-	"encoding/json"
-	"errors"
 	"fmt"
-	"net/http"
-	"os"
 	"os/exec"
-	"path/filepath"
-	"pinata/internal/common"
-	"pinata/internal/config"
-	"pinata/internal/types"
-	"pinata/internal/utils"
 	"runtime"
-	"strings"
-	"time"
+
+	"github.com/PinataCloud/ipfs-cli/internal/api"
+	"github.com/PinataCloud/ipfs-cli/internal/common"
+	"github.com/PinataCloud/ipfs-cli/internal/config"
+	"github.com/PinataCloud/ipfs-cli/internal/utils"
 )
 
 func FindGatewayDomain() ([]byte, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return nil, err
-	}
-	dotFilePath := filepath.Join(homeDir, ".pinata-files-cli-gateway")
-	Domain, err := os.ReadFile(dotFilePath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, errors.New("JWT not found. Please authorize first using the 'auth' command")
-		} else {
-			return nil, err
-		}
-	}
-	return Domain, err
+	return common.FindGatewayDomain()
 }
 
 func SetGateway(domain string) error {
 	if domain == "" {
-		jwt, err := common.FindToken()
+		gateways, err := api.Client().ListGateways(api.Context())
 		if err != nil {
 			return err
 		}
-		url := fmt.Sprintf("https://%s/v3/ipfs/gateways", config.GetAPIHost())
-
-		req, err := http.NewRequest("GET", url, nil)
-		if err != nil {
-			return errors.Join(err, errors.New("failed to create the request"))
-		}
-		req.Header.Set("Authorization", "Bearer "+string(jwt))
-		req.Header.Set("content-type", "application/json")
 
-		client := &http.Client{}
-		resp, err := client.Do(req)
-		if err != nil {
-			return errors.Join(err, errors.New("failed to send the request"))
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != 200 {
-			return fmt.Errorf("server Returned an error %d", resp.StatusCode)
-		}
-		var response types.GetGatewaysResponse
-
-		err = json.NewDecoder(resp.Body).Decode(&response)
-		if err != nil {
-			return err
-		}
-
-		options := make([]string, len(response.Data.Rows))
-		for i, item := range response.Data.Rows {
+		options := make([]string, len(gateways))
+		for i, item := range gateways {
 			options[i] = item.Domain + ".mypinata.cloud"
 		}
 		domain, err := utils.MultiSelect(options)
@@ -76,23 +31,12 @@ func SetGateway(domain string) error {
 			fmt.Println("Error:", err)
 			return nil
 		}
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return err
-		}
-		p := filepath.Join(home, ".pinata-files-cli-gateway")
-		err = os.WriteFile(p, []byte(domain), 0600)
-		if err != nil {
-			return err
+		if domain == "" {
+			return nil
 		}
-		return nil
-	}
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return err
+		return saveGateway(domain)
 	}
-	p := filepath.Join(home, ".pinata-files-cli-gateway")
-	err = os.WriteFile(p, []byte(domain), 0600)
+	err := saveGateway(domain)
 	if err != nil {
 		return err
 	}
@@ -101,80 +45,54 @@ func SetGateway(domain string) error {
 	return nil
 }
 
-func GetAccessLink(cid string, expires int, network string) (types.GetSignedURLResponse, error) {
+func saveGateway(domain string) error {
+	return config.UpdateActiveProfile(func(p *config.Profile) {
+		p.Gateway = domain
+	})
+}
 
-	jwt, err := common.FindToken()
+func GetAccessLink(cid string, expires int, network string, opts LinkOptions) (string, error) {
+	url, err := AccessLink(cid, expires, network, opts)
 	if err != nil {
-		return types.GetSignedURLResponse{}, err
+		return "", err
+	}
+	fmt.Println(url)
+	return url, nil
+}
+
+// AccessLink returns a gateway link to a public file or a signed link, valid
+// for expires seconds, to a private one
+func AccessLink(cid string, expires int, network string, opts LinkOptions) (string, error) {
+	if err := opts.Validate(); err != nil {
+		return "", err
 	}
 
 	networkParam, err := config.GetNetworkParam(network)
 	if err != nil {
-		return types.GetSignedURLResponse{}, err
+		return "", err
 	}
 
 	domain, err := FindGatewayDomain()
 	if err != nil {
-		return types.GetSignedURLResponse{}, err
+		return "", err
 	}
 
 	if networkParam == "public" {
-		url := fmt.Sprintf("https://%s/ipfs/%s", domain, cid)
-		fmt.Println(url)
-		return types.GetSignedURLResponse{}, err
+		return buildGatewayURL(string(domain), "ipfs", cid, opts), nil
 	}
 
-	domainUrl := fmt.Sprintf("https://%s/files/%s", domain, cid)
+	// The query parameters are part of the signed payload so the gateway
+	// accepts them on the resulting link
+	domainUrl := buildGatewayURL(string(domain), "files", cid, opts)
 
-	currentTime := time.Now().Unix()
-
-	payload := types.GetSignedURLBody{
-		URL:     domainUrl,
-		Expires: expires,
-		Date:    currentTime,
-		Method:  "GET",
-	}
-
-	jsonPayload, err := json.Marshal(payload)
-
-	if err != nil {
-		return types.GetSignedURLResponse{}, errors.Join(err, errors.New("Failed to marshal paylod"))
-	}
-
-	url := fmt.Sprintf("https://%s/v3/files/private/download_link", config.GetAPIHost())
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonPayload))
-	if err != nil {
-		return types.GetSignedURLResponse{}, errors.Join(err, errors.New("failed to create the request"))
-	}
-	req.Header.Set("Authorization", "Bearer "+string(jwt))
-	req.Header.Set("content-type", "application/json")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return types.GetSignedURLResponse{}, errors.Join(err, errors.New("failed to send the request"))
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return types.GetSignedURLResponse{}, fmt.Errorf("server Returned an error %d", resp.StatusCode)
-	}
+	return api.Client().SignURL(api.Context(), domainUrl, expires)
+}
 
-	var response types.GetSignedURLResponse
-	err = json.NewDecoder(resp.Body).Decode(&response)
-	if err != nil {
-		return types.GetSignedURLResponse{}, err
+func OpenCID(cid string, network string, opts LinkOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
 	}
 
-	unescapedURL := strings.ReplaceAll(response.Data, "\\u0026", "&")
-	unescapedURL = strings.Trim(unescapedURL, "\"")
-
-	fmt.Println(unescapedURL)
-
-	return response, nil
-}
-
-func OpenCID(cid string, network string) error {
 	networkParam, err := config.GetNetworkParam(network)
 	if err != nil {
 		return fmt.Errorf("problem getting network parameter: %w", err)
@@ -186,20 +104,26 @@ func OpenCID(cid string, network string) error {
 		if err != nil {
 			return fmt.Errorf("problem finding gateway domain: %w", err)
 		}
-		url = fmt.Sprintf("https://%s/ipfs/%s", string(domain), cid)
+		url = buildGatewayURL(string(domain), "ipfs", cid, opts)
 	} else {
-		resp, err := GetAccessLink(cid, 30, networkParam)
+		url, err = AccessLink(cid, 30, networkParam, opts)
 		if err != nil {
 			return fmt.Errorf("problem creating URL: %w", err)
 		}
-
-		url = resp.Data
-		url = strings.ReplaceAll(url, "\\u0026", "&")
-		url = strings.Trim(url, "\"")
 	}
 
 	fmt.Printf("Opening URL: %s\n", url)
 
+	if err := OpenURL(url); err != nil {
+		return fmt.Errorf("problem opening URL: %w", err)
+	}
+
+	return nil
+}
+
+// OpenURL opens a link in the default browser
+func OpenURL(url string) error {
+	var err error
 	switch runtime.GOOS {
 	case "darwin":
 		err = exec.Command("open", url).Start()
@@ -210,10 +134,5 @@ func OpenCID(cid string, network string) error {
 	default:
 		err = fmt.Errorf("unsupported platform")
 	}
-
-	if err != nil {
-		return fmt.Errorf("problem opening URL: %w", err)
-	}
-
-	return nil
+	return err
 }
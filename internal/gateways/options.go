@@ -0,0 +1,115 @@
+package gateways
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+)
+
+const maxImageDimension = 12000
+
+var (
+	imageFormats = []string{"auto", "webp", "avif", "jpeg", "png"}
+	imageFits    = []string{"scale-down", "contain", "cover", "crop", "pad"}
+)
+
+// LinkOptions holds the image optimization, download and response format
+// parameters that dedicated gateways accept as query parameters
+type LinkOptions struct {
+	Width      int
+	Height     int
+	Format     string
+	Quality    int
+	Fit        string
+	DownloadAs string
+	// CAR asks for the content's blocks as a CAR, so they can be verified
+	CAR bool
+}
+
+// Validate checks the option combinations before anything is sent to the
+// gateway. A width, height or quality of 0 leaves it unset.
+func (o LinkOptions) Validate() error {
+	if o.Width < 0 || o.Width > maxImageDimension {
+		return exitcode.InvalidInputf("invalid width %d: must be from 1 to %d, or 0 to keep the original width", o.Width, maxImageDimension)
+	}
+	if o.Height < 0 || o.Height > maxImageDimension {
+		return exitcode.InvalidInputf("invalid height %d: must be from 1 to %d, or 0 to keep the original height", o.Height, maxImageDimension)
+	}
+	if o.Quality < 0 || o.Quality > 100 {
+		return exitcode.InvalidInputf("invalid quality %d: must be from 1 to 100, or 0 for the gateway's default", o.Quality)
+	}
+	if o.Format != "" && !contains(imageFormats, o.Format) {
+		return exitcode.InvalidInputf("invalid format %q: must be one of %s", o.Format, strings.Join(imageFormats, ", "))
+	}
+	if o.Fit != "" {
+		if !contains(imageFits, o.Fit) {
+			return exitcode.InvalidInputf("invalid fit %q: must be one of %s", o.Fit, strings.Join(imageFits, ", "))
+		}
+		if o.Width == 0 && o.Height == 0 {
+			return exitcode.InvalidInputf("--fit requires --width or --height")
+		}
+	}
+	if o.DownloadAs != "" {
+		if strings.ContainsAny(o.DownloadAs, `/\`) {
+			return exitcode.InvalidInputf("invalid download name %q: must not contain path separators", o.DownloadAs)
+		}
+		if strings.TrimSpace(o.DownloadAs) == "" {
+			return exitcode.InvalidInputf("download name cannot be blank")
+		}
+	}
+	return nil
+}
+
+// Query encodes the options as gateway query parameters
+func (o LinkOptions) Query() url.Values {
+	query := url.Values{}
+	if o.Width > 0 {
+		query.Set("img-width", strconv.Itoa(o.Width))
+	}
+	if o.Height > 0 {
+		query.Set("img-height", strconv.Itoa(o.Height))
+	}
+	if o.Fit != "" {
+		query.Set("img-fit", o.Fit)
+	}
+	if o.Format != "" {
+		query.Set("img-format", o.Format)
+	}
+	if o.Quality > 0 {
+		query.Set("img-quality", strconv.Itoa(o.Quality))
+	}
+	if o.DownloadAs != "" {
+		query.Set("download", "true")
+		query.Set("filename", o.DownloadAs)
+	}
+	if o.CAR {
+		query.Set("format", "car")
+	}
+	return query
+}
+
+// buildGatewayURL returns the gateway URL for a CID under the given path
+// prefix ("ipfs" or "files") with any link options applied. The domain may
+// include a scheme, such as http:// for a local dev server.
+func buildGatewayURL(domain string, prefix string, cid string, opts LinkOptions) string {
+	if !strings.Contains(domain, "://") {
+		domain = "https://" + domain
+	}
+	link := fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(domain, "/"), prefix, cid)
+	if query := opts.Query(); len(query) > 0 {
+		link += "?" + query.Encode()
+	}
+	return link
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
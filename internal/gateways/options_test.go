@@ -0,0 +1,92 @@
+package gateways
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    LinkOptions
+		message string
+	}{
+		{"unset", LinkOptions{}, ""},
+		{"smallest", LinkOptions{Width: 1, Height: 1, Quality: 1}, ""},
+		{"largest", LinkOptions{Width: 12000, Height: 12000, Quality: 100}, ""},
+		{"width too large", LinkOptions{Width: 12001}, "invalid width 12001: must be from 1 to 12000, or 0"},
+		{"negative width", LinkOptions{Width: -1}, "invalid width -1"},
+		{"height too large", LinkOptions{Height: 12001}, "invalid height 12001: must be from 1 to 12000, or 0"},
+		{"negative height", LinkOptions{Height: -1}, "invalid height -1"},
+		{"quality too high", LinkOptions{Quality: 101}, "invalid quality 101: must be from 1 to 100, or 0"},
+		{"negative quality", LinkOptions{Quality: -1}, "invalid quality -1"},
+
+		{"format", LinkOptions{Format: "avif"}, ""},
+		{"unknown format", LinkOptions{Format: "gif"}, `invalid format "gif"`},
+		{"fit with a width", LinkOptions{Fit: "cover", Width: 100}, ""},
+		{"fit with a height", LinkOptions{Fit: "pad", Height: 100}, ""},
+		{"fit alone", LinkOptions{Fit: "cover"}, "--fit requires --width or --height"},
+		{"unknown fit", LinkOptions{Fit: "stretch", Width: 100}, `invalid fit "stretch"`},
+
+		{"download name", LinkOptions{DownloadAs: "report 2026.pdf"}, ""},
+		{"download path", LinkOptions{DownloadAs: "../report.pdf"}, "must not contain path separators"},
+		{"download Windows path", LinkOptions{DownloadAs: `a\b.pdf`}, "must not contain path separators"},
+		{"blank download name", LinkOptions{DownloadAs: "  "}, "cannot be blank"},
+	}
+	for _, tt := range tests {
+		err := tt.opts.Validate()
+		if tt.message == "" {
+			if err != nil {
+				t.Errorf("%s: got %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("%s: got %v, want an error containing %q", tt.name, err, tt.message)
+		}
+		if exitcode.For(err) != exitcode.InvalidInput {
+			t.Errorf("%s: got %v, want invalid input", tt.name, err)
+		}
+	}
+}
+
+func TestQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		opts LinkOptions
+		want string
+	}{
+		{"unset", LinkOptions{}, ""},
+		{"image", LinkOptions{Width: 800, Height: 600, Fit: "cover", Format: "webp", Quality: 80}, "img-fit=cover&img-format=webp&img-height=600&img-quality=80&img-width=800"},
+		{"width only", LinkOptions{Width: 800}, "img-width=800"},
+		{"download", LinkOptions{DownloadAs: "my report.pdf"}, "download=true&filename=my+report.pdf"},
+		{"CAR", LinkOptions{CAR: true}, "format=car"},
+	}
+	for _, tt := range tests {
+		if got := tt.opts.Query().Encode(); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBuildGatewayURL(t *testing.T) {
+	cid := "bafkreieb3nt3njlqfonwr4abn4dbyqe36p5rnudc7scu2g2cjo2otqumky"
+	tests := []struct {
+		domain, prefix string
+		opts           LinkOptions
+		want           string
+	}{
+		{"example.mypinata.cloud", "ipfs", LinkOptions{}, "https://example.mypinata.cloud/ipfs/" + cid},
+		{"example.mypinata.cloud/", "files", LinkOptions{}, "https://example.mypinata.cloud/files/" + cid},
+		{"http://127.0.0.1:8799", "ipfs", LinkOptions{}, "http://127.0.0.1:8799/ipfs/" + cid},
+		{"example.mypinata.cloud", "ipfs", LinkOptions{Width: 100, CAR: true}, "https://example.mypinata.cloud/ipfs/" + cid + "?format=car&img-width=100"},
+	}
+	for _, tt := range tests {
+		got := buildGatewayURL(tt.domain, tt.prefix, cid, tt.opts)
+		if got != tt.want {
+			t.Errorf("buildGatewayURL(%q, %q) = %q, want %q", tt.domain, tt.prefix, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package keys
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+)
+
+const (
+	AuditNearExhaustion = "near-exhaustion"
+	AuditUnused         = "unused"
+	AuditAdmin          = "admin"
+	AuditDuplicateName  = "duplicate-name"
+)
+
+var auditChecks = []string{AuditNearExhaustion, AuditUnused, AuditAdmin, AuditDuplicateName}
+
+// AuditOptions holds the thresholds used by AuditKeys
+type AuditOptions struct {
+	// ExhaustionPercent flags limited keys that have used at least this
+	// percentage of their max uses
+	ExhaustionPercent int
+	// UnusedDays flags keys with no uses that are older than this many days
+	UnusedDays int
+	// Ignore lists checks that are reported but do not fail the audit
+	Ignore []string
+	JSON   bool
+}
+
+// AuditFinding is a single issue found on a key
+type AuditFinding struct {
+	Check   string `json:"check"`
+	KeyID   string `json:"key_id"`
+	Name    string `json:"name"`
+	Key     string `json:"key"`
+	Detail  string `json:"detail"`
+	Ignored bool   `json:"ignored,omitempty"`
+}
+
+// AuditReport is the result of AuditKeys
+type AuditReport struct {
+	KeysScanned int            `json:"keys_scanned"`
+	Findings    []AuditFinding `json:"findings"`
+	Breaches    int            `json:"breaches"`
+}
+
+// AuditKeys pages through every active key, prints the findings and returns an
+// error when any check that is not ignored was breached
+func AuditKeys(opts AuditOptions) (AuditReport, error) {
+	for _, check := range opts.Ignore {
+		if !contains(auditChecks, check) {
+			return AuditReport{}, exitcode.InvalidInputf("unknown check %q: must be one of %s", check, strings.Join(auditChecks, ", "))
+		}
+	}
+	if opts.ExhaustionPercent < 1 || opts.ExhaustionPercent > 100 {
+		return AuditReport{}, exitcode.InvalidInputf("--near-exhaustion must be between 1 and 100")
+	}
+
+	keys, err := ListAllKeys()
+	if err != nil {
+		return AuditReport{}, err
+	}
+
+	report := auditKeys(keys, opts, time.Now())
+
+	if opts.JSON {
+		formattedJSON, err := json.MarshalIndent(report, "", "    ")
+		if err != nil {
+			return report, errors.New("failed to format JSON")
+		}
+		fmt.Println(string(formattedJSON))
+	} else {
+		printAuditTable(report)
+	}
+
+	if report.Breaches > 0 {
+		return report, exitcode.CheckFailedf("key audit failed with %d finding(s)", report.Breaches)
+	}
+	return report, nil
+}
+
+func auditKeys(keys []pinata.Key, opts AuditOptions, now time.Time) AuditReport {
+	report := AuditReport{Findings: []AuditFinding{}}
+
+	active := []pinata.Key{}
+	names := map[string][]pinata.Key{}
+	for _, key := range keys {
+		if key.Revoked {
+			continue
+		}
+		active = append(active, key)
+		names[key.Name] = append(names[key.Name], key)
+	}
+	report.KeysScanned = len(active)
+
+	add := func(check string, key pinata.Key, detail string) {
+		finding := AuditFinding{
+			Check:   check,
+			KeyID:   key.ID,
+			Name:    key.Name,
+			Key:     key.Key,
+			Detail:  detail,
+			Ignored: contains(opts.Ignore, check),
+		}
+		if !finding.Ignored {
+			report.Breaches++
+		}
+		report.Findings = append(report.Findings, finding)
+	}
+
+	for _, key := range active {
+		if key.MaxUses > 0 && key.Uses*100 >= key.MaxUses*opts.ExhaustionPercent {
+			add(AuditNearExhaustion, key, fmt.Sprintf("%d of %d uses", key.Uses, key.MaxUses))
+		}
+
+		if key.Uses == 0 && opts.UnusedDays > 0 {
+			created, err := time.Parse(time.RFC3339, key.CreatedAt)
+			if err == nil {
+				age := int(now.Sub(created).Hours() / 24)
+				if age > opts.UnusedDays {
+					add(AuditUnused, key, fmt.Sprintf("never used, created %d days ago", age))
+				}
+			}
+		}
+
+		if key.Scopes.Admin {
+			add(AuditAdmin, key, "key has admin permissions")
+		}
+
+		if len(names[key.Name]) > 1 {
+			add(AuditDuplicateName, key, fmt.Sprintf("%d active keys share this name", len(names[key.Name])))
+		}
+	}
+
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		return report.Findings[i].Check < report.Findings[j].Check
+	})
+
+	return report
+}
+
+func printAuditTable(report AuditReport) {
+	if len(report.Findings) == 0 {
+		fmt.Printf("Scanned %d active keys, no findings\n", report.KeysScanned)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tKEY ID\tNAME\tDETAIL")
+	for _, finding := range report.Findings {
+		check := finding.Check
+		if finding.Ignored {
+			check += " (ignored)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", check, finding.KeyID, finding.Name, finding.Detail)
+	}
+	w.Flush()
+	fmt.Printf("\nScanned %d active keys, %d finding(s) breach the thresholds\n", report.KeysScanned, report.Breaches)
+}
@@ -0,0 +1,158 @@
+package keys
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+)
+
+var auditNow = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+// testKey returns a used key created a day before auditNow, changed by set
+func testKey(id string, set func(*pinata.Key)) pinata.Key {
+	key := pinata.Key{
+		ID:        id,
+		Name:      id,
+		Key:       "key-" + id,
+		Uses:      1,
+		CreatedAt: auditNow.Add(-24 * time.Hour).Format(time.RFC3339),
+	}
+	if set != nil {
+		set(&key)
+	}
+	return key
+}
+
+func daysAgo(days float64) string {
+	return auditNow.Add(-time.Duration(days * 24 * float64(time.Hour))).Format(time.RFC3339)
+}
+
+func TestAuditKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		key  pinata.Key
+		want []string
+	}{
+		{"healthy", testKey("a", nil), nil},
+
+		{"below exhaustion", testKey("a", func(k *pinata.Key) { k.Uses, k.MaxUses = 79, 100 }), nil},
+		{"at exhaustion", testKey("a", func(k *pinata.Key) { k.Uses, k.MaxUses = 80, 100 }), []string{AuditNearExhaustion}},
+		{"used up", testKey("a", func(k *pinata.Key) { k.Uses, k.MaxUses = 3, 3 }), []string{AuditNearExhaustion}},
+		// 2 of 3 is 66.7%
+		{"rounding below exhaustion", testKey("a", func(k *pinata.Key) { k.Uses, k.MaxUses = 2, 3 }), nil},
+		{"unlimited", testKey("a", func(k *pinata.Key) { k.Uses, k.MaxUses = 1000000, 0 }), nil},
+
+		{"unused for the threshold", testKey("a", func(k *pinata.Key) { k.Uses, k.CreatedAt = 0, daysAgo(30) }), nil},
+		{"unused for part of a day more", testKey("a", func(k *pinata.Key) { k.Uses, k.CreatedAt = 0, daysAgo(30.9) }), nil},
+		{"unused past the threshold", testKey("a", func(k *pinata.Key) { k.Uses, k.CreatedAt = 0, daysAgo(31) }), []string{AuditUnused}},
+		{"old but used", testKey("a", func(k *pinata.Key) { k.CreatedAt = daysAgo(400) }), nil},
+		{"unused with an unknown age", testKey("a", func(k *pinata.Key) { k.Uses, k.CreatedAt = 0, "yesterday" }), nil},
+
+		{"admin", testKey("a", func(k *pinata.Key) { k.Scopes.Admin = true }), []string{AuditAdmin}},
+		{"revoked admin", testKey("a", func(k *pinata.Key) { k.Scopes.Admin, k.Revoked = true, true }), nil},
+
+		{
+			"every check",
+			testKey("a", func(k *pinata.Key) {
+				k.Uses, k.MaxUses, k.CreatedAt, k.Scopes.Admin = 0, 1, daysAgo(90), true
+			}),
+			[]string{AuditAdmin, AuditUnused},
+		},
+	}
+	opts := AuditOptions{ExhaustionPercent: 80, UnusedDays: 30}
+	for _, tt := range tests {
+		report := auditKeys([]pinata.Key{tt.key}, opts, auditNow)
+		var checks []string
+		for _, finding := range report.Findings {
+			checks = append(checks, finding.Check)
+		}
+		if !slices.Equal(checks, tt.want) {
+			t.Errorf("%s: found %v, want %v", tt.name, checks, tt.want)
+		}
+		if report.Breaches != len(tt.want) {
+			t.Errorf("%s: %d breaches, want %d", tt.name, report.Breaches, len(tt.want))
+		}
+	}
+}
+
+func TestAuditThresholds(t *testing.T) {
+	key := testKey("a", func(k *pinata.Key) { k.Uses, k.MaxUses, k.CreatedAt = 0, 10, daysAgo(5) })
+	tests := []struct {
+		opts AuditOptions
+		want []string
+	}{
+		// No uses of 10 is 0%
+		{AuditOptions{ExhaustionPercent: 1, UnusedDays: 4}, []string{AuditUnused}},
+		{AuditOptions{ExhaustionPercent: 1, UnusedDays: 5}, nil},
+		// 0 turns the unused check off
+		{AuditOptions{ExhaustionPercent: 1, UnusedDays: 0}, nil},
+	}
+	for _, tt := range tests {
+		report := auditKeys([]pinata.Key{key}, tt.opts, auditNow)
+		var checks []string
+		for _, finding := range report.Findings {
+			checks = append(checks, finding.Check)
+		}
+		if !slices.Equal(checks, tt.want) {
+			t.Errorf("%+v found %v, want %v", tt.opts, checks, tt.want)
+		}
+	}
+
+	full := testKey("a", func(k *pinata.Key) { k.Uses, k.MaxUses = 99, 100 })
+	if report := auditKeys([]pinata.Key{full}, AuditOptions{ExhaustionPercent: 100}, auditNow); len(report.Findings) != 0 {
+		t.Errorf("99 of 100 uses was flagged at 100%%: %+v", report.Findings)
+	}
+	full.Uses = 100
+	if report := auditKeys([]pinata.Key{full}, AuditOptions{ExhaustionPercent: 100}, auditNow); len(report.Findings) != 1 {
+		t.Errorf("100 of 100 uses wasn't flagged at 100%%")
+	}
+}
+
+func TestAuditDuplicateNames(t *testing.T) {
+	keys := []pinata.Key{
+		testKey("a", func(k *pinata.Key) { k.Name = "ci" }),
+		testKey("b", func(k *pinata.Key) { k.Name = "ci" }),
+		// Revoked keys don't count
+		testKey("c", func(k *pinata.Key) { k.Name = "deploy" }),
+		testKey("d", func(k *pinata.Key) { k.Name, k.Revoked = "deploy", true }),
+		testKey("e", func(k *pinata.Key) { k.Name, k.Scopes.Admin = "admin", true }),
+	}
+	report := auditKeys(keys, AuditOptions{ExhaustionPercent: 80, Ignore: []string{AuditDuplicateName}}, auditNow)
+
+	if report.KeysScanned != 4 {
+		t.Errorf("scanned %d keys, want the 4 active ones", report.KeysScanned)
+	}
+	var got []string
+	for _, finding := range report.Findings {
+		got = append(got, finding.Check+":"+finding.KeyID)
+		if finding.Ignored != (finding.Check == AuditDuplicateName) {
+			t.Errorf("%+v is ignored %v", finding, finding.Ignored)
+		}
+	}
+	// Findings are sorted by check
+	if want := []string{"admin:e", "duplicate-name:a", "duplicate-name:b"}; !slices.Equal(got, want) {
+		t.Errorf("found %v, want %v", got, want)
+	}
+	if report.Breaches != 1 {
+		t.Errorf("%d breaches, want only the admin key", report.Breaches)
+	}
+	if report.Findings[1].Detail != "2 active keys share this name" || report.Findings[1].Key != "key-a" {
+		t.Errorf("finding is %+v", report.Findings[1])
+	}
+}
+
+func TestAuditOptions(t *testing.T) {
+	tests := []AuditOptions{
+		{ExhaustionPercent: 0},
+		{ExhaustionPercent: 101},
+		{ExhaustionPercent: 80, Ignore: []string{"expired"}},
+	}
+	for _, opts := range tests {
+		if _, err := AuditKeys(opts); exitcode.For(err) != exitcode.InvalidInput {
+			t.Errorf("%+v gave %v, want invalid input", opts, err)
+		}
+	}
+}
@@ -1,76 +1,22 @@
 package keys
 
 import (
-	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
-	"pinata/internal/common"
-	"pinata/internal/config"
-	"pinata/internal/types"
-	"strings"
+	"github.com/PinataCloud/ipfs-cli/internal/api"
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
 )
 
-func ListKeys(name string, revoked bool, limitedUse bool, exhausted bool, offset string) (types.KeyListResponse, error) {
-	jwt, err := common.FindToken()
+func ListKeys(name string, revoked bool, limitedUse bool, exhausted bool, offset string) (pinata.KeyList, error) {
+	response, err := listKeys(name, revoked, limitedUse, exhausted, offset)
 	if err != nil {
-		return types.KeyListResponse{}, err
-	}
-	url := fmt.Sprintf("https://%s/v3/pinata/keys?", config.GetAPIHost())
-
-	params := []string{}
-
-	if name != "" {
-		params = append(params, "name="+name)
-	}
-
-	if revoked {
-		params = append(params, "revoked=true")
-	}
-
-	if limitedUse {
-		params = append(params, "limitedUse=true")
-	}
-
-	if exhausted {
-		params = append(params, "exhausted=true")
-	}
-	if offset != "" {
-		params = append(params, "offset="+offset)
-	}
-
-	if len(params) > 0 {
-		url += strings.Join(params, "&")
-	}
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return types.KeyListResponse{}, errors.Join(err, errors.New("failed to create the request"))
-	}
-	req.Header.Set("Authorization", "Bearer "+string(jwt))
-	req.Header.Set("content-type", "application/json")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return types.KeyListResponse{}, errors.Join(err, errors.New("failed to send the request"))
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return types.KeyListResponse{}, fmt.Errorf("server Returned an error %d", resp.StatusCode)
-	}
-
-	var response types.KeyListResponse
-
-	err = json.NewDecoder(resp.Body).Decode(&response)
-	if err != nil {
-		return types.KeyListResponse{}, err
+		return pinata.KeyList{}, err
 	}
 	formattedJSON, err := json.MarshalIndent(response, "", "    ")
 	if err != nil {
-		return types.KeyListResponse{}, errors.New("failed to format JSON")
+		return pinata.KeyList{}, errors.New("failed to format JSON")
 	}
 
 	fmt.Println(string(formattedJSON))
@@ -79,93 +25,47 @@ func ListKeys(name string, revoked bool, limitedUse bool, exhausted bool, offset
 
 }
 
-func CreateKey(name string, admin bool, uses int, endpoints []string) (types.CreateKeyResponse, error) {
-	jwt, err := common.FindToken()
+func listKeys(name string, revoked bool, limitedUse bool, exhausted bool, offset string) (pinata.KeyList, error) {
+	response, err := api.Client().ListKeys(api.Context(), pinata.ListKeysOptions{
+		Name:       name,
+		Revoked:    revoked,
+		LimitedUse: limitedUse,
+		Exhausted:  exhausted,
+		Offset:     offset,
+	})
 	if err != nil {
-		return types.CreateKeyResponse{}, err
-	}
-
-	payload := types.CreatKeyBody{
-		KeyName: name,
-		Permissions: types.Permissions{
-			Admin: admin,
-		},
+		return pinata.KeyList{}, err
 	}
 
-	if uses > 0 {
-		payload.MaxUses = uses
-	}
+	return *response, nil
+}
 
-	if !admin && len(endpoints) > 0 {
-		dataEndpoints := types.DataEndpoints{}
-		pinningEndpoints := types.PinningEndpoints{}
-
-		for _, endpoint := range endpoints {
-			switch endpoint {
-			case "pinList":
-				dataEndpoints.PinList = true
-			case "userPinnedDataTotal":
-				dataEndpoints.UserPinnedDataTotal = true
-
-			case "hashMetadata":
-				pinningEndpoints.HashMetadata = true
-			case "hashPinPolicy":
-				pinningEndpoints.HashPinPolicy = true
-			case "pinByHash":
-				pinningEndpoints.PinByHash = true
-			case "pinFileToIPFS":
-				pinningEndpoints.PinFileToIPFS = true
-			case "pinJSONToIPFS":
-				pinningEndpoints.PinJSONToIPFS = true
-			case "pinJobs":
-				pinningEndpoints.PinJobs = true
-			case "unpin":
-				pinningEndpoints.Unpin = true
-			case "userPinPolicy":
-				pinningEndpoints.UserPinPolicy = true
-			}
-		}
-
-		payload.Permissions.Endpoints = types.Endpoints{
-			Data:    dataEndpoints,
-			Pinning: pinningEndpoints,
-		}
+func CreateKey(name string, admin bool, uses int, scopes []string) (pinata.CreateKeyResponse, error) {
+	if name == "" {
+		return pinata.CreateKeyResponse{}, exitcode.InvalidInputf("key name is required")
 	}
 
-	jsonPayload, err := json.Marshal(payload)
-
+	permissions, err := BuildPermissions(admin, scopes)
 	if err != nil {
-		return types.CreateKeyResponse{}, errors.Join(err, errors.New("Failed to marshal paylod"))
+		return pinata.CreateKeyResponse{}, err
 	}
 
-	url := fmt.Sprintf("https://%s/v3/pinata/keys", config.GetAPIHost())
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonPayload))
-	if err != nil {
-		return types.CreateKeyResponse{}, errors.Join(err, errors.New("failed to create the request"))
+	payload := pinata.CreateKeyBody{
+		KeyName:     name,
+		Permissions: permissions,
 	}
-	req.Header.Set("Authorization", "Bearer "+string(jwt))
-	req.Header.Set("content-type", "application/json")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return types.CreateKeyResponse{}, errors.Join(err, errors.New("failed to send the request"))
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return types.CreateKeyResponse{}, fmt.Errorf("server Returned an error %d", resp.StatusCode)
+	if uses > 0 {
+		payload.MaxUses = uses
 	}
 
-	var response types.CreateKeyResponse
-
-	err = json.NewDecoder(resp.Body).Decode(&response)
+	response, err := createKey("", payload)
 	if err != nil {
-		return types.CreateKeyResponse{}, err
+		return pinata.CreateKeyResponse{}, err
 	}
 	formattedJSON, err := json.MarshalIndent(response, "", "    ")
 	if err != nil {
-		return types.CreateKeyResponse{}, errors.New("failed to format JSON")
+		return pinata.CreateKeyResponse{}, errors.New("failed to format JSON")
 	}
 
 	fmt.Println(string(formattedJSON))
@@ -174,29 +74,21 @@ func CreateKey(name string, admin bool, uses int, endpoints []string) (types.Cre
 
 }
 
-func RevokeKey(id string) error {
-	jwt, err := common.FindToken()
+// createKey creates a key with the given JWT, or the active profile's when
+// jwt is empty
+func createKey(jwt string, payload pinata.CreateKeyBody) (pinata.CreateKeyResponse, error) {
+	response, err := clientFor(jwt).CreateKey(api.Context(), payload)
 	if err != nil {
-		return err
+		return pinata.CreateKeyResponse{}, err
 	}
-	url := fmt.Sprintf("https://%s/v3/pinata/keys/%s", config.GetAPIHost(), id)
 
-	req, err := http.NewRequest("PUT", url, nil)
-	if err != nil {
-		return errors.Join(err, errors.New("failed to create the request"))
-	}
-	req.Header.Set("Authorization", "Bearer "+string(jwt))
-	req.Header.Set("content-type", "application/json")
+	return *response, nil
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+func RevokeKey(id string) error {
+	err := revokeKey("", id)
 	if err != nil {
-		return errors.Join(err, errors.New("failed to send the request"))
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("server Returned an error %d, check CID", resp.StatusCode)
+		return err
 	}
 
 	fmt.Println("Key Revoked")
@@ -204,3 +96,17 @@ func RevokeKey(id string) error {
 	return nil
 
 }
+
+// revokeKey revokes a key with the given JWT, or the active profile's when
+// jwt is empty
+func revokeKey(jwt string, id string) error {
+	return clientFor(jwt).RevokeKey(api.Context(), id)
+}
+
+// clientFor returns the shared client, authenticated with jwt when it's set
+func clientFor(jwt string) *pinata.Client {
+	if jwt == "" {
+		return api.Client()
+	}
+	return api.Client().WithToken(jwt)
+}
@@ -0,0 +1,184 @@
+package keys
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PinataCloud/ipfs-cli/internal/api"
+	"github.com/PinataCloud/ipfs-cli/internal/auth"
+	"github.com/PinataCloud/ipfs-cli/internal/common"
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+)
+
+const (
+	RotateTargetAuth   = "auth"
+	RotateTargetFile   = "file"
+	RotateTargetStdout = "stdout"
+)
+
+// RotateOptions controls where the replacement key is written and how the
+// old key is retired
+type RotateOptions struct {
+	Name   string
+	Target string
+	File   string
+	Grace  time.Duration
+}
+
+// RotateKey creates a replacement for an existing key with the same scopes and
+// max uses, stores and verifies the new JWT, then revokes the old key
+func RotateKey(idOrName string, opts RotateOptions) (pinata.CreateKeyResponse, error) {
+	switch opts.Target {
+	case RotateTargetAuth, RotateTargetStdout:
+	case RotateTargetFile:
+		if opts.File == "" {
+			return pinata.CreateKeyResponse{}, exitcode.InvalidInputf("--file is required when the target is 'file'")
+		}
+	default:
+		return pinata.CreateKeyResponse{}, exitcode.InvalidInputf("invalid target %q: must be one of auth, file or stdout", opts.Target)
+	}
+
+	jwt, err := common.FindToken()
+	if err != nil {
+		return pinata.CreateKeyResponse{}, err
+	}
+
+	old, err := FindKey(idOrName)
+	if err != nil {
+		return pinata.CreateKeyResponse{}, err
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = old.Name
+	}
+	payload := pinata.CreateKeyBody{
+		KeyName:     name,
+		Permissions: old.Scopes,
+		MaxUses:     old.MaxUses,
+	}
+
+	status("Creating replacement for key %s (%s)", old.Name, old.ID)
+	created, err := createKey(string(jwt), payload)
+	if err != nil {
+		return pinata.CreateKeyResponse{}, errors.Join(err, errors.New("failed to create the replacement key"))
+	}
+
+	// Verify before storing, so a working credential is never replaced by
+	// one that doesn't work
+	err = auth.VerifyJWT(created.JWT)
+	if err != nil {
+		return created, fmt.Errorf("replacement key %s failed verification and was not stored, the old key was not revoked: %w", created.PinataAPIKey, err)
+	}
+	status("Replacement key %s verified", created.PinataAPIKey)
+
+	err = writeRotatedJWT(created.JWT, opts)
+	if err != nil {
+		return created, fmt.Errorf("replacement key %s was created but could not be stored, the old key was not revoked: %w", created.PinataAPIKey, err)
+	}
+
+	if opts.Grace > 0 {
+		status("Waiting %s before revoking the old key", opts.Grace)
+		// An interrupt leaves both keys active rather than waiting it out
+		select {
+		case <-api.Context().Done():
+			return created, fmt.Errorf("replacement key %s is active, the old key was not revoked: %w", created.PinataAPIKey, api.Context().Err())
+		case <-time.After(opts.Grace):
+		}
+	}
+
+	// The auth store may hold the new JWT by now, so revoke with the token the
+	// rotation started with
+	err = revokeKey(string(jwt), old.Key)
+	if err != nil {
+		return created, fmt.Errorf("replacement key %s is active but the old key could not be revoked: %w", created.PinataAPIKey, err)
+	}
+	status("Old key %s revoked", old.Key)
+
+	return created, nil
+}
+
+// FindKey looks up an active key by ID or by its exact name
+func FindKey(idOrName string) (pinata.Key, error) {
+	keys, err := ListAllKeys()
+	if err != nil {
+		return pinata.Key{}, err
+	}
+
+	var matches []pinata.Key
+	for _, key := range keys {
+		if key.Revoked {
+			continue
+		}
+		if key.ID == idOrName || key.Key == idOrName {
+			return key, nil
+		}
+		if key.Name == idOrName {
+			matches = append(matches, key)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return pinata.Key{}, exitcode.NotFoundf("no active key found with ID or name %q", idOrName)
+	case 1:
+		return matches[0], nil
+	default:
+		ids := make([]string, len(matches))
+		for i, key := range matches {
+			ids[i] = key.ID
+		}
+		return pinata.Key{}, exitcode.InvalidInputf("multiple active keys are named %q, use one of the IDs instead: %s", idOrName, strings.Join(ids, ", "))
+	}
+}
+
+// ListAllKeys pages through every key on the account
+func ListAllKeys() ([]pinata.Key, error) {
+	var keys []pinata.Key
+	seen := map[string]bool{}
+	for {
+		response, err := listKeys("", false, false, false, strconv.Itoa(len(keys)))
+		if err != nil {
+			return nil, err
+		}
+		// Stop on an empty page, or if the offset was ignored and the page
+		// repeats keys we already have
+		if len(response.Keys) == 0 || seen[response.Keys[0].ID] {
+			return keys, nil
+		}
+		for _, key := range response.Keys {
+			seen[key.ID] = true
+		}
+		keys = append(keys, response.Keys...)
+	}
+}
+
+func writeRotatedJWT(jwt string, opts RotateOptions) error {
+	switch opts.Target {
+	case RotateTargetAuth:
+		err := auth.WriteJWT(jwt)
+		if err != nil {
+			return err
+		}
+		status("Saved the new JWT to the CLI auth store")
+	case RotateTargetFile:
+		err := os.WriteFile(opts.File, []byte(jwt), 0600)
+		if err != nil {
+			return err
+		}
+		status("Saved the new JWT to %s", opts.File)
+	case RotateTargetStdout:
+		fmt.Println(jwt)
+	}
+	return nil
+}
+
+// status writes progress to stderr so stdout only ever carries the JWT
+func status(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+}
@@ -0,0 +1,179 @@
+package keys
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PinataCloud/ipfs-cli/internal/api"
+	"github.com/PinataCloud/ipfs-cli/internal/devserver"
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+)
+
+// keyServer is a dev server that logs the key requests it's sent
+type keyServer struct {
+	// stored is the file the rotated JWT is written to
+	stored string
+	// rejectNew fails the verification of any key but the one the test
+	// started with
+	rejectNew bool
+
+	mu  sync.Mutex
+	log []string
+	// verified is when the replacement key was verified, and revoked when
+	// a key was revoked
+	verified, revoked time.Time
+}
+
+// useKeyServer points the API client at a dev server, with "dev" as the
+// JWT, until the test ends
+func useKeyServer(t *testing.T, s *keyServer) {
+	t.Helper()
+	handler := devserver.New(devserver.Options{}).Handler()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		s.mu.Lock()
+		switch {
+		case r.URL.Path == "/data/testAuthentication" && token != "dev":
+			_, err := os.Stat(s.stored)
+			s.log = append(s.log, "verify, stored: "+map[bool]string{true: "yes", false: "no"}[err == nil])
+			s.verified = time.Now()
+			if s.rejectNew {
+				s.mu.Unlock()
+				http.Error(w, `{"error": "INVALID_CREDENTIALS"}`, http.StatusUnauthorized)
+				return
+			}
+		case r.Method == http.MethodPost && r.URL.Path == "/v3/pinata/keys":
+			s.log = append(s.log, "create")
+		case r.Method == http.MethodPut:
+			s.log = append(s.log, "revoke with "+token)
+			s.revoked = time.Now()
+		}
+		s.mu.Unlock()
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("PINATA_CONFIG_DIR", t.TempDir())
+	t.Setenv("PINATA_JWT", "dev")
+
+	client := api.Client()
+	saved := *client
+	client.APIHost, client.UploadsHost = server.URL, server.URL
+	client.Retries = 0
+	t.Cleanup(func() {
+		client.APIHost, client.UploadsHost = saved.APIHost, saved.UploadsHost
+		client.Retries = saved.Retries
+	})
+}
+
+// createTestKey creates the key a test rotates
+func createTestKey(t *testing.T) pinata.CreateKeyResponse {
+	t.Helper()
+	created, err := createKey("dev", pinata.CreateKeyBody{
+		KeyName:     "ci",
+		Permissions: pinata.Permissions{Resources: []string{"org:files:read"}},
+		MaxUses:     50,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return created
+}
+
+// works reports whether the API accepts a JWT
+func works(jwt string) bool {
+	return api.Client().WithToken(jwt).TestAuthentication(context.Background()) == nil
+}
+
+func TestRotateKey(t *testing.T) {
+	s := &keyServer{stored: filepath.Join(t.TempDir(), "jwt")}
+	useKeyServer(t, s)
+	old := createTestKey(t)
+
+	grace := 300 * time.Millisecond
+	created, err := RotateKey("ci", RotateOptions{Target: RotateTargetFile, File: s.stored, Grace: grace})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"create", "create", "verify, stored: no", "revoke with dev"}
+	if strings.Join(s.log, "\n") != strings.Join(want, "\n") {
+		t.Errorf("requests were %q, want %q", s.log, want)
+	}
+	if waited := s.revoked.Sub(s.verified); waited < grace {
+		t.Errorf("revoked %v after the verification, before the grace of %v", waited, grace)
+	}
+	if data, err := os.ReadFile(s.stored); err != nil || string(data) != created.JWT {
+		t.Errorf("stored %q, %v; want the new JWT", data, err)
+	}
+	if works(old.JWT) || !works(created.JWT) {
+		t.Errorf("the old key works: %v, the new one works: %v", works(old.JWT), works(created.JWT))
+	}
+
+	// The replacement keeps the scopes and max uses
+	replacement, err := FindKey(created.PinataAPIKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replacement.Name != "ci" || replacement.MaxUses != 50 || len(replacement.Scopes.Resources) != 1 {
+		t.Errorf("replacement is %+v", replacement)
+	}
+}
+
+func TestRotateKeyFailedVerification(t *testing.T) {
+	s := &keyServer{stored: filepath.Join(t.TempDir(), "jwt"), rejectNew: true}
+	useKeyServer(t, s)
+	old := createTestKey(t)
+
+	_, err := RotateKey("ci", RotateOptions{Target: RotateTargetFile, File: s.stored, Grace: time.Hour})
+	if err == nil || !strings.Contains(err.Error(), "was not stored") {
+		t.Fatalf("got %v, want a failed verification", err)
+	}
+	if _, err := os.Stat(s.stored); !os.IsNotExist(err) {
+		t.Errorf("a key that failed verification was stored")
+	}
+	s.rejectNew = false
+	if !s.revoked.IsZero() || !works(old.JWT) {
+		t.Errorf("the old key was revoked")
+	}
+}
+
+func TestRotateKeyInterruptedGrace(t *testing.T) {
+	s := &keyServer{stored: filepath.Join(t.TempDir(), "jwt")}
+	useKeyServer(t, s)
+	old := createTestKey(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	api.SetContext(ctx)
+	t.Cleanup(func() { api.SetContext(context.Background()) })
+	// Interrupt once the new key is stored and the wait has begun
+	go func() {
+		for {
+			if _, err := os.Stat(s.stored); err == nil {
+				cancel()
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	started := time.Now()
+	created, err := RotateKey("ci", RotateOptions{Target: RotateTargetFile, File: s.stored, Grace: time.Hour})
+	if exitcode.For(err) != exitcode.Interrupted {
+		t.Fatalf("got %v, want an interruption", err)
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("returned after %v, the grace wasn't interrupted", elapsed)
+	}
+	if !s.revoked.IsZero() || !works(old.JWT) || !works(created.JWT) {
+		t.Errorf("want both keys still active")
+	}
+}
@@ -0,0 +1,177 @@
+package keys
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+)
+
+// resourceScopes are the v3 resource permissions, written without the "org:"
+// prefix the API expects
+var resourceScopes = []string{
+	"files:read",
+	"files:write",
+	"groups:read",
+	"groups:write",
+	"gateways:read",
+	"gateways:write",
+	"analytics:read",
+	"analytics:write",
+	"signatures:read",
+	"signatures:write",
+}
+
+// endpointScopes are the legacy per-endpoint permissions
+var endpointScopes = map[string]func(*pinata.Endpoints){
+	"pinList":             func(e *pinata.Endpoints) { e.Data.PinList = true },
+	"userPinnedDataTotal": func(e *pinata.Endpoints) { e.Data.UserPinnedDataTotal = true },
+	"hashMetadata":        func(e *pinata.Endpoints) { e.Pinning.HashMetadata = true },
+	"hashPinPolicy":       func(e *pinata.Endpoints) { e.Pinning.HashPinPolicy = true },
+	"pinByHash":           func(e *pinata.Endpoints) { e.Pinning.PinByHash = true },
+	"pinFileToIPFS":       func(e *pinata.Endpoints) { e.Pinning.PinFileToIPFS = true },
+	"pinJSONToIPFS":       func(e *pinata.Endpoints) { e.Pinning.PinJSONToIPFS = true },
+	"pinJobs":             func(e *pinata.Endpoints) { e.Pinning.PinJobs = true },
+	"unpin":               func(e *pinata.Endpoints) { e.Pinning.Unpin = true },
+	"userPinPolicy":       func(e *pinata.Endpoints) { e.Pinning.UserPinPolicy = true },
+}
+
+// Policy describes a key in a file that can be kept in version control
+type Policy struct {
+	Name    string   `json:"name"`
+	Admin   bool     `json:"admin"`
+	MaxUses int      `json:"max_uses"`
+	Scopes  []string `json:"scopes"`
+}
+
+// LoadPolicy reads and validates a key policy file
+func LoadPolicy(path string) (Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Policy{}, err
+	}
+
+	var policy Policy
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&policy); err != nil {
+		return Policy{}, exitcode.InvalidInputf("invalid policy file %s: %w", path, err)
+	}
+	if policy.MaxUses < 0 {
+		return Policy{}, exitcode.InvalidInputf("invalid policy file %s: max_uses cannot be negative", path)
+	}
+	if _, err := BuildPermissions(policy.Admin, policy.Scopes); err != nil {
+		return Policy{}, exitcode.InvalidInputf("invalid policy file %s: %w", path, err)
+	}
+
+	return policy, nil
+}
+
+// KnownScopes returns every scope accepted by BuildPermissions
+func KnownScopes() []string {
+	scopes := append([]string{}, resourceScopes...)
+	endpoints := make([]string, 0, len(endpointScopes))
+	for name := range endpointScopes {
+		endpoints = append(endpoints, name)
+	}
+	sort.Strings(endpoints)
+	return append(scopes, endpoints...)
+}
+
+// BuildPermissions converts scope names into the permissions sent to the API.
+// Resource scopes may be written with or without the "org:" prefix.
+func BuildPermissions(admin bool, scopes []string) (pinata.Permissions, error) {
+	permissions := pinata.Permissions{Admin: admin}
+	if len(scopes) == 0 {
+		return permissions, nil
+	}
+	if admin {
+		return pinata.Permissions{}, exitcode.InvalidInputf("admin keys already have every scope, remove the scopes or the admin flag")
+	}
+
+	seen := map[string]bool{}
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if scope == "" || seen[scope] {
+			continue
+		}
+		seen[scope] = true
+
+		if setter, ok := endpointScopes[scope]; ok {
+			setter(&permissions.Endpoints)
+			continue
+		}
+
+		resource := strings.TrimPrefix(scope, "org:")
+		if contains(resourceScopes, resource) {
+			permissions.Resources = append(permissions.Resources, "org:"+resource)
+			continue
+		}
+
+		return pinata.Permissions{}, unknownScopeError(scope)
+	}
+
+	return permissions, nil
+}
+
+func unknownScopeError(scope string) error {
+	suggestions := suggestScopes(scope)
+	if len(suggestions) == 0 {
+		return exitcode.InvalidInputf("unknown scope %q, valid scopes are: %s", scope, strings.Join(KnownScopes(), ", "))
+	}
+	return exitcode.InvalidInputf("unknown scope %q, did you mean %s?", scope, strings.Join(suggestions, " or "))
+}
+
+// suggestScopes returns the known scopes closest to the given name
+func suggestScopes(scope string) []string {
+	scope = strings.ToLower(strings.TrimPrefix(scope, "org:"))
+	best := 4
+	var suggestions []string
+	for _, known := range KnownScopes() {
+		distance := levenshtein(scope, strings.ToLower(known))
+		if strings.HasPrefix(strings.ToLower(known), scope+":") {
+			distance = 1
+		}
+		switch {
+		case distance < best:
+			best = distance
+			suggestions = []string{known}
+		case distance == best:
+			suggestions = append(suggestions, known)
+		}
+	}
+	return suggestions
+}
+
+func levenshtein(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package keys
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+)
+
+func TestBuildPermissions(t *testing.T) {
+	permissions, err := BuildPermissions(false, []string{"files:read", "org:groups:write", " pinList ", "unpin", "files:read"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := pinata.Permissions{Resources: []string{"org:files:read", "org:groups:write"}}
+	want.Endpoints.Data.PinList = true
+	want.Endpoints.Pinning.Unpin = true
+	if !reflect.DeepEqual(permissions, want) {
+		t.Errorf("got %+v, want %+v", permissions, want)
+	}
+}
+
+func TestBuildPermissionsAdmin(t *testing.T) {
+	permissions, err := BuildPermissions(true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(permissions, pinata.Permissions{Admin: true}) {
+		t.Errorf("got %+v, want only admin", permissions)
+	}
+
+	_, err = BuildPermissions(true, []string{"files:read"})
+	if exitcode.For(err) != exitcode.InvalidInput {
+		t.Errorf("admin with scopes gave %v, want invalid input", err)
+	}
+}
+
+func TestBuildPermissionsUnknownScope(t *testing.T) {
+	tests := []struct {
+		scope   string
+		message string
+	}{
+		{"files:reed", `did you mean files:read?`},
+		{"org:file:write", `did you mean files:write?`},
+		{"pinlist", `did you mean pinList?`},
+		{"files", `did you mean files:read or files:write?`},
+		{"everything", `valid scopes are: files:read`},
+	}
+	for _, tt := range tests {
+		_, err := BuildPermissions(false, []string{tt.scope})
+		if err == nil {
+			t.Errorf("%q was accepted", tt.scope)
+			continue
+		}
+		if exitcode.For(err) != exitcode.InvalidInput {
+			t.Errorf("%q gave exit code %d, want invalid input", tt.scope, exitcode.For(err))
+		}
+		if !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("%q gave %q, want it to contain %q", tt.scope, err, tt.message)
+		}
+	}
+}
+
+func TestKnownScopesAreAccepted(t *testing.T) {
+	for _, scope := range KnownScopes() {
+		if _, err := BuildPermissions(false, []string{scope}); err != nil {
+			t.Errorf("%s: %v", scope, err)
+		}
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	policy, err := LoadPolicy(write("ok.json", `{"name": "ci", "max_uses": 5, "scopes": ["files:write"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy.Name != "ci" || policy.MaxUses != 5 || len(policy.Scopes) != 1 {
+		t.Errorf("got %+v", policy)
+	}
+
+	for name, content := range map[string]string{
+		"unknown-field.json": `{"name": "ci", "scope": ["files:write"]}`,
+		"negative.json":      `{"name": "ci", "max_uses": -1}`,
+		"bad-scope.json":     `{"name": "ci", "scopes": ["files:delete"]}`,
+		"admin-scopes.json":  `{"name": "ci", "admin": true, "scopes": ["files:read"]}`,
+	} {
+		if _, err := LoadPolicy(write(name, content)); exitcode.For(err) != exitcode.InvalidInput {
+			t.Errorf("%s gave %v, want invalid input", name, err)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+// Package pins queues content that's already on IPFS, such as files pinned
+// with another service, to be pinned on Pinata and follows the jobs.
+package pins
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/PinataCloud/ipfs-cli/internal/api"
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+	"github.com/PinataCloud/ipfs-cli/internal/utils"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+)
+
+// Pin queues a single CID and prints the job
+func Pin(cid string, opts pinata.PinByCIDOptions) (*pinata.PinJob, error) {
+	job, err := api.Client().PinByCID(api.Context(), cid, opts)
+	if err != nil {
+		return nil, err
+	}
+	return job, printJSON(job)
+}
+
+// PinFromFile queues every CID listed in a file, or stdin when path is -.
+// Each line holds a CID, optionally followed by a name; blank lines and lines
+// starting with # are skipped. Failed CIDs are reported and the rest are
+// still queued.
+func PinFromFile(path string, opts pinata.PinByCIDOptions) ([]pinata.PinJob, error) {
+	entries, err := readCIDs(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, exitcode.InvalidInputf("no CIDs found in %s", path)
+	}
+
+	jobs := []pinata.PinJob{}
+	failed := 0
+	for i, entry := range entries {
+		entryOpts := opts
+		if entry.name != "" {
+			entryOpts.Name = entry.name
+		}
+		job, err := api.Client().PinByCID(api.Context(), entry.cid, entryOpts)
+		if err != nil {
+			if api.Context().Err() != nil {
+				return jobs, err
+			}
+			failed++
+			fmt.Fprintf(os.Stderr, "Failed to pin %s: %v\n", entry.cid, err)
+			continue
+		}
+		jobs = append(jobs, *job)
+		fmt.Fprintf(os.Stderr, "Queued %d of %d: %s\n", i+1, len(entries), entry.cid)
+	}
+
+	if err := printJSON(jobs); err != nil {
+		return jobs, err
+	}
+	if failed > 0 {
+		return jobs, fmt.Errorf("failed to pin %d of %d CIDs", failed, len(entries))
+	}
+	return jobs, nil
+}
+
+type cidEntry struct {
+	cid  string
+	name string
+}
+
+func readCIDs(path string) ([]cidEntry, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, exitcode.AsInvalidInput(err)
+		}
+		defer file.Close()
+		r = file
+	}
+
+	var entries []cidEntry
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		cid, name, _ := strings.Cut(line, " ")
+		entries = append(entries, cidEntry{cid: cid, name: strings.TrimSpace(name)})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// ListJobs prints a page of the pin queue
+func ListJobs(amount string, pageToken string, opts pinata.ListPinJobsOptions) (*pinata.PinJobList, error) {
+	limit, err := utils.ParseLimit(amount)
+	if err != nil {
+		return nil, err
+	}
+	opts.Limit = limit
+	opts.PageToken = pageToken
+
+	list, err := api.Client().ListPinJobs(api.Context(), opts)
+	if err != nil {
+		return nil, err
+	}
+	return list, printJSON(list)
+}
+
+// WatchJobs polls the pin queue every interval and prints a line whenever a
+// job changes state, until none are pending. Only jobs seen pending are
+// watched, so jobs that had already failed or expired don't count. A watched
+// job that leaves the queue has been pinned. It returns an error if any
+// watched job failed.
+func WatchJobs(opts pinata.ListPinJobsOptions, interval time.Duration) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	seen := map[string]pinata.PinJob{}
+	failed := map[string]bool{}
+	watched := 0
+
+	for {
+		jobs, err := allJobs(opts)
+		if err != nil {
+			return err
+		}
+
+		current := map[string]bool{}
+		pending := 0
+		for _, job := range jobs {
+			previous, ok := seen[job.Id]
+			if !ok && !job.Pending() {
+				continue
+			}
+			current[job.Id] = true
+			if job.Pending() {
+				pending++
+			} else {
+				failed[job.Id] = true
+			}
+			if !ok {
+				watched++
+			}
+			if !ok || previous.Status != job.Status {
+				printChange(w, job, job.Status)
+			}
+			seen[job.Id] = job
+		}
+		for id, job := range seen {
+			if !current[id] && !failed[id] {
+				printChange(w, job, "pinned")
+				delete(seen, id)
+			}
+		}
+		w.Flush()
+
+		if pending == 0 {
+			break
+		}
+		select {
+		case <-api.Context().Done():
+			return api.Context().Err()
+		case <-time.After(interval):
+		}
+	}
+
+	switch {
+	case len(failed) > 0:
+		return fmt.Errorf("%d pin job(s) failed", len(failed))
+	case watched == 0:
+		fmt.Println("No pending pin jobs")
+	}
+	return nil
+}
+
+// allJobs lists every page of the pin queue
+func allJobs(opts pinata.ListPinJobsOptions) ([]pinata.PinJob, error) {
+	var jobs []pinata.PinJob
+	opts.PageToken = ""
+	for {
+		list, err := api.Client().ListPinJobs(api.Context(), opts)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, list.Jobs...)
+		if list.NextPageToken == "" || len(list.Jobs) == 0 {
+			return jobs, nil
+		}
+		opts.PageToken = list.NextPageToken
+	}
+}
+
+func printChange(w io.Writer, job pinata.PinJob, status string) {
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", time.Now().Format(time.TimeOnly), job.Cid, job.Name, status)
+}
+
+func printJSON(v interface{}) error {
+	formattedJSON, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return errors.New("failed to format JSON")
+	}
+	fmt.Println(string(formattedJSON))
+	return nil
+}
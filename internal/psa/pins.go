@@ -0,0 +1,611 @@
+package psa
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+
+	"github.com/google/uuid"
+)
+
+// Statuses of a pin in the Pinning Service API
+const (
+	StatusQueued  = "queued"
+	StatusPinning = "pinning"
+	StatusPinned  = "pinned"
+	StatusFailed  = "failed"
+)
+
+// requestIDKey is the keyvalue holding the request ID of pins added through
+// the bridge. It's copied from the job to the file once pinned, so the pin
+// keeps its request ID. Jobs queued some other way use their own ID, while
+// files without the keyvalue aren't pins of the bridge.
+const requestIDKey = "psa_requestid"
+
+const (
+	defaultLimit = 10
+	maxLimit     = 1000
+	// maxCIDs is how many CIDs a list request may filter by
+	maxCIDs = 10
+	// pageSize is how many files or jobs are read from Pinata at a time
+	pageSize = 1000
+)
+
+// Pin is the content to pin
+type Pin struct {
+	Cid     string            `json:"cid"`
+	Name    string            `json:"name,omitempty"`
+	Origins []string          `json:"origins,omitempty"`
+	Meta    map[string]string `json:"meta,omitempty"`
+}
+
+// PinStatus is a pin request and its progress
+type PinStatus struct {
+	RequestID string            `json:"requestid"`
+	Status    string            `json:"status"`
+	Created   string            `json:"created"`
+	Pin       Pin               `json:"pin"`
+	Delegates []string          `json:"delegates"`
+	Info      map[string]string `json:"info,omitempty"`
+
+	created time.Time
+}
+
+// PinResults is a page of pins
+type PinResults struct {
+	Count   int         `json:"count"`
+	Results []PinStatus `json:"results"`
+}
+
+// pinQuery is the filter of a list request
+type pinQuery struct {
+	cids     []string
+	name     string
+	match    string
+	statuses []string
+	before   time.Time
+	after    time.Time
+	limit    int
+	meta     map[string]string
+}
+
+func (s *Server) listPins(w http.ResponseWriter, r *http.Request) {
+	q, err := parseQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+
+	page := pinPage{limit: q.limit}
+	collect := func(pin PinStatus) bool {
+		if !q.after.IsZero() && !pin.created.After(q.after) {
+			// Pins come newest first, so the rest are older still
+			return false
+		}
+		if q.matches(pin) {
+			page.add(pin)
+		}
+		return true
+	}
+	if slices.ContainsFunc(q.statuses, func(status string) bool { return status != StatusPinned }) {
+		for _, opts := range q.jobFilters() {
+			err := s.eachJob(r.Context(), opts, func(job pinata.PinJob) bool {
+				return collect(s.jobStatus(job))
+			})
+			if err != nil {
+				writePinataError(w, err)
+				return
+			}
+		}
+	}
+	if slices.Contains(q.statuses, StatusPinned) {
+		for _, opts := range q.fileFilters() {
+			err := s.eachFile(r.Context(), opts, func(file pinata.File) bool {
+				return !tagged(file) || collect(s.fileStatus(file))
+			})
+			if err != nil {
+				writePinataError(w, err)
+				return
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, page.results())
+}
+
+// pinPage keeps the newest pins matching a list request and counts all of
+// them, since the count is the total and not the size of the page
+type pinPage struct {
+	limit int
+	count int
+	pins  []PinStatus
+}
+
+func (p *pinPage) add(pin PinStatus) {
+	p.count++
+	p.pins = append(p.pins, pin)
+	if len(p.pins) > 2*p.limit {
+		p.trim()
+	}
+}
+
+// trim sorts the pins newest first and drops those past the limit
+func (p *pinPage) trim() {
+	sort.SliceStable(p.pins, func(i, j int) bool {
+		return p.pins[i].created.After(p.pins[j].created)
+	})
+	if len(p.pins) > p.limit {
+		p.pins = p.pins[:p.limit]
+	}
+}
+
+func (p *pinPage) results() PinResults {
+	p.trim()
+	results := PinResults{Count: p.count, Results: p.pins}
+	if results.Results == nil {
+		results.Results = []PinStatus{}
+	}
+	return results
+}
+
+// jobStatuses are the job statuses behind each pin status but failed, which
+// also covers statuses the bridge doesn't know of
+var jobStatuses = map[string][]string{
+	StatusQueued:  {pinata.PinStatusPrechecking},
+	StatusPinning: {pinata.PinStatusSearching, pinata.PinStatusRetrieving},
+}
+
+// jobFilters returns the pin queue listings that cover a query, filtered by
+// CID and, unless failed pins are wanted, by job status
+func (q pinQuery) jobFilters() []pinata.ListPinJobsOptions {
+	statuses := []string{""}
+	if !slices.Contains(q.statuses, StatusFailed) {
+		statuses = nil
+		for _, status := range q.statuses {
+			statuses = append(statuses, jobStatuses[status]...)
+		}
+	}
+
+	var filters []pinata.ListPinJobsOptions
+	for _, cid := range q.cidFilters() {
+		for _, status := range statuses {
+			filters = append(filters, pinata.ListPinJobsOptions{Cid: cid, Status: status})
+		}
+	}
+	return filters
+}
+
+// fileFilters returns the file listings that cover a query
+func (q pinQuery) fileFilters() []pinata.ListFilesOptions {
+	var filters []pinata.ListFilesOptions
+	for _, cid := range q.cidFilters() {
+		filters = append(filters, pinata.ListFilesOptions{Cid: cid, KeyValues: q.meta})
+	}
+	return filters
+}
+
+// cidFilters returns the CIDs of a query, or one empty filter for any CID
+func (q pinQuery) cidFilters() []string {
+	if len(q.cids) == 0 {
+		return []string{""}
+	}
+	return q.cids
+}
+
+func (s *Server) addPin(w http.ResponseWriter, r *http.Request) {
+	pin, ok := readPin(w, r)
+	if !ok {
+		return
+	}
+	status, err := s.add(r.Context(), pin)
+	if err != nil {
+		writePinataError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, status)
+}
+
+// add queues a pin-by-CID job for a pin, or gives the name and meta of the
+// pin to the file when the CID is already pinned
+func (s *Server) add(ctx context.Context, pin Pin) (PinStatus, error) {
+	existing, err := s.client.ListFiles(ctx, pinata.ListFilesOptions{
+		Network: pinata.NetworkPublic,
+		Cid:     pin.Cid,
+		Limit:   1,
+	})
+	if err != nil {
+		return PinStatus{}, err
+	}
+	if len(existing.Files) > 0 {
+		return s.update(ctx, existing.Files[0], pin)
+	}
+
+	keyValues := map[string]string{}
+	for key, value := range pin.Meta {
+		keyValues[key] = value
+	}
+	keyValues[requestIDKey] = uuid.New().String()
+
+	job, err := s.client.PinByCID(ctx, pin.Cid, pinata.PinByCIDOptions{
+		Name:      pin.Name,
+		KeyValues: keyValues,
+		HostNodes: pin.Origins,
+	})
+	if err != nil {
+		return PinStatus{}, err
+	}
+	return s.jobStatus(*job), nil
+}
+
+// update renames a pinned file and sets its meta as a pin asks. A file the
+// bridge didn't pin is given a request ID, making it a pin from then on.
+func (s *Server) update(ctx context.Context, file pinata.File, pin Pin) (PinStatus, error) {
+	updated := &file
+	var err error
+	if pin.Name != "" && pin.Name != file.Name {
+		updated, err = s.client.UpdateFile(ctx, pinata.NetworkPublic, file.Id, pin.Name)
+		if err != nil {
+			return PinStatus{}, err
+		}
+	}
+
+	keyValues := map[string]string{}
+	for key, value := range pin.Meta {
+		keyValues[key] = value
+	}
+	if !tagged(file) {
+		keyValues[requestIDKey] = uuid.New().String()
+	}
+	if len(keyValues) > 0 {
+		updated, err = s.client.SetFileKeyValues(ctx, pinata.NetworkPublic, file.Id, keyValues)
+		if err != nil {
+			return PinStatus{}, err
+		}
+	}
+	return s.fileStatus(*updated), nil
+}
+
+func (s *Server) getPin(w http.ResponseWriter, r *http.Request) {
+	status, _, _, err := s.find(r.Context(), r.PathValue("requestid"))
+	if err != nil {
+		writePinataError(w, err)
+		return
+	}
+	if status == nil {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "the pin does not exist")
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// replacePin pins new content in place of an existing pin, which is removed
+// once the new one is queued
+func (s *Server) replacePin(w http.ResponseWriter, r *http.Request) {
+	status, job, file, err := s.find(r.Context(), r.PathValue("requestid"))
+	if err != nil {
+		writePinataError(w, err)
+		return
+	}
+	if status == nil {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "the pin does not exist")
+		return
+	}
+	pin, ok := readPin(w, r)
+	if !ok {
+		return
+	}
+
+	replacement, err := s.add(r.Context(), pin)
+	if err == nil && replacement.RequestID != status.RequestID {
+		err = s.remove(r.Context(), job, file)
+	}
+	if err != nil {
+		writePinataError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, replacement)
+}
+
+func (s *Server) removePin(w http.ResponseWriter, r *http.Request) {
+	status, job, file, err := s.find(r.Context(), r.PathValue("requestid"))
+	if err == nil && status != nil {
+		err = s.remove(r.Context(), job, file)
+	}
+	switch {
+	case err != nil:
+		writePinataError(w, err)
+	case status == nil:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "the pin does not exist")
+	default:
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// remove cancels a job or deletes a file
+func (s *Server) remove(ctx context.Context, job *pinata.PinJob, file *pinata.File) error {
+	if job != nil {
+		return s.client.CancelPinJob(ctx, job.Id)
+	}
+	return s.client.DeleteFile(ctx, pinata.NetworkPublic, file.Id)
+}
+
+// find returns the pin with a request ID and the job or file behind it, or
+// a nil status when there's none. Only jobs and files the bridge pinned are
+// found, so callers can't reach other files of the account.
+func (s *Server) find(ctx context.Context, requestID string) (*PinStatus, *pinata.PinJob, *pinata.File, error) {
+	file, err := s.taggedFile(ctx, requestID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if file == nil {
+		job, err := s.findJob(ctx, requestID)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if job != nil {
+			status := s.jobStatus(*job)
+			return &status, job, nil, nil
+		}
+		// The job may have finished while the queue was read
+		file, err = s.taggedFile(ctx, requestID)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if file == nil {
+		return nil, nil, nil, nil
+	}
+	status := s.fileStatus(*file)
+	return &status, nil, file, nil
+}
+
+// findJob returns the job in the pin queue with a request ID, or nil when
+// there's none
+func (s *Server) findJob(ctx context.Context, requestID string) (*pinata.PinJob, error) {
+	var found *pinata.PinJob
+	err := s.eachJob(ctx, pinata.ListPinJobsOptions{}, func(job pinata.PinJob) bool {
+		if s.jobStatus(job).RequestID == requestID {
+			found = &job
+		}
+		return found == nil
+	})
+	return found, err
+}
+
+// taggedFile returns the pinned file with a request ID, or nil when there's
+// none
+func (s *Server) taggedFile(ctx context.Context, requestID string) (*pinata.File, error) {
+	list, err := s.client.ListFiles(ctx, pinata.ListFilesOptions{
+		Network:   pinata.NetworkPublic,
+		KeyValues: map[string]string{requestIDKey: requestID},
+		Limit:     1,
+	})
+	if err != nil || len(list.Files) == 0 {
+		return nil, err
+	}
+	return &list.Files[0], nil
+}
+
+// tagged reports whether the bridge pinned a file
+func tagged(file pinata.File) bool {
+	_, ok := file.KeyValues[requestIDKey]
+	return ok
+}
+
+// eachJob calls fn with the jobs in the pin queue matching opts, newest
+// first, until it returns false
+func (s *Server) eachJob(ctx context.Context, opts pinata.ListPinJobsOptions, fn func(pinata.PinJob) bool) error {
+	opts.Sort = "DESC"
+	opts.Limit = pageSize
+	for {
+		list, err := s.client.ListPinJobs(ctx, opts)
+		if err != nil {
+			return err
+		}
+		for _, job := range list.Jobs {
+			if !fn(job) {
+				return nil
+			}
+		}
+		if list.NextPageToken == "" || len(list.Jobs) == 0 {
+			return nil
+		}
+		opts.PageToken = list.NextPageToken
+	}
+}
+
+// eachFile calls fn with the public files matching opts, newest first, until
+// it returns false
+func (s *Server) eachFile(ctx context.Context, opts pinata.ListFilesOptions, fn func(pinata.File) bool) error {
+	opts.Network = pinata.NetworkPublic
+	opts.Limit = pageSize
+	for {
+		list, err := s.client.ListFiles(ctx, opts)
+		if err != nil {
+			return err
+		}
+		for _, file := range list.Files {
+			if !fn(file) {
+				return nil
+			}
+		}
+		if list.NextPageToken == "" || len(list.Files) == 0 {
+			return nil
+		}
+		opts.PageToken = list.NextPageToken
+	}
+}
+
+func (s *Server) jobStatus(job pinata.PinJob) PinStatus {
+	status := StatusFailed
+	switch job.Status {
+	case pinata.PinStatusPrechecking:
+		status = StatusQueued
+	case pinata.PinStatusSearching, pinata.PinStatusRetrieving:
+		status = StatusPinning
+	}
+	pin := s.status(job.Id, job.Cid, job.Name, job.KeyValues, job.DateQueued)
+	pin.Status = status
+	pin.Pin.Origins = job.HostNodes
+	pin.Info = map[string]string{"status_details": job.Status}
+	return pin
+}
+
+func (s *Server) fileStatus(file pinata.File) PinStatus {
+	pin := s.status(file.Id, file.Cid, file.Name, file.KeyValues, file.CreatedAt)
+	pin.Status = StatusPinned
+	return pin
+}
+
+func (s *Server) status(id, cid, name string, keyValues map[string]interface{}, created string) PinStatus {
+	meta := map[string]string{}
+	requestID := id
+	for key, value := range keyValues {
+		if key == requestIDKey {
+			requestID = fmt.Sprint(value)
+			continue
+		}
+		meta[key] = fmt.Sprint(value)
+	}
+	if len(meta) == 0 {
+		meta = nil
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, created)
+	if err == nil {
+		created = createdAt.UTC().Format(time.RFC3339Nano)
+	}
+	return PinStatus{
+		RequestID: requestID,
+		Created:   created,
+		Pin:       Pin{Cid: cid, Name: name, Meta: meta},
+		Delegates: s.opts.Delegates,
+		created:   createdAt,
+	}
+}
+
+// readPin decodes and checks the pin in a request body, responding with a
+// 400 when it's invalid
+func readPin(w http.ResponseWriter, r *http.Request) (Pin, bool) {
+	var pin Pin
+	err := json.NewDecoder(r.Body).Decode(&pin)
+	switch {
+	case err != nil:
+		err = fmt.Errorf("invalid JSON body: %w", err)
+	case pin.Cid == "":
+		err = errors.New("cid is required")
+	case len(pin.Name) > 255:
+		err = errors.New("name can't be longer than 255 characters")
+	case pin.Meta[requestIDKey] != "":
+		err = fmt.Errorf("meta can't set %s, which the bridge uses", requestIDKey)
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return Pin{}, false
+	}
+	return pin, true
+}
+
+func parseQuery(r *http.Request) (pinQuery, error) {
+	values := r.URL.Query()
+	q := pinQuery{
+		name:     values.Get("name"),
+		match:    values.Get("match"),
+		statuses: []string{StatusPinned},
+		limit:    defaultLimit,
+	}
+
+	if cids := values.Get("cid"); cids != "" {
+		q.cids = strings.Split(cids, ",")
+		if len(q.cids) > maxCIDs {
+			return q, fmt.Errorf("at most %d CIDs can be listed at once", maxCIDs)
+		}
+		// Each CID is listed once, so no pin is counted twice
+		slices.Sort(q.cids)
+		q.cids = slices.Compact(q.cids)
+	}
+	switch q.match {
+	case "":
+		q.match = "exact"
+	case "exact", "iexact", "partial", "ipartial":
+	default:
+		return q, fmt.Errorf("invalid match %q: must be exact, iexact, partial or ipartial", q.match)
+	}
+	if statuses := values.Get("status"); statuses != "" {
+		q.statuses = strings.Split(statuses, ",")
+		for _, status := range q.statuses {
+			if !slices.Contains([]string{StatusQueued, StatusPinning, StatusPinned, StatusFailed}, status) {
+				return q, fmt.Errorf("invalid status %q: must be queued, pinning, pinned or failed", status)
+			}
+		}
+		slices.Sort(q.statuses)
+		q.statuses = slices.Compact(q.statuses)
+	}
+	for key, t := range map[string]*time.Time{"before": &q.before, "after": &q.after} {
+		if value := values.Get(key); value != "" {
+			parsed, err := time.Parse(time.RFC3339Nano, value)
+			if err != nil {
+				return q, fmt.Errorf("invalid %s %q: must be an RFC 3339 timestamp", key, value)
+			}
+			*t = parsed
+		}
+	}
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxLimit {
+			return q, fmt.Errorf("limit must be between 1 and %d", maxLimit)
+		}
+		q.limit = n
+	}
+	if meta := values.Get("meta"); meta != "" {
+		if err := json.Unmarshal([]byte(meta), &q.meta); err != nil {
+			return q, errors.New("meta must be a JSON object of strings")
+		}
+	}
+	return q, nil
+}
+
+// matches reports whether a pin passes the filters of a query
+func (q pinQuery) matches(pin PinStatus) bool {
+	if !slices.Contains(q.statuses, pin.Status) {
+		return false
+	}
+	if len(q.cids) > 0 && !slices.Contains(q.cids, pin.Pin.Cid) {
+		return false
+	}
+	if q.name != "" && !matchName(q.match, q.name, pin.Pin.Name) {
+		return false
+	}
+	if !q.before.IsZero() && !pin.created.Before(q.before) {
+		return false
+	}
+	if !q.after.IsZero() && !pin.created.After(q.after) {
+		return false
+	}
+	for key, value := range q.meta {
+		if pin.Pin.Meta[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+func matchName(match, want, name string) bool {
+	switch match {
+	case "iexact":
+		return strings.EqualFold(want, name)
+	case "partial":
+		return strings.Contains(name, want)
+	case "ipartial":
+		return strings.Contains(strings.ToLower(name), strings.ToLower(want))
+	}
+	return want == name
+}
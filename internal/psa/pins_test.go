@@ -0,0 +1,271 @@
+package psa
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/PinataCloud/ipfs-cli/internal/car"
+	"github.com/PinataCloud/ipfs-cli/internal/devserver"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+)
+
+const testToken = "psa-token"
+
+// testBridge is a bridge in front of a dev server
+type testBridge struct {
+	t      *testing.T
+	url    string
+	client *pinata.Client
+}
+
+func newBridge(t *testing.T) *testBridge {
+	t.Helper()
+	pinataServer := httptest.NewServer(devserver.New(devserver.Options{}).Handler())
+	t.Cleanup(pinataServer.Close)
+	client := pinata.New(pinata.StaticToken("dev"))
+	client.APIHost = pinataServer.URL
+	client.UploadsHost = pinataServer.URL
+	client.Retries = 0
+
+	bridge := httptest.NewServer(New(client, Options{Token: testToken}).Handler())
+	t.Cleanup(bridge.Close)
+	return &testBridge{t: t, url: bridge.URL, client: client}
+}
+
+// do sends a request to the bridge and decodes the JSON response into out
+// when it's given
+func (b *testBridge) do(method, path string, body interface{}, out interface{}) int {
+	b.t.Helper()
+	var reader bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reader).Encode(body); err != nil {
+			b.t.Fatal(err)
+		}
+	}
+	req, err := http.NewRequest(method, b.url+path, &reader)
+	if err != nil {
+		b.t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		b.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if out != nil && resp.StatusCode < 300 {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			b.t.Fatal(err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func (b *testBridge) add(pin Pin) PinStatus {
+	b.t.Helper()
+	var status PinStatus
+	if code := b.do(http.MethodPost, "/pins", pin, &status); code != http.StatusAccepted {
+		b.t.Fatalf("adding %s gave %d", pin.Cid, code)
+	}
+	return status
+}
+
+func (b *testBridge) list(query string) PinResults {
+	b.t.Helper()
+	var results PinResults
+	if code := b.do(http.MethodGet, "/pins"+query, nil, &results); code != http.StatusOK {
+		b.t.Fatalf("listing %s gave %d", query, code)
+	}
+	return results
+}
+
+// upload stores a file on the public network without the bridge
+func (b *testBridge) upload(name string, data []byte) *pinata.UploadResult {
+	b.t.Helper()
+	path := filepath.Join(b.t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		b.t.Fatal(err)
+	}
+	uploaded, err := b.client.Upload(context.Background(), path, pinata.UploadOptions{Network: pinata.NetworkPublic})
+	if err != nil {
+		b.t.Fatal(err)
+	}
+	return uploaded
+}
+
+var pending = []string{StatusQueued, StatusPinning}
+
+func TestAuthenticate(t *testing.T) {
+	b := newBridge(t)
+	for _, header := range []string{"", "Bearer ", "Bearer wrong", testToken} {
+		req, _ := http.NewRequest(http.MethodGet, b.url+"/pins", nil)
+		req.Header.Set("Authorization", header)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Authorization %q gave %d, want 401", header, resp.StatusCode)
+		}
+	}
+}
+
+func TestAddGetDelete(t *testing.T) {
+	b := newBridge(t)
+	cid := car.Sum(car.CodecRaw, []byte("not pinned yet")).String()
+
+	queued := b.add(Pin{Cid: cid, Name: "later", Meta: map[string]string{"app": "test"}, Origins: []string{"/ip4/127.0.0.1/tcp/4001"}})
+	if !slices.Contains(pending, queued.Status) || queued.Pin.Name != "later" || queued.Pin.Meta["app"] != "test" || len(queued.Delegates) == 0 {
+		t.Errorf("added %+v", queued)
+	}
+
+	var got PinStatus
+	if code := b.do(http.MethodGet, "/pins/"+queued.RequestID, nil, &got); code != http.StatusOK {
+		t.Fatalf("getting the pin gave %d", code)
+	}
+	if got.RequestID != queued.RequestID || got.Pin.Cid != cid || got.Pin.Origins[0] != "/ip4/127.0.0.1/tcp/4001" {
+		t.Errorf("got %+v", got)
+	}
+	if results := b.list("?status=queued,pinning"); results.Count != 1 || results.Results[0].RequestID != queued.RequestID {
+		t.Errorf("listed %+v", results)
+	}
+
+	if code := b.do(http.MethodDelete, "/pins/"+queued.RequestID, nil, nil); code != http.StatusAccepted {
+		t.Errorf("deleting the pin gave %d", code)
+	}
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		if code := b.do(method, "/pins/"+queued.RequestID, nil, nil); code != http.StatusNotFound {
+			t.Errorf("%s of a deleted pin gave %d, want 404", method, code)
+		}
+	}
+}
+
+func TestAddPinned(t *testing.T) {
+	b := newBridge(t)
+	uploaded := b.upload("a.txt", []byte("already pinned"))
+
+	// The name and meta of the pin are given to the file
+	pinned := b.add(Pin{Cid: uploaded.Cid, Name: "renamed", Meta: map[string]string{"env": "prod"}})
+	if pinned.Status != StatusPinned || pinned.Pin.Name != "renamed" || pinned.Pin.Meta["env"] != "prod" {
+		t.Errorf("added %+v", pinned)
+	}
+	file, err := b.client.GetFile(context.Background(), pinata.NetworkPublic, uploaded.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Name != "renamed" || file.KeyValues["env"] != "prod" || file.KeyValues[requestIDKey] != pinned.RequestID {
+		t.Errorf("the file is %+v", file)
+	}
+
+	// Adding it again keeps the request ID and updates the meta
+	again := b.add(Pin{Cid: uploaded.Cid, Meta: map[string]string{"env": "staging"}})
+	if again.RequestID != pinned.RequestID || again.Pin.Name != "renamed" || again.Pin.Meta["env"] != "staging" {
+		t.Errorf("added again %+v", again)
+	}
+
+	var got PinStatus
+	if code := b.do(http.MethodGet, "/pins/"+pinned.RequestID, nil, &got); code != http.StatusOK || got.Pin.Cid != uploaded.Cid {
+		t.Errorf("getting the pin gave %d %+v", code, got)
+	}
+	if code := b.do(http.MethodDelete, "/pins/"+pinned.RequestID, nil, nil); code != http.StatusAccepted {
+		t.Errorf("deleting the pin gave %d", code)
+	}
+	if _, err := b.client.GetFile(context.Background(), pinata.NetworkPublic, uploaded.Id); pinata.StatusCode(err) != http.StatusNotFound {
+		t.Errorf("the file of a deleted pin gave %v, want a 404", err)
+	}
+}
+
+func TestOtherFilesAreHidden(t *testing.T) {
+	b := newBridge(t)
+	other := b.upload("other.txt", []byte("not from the bridge"))
+
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		if code := b.do(method, "/pins/"+other.Id, nil, nil); code != http.StatusNotFound {
+			t.Errorf("%s of a file the bridge didn't pin gave %d, want 404", method, code)
+		}
+	}
+	if code := b.do(http.MethodPost, "/pins/"+other.Id, Pin{Cid: other.Cid}, nil); code != http.StatusNotFound {
+		t.Errorf("replacing a file the bridge didn't pin gave %d, want 404", code)
+	}
+	if results := b.list(""); results.Count != 0 {
+		t.Errorf("listed %+v", results)
+	}
+	if _, err := b.client.GetFile(context.Background(), pinata.NetworkPublic, other.Id); err != nil {
+		t.Errorf("the file is gone: %v", err)
+	}
+}
+
+func TestReplace(t *testing.T) {
+	b := newBridge(t)
+	queued := b.add(Pin{Cid: car.Sum(car.CodecRaw, []byte("old content")).String()})
+	uploaded := b.upload("new.txt", []byte("new content"))
+
+	var replacement PinStatus
+	if code := b.do(http.MethodPost, "/pins/"+queued.RequestID, Pin{Cid: uploaded.Cid, Name: "new"}, &replacement); code != http.StatusAccepted {
+		t.Fatalf("replacing the pin gave %d", code)
+	}
+	if replacement.Status != StatusPinned || replacement.Pin.Cid != uploaded.Cid || replacement.Pin.Name != "new" {
+		t.Errorf("replaced with %+v", replacement)
+	}
+	if code := b.do(http.MethodGet, "/pins/"+queued.RequestID, nil, nil); code != http.StatusNotFound {
+		t.Errorf("the replaced pin gave %d, want 404", code)
+	}
+	if results := b.list("?status=queued,pinning,pinned,failed"); results.Count != 1 || results.Results[0].RequestID != replacement.RequestID {
+		t.Errorf("listed %+v", results)
+	}
+
+	if code := b.do(http.MethodPost, "/pins/"+replacement.RequestID, map[string]string{"name": "no cid"}, nil); code != http.StatusBadRequest {
+		t.Errorf("replacing without a CID gave %d, want 400", code)
+	}
+}
+
+func TestListCount(t *testing.T) {
+	b := newBridge(t)
+	var cids []string
+	for _, content := range []string{"one", "two", "three"} {
+		uploaded := b.upload(content+".txt", []byte(content))
+		b.add(Pin{Cid: uploaded.Cid, Meta: map[string]string{"batch": "1"}})
+		cids = append(cids, uploaded.Cid)
+	}
+	b.add(Pin{Cid: car.Sum(car.CodecRaw, []byte("four")).String()})
+
+	tests := []struct {
+		query   string
+		count   int
+		results int
+	}{
+		{"", 3, 3},
+		// The count is the total, not the size of the page
+		{"?limit=2", 3, 2},
+		{"?cid=" + cids[0], 1, 1},
+		{"?cid=" + cids[0] + "," + cids[0], 1, 1},
+		{"?cid=" + cids[0] + "," + cids[1] + "&limit=1", 2, 1},
+		{"?meta=" + url.QueryEscape(`{"batch":"1"}`), 3, 3},
+		{"?meta=" + url.QueryEscape(`{"batch":"2"}`), 0, 0},
+		{"?name=three.txt", 1, 1},
+		{"?name=O&match=ipartial", 2, 2},
+		{"?status=queued,pinning", 1, 1},
+		{"?status=pinned,queued,pinning&limit=3", 4, 3},
+		{"?status=failed", 0, 0},
+	}
+	for _, tt := range tests {
+		results := b.list(tt.query)
+		if results.Count != tt.count || len(results.Results) != tt.results {
+			t.Errorf("%s gave a count of %d and %d results, want %d and %d", tt.query, results.Count, len(results.Results), tt.count, tt.results)
+		}
+	}
+
+	for _, query := range []string{"?limit=0", "?status=done", "?match=fuzzy", "?before=yesterday", "?meta=[]"} {
+		if code := b.do(http.MethodGet, "/pins"+query, nil, nil); code != http.StatusBadRequest {
+			t.Errorf("%s gave %d, want 400", query, code)
+		}
+	}
+}
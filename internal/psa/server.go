@@ -0,0 +1,149 @@
+// Package psa serves the IPFS Pinning Service API on top of Pinata, so tools
+// like Kubo's `ipfs pin remote` can pin to the account. Pins are public
+// files the bridge pinned, and pins in progress are pin-by-CID jobs.
+//
+// See https://ipfs.github.io/pinning-services-api-spec/ for the API.
+package psa
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+)
+
+// DefaultAddr is the address the server listens on by default
+const DefaultAddr = "127.0.0.1:5050"
+
+// DefaultDelegates are the peers callers are told to connect to so Pinata
+// can fetch the content from them
+var DefaultDelegates = []string{"/dnsaddr/bitswap.pinata.cloud"}
+
+// Options configures a Server
+type Options struct {
+	// Token is the bearer token callers must send
+	Token string
+	// Delegates are the multiaddrs reported in each pin status
+	Delegates []string
+}
+
+// Server translates Pinning Service API requests into Pinata API calls
+type Server struct {
+	client *pinata.Client
+	opts   Options
+}
+
+// New returns a server that calls Pinata with client
+func New(client *pinata.Client, opts Options) *Server {
+	if len(opts.Delegates) == 0 {
+		opts.Delegates = DefaultDelegates
+	}
+	return &Server{client: client, opts: opts}
+}
+
+// Handler returns the handler for the /pins endpoints
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /pins", s.listPins)
+	mux.HandleFunc("POST /pins", s.addPin)
+	mux.HandleFunc("GET /pins/{requestid}", s.getPin)
+	mux.HandleFunc("POST /pins/{requestid}", s.replacePin)
+	mux.HandleFunc("DELETE /pins/{requestid}", s.removePin)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", fmt.Sprintf("no endpoint for %s %s", r.Method, r.URL.Path))
+	})
+	return s.authenticate(mux)
+}
+
+// ListenAndServe serves the API on addr until ctx is done. ready is called
+// with the base URL once the server is listening.
+func (s *Server) ListenAndServe(ctx context.Context, addr string, ready func(baseURL string)) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{Handler: s.Handler()}
+	stop := context.AfterFunc(ctx, func() {
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	})
+	defer stop()
+
+	if ready != nil {
+		ready("http://" + ln.Addr().String())
+	}
+	err = srv.Serve(ln)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// NewToken returns a random bearer token for callers
+func NewToken() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// authenticate rejects requests without the bearer token
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "no access token provided")
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.opts.Token)) != 1 {
+			writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "the access token is not valid")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError responds in the {"error": {"reason", "details"}} format of the
+// Pinning Service API
+func writeError(w http.ResponseWriter, status int, reason, details string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error": map[string]string{
+			"reason":  reason,
+			"details": details,
+		},
+	})
+}
+
+// writePinataError responds to a failed Pinata call. Errors about the
+// request are passed on, while the bridge's own credentials being rejected
+// and Pinata being unreachable are reported as a bad gateway.
+func writePinataError(w http.ResponseWriter, err error) {
+	var apiErr *pinata.Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.Status {
+		case http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusTooManyRequests:
+			reason := strings.ToUpper(strings.ReplaceAll(http.StatusText(apiErr.Status), " ", "_"))
+			writeError(w, apiErr.Status, reason, apiErr.Error())
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Pinata request failed: %v\n", err)
+	writeError(w, http.StatusBadGateway, "PINATA_ERROR", err.Error())
+}
@@ -0,0 +1,284 @@
+package refs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/PinataCloud/ipfs-cli/internal/api"
+	"github.com/PinataCloud/ipfs-cli/internal/config"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+)
+
+// cacheTTL is how long listings are reused, long enough to cover the repeated
+// tabs of one command line or the commands of a short script
+const cacheTTL = time.Minute
+
+// listLimit is how many groups or files are listed per page
+const listLimit = 1000
+
+// maxPages bounds how many pages of groups or files are listed to match
+// against. Past that the listing is truncated, and names and prefixes aren't
+// resolved since they can't be known to be unique.
+const maxPages = 10
+
+// Item is one resource that a reference can resolve to
+type Item struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+// listing is the resources of a kind within a scope
+type listing struct {
+	Items []Item `json:"items"`
+	// Truncated is set when there were more than maxPages pages
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+type cacheEntry struct {
+	Fetched time.Time `json:"fetched"`
+	listing
+}
+
+// Scope returns what a listing of kind depends on: the network of groups and
+// files, or the agent that tasks, domains and attached skills belong to
+func Scope(kind Kind, network, agentID string) (string, error) {
+	switch {
+	case kind == Group || kind == File:
+		return config.GetNetworkParam(network)
+	case kind.Scoped():
+		return agentID, nil
+	}
+	return "", nil
+}
+
+// List returns the resources of a kind within a scope, from the cache when
+// they were listed in the last minute. Groups and files may be truncated to
+// the first maxPages pages.
+func List(ctx context.Context, kind Kind, scope string) ([]Item, error) {
+	l, err := list(ctx, kind, scope)
+	return l.Items, err
+}
+
+// Refresh lists the resources of a kind from the API and caches them
+func Refresh(ctx context.Context, kind Kind, scope string) ([]Item, error) {
+	l, err := refresh(ctx, kind, scope)
+	return l.Items, err
+}
+
+func list(ctx context.Context, kind Kind, scope string) (listing, error) {
+	if l, ok := readCache(cachePath(ctx, kind, scope)); ok {
+		return l, nil
+	}
+	return refresh(ctx, kind, scope)
+}
+
+func refresh(ctx context.Context, kind Kind, scope string) (listing, error) {
+	l, err := fetch(ctx, kind, scope)
+	if err != nil {
+		return listing{}, err
+	}
+	writeCache(cachePath(ctx, kind, scope), l)
+	return l, nil
+}
+
+func fetch(ctx context.Context, kind Kind, scope string) (listing, error) {
+	client := api.Client()
+	switch kind {
+	case Group:
+		return fetchPages(func(pageToken string) ([]Item, string, error) {
+			list, err := client.ListGroups(ctx, pinata.ListGroupsOptions{Network: scope, Limit: listLimit, PageToken: pageToken})
+			if err != nil {
+				return nil, "", err
+			}
+			var items []Item
+			for _, g := range list.Groups {
+				items = append(items, Item{ID: g.Id, Name: g.Name})
+			}
+			return items, list.NextPageToken, nil
+		})
+	case File:
+		return fetchPages(func(pageToken string) ([]Item, string, error) {
+			list, err := client.ListFiles(ctx, pinata.ListFilesOptions{Network: scope, Limit: listLimit, PageToken: pageToken})
+			if err != nil {
+				return nil, "", err
+			}
+			var items []Item
+			for _, f := range list.Files {
+				items = append(items, Item{ID: f.Id, Name: f.Name})
+			}
+			return items, list.NextPageToken, nil
+		})
+	}
+
+	items, err := fetchAll(ctx, client, kind, scope)
+	if err != nil {
+		return listing{}, err
+	}
+	return listing{Items: items}, nil
+}
+
+// fetchPages lists up to maxPages pages, with page listing the one after
+// pageToken and returning the token of the next
+func fetchPages(page func(pageToken string) ([]Item, string, error)) (listing, error) {
+	var l listing
+	pageToken := ""
+	for range maxPages {
+		items, next, err := page(pageToken)
+		if err != nil {
+			return listing{}, err
+		}
+		l.Items = append(l.Items, items...)
+		if next == "" || len(items) == 0 {
+			return l, nil
+		}
+		pageToken = next
+	}
+	l.Truncated = true
+	return l, nil
+}
+
+// fetchAll lists the kinds whose endpoints return everything at once
+func fetchAll(ctx context.Context, client *pinata.Client, kind Kind, scope string) ([]Item, error) {
+	var items []Item
+	switch kind {
+	case Agent:
+		list, err := client.ListAgents(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range list {
+			items = append(items, Item{ID: a.AgentID, Name: a.Name})
+		}
+	case Secret:
+		list, err := client.ListSecrets(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range list {
+			items = append(items, Item{ID: s.ID, Name: s.Name})
+		}
+	case Skill:
+		list, err := client.ListSkills(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range list {
+			items = append(items, Item{ID: s.SkillCid, Name: s.Name})
+		}
+	case AttachedSkill:
+		agent, err := client.GetAgent(ctx, scope)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range agent.Skills {
+			items = append(items, Item{ID: s.SkillID, Name: s.Name})
+		}
+	case Task:
+		list, err := client.ListTasks(ctx, scope, true)
+		if err != nil {
+			return nil, err
+		}
+		items = taskItems(list)
+	case Domain:
+		list, err := client.ListDomains(ctx, scope)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range list.Domains {
+			items = append(items, Item{ID: d.ID, Name: domainName(d)})
+		}
+	}
+	return items, nil
+}
+
+// taskItems picks the jobs out of a task listing, whose shape isn't typed:
+// either an array of jobs or an object holding one under "jobs" or "tasks"
+func taskItems(list interface{}) []Item {
+	if object, ok := list.(map[string]interface{}); ok {
+		list = object["jobs"]
+		if list == nil {
+			list = object["tasks"]
+		}
+	}
+	jobs, _ := list.([]interface{})
+
+	var items []Item
+	for _, job := range jobs {
+		fields, _ := job.(map[string]interface{})
+		id, _ := fields["id"].(string)
+		if id == "" {
+			id, _ = fields["jobId"].(string)
+		}
+		name, _ := fields["name"].(string)
+		if id != "" {
+			items = append(items, Item{ID: id, Name: name})
+		}
+	}
+	return items
+}
+
+func domainName(d pinata.CustomDomain) string {
+	if d.CustomDomain != nil {
+		return *d.CustomDomain
+	}
+	if d.Subdomain != nil {
+		return *d.Subdomain
+	}
+	return ""
+}
+
+// cachePath returns where a listing is cached. The key covers the profile,
+// hosts and JWT, so switching any of them, such as setting PINATA_JWT to
+// another account's, never matches stale IDs. The JWT is only hashed.
+func cachePath(ctx context.Context, kind Kind, scope string) string {
+	dir, err := config.Dir()
+	if err != nil {
+		return ""
+	}
+	profile, _, _ := config.ActiveProfile()
+	client := api.Client()
+	jwt, err := client.Tokens.Token(ctx)
+	if err != nil {
+		return ""
+	}
+	key := strings.Join([]string{profile, client.APIHost, client.AgentsHost, jwt, string(kind), scope}, "\n")
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(dir, "cache", "refs", hex.EncodeToString(sum[:8])+".json")
+}
+
+func readCache(path string) (listing, bool) {
+	if path == "" {
+		return listing{}, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return listing{}, false
+	}
+	var entry cacheEntry
+	if json.Unmarshal(data, &entry) != nil || time.Since(entry.Fetched) > cacheTTL {
+		return listing{}, false
+	}
+	return entry.listing, true
+}
+
+// writeCache saves a listing. Failing to is harmless, the next lookup lists
+// again.
+func writeCache(path string, l listing) {
+	if path == "" {
+		return
+	}
+	data, err := json.Marshal(cacheEntry{Fetched: time.Now(), listing: l})
+	if err != nil {
+		return
+	}
+	if os.MkdirAll(filepath.Dir(path), 0o700) != nil {
+		return
+	}
+	os.WriteFile(path, data, 0o600)
+}
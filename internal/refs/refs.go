@@ -0,0 +1,311 @@
+// Package refs lets commands take a name or a unique prefix wherever they
+// take an ID. Which arguments are references is declared per command with
+// Install, and completion reads the same declarations. A reference may name
+// its kind, as in group:releases or secret:OPENAI_KEY.
+package refs
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/PinataCloud/ipfs-cli/internal/api"
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+
+	"github.com/google/uuid"
+	"github.com/urfave/cli/v2"
+)
+
+// Kind is a type of resource that can be referred to
+type Kind string
+
+const (
+	Agent  Kind = "agent"
+	Group  Kind = "group"
+	File   Kind = "file"
+	Secret Kind = "secret"
+	Skill  Kind = "skill"
+	// AttachedSkill is a skill attached to an agent, referred to by its
+	// skill ID rather than its CID
+	AttachedSkill Kind = "attached skill"
+	Task          Kind = "task"
+	Domain        Kind = "domain"
+)
+
+// Flags maps the names of flags that take a reference to their kind
+var Flags = map[string]Kind{
+	"group": Group,
+}
+
+// Scoped reports whether resources of the kind belong to an agent, the
+// command's first argument
+func (k Kind) Scoped() bool {
+	return k == AttachedSkill || k == Task || k == Domain
+}
+
+// prefix is how a reference names the kind
+func (k Kind) prefix() string {
+	if k == AttachedSkill {
+		return string(Skill)
+	}
+	return string(k)
+}
+
+// Arg is what a positional argument of a command refers to
+type Arg struct {
+	Kind Kind
+	// Repeats is set for the last argument when it covers every argument
+	// from there on
+	Repeats bool
+}
+
+// Commands declares the positional arguments of commands, by their full name
+// such as "agents tasks get". Arguments that aren't references have an empty
+// Kind, and trailing ones can be left out.
+type Commands map[string][]Arg
+
+// declared holds the arguments of each command passed to Install
+var declared = map[*cli.Command][]Arg{}
+
+// Declared returns the arguments declared for a command
+func Declared(command *cli.Command) []Arg {
+	return declared[command]
+}
+
+// At returns what the argument at position refers to
+func At(args []Arg, position int) Kind {
+	switch {
+	case position < len(args):
+		return args[position].Kind
+	case len(args) > 0 && args[len(args)-1].Repeats:
+		return args[len(args)-1].Kind
+	}
+	return ""
+}
+
+// Resolve returns the ID a reference stands for. A reference is an ID, a
+// name or a unique prefix of either, optionally written as kind:reference.
+// Bare references that match nothing are returned as they are, since they
+// may be IDs the listing doesn't cover.
+func Resolve(ctx context.Context, kind Kind, ref, scope string) (string, error) {
+	value, explicit := strings.CutPrefix(ref, kind.prefix()+":")
+	if !explicit {
+		if other, _, ok := strings.Cut(ref, ":"); ok && isPrefix(other) {
+			return "", exitcode.InvalidInputf("%q refers to a %s, expected a %s", ref, other, kind)
+		}
+		if isID(kind, ref) {
+			return ref, nil
+		}
+	}
+	if value == "" {
+		return "", exitcode.InvalidInputf("%q is missing the %s name", ref, kind)
+	}
+
+	l, err := list(ctx, kind, scope)
+	if err == nil {
+		id, found, err := match(kind, l, value)
+		if err != nil || found {
+			return id, err
+		}
+		// The resource may be newer than the cache
+		l, err = refresh(ctx, kind, scope)
+	}
+	if err != nil {
+		if !explicit {
+			return ref, nil
+		}
+		return "", fmt.Errorf("failed to look up %s %q: %w", kind, value, err)
+	}
+
+	id, found, err := match(kind, l, value)
+	switch {
+	case err != nil || found:
+		return id, err
+	case explicit && l.Truncated:
+		return "", exitcode.NotFoundf("no %s among the first %d is named %q or has an ID starting with it, use the full ID", kind, len(l.Items), value)
+	case explicit:
+		return "", exitcode.NotFoundf("no %s is named %q or has an ID starting with it", kind, value)
+	}
+	return ref, nil
+}
+
+// match finds the item a value refers to: the one with that ID, else the
+// one with that name, else the one whose ID or name starts with it. Names and
+// prefixes aren't matched in a truncated listing, since another match may be
+// past its end.
+func match(kind Kind, l listing, value string) (string, bool, error) {
+	var named, prefixed []Item
+	for _, it := range l.Items {
+		switch {
+		case it.ID == value:
+			return it.ID, true, nil
+		case it.Name == value:
+			named = append(named, it)
+		case strings.HasPrefix(it.ID, value) || strings.HasPrefix(it.Name, value):
+			prefixed = append(prefixed, it)
+		}
+	}
+	candidates := named
+	if len(candidates) == 0 {
+		candidates = prefixed
+	}
+	switch {
+	case len(candidates) == 0:
+		return "", false, nil
+	case l.Truncated:
+		return "", false, exitcode.InvalidInputf("there are more than %d %ss, so %q can't be resolved by name or prefix. Use the full ID", len(l.Items), kind, value)
+	case len(candidates) == 1:
+		return candidates[0].ID, true, nil
+	}
+
+	var names []string
+	for _, it := range candidates {
+		names = append(names, fmt.Sprintf("%s (%s)", it.ID, it.Name))
+	}
+	return "", false, exitcode.InvalidInputf("%q matches %d %ss: %s. Use a longer prefix or the full ID",
+		value, len(candidates), kind, strings.Join(names, ", "))
+}
+
+// isPrefix reports whether s names a kind, as the prefix of a reference
+func isPrefix(s string) bool {
+	for _, kind := range []Kind{Agent, Group, File, Secret, Skill, Task, Domain} {
+		if s == kind.prefix() {
+			return true
+		}
+	}
+	return false
+}
+
+// resolvable reports whether the kind is resolved from a listing. Other
+// kinds can be declared for completion alone.
+func (k Kind) resolvable() bool {
+	return k == AttachedSkill || isPrefix(string(k))
+}
+
+// isID reports whether a bare reference is already a full ID, so it can be
+// used without listing anything
+func isID(kind Kind, ref string) bool {
+	if kind == Skill {
+		return strings.HasPrefix(ref, "baf") && len(ref) > 50 || strings.HasPrefix(ref, "Qm") && len(ref) == 46
+	}
+	_, err := uuid.Parse(ref)
+	return err == nil && len(ref) == 36
+}
+
+type argsKey struct{}
+
+// Install resolves the references among the declared arguments and the flags
+// of every command before its action runs. Actions read the resolved
+// arguments with Args. It panics when a declared command doesn't exist.
+func Install(commands []*cli.Command, args Commands) {
+	remaining := maps.Clone(args)
+	installAll(commands, "", remaining)
+	if len(remaining) > 0 {
+		panic(fmt.Sprintf("refs: arguments declared for unknown commands: %s", strings.Join(slices.Sorted(maps.Keys(remaining)), ", ")))
+	}
+}
+
+func installAll(commands []*cli.Command, parent string, args Commands) {
+	for _, command := range commands {
+		name := parent + command.Name
+		if declaredArgs, ok := args[name]; ok {
+			declared[command] = declaredArgs
+			delete(args, name)
+		}
+		install(command)
+		installAll(command.Subcommands, name+" ", args)
+	}
+}
+
+func install(command *cli.Command) {
+	args := declared[command]
+	var flags []string
+	for _, flag := range command.Flags {
+		if Flags[flag.Names()[0]] != "" {
+			flags = append(flags, flag.Names()[0])
+		}
+	}
+	if command.Action == nil || (len(args) == 0 && len(flags) == 0) {
+		return
+	}
+
+	action := command.Action
+	command.Action = func(ctx *cli.Context) error {
+		resolved := ctx.Args().Slice()
+		for i, ref := range resolved {
+			kind := At(args, i)
+			if !kind.resolvable() || ref == "" {
+				continue
+			}
+			id, err := resolve(ctx, kind, ref, resolved[0])
+			if err != nil {
+				return err
+			}
+			resolved[i] = id
+		}
+		for _, name := range flags {
+			if ref := ctx.String(name); ref != "" {
+				id, err := resolve(ctx, Flags[name], ref, "")
+				if err != nil {
+					return err
+				}
+				if err := ctx.Set(name, id); err != nil {
+					return err
+				}
+			}
+		}
+		ctx.Context = context.WithValue(ctx.Context, argsKey{}, Args(resolved))
+		return action(ctx)
+	}
+}
+
+func resolve(ctx *cli.Context, kind Kind, ref, agentID string) (string, error) {
+	scope, err := Scope(kind, ctx.String("network"), agentID)
+	if err != nil {
+		return "", err
+	}
+	return Resolve(api.Context(), kind, ref, scope)
+}
+
+// Args is a command's arguments with references resolved to IDs
+type Args []string
+
+// ArgsOf returns the arguments of a command with its references resolved
+func ArgsOf(ctx *cli.Context) cli.Args {
+	if args, ok := ctx.Context.Value(argsKey{}).(Args); ok {
+		return &args
+	}
+	return ctx.Args()
+}
+
+func (a *Args) Get(n int) string {
+	if n < len(*a) {
+		return (*a)[n]
+	}
+	return ""
+}
+
+func (a *Args) First() string {
+	return a.Get(0)
+}
+
+func (a *Args) Tail() []string {
+	if len(*a) < 2 {
+		return []string{}
+	}
+	return append([]string{}, (*a)[1:]...)
+}
+
+func (a *Args) Len() int {
+	return len(*a)
+}
+
+func (a *Args) Present() bool {
+	return len(*a) != 0
+}
+
+func (a *Args) Slice() []string {
+	return append([]string{}, *a...)
+}
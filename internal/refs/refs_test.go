@@ -0,0 +1,216 @@
+package refs
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/PinataCloud/ipfs-cli/internal/api"
+	"github.com/PinataCloud/ipfs-cli/internal/devserver"
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+
+	"github.com/urfave/cli/v2"
+)
+
+var testItems = []Item{
+	{ID: "0f3a9c1e-aaaa-4000-8000-000000000001", Name: "releases"},
+	{ID: "0f3a9c1e-bbbb-4000-8000-000000000002", Name: "release-candidates"},
+	{ID: "7d21e4b0-cccc-4000-8000-000000000003", Name: "backups"},
+	{ID: "9e88aa02-dddd-4000-8000-000000000004", Name: "backups"},
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+		found bool
+		err   bool
+	}{
+		{value: "7d21e4b0-cccc-4000-8000-000000000003", want: "7d21e4b0-cccc-4000-8000-000000000003", found: true},
+		// An exact name wins over longer names it's a prefix of
+		{value: "releases", want: "0f3a9c1e-aaaa-4000-8000-000000000001", found: true},
+		{value: "release-c", want: "0f3a9c1e-bbbb-4000-8000-000000000002", found: true},
+		{value: "9e88", want: "9e88aa02-dddd-4000-8000-000000000004", found: true},
+		{value: "release", err: true},
+		{value: "0f3a", err: true},
+		{value: "backups", err: true},
+		{value: "nothing"},
+	}
+	for _, tt := range tests {
+		id, found, err := match(Group, listing{Items: testItems}, tt.value)
+		if tt.err {
+			if exitcode.For(err) != exitcode.InvalidInput {
+				t.Errorf("match(%q) gave %v, want an ambiguous match", tt.value, err)
+			}
+			continue
+		}
+		if err != nil || id != tt.want || found != tt.found {
+			t.Errorf("match(%q) = %q, %v, %v, want %q, %v", tt.value, id, found, err, tt.want, tt.found)
+		}
+	}
+}
+
+func TestMatchTruncated(t *testing.T) {
+	l := listing{Items: testItems, Truncated: true}
+
+	// A full ID is still found
+	id, found, err := match(Group, l, testItems[2].ID)
+	if err != nil || !found || id != testItems[2].ID {
+		t.Errorf("got %q, %v, %v, want the exact ID", id, found, err)
+	}
+
+	// Names and prefixes may match something past the end of the listing
+	for _, value := range []string{"releases", "release-c", "9e88"} {
+		_, found, err := match(Group, l, value)
+		if found || exitcode.For(err) != exitcode.InvalidInput || !strings.Contains(err.Error(), "Use the full ID") {
+			t.Errorf("match(%q) gave %v, %v, want a refusal", value, found, err)
+		}
+	}
+
+	_, found, err = match(Group, l, "nothing")
+	if found || err != nil {
+		t.Errorf("got %v, %v, want no match", found, err)
+	}
+}
+
+func TestFetchPages(t *testing.T) {
+	pages := func(count int) func(string) ([]Item, string, error) {
+		return func(pageToken string) ([]Item, string, error) {
+			page := len(pageToken)
+			next := ""
+			if page+1 < count {
+				next = strings.Repeat("x", page+1)
+			}
+			return []Item{{ID: next}}, next, nil
+		}
+	}
+
+	l, err := fetchPages(pages(3))
+	if err != nil || len(l.Items) != 3 || l.Truncated {
+		t.Errorf("3 pages gave %d items, truncated %v, %v", len(l.Items), l.Truncated, err)
+	}
+	l, err = fetchPages(pages(maxPages))
+	if err != nil || len(l.Items) != maxPages || l.Truncated {
+		t.Errorf("%d pages gave %d items, truncated %v, %v", maxPages, len(l.Items), l.Truncated, err)
+	}
+	l, err = fetchPages(pages(maxPages + 1))
+	if err != nil || len(l.Items) != maxPages || !l.Truncated {
+		t.Errorf("%d pages gave %d items, truncated %v, %v", maxPages+1, len(l.Items), l.Truncated, err)
+	}
+}
+
+func TestAt(t *testing.T) {
+	args := []Arg{{Kind: Agent}, {}, {Kind: Task, Repeats: true}}
+	for position, want := range []Kind{Agent, "", Task, Task, Task} {
+		if got := At(args, position); got != want {
+			t.Errorf("At(%d) = %q, want %q", position, got, want)
+		}
+	}
+	if got := At([]Arg{{Kind: Group}}, 1); got != "" {
+		t.Errorf("At past the end = %q, want none", got)
+	}
+}
+
+func TestInstallUnknownCommand(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(r.(string), "groups nope") {
+			t.Errorf("got panic %v, want one naming the command", r)
+		}
+	}()
+	commands := []*cli.Command{{Name: "groups", Subcommands: []*cli.Command{{Name: "get"}}}}
+	Install(commands, Commands{"groups get": {{Kind: Group}}, "groups nope": {{Kind: Group}}})
+}
+
+// useDevServer points the API client at a fresh dev server and the config at
+// an empty directory
+func useDevServer(t *testing.T) {
+	t.Helper()
+	server := httptest.NewServer(devserver.New(devserver.Options{}).Handler())
+	t.Cleanup(server.Close)
+	t.Setenv("PINATA_CONFIG_DIR", t.TempDir())
+	t.Setenv("PINATA_JWT", "dev")
+
+	client := api.Client()
+	hosts := []string{client.APIHost, client.UploadsHost, client.AgentsHost}
+	client.APIHost, client.UploadsHost, client.AgentsHost = server.URL, server.URL, server.URL
+	t.Cleanup(func() {
+		client.APIHost, client.UploadsHost, client.AgentsHost = hosts[0], hosts[1], hosts[2]
+	})
+}
+
+func TestResolve(t *testing.T) {
+	useDevServer(t)
+	ctx := context.Background()
+	client := api.Client()
+
+	releases, err := client.CreateGroup(ctx, "public", "releases")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CreateGroup(ctx, "public", "release-candidates"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ref := range []string{releases.Id, "releases", "group:releases", "group:" + releases.Id[:8]} {
+		id, err := Resolve(ctx, Group, ref, "public")
+		if err != nil || id != releases.Id {
+			t.Errorf("Resolve(%q) = %q, %v, want %s", ref, id, err, releases.Id)
+		}
+	}
+
+	// A group made after the listing was cached is found by refreshing it
+	later, err := client.CreateGroup(ctx, "public", "later")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, err := Resolve(ctx, Group, "later", "public"); err != nil || id != later.Id {
+		t.Errorf("Resolve(later) = %q, %v, want %s", id, err, later.Id)
+	}
+
+	if _, err := Resolve(ctx, Group, "release", "public"); exitcode.For(err) != exitcode.InvalidInput {
+		t.Errorf("an ambiguous prefix gave %v, want invalid input", err)
+	}
+	if _, err := Resolve(ctx, Group, "group:missing", "public"); exitcode.For(err) != exitcode.NotFound {
+		t.Errorf("an unknown explicit name gave %v, want not found", err)
+	}
+	if _, err := Resolve(ctx, Group, "secret:releases", "public"); exitcode.For(err) != exitcode.InvalidInput {
+		t.Errorf("the wrong kind gave %v, want invalid input", err)
+	}
+	if _, err := Resolve(ctx, Group, "group:", "public"); exitcode.For(err) != exitcode.InvalidInput {
+		t.Errorf("a missing name gave %v, want invalid input", err)
+	}
+	// Bare references that match nothing may be IDs the listing doesn't cover
+	if id, err := Resolve(ctx, Group, "missing", "public"); err != nil || id != "missing" {
+		t.Errorf("Resolve(missing) = %q, %v, want it passed through", id, err)
+	}
+}
+
+func TestCacheIsPerJWT(t *testing.T) {
+	useDevServer(t)
+	ctx := context.Background()
+	writeCache(cachePath(ctx, Group, "public"), listing{Items: testItems})
+	if items, err := List(ctx, Group, "public"); err != nil || len(items) != len(testItems) {
+		t.Fatalf("got %v, %v, want the cached listing", items, err)
+	}
+
+	// Another account lists its own groups, of which the dev server has none
+	t.Setenv("PINATA_JWT", "another-account")
+	if items, err := List(ctx, Group, "public"); err != nil || len(items) != 0 {
+		t.Errorf("another JWT got %v, %v, want no groups", items, err)
+	}
+	if path := cachePath(ctx, Group, "public"); path == "" || strings.Contains(path, "another-account") {
+		t.Errorf("cached at %q", path)
+	}
+
+	t.Setenv("PINATA_JWT", "dev")
+	if items, err := List(ctx, Group, "public"); err != nil || len(items) != len(testItems) {
+		t.Errorf("got %v, %v, want the first JWT's listing", items, err)
+	}
+
+	// Without a JWT nothing is cached
+	t.Setenv("PINATA_JWT", "")
+	if path := cachePath(ctx, Group, "public"); path != "" {
+		t.Errorf("cached at %q without a JWT", path)
+	}
+}
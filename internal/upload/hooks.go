@@ -0,0 +1,162 @@
+package uploads
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/PinataCloud/ipfs-cli/internal/api"
+	"github.com/PinataCloud/ipfs-cli/internal/config"
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+)
+
+// Hooks run after each successful upload. Both may hold placeholders such
+// as {cid}, filled from the upload.
+type Hooks struct {
+	// OnSuccess is a shell command, which gets the upload response as JSON
+	// on stdin
+	OnSuccess string
+	// Webhook is a URL the upload response is POSTed to as JSON
+	Webhook string
+}
+
+// hooks apply to every upload
+var hooks Hooks
+
+// SetHooks sets the hooks run after each upload
+func SetHooks(h Hooks) error {
+	if h.Webhook != "" {
+		if err := ValidateWebhook(h.Webhook); err != nil {
+			return err
+		}
+	}
+	hooks = h
+	return nil
+}
+
+// ValidateWebhook checks that a webhook is an http or https URL once its
+// placeholders are filled
+func ValidateWebhook(webhook string) error {
+	u, err := url.Parse(expandHook(webhook, &pinata.UploadResult{}, "", url.QueryEscape))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return exitcode.InvalidInputf("invalid --webhook %q: must be an http or https URL", webhook)
+	}
+	return nil
+}
+
+// runHooks runs the hooks for an upload of path to network. Both run even if
+// one fails.
+func runHooks(result *pinata.UploadResult, path string, network string) error {
+	if hooks.OnSuccess == "" && hooks.Webhook == "" {
+		return nil
+	}
+	// Only some responses say which network the file is on
+	if result.Network == "" {
+		if networkParam, err := config.GetNetworkParam(network); err == nil {
+			withNetwork := *result
+			withNetwork.Network = networkParam
+			result = &withNetwork
+		}
+	}
+	response, err := json.Marshal(pinata.UploadResponse{Data: *result})
+	if err != nil {
+		return err
+	}
+
+	var failures []string
+	if hooks.OnSuccess != "" {
+		command, env := hookCommand(hooks.OnSuccess, result, path, runtime.GOOS)
+		if err := runCommand(command, env, response); err != nil {
+			failures = append(failures, fmt.Sprintf("the --on-success command failed: %v", err))
+		}
+	}
+	if hooks.Webhook != "" {
+		err := api.Do(api.Context(), &api.Request{
+			Method: http.MethodPost,
+			URL:    expandHook(hooks.Webhook, result, path, url.QueryEscape),
+			Body:   response,
+			NoAuth: true,
+		}, nil)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("the --webhook failed: %v", err))
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("uploaded %s, but %s", result.Cid, strings.Join(failures, ", and "))
+	}
+	return nil
+}
+
+// runCommand runs a command with the shell, adding env to its environment
+// and sending its output to stderr so stdout only holds the CLI's results
+func runCommand(command string, env []string, stdin []byte) error {
+	cmd := shellCommand(api.Context(), command)
+	if env != nil {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// hookFields returns the placeholders of hooks and their values for an
+// upload
+func hookFields(result *pinata.UploadResult, path string) [][2]string {
+	groupId := ""
+	if result.GroupId != nil {
+		groupId = *result.GroupId
+	}
+	return [][2]string{
+		{"cid", result.Cid},
+		{"id", result.Id},
+		{"name", result.Name},
+		{"size", strconv.Itoa(result.Size)},
+		{"mime_type", result.MimeType},
+		{"network", result.Network},
+		{"group_id", groupId},
+		{"path", path},
+	}
+}
+
+// expandHook fills the placeholders of a hook with the fields of an upload,
+// each passed through escape
+func expandHook(template string, result *pinata.UploadResult, path string, escape func(string) string) string {
+	replacements := []string{}
+	for _, field := range hookFields(result, path) {
+		replacements = append(replacements, "{"+field[0]+"}", escape(field[1]))
+	}
+	return strings.NewReplacer(replacements...).Replace(template)
+}
+
+// hookCommand fills the placeholders of an --on-success command for the
+// shell of goos, returning the command and any variables to add to its
+// environment. sh gets each value quoted. cmd can't quote a value safely,
+// as it expands %VAR% even inside quotes, so each placeholder becomes a
+// PINATA_HOOK_ variable that cmd's delayed expansion fills in once the
+// command has been parsed.
+func hookCommand(template string, result *pinata.UploadResult, path string, goos string) (string, []string) {
+	if goos != "windows" {
+		return expandHook(template, result, path, shellQuote), nil
+	}
+	var replacements, env []string
+	for _, field := range hookFields(result, path) {
+		variable := "PINATA_HOOK_" + strings.ToUpper(field[0])
+		replacements = append(replacements, "{"+field[0]+"}", `"!`+variable+`!"`)
+		// A quote in the value would end the quotes around it
+		env = append(env, variable+"="+strings.ReplaceAll(field[1], `"`, ""))
+	}
+	return strings.NewReplacer(replacements...).Replace(template), env
+}
+
+// shellQuote quotes a value so sh passes it to the command as is
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
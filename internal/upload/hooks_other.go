@@ -0,0 +1,13 @@
+//go:build !windows
+
+package uploads
+
+import (
+	"context"
+	"os/exec"
+)
+
+// shellCommand runs a command with sh
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
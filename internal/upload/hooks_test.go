@@ -0,0 +1,118 @@
+package uploads
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+)
+
+// hostileValues are names and paths that would run something if they
+// reached the shell unquoted
+var hostileValues = []string{
+	"report.txt",
+	"it's here",
+	"'; touch pwned; '",
+	"$(touch pwned)",
+	"`touch pwned`",
+	"${HOME}",
+	"line one\nline two",
+	"a & b | c > d",
+	"%PATH%",
+	"!PATH!",
+	"^&",
+	`say "hi"`,
+	"",
+}
+
+func TestExpandHook(t *testing.T) {
+	group := "9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d"
+	result := &pinata.UploadResult{
+		Id:       "e59d6704-093c-454c-9774-86afc5bec00b",
+		Name:     "a b.txt",
+		Cid:      "bafkreieb3nt3njlqfonwr4abn4dbyqe36p5rnudc7scu2g2cjo2otqumky",
+		Size:     1234,
+		MimeType: "text/plain",
+		Network:  pinata.NetworkPublic,
+		GroupId:  &group,
+	}
+	got := expandHook("{cid} {id} {name} {size} {mime_type} {network} {group_id} {path} {unknown}", result, "/tmp/a b.txt", url.QueryEscape)
+	want := result.Cid + " " + result.Id + " a+b.txt 1234 text%2Fplain public " + group + " %2Ftmp%2Fa+b.txt {unknown}"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// A value holding a placeholder isn't filled in again
+	result.Name = "{cid}"
+	if got := expandHook("{name}", result, "", func(s string) string { return s }); got != "{cid}" {
+		t.Errorf("got %q, want the name as is", got)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain", `'plain'`},
+		{"", `''`},
+		{"it's", `'it'\''s'`},
+		{"$(id)", `'$(id)'`},
+		{"`id`", "'`id`'"},
+		{"a\nb", "'a\nb'"},
+	}
+	for _, tt := range tests {
+		if got := shellQuote(tt.in); got != tt.want {
+			t.Errorf("shellQuote(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestHookCommandSh(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sh isn't the shell of hooks on Windows")
+	}
+	t.Chdir(t.TempDir())
+
+	for _, value := range hostileValues {
+		command, env := hookCommand(`printf '%s|%s' {name} {path}`, &pinata.UploadResult{Name: value}, value, runtime.GOOS)
+		if env != nil {
+			t.Errorf("sh was given variables %v", env)
+		}
+		var out bytes.Buffer
+		cmd := shellCommand(context.Background(), command)
+		cmd.Stdout = &out
+		if err := cmd.Run(); err != nil {
+			t.Errorf("%q: %v", value, err)
+			continue
+		}
+		if want := value + "|" + value; out.String() != want {
+			t.Errorf("%q reached the command as %q", value, out.String())
+		}
+	}
+	if _, err := os.Stat("pwned"); err == nil {
+		t.Error("a value ran a command")
+	}
+}
+
+func TestHookCommandCmd(t *testing.T) {
+	for _, value := range hostileValues {
+		command, env := hookCommand(`echo {name} & type {path}`, &pinata.UploadResult{Name: value, Cid: "bafy"}, value, "windows")
+		if want := `echo "!PINATA_HOOK_NAME!" & type "!PINATA_HOOK_PATH!"`; command != want {
+			t.Errorf("%q gave the command %q, want %q", value, command, want)
+		}
+		// Values only reach cmd in variables, without quotes that would end
+		// the quotes around them
+		stripped := strings.ReplaceAll(value, `"`, "")
+		for _, variable := range []string{"PINATA_HOOK_NAME=" + stripped, "PINATA_HOOK_PATH=" + stripped, "PINATA_HOOK_CID=bafy"} {
+			if !slices.Contains(env, variable) {
+				t.Errorf("%q gave the variables %q, want %q", value, env, variable)
+			}
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package uploads
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+)
+
+// shellCommand runs a command with cmd. The command line is written out as
+// cmd reads it, since the quoting Go gives arguments isn't what cmd expects.
+// /V:ON turns on the delayed expansion of the PINATA_HOOK_ variables, and /S
+// keeps the quotes of the command as they are.
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "cmd")
+	cmd.SysProcAttr = &syscall.SysProcAttr{CmdLine: `cmd /V:ON /S /C "` + command + `"`}
+	return cmd
+}
@@ -0,0 +1,355 @@
+package uploads
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/PinataCloud/ipfs-cli/internal/api"
+	"github.com/PinataCloud/ipfs-cli/internal/config"
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+)
+
+// JSONOptions controls UploadJSON
+type JSONOptions struct {
+	Network   string
+	Name      string
+	GroupId   string
+	KeyValues map[string]string
+	// Canonicalize sorts object keys and drops insignificant whitespace, so
+	// equal documents get the same CID
+	Canonicalize bool
+	// Template is the path of a JSON skeleton. When set the input is CSV,
+	// and one document is uploaded per row with the skeleton's placeholders
+	// filled from it.
+	Template string
+	// DryRun prints the documents instead of uploading them
+	DryRun bool
+}
+
+// jsonDocument is a document ready to upload, as printed by a dry run
+type jsonDocument struct {
+	Name      string            `json:"name"`
+	KeyValues map[string]string `json:"keyvalues,omitempty"`
+	JSON      json.RawMessage   `json:"json"`
+}
+
+// UploadJSON uploads a JSON document given inline, read from fromFile or
+// read from stdin when both are empty or either is -. With a template it
+// uploads a document per CSV row instead.
+func UploadJSON(inline string, fromFile string, opts JSONOptions) ([]pinata.UploadResult, error) {
+	if inline != "" && fromFile != "" {
+		return nil, exitcode.InvalidInputf("pass either a JSON document or --from-file, not both")
+	}
+	input, source, err := readInput(inline, fromFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []jsonDocument
+	if opts.Template != "" {
+		docs, err = fillTemplate(opts.Template, input, opts)
+	} else {
+		docs, err = singleDocument(input, source, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.DryRun {
+		if opts.Template == "" {
+			return nil, printJSON(docs[0])
+		}
+		return nil, printJSON(docs)
+	}
+
+	networkParam, err := config.GetNetworkParam(opts.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []pinata.UploadResult{}
+	// A failed hook doesn't stop the batch, as the upload went through
+	var hookErrs []error
+	for i, doc := range docs {
+		uploadOpts := pinata.UploadOptions{
+			Network:   networkParam,
+			Name:      doc.Name,
+			GroupId:   opts.GroupId,
+			KeyValues: doc.KeyValues,
+		}
+		transfer.apply(&uploadOpts)
+		var t *tracker
+		if len(docs) > 1 {
+			t = track("", doc.Name, i+1, len(docs))
+		} else {
+			t = track(source, doc.Name, 0, 0)
+		}
+		t.hook(&uploadOpts)
+		result, err := api.Client().UploadJSON(api.Context(), doc.JSON, uploadOpts)
+		if err != nil {
+			t.failed(err)
+			if len(docs) > 1 {
+				printJSON(results)
+				err = fmt.Errorf("failed to upload %s, document %d of %d: %w", doc.Name, i+1, len(docs), err)
+				return results, errors.Join(append(hookErrs, err)...)
+			}
+			return nil, err
+		}
+		t.completed(result)
+		results = append(results, *result)
+		// The count would break up the progress events if they share stderr
+		if len(docs) > 1 && !eventsOn(int(os.Stderr.Fd())) {
+			fmt.Fprintf(os.Stderr, "Uploaded %d of %d: %s\n", i+1, len(docs), doc.Name)
+		}
+		if err := runHooks(result, source, networkParam); err != nil {
+			hookErrs = append(hookErrs, err)
+		}
+	}
+
+	if opts.Template == "" {
+		err = printJSON(results[0])
+	} else {
+		err = printJSON(results)
+	}
+	return results, errors.Join(append(hookErrs, err)...)
+}
+
+// readInput returns the input and a name for it: the path of the file it was
+// read from, or empty
+func readInput(inline string, fromFile string) ([]byte, string, error) {
+	switch {
+	case inline != "" && inline != "-":
+		return []byte(inline), "", nil
+	case fromFile != "" && fromFile != "-":
+		data, err := os.ReadFile(fromFile)
+		if err != nil {
+			return nil, "", exitcode.AsInvalidInput(err)
+		}
+		return data, fromFile, nil
+	}
+
+	if stat, err := os.Stdin.Stat(); err == nil && stat.Mode()&os.ModeCharDevice != 0 {
+		return nil, "", exitcode.InvalidInputf("no JSON provided: pass it as an argument, with --from-file or on stdin")
+	}
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read stdin: %w", err)
+	}
+	return data, "", nil
+}
+
+func singleDocument(input []byte, source string, opts JSONOptions) ([]jsonDocument, error) {
+	data, err := prepareJSON(input, opts.Canonicalize)
+	if err != nil {
+		if source != "" {
+			return nil, exitcode.InvalidInputf("%s: %v", source, err)
+		}
+		return nil, exitcode.AsInvalidInput(err)
+	}
+
+	name := opts.Name
+	if name == "" && source != "" {
+		name = filepath.Base(source)
+	}
+	if name == "" {
+		name = "data.json"
+	}
+	return []jsonDocument{{Name: name, KeyValues: opts.KeyValues, JSON: data}}, nil
+}
+
+// prepareJSON validates a document and optionally canonicalizes it
+func prepareJSON(data []byte, canonicalize bool) ([]byte, error) {
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return nil, describeJSONError(data, err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("invalid JSON: unexpected data after the document")
+	}
+	if !canonicalize {
+		return bytes.TrimSpace(data), nil
+	}
+
+	// Maps are encoded with sorted keys, and json.Number keeps numbers as
+	// they were written
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// describeJSONError adds the line and column to a syntax error
+func describeJSONError(data []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		line, column := position(data, syntaxErr.Offset)
+		return fmt.Errorf("invalid JSON at line %d, column %d: %v", line, column, err)
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return errors.New("invalid JSON: unexpected end of input")
+	}
+	return fmt.Errorf("invalid JSON: %w", err)
+}
+
+func position(data []byte, offset int64) (int, int) {
+	before := data[:min(int(offset), len(data))]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
+
+var (
+	// rawPlaceholder is a whole JSON string holding {{{column}}}, replaced
+	// by the cell as a JSON value
+	rawPlaceholder = regexp.MustCompile(`"\{\{\{\s*([^{}]+?)\s*\}\}\}"`)
+	// placeholder is {{column}} anywhere in a string, replaced by the cell
+	// as text
+	placeholder = regexp.MustCompile(`\{\{\s*([^{}]+?)\s*\}\}`)
+	// skeletonPlaceholder matches either kind, so a skeleton is filled in one
+	// pass and cells are never searched for placeholders themselves
+	skeletonPlaceholder = regexp.MustCompile(rawPlaceholder.String() + "|" + placeholder.String())
+)
+
+// fillTemplate makes a document per CSV row by filling the placeholders of
+// the template. The first row of the CSV names the columns.
+func fillTemplate(path string, input []byte, opts JSONOptions) ([]jsonDocument, error) {
+	skeleton, err := os.ReadFile(path)
+	if err != nil {
+		return nil, exitcode.AsInvalidInput(err)
+	}
+	if _, err := prepareJSON(skeleton, false); err != nil {
+		return nil, exitcode.InvalidInputf("template %s: %v", path, err)
+	}
+
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(input, []byte("\ufeff"))))
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, exitcode.InvalidInputf("invalid CSV: %v", err)
+	}
+	if len(records) < 2 {
+		return nil, exitcode.InvalidInputf("the CSV needs a header row naming the columns and at least one row")
+	}
+	header := records[0]
+
+	var docs []jsonDocument
+	for i, record := range records[1:] {
+		row := map[string]string{}
+		for j, column := range header {
+			row[strings.TrimSpace(column)] = record[j]
+		}
+		fail := func(err error) ([]jsonDocument, error) {
+			return nil, exitcode.InvalidInputf("CSV row %d: %v", i+1, err)
+		}
+
+		filled, err := fill(skeleton, row)
+		if err != nil {
+			return fail(err)
+		}
+		data, err := prepareJSON(filled, opts.Canonicalize)
+		if err != nil {
+			return fail(fmt.Errorf("the filled template is %v", err))
+		}
+
+		name := fmt.Sprintf("%d.json", i+1)
+		if opts.Name != "" {
+			name, err = expand(opts.Name, row, identity)
+			if err != nil {
+				return fail(err)
+			}
+		}
+		var keyValues map[string]string
+		if len(opts.KeyValues) > 0 {
+			keyValues = map[string]string{}
+			for key, value := range opts.KeyValues {
+				if keyValues[key], err = expand(value, row, identity); err != nil {
+					return fail(err)
+				}
+			}
+		}
+		docs = append(docs, jsonDocument{Name: name, KeyValues: keyValues, JSON: data})
+	}
+	return docs, nil
+}
+
+// fill replaces the placeholders of a JSON skeleton with the cells of a row.
+// Placeholders can only occur in strings, since {{ is invalid elsewhere.
+func fill(skeleton []byte, row map[string]string) ([]byte, error) {
+	var err error
+	out := skeletonPlaceholder.ReplaceAllFunc(skeleton, func(match []byte) []byte {
+		groups := skeletonPlaceholder.FindSubmatch(match)
+		raw := groups[1] != nil
+		column := string(groups[1])
+		if !raw {
+			column = string(groups[2])
+		}
+		value, ok := row[column]
+		switch {
+		case !ok:
+			err = fmt.Errorf("no column named %q", column)
+			return match
+		case !raw:
+			return []byte(escapeJSON(value))
+		case !json.Valid([]byte(value)):
+			err = fmt.Errorf("column %q is %q, which isn't a JSON value", column, value)
+			return match
+		}
+		return []byte(value)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// expand replaces the {{column}} placeholders in s with the escaped cells of
+// a row
+func expand(s string, row map[string]string, escape func(string) string) (string, error) {
+	var err error
+	out := placeholder.ReplaceAllStringFunc(s, func(match string) string {
+		column := placeholder.FindStringSubmatch(match)[1]
+		value, ok := row[column]
+		if !ok {
+			err = fmt.Errorf("no column named %q", column)
+			return match
+		}
+		return escape(value)
+	})
+	return out, err
+}
+
+func identity(s string) string {
+	return s
+}
+
+// escapeJSON escapes text for use inside a JSON string
+func escapeJSON(s string) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	quoted := strings.TrimSuffix(buf.String(), "\n")
+	return quoted[1 : len(quoted)-1]
+}
+
+func printJSON(v interface{}) error {
+	formattedJSON, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return errors.New("failed to format JSON")
+	}
+	fmt.Println(string(formattedJSON))
+	return nil
+}
@@ -0,0 +1,141 @@
+package uploads
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+)
+
+func TestFill(t *testing.T) {
+	tests := []struct {
+		name     string
+		skeleton string
+		row      map[string]string
+		want     string
+	}{
+		{
+			name:     "text",
+			skeleton: `{"title": "{{title}}", "by": "{{ author }}"}`,
+			row:      map[string]string{"title": `Say "hi"`, "author": "Ada"},
+			want:     `{"title": "Say \"hi\"", "by": "Ada"}`,
+		},
+		{
+			name:     "text within a string",
+			skeleton: `{"path": "/items/{{id}}.json"}`,
+			row:      map[string]string{"id": "42"},
+			want:     `{"path": "/items/42.json"}`,
+		},
+		{
+			name:     "raw",
+			skeleton: `{"count": "{{{count}}}", "tags": "{{{tags}}}"}`,
+			row:      map[string]string{"count": "3", "tags": `["a", "b"]`},
+			want:     `{"count": 3, "tags": ["a", "b"]}`,
+		},
+		{
+			name:     "raw cell holding a placeholder",
+			skeleton: `{"meta": "{{{meta}}}", "name": "{{name}}"}`,
+			row:      map[string]string{"meta": `{"note": "{{name}} {{unknown}}"}`, "name": "x"},
+			want:     `{"meta": {"note": "{{name}} {{unknown}}"}, "name": "x"}`,
+		},
+		{
+			name:     "text cell holding a placeholder",
+			skeleton: `{"a": "{{a}}", "b": "{{b}}"}`,
+			row:      map[string]string{"a": "{{b}}", "b": "2"},
+			want:     `{"a": "{{b}}", "b": "2"}`,
+		},
+		{
+			name:     "raw placeholder within a string is text",
+			skeleton: `{"a": "n={{{n}}}"}`,
+			row:      map[string]string{"n": "1"},
+			want:     `{"a": "n={1}"}`,
+		},
+		{
+			name:     "html is kept",
+			skeleton: `{"a": "{{a}}"}`,
+			row:      map[string]string{"a": "<b>&</b>"},
+			want:     `{"a": "<b>&</b>"}`,
+		},
+	}
+	for _, tt := range tests {
+		got, err := fill([]byte(tt.skeleton), tt.row)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+		if !json.Valid(got) {
+			t.Errorf("%s: %s isn't valid JSON", tt.name, got)
+		}
+	}
+}
+
+func TestFillErrors(t *testing.T) {
+	tests := []struct {
+		skeleton string
+		row      map[string]string
+		message  string
+	}{
+		{`{"a": "{{missing}}"}`, map[string]string{}, `no column named "missing"`},
+		{`{"a": "{{{missing}}}"}`, map[string]string{}, `no column named "missing"`},
+		{`{"a": "{{{n}}}"}`, map[string]string{"n": "not json"}, `column "n" is "not json", which isn't a JSON value`},
+	}
+	for _, tt := range tests {
+		_, err := fill([]byte(tt.skeleton), tt.row)
+		if err == nil || !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("fill(%s) gave %v, want %q", tt.skeleton, err, tt.message)
+		}
+	}
+}
+
+func TestFillTemplate(t *testing.T) {
+	template := filepath.Join(t.TempDir(), "template.json")
+	if err := os.WriteFile(template, []byte(`{"sku": "{{sku}}", "price": "{{{price}}}"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	csv := "\ufeffsku, price\nA-1,9.5\nB-2,12\n"
+
+	docs, err := fillTemplate(template, []byte(csv), JSONOptions{
+		Name:         "{{sku}}.json",
+		KeyValues:    map[string]string{"sku": "{{sku}}"},
+		Canonicalize: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 {
+		t.Fatalf("got %d documents, want 2", len(docs))
+	}
+	if docs[0].Name != "A-1.json" || docs[1].KeyValues["sku"] != "B-2" {
+		t.Errorf("got names and keyvalues %+v", docs)
+	}
+	if got := string(docs[0].JSON); got != `{"price":9.5,"sku":"A-1"}` {
+		t.Errorf("got %s", got)
+	}
+
+	docs, err = fillTemplate(template, []byte(csv), JSONOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if docs[1].Name != "2.json" {
+		t.Errorf("default name is %q, want 2.json", docs[1].Name)
+	}
+
+	for input, message := range map[string]string{
+		"sku,price\n":                     "at least one row",
+		"sku,price\nA-1,nine\n":           "CSV row 1",
+		"sku\nA-1\n":                      `no column named "price"`,
+		"sku,price\nA-1,1\nB-2\n":         "invalid CSV",
+		"sku,price\nA-1,\"{\"\"x\"\"\"\n": "isn't a JSON value",
+	} {
+		_, err := fillTemplate(template, []byte(input), JSONOptions{})
+		if exitcode.For(err) != exitcode.InvalidInput || !strings.Contains(err.Error(), message) {
+			t.Errorf("%q gave %v, want invalid input mentioning %q", input, err, message)
+		}
+	}
+}
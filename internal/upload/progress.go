@@ -0,0 +1,212 @@
+package uploads
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+)
+
+// Ways uploads can report progress
+const (
+	// ProgressBar draws a progress bar, like --verbose
+	ProgressBar = "bar"
+	// ProgressJSONL writes an event per line as JSON
+	ProgressJSONL = "jsonl"
+)
+
+// progressInterval is the least time between two progress events of an
+// upload, other than the last
+const progressInterval = 500 * time.Millisecond
+
+var (
+	// events is where progress events are written, or nil when they're off,
+	// and eventsFd its file descriptor
+	events   io.Writer
+	eventsFd int
+	eventsMu sync.Mutex
+)
+
+// SetProgress sets how uploads report progress. With ProgressJSONL every
+// upload writes its events as JSON lines to the file descriptor fd, which is
+// usually 2 for stderr.
+func SetProgress(mode string, fd int) error {
+	switch mode {
+	case "", ProgressBar:
+		events = nil
+		return nil
+	case ProgressJSONL:
+	default:
+		return exitcode.InvalidInputf("invalid --progress %q: must be %s or %s", mode, ProgressBar, ProgressJSONL)
+	}
+
+	if fd < 0 {
+		return exitcode.InvalidInputf("invalid --progress-fd %d", fd)
+	}
+	f := os.NewFile(uintptr(fd), fmt.Sprintf("fd %d", fd))
+	if f == nil {
+		return exitcode.InvalidInputf("file descriptor %d isn't open", fd)
+	}
+	if _, err := f.Stat(); err != nil {
+		return exitcode.InvalidInputf("file descriptor %d isn't open", fd)
+	}
+	events, eventsFd = f, fd
+	return nil
+}
+
+// eventsOn reports whether progress events are written to the file
+// descriptor fd
+func eventsOn(fd int) bool {
+	return events != nil && eventsFd == fd
+}
+
+// progressEvent is a line written by a tracker
+type progressEvent struct {
+	Time  string `json:"time"`
+	Event string `json:"event"`
+	Path  string `json:"path,omitempty"`
+	Name  string `json:"name,omitempty"`
+	// Index and Count place an upload in a batch
+	Index int `json:"index,omitempty"`
+	Count int `json:"count,omitempty"`
+	// Total is left out of a started event written before the size of the
+	// request was known
+	Bytes int64 `json:"bytes"`
+	Total int64 `json:"total,omitempty"`
+	// Rate is in bytes per second, and ETA in seconds
+	Rate  int64   `json:"rate,omitempty"`
+	ETA   float64 `json:"eta,omitempty"`
+	Id    string  `json:"id,omitempty"`
+	Cid   string  `json:"cid,omitempty"`
+	Error string  `json:"error,omitempty"`
+}
+
+// tracker writes the progress events of an upload: started, progress,
+// chunk_committed for each TUS chunk, then completed or failed. The methods
+// of a nil tracker do nothing.
+type tracker struct {
+	mu    sync.Mutex
+	event progressEvent
+	// started is when the body started being sent, for the rate
+	started    time.Time
+	lastReport time.Time
+	// announced is set once the started event is written
+	announced bool
+}
+
+// track returns a tracker for an upload, or nil when events are off. index
+// and count are zero unless the upload is part of a batch. The started event
+// waits for the size of the request, which the SDK reports before sending it.
+func track(path, name string, index, count int) *tracker {
+	if events == nil {
+		return nil
+	}
+	t := &tracker{event: progressEvent{Path: path, Name: name, Index: index, Count: count}}
+	t.started = time.Now()
+	return t
+}
+
+// announce writes the started event, unless it was already written. An
+// upload that fails before its size is known still starts, without a total.
+func (t *tracker) announce() {
+	if t.announced {
+		return
+	}
+	t.announced = true
+	t.write("started")
+}
+
+// hook makes the upload report to the tracker, as well as to any progress
+// callback it already has
+func (t *tracker) hook(opts *pinata.UploadOptions) {
+	if t == nil {
+		return
+	}
+	previous := opts.Progress
+	opts.Progress = func(sent, total int64) {
+		if previous != nil {
+			previous(sent, total)
+		}
+		t.progress(sent, total)
+	}
+	opts.Chunk = t.chunk
+}
+
+func (t *tracker) progress(sent, total int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	t.event.Bytes, t.event.Total = sent, total
+	t.announce()
+
+	if sent == 0 {
+		// The rate counts from when the body starts, and the body is sent
+		// again when a request is retried
+		t.started = now
+		return
+	}
+	if sent < total && now.Sub(t.lastReport) < progressInterval {
+		return
+	}
+	t.lastReport = now
+	t.write("progress")
+}
+
+func (t *tracker) chunk(committed, total int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.event.Bytes, t.event.Total = committed, total
+	t.announce()
+	t.write("chunk_committed")
+}
+
+func (t *tracker) completed(result *pinata.UploadResult) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.announce()
+	t.event.Bytes = t.event.Total
+	t.event.Id, t.event.Cid = result.Id, result.Cid
+	t.write("completed")
+}
+
+func (t *tracker) failed(err error) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.announce()
+	t.event.Error = err.Error()
+	t.write("failed")
+}
+
+// write writes an event with the rate and ETA of a progress event
+func (t *tracker) write(name string) {
+	event := t.event
+	event.Event = name
+	event.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	if name == "progress" || name == "chunk_committed" {
+		if elapsed := time.Since(t.started).Seconds(); elapsed > 0 && event.Bytes > 0 {
+			rate := float64(event.Bytes) / elapsed
+			event.Rate = int64(rate)
+			event.ETA = math.Round(float64(event.Total-event.Bytes)/rate*10) / 10
+		}
+	}
+
+	line, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	eventsMu.Lock()
+	defer eventsMu.Unlock()
+	fmt.Fprintln(events, string(line))
+}
@@ -0,0 +1,146 @@
+package uploads
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/PinataCloud/ipfs-cli/internal/api"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+)
+
+// captureEvents writes progress events to a buffer until the test ends
+func captureEvents(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	savedEvents, savedFd := events, eventsFd
+	events, eventsFd = &buf, -1
+	t.Cleanup(func() { events, eventsFd = savedEvents, savedFd })
+	return &buf
+}
+
+// readEvents parses the events written, and returns them with the names of
+// their kinds in order. Repeats are left out, and so are progress events,
+// which are written depending on time.
+func readEvents(t *testing.T, buf *bytes.Buffer) ([]progressEvent, []string) {
+	t.Helper()
+	var list []progressEvent
+	var kinds []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var event progressEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("%q isn't an event: %v", line, err)
+		}
+		list = append(list, event)
+		if event.Event != "progress" && (len(kinds) == 0 || kinds[len(kinds)-1] != event.Event) {
+			kinds = append(kinds, event.Event)
+		}
+	}
+	return list, kinds
+}
+
+func TestProgressEvents(t *testing.T) {
+	data := bytes.Repeat([]byte("progress\n"), 1000)
+	tests := []struct {
+		name     string
+		failures int32
+		retries  int
+		tus      bool
+		missing  bool
+		want     []string
+	}{
+		{"upload", 0, 0, false, false, []string{"started", "completed"}},
+		// The body is sent again, as part of the same upload
+		{"retried", 1, 1, false, false, []string{"started", "completed"}},
+		{"failed", 100, 0, false, false, []string{"started", "failed"}},
+		{"missing file", 0, 0, false, true, []string{"started", "failed"}},
+		{"TUS", 0, 0, true, false, []string{"started", "chunk_committed", "completed"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useFlakyDevServer(t, tt.failures)
+			api.Client().Retries = tt.retries
+			if tt.tus {
+				saved := transfer
+				transfer = TransferOptions{TUSThreshold: 1024, ChunkSize: 4000, Parallel: 1}
+				t.Cleanup(func() { transfer = saved })
+			}
+			path := filepath.Join(t.TempDir(), "notes.txt")
+			if !tt.missing {
+				if err := os.WriteFile(path, data, 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			buf := captureEvents(t)
+
+			_, err := upload(path, "", "nil", false, pinata.NetworkPublic)
+			if (err != nil) != (tt.want[len(tt.want)-1] == "failed") {
+				t.Fatalf("upload returned %v", err)
+			}
+			list, kinds := readEvents(t, buf)
+			if !slices.Equal(kinds, tt.want) {
+				t.Fatalf("events are %v, want %v", kinds, tt.want)
+			}
+
+			counts := map[string]int{}
+			for _, event := range list {
+				counts[event.Event]++
+			}
+			// The last progress is always reported
+			if counts["started"] != 1 || (counts["progress"] == 0) != tt.missing {
+				t.Errorf("got %v events", counts)
+			}
+			if tt.tus && counts["chunk_committed"] != 3 {
+				t.Errorf("committed %d chunks, want 3", counts["chunk_committed"])
+			}
+
+			started, last := list[0], list[len(list)-1]
+			if started.Bytes != 0 || started.Path != path {
+				t.Errorf("started with %+v", started)
+			}
+			if tt.missing {
+				// The size was never known
+				if started.Total != 0 || strings.Contains(strings.SplitN(buf.String(), "\n", 2)[0], `"total"`) {
+					t.Errorf("started with a total: %s", buf.String())
+				}
+			} else if started.Total < int64(len(data)) {
+				t.Errorf("started with a total of %d, want the size of the request", started.Total)
+			}
+			for _, event := range list[1 : len(list)-1] {
+				if event.Total != started.Total || event.Bytes <= 0 || event.Bytes > event.Total {
+					t.Errorf("%s event has %d of %d bytes", event.Event, event.Bytes, event.Total)
+				}
+			}
+			if last.Event == "failed" {
+				if last.Error == "" {
+					t.Errorf("failed without an error: %+v", last)
+				}
+			} else if last.Cid == "" || last.Id == "" || last.Bytes != last.Total {
+				t.Errorf("completed with %+v", last)
+			}
+		})
+	}
+}
+
+func TestTrackerOff(t *testing.T) {
+	savedEvents := events
+	events = nil
+	t.Cleanup(func() { events = savedEvents })
+
+	// A nil tracker takes every call
+	tr := track("a.txt", "", 0, 0)
+	if tr != nil {
+		t.Fatalf("got a tracker with events off")
+	}
+	opts := pinata.UploadOptions{}
+	tr.hook(&opts)
+	tr.completed(&pinata.UploadResult{})
+	tr.failed(os.ErrNotExist)
+	if opts.Progress != nil || opts.Chunk != nil {
+		t.Error("a nil tracker hooked the upload")
+	}
+}
@@ -0,0 +1,43 @@
+package uploads
+
+import (
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+)
+
+// maxParallel is the most partial uploads a file is split into
+const maxParallel = 16
+
+// TransferOptions tunes how uploads are sent. Zero values keep the defaults
+// of the SDK, except Parallel which must be set.
+type TransferOptions struct {
+	// RateLimit caps each upload to this many bytes per second
+	RateLimit int64
+	// ChunkSize is the size of each TUS request
+	ChunkSize int64
+	// TUSThreshold is the size above which files are sent with TUS
+	TUSThreshold int64
+	// Parallel sends TUS uploads as this many partial uploads at once, when
+	// the server supports concatenating them. It's from 1, one upload at a
+	// time, to maxParallel.
+	Parallel int
+}
+
+// transfer applies to every upload
+var transfer TransferOptions
+
+// SetTransfer sets how uploads are sent
+func SetTransfer(opts TransferOptions) error {
+	if opts.Parallel < 1 || opts.Parallel > maxParallel {
+		return exitcode.InvalidInputf("invalid --parallel %d: must be from 1 to %d", opts.Parallel, maxParallel)
+	}
+	transfer = opts
+	return nil
+}
+
+func (t TransferOptions) apply(opts *pinata.UploadOptions) {
+	opts.RateLimit = t.RateLimit
+	opts.ChunkSize = t.ChunkSize
+	opts.TUSThreshold = t.TUSThreshold
+	opts.Parallel = t.Parallel
+}
@@ -0,0 +1,328 @@
+package uploads
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/PinataCloud/ipfs-cli/internal/api"
+	"github.com/PinataCloud/ipfs-cli/internal/config"
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+)
+
+// What a watch does with the previous version of a file it uploads again
+const (
+	ReplaceKeep   = ""
+	ReplaceDelete = "delete"
+	ReplaceSwap   = "swap"
+)
+
+// Defaults for WatchOptions
+const (
+	DefaultWatchInterval = time.Second
+	DefaultWatchDebounce = 2 * time.Second
+)
+
+// WatchOptions controls Watch
+type WatchOptions struct {
+	GroupId string
+	Network string
+	// Interval is how often the files are polled
+	Interval time.Duration
+	// Debounce is how long a file must go unchanged before it's uploaded,
+	// so a burst of writes leads to a single upload
+	Debounce time.Duration
+	// Replace is ReplaceDelete to delete the version a new upload
+	// supersedes, or ReplaceSwap to swap the first CID of the file to the
+	// new one
+	Replace string
+	// Initial uploads the files already there when the watch starts
+	Initial bool
+}
+
+// watchEvent is a line of the JSONL log Watch writes to stdout
+type watchEvent struct {
+	Time  string `json:"time"`
+	Event string `json:"event"`
+	Path  string `json:"path,omitempty"`
+	Id    string `json:"id,omitempty"`
+	Cid   string `json:"cid,omitempty"`
+	Size  int    `json:"size,omitempty"`
+	// Files is the number of files being watched
+	Files    int    `json:"files,omitempty"`
+	Previous string `json:"previous,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// watchedFile is what a watch knows about a file
+type watchedFile struct {
+	modTime time.Time
+	size    int64
+	// changed is when the file was last seen changing, or its upload last
+	// failed, and zero once the change has been uploaded
+	changed time.Time
+	// hash is the sha256 of the content last uploaded, or found at the start
+	hash string
+	// id and cid are the latest version uploaded, and first is the CID of
+	// the first. A version uploaded before the watch counts when --replace
+	// is set.
+	id, cid, first string
+}
+
+// Watch polls a folder, or a single file, and uploads each new or modified
+// file once it has settled, named by its path under the folder. Hidden files
+// and folders are skipped, and content that hashes the same as the last
+// version is not uploaded again. Every action is logged to stdout as a JSON
+// line. It runs until interrupted.
+func Watch(root string, opts WatchOptions) error {
+	stats, err := os.Stat(root)
+	if err != nil {
+		return exitcode.AsInvalidInput(err)
+	}
+	switch opts.Replace {
+	case ReplaceKeep, ReplaceDelete, ReplaceSwap:
+	default:
+		return exitcode.InvalidInputf("invalid --replace %q: must be %s or %s", opts.Replace, ReplaceDelete, ReplaceSwap)
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultWatchInterval
+	}
+	if opts.Debounce < 0 {
+		opts.Debounce = 0
+	}
+	networkParam, err := config.GetNetworkParam(opts.Network)
+	if err != nil {
+		return err
+	}
+	opts.Network = networkParam
+
+	files := map[string]*watchedFile{}
+	scanned, err := scan(root, stats.IsDir())
+	if err != nil {
+		return err
+	}
+	for path, info := range scanned {
+		f := &watchedFile{modTime: info.ModTime(), size: info.Size()}
+		if opts.Initial {
+			f.changed = time.Now()
+		} else if f.hash, err = hashFile(path); err != nil {
+			return err
+		}
+		files[path] = f
+	}
+	logEvent(watchEvent{Event: "watching", Path: root, Files: len(files)})
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-api.Context().Done():
+			logEvent(watchEvent{Event: "stopped", Path: root})
+			return nil
+		case <-ticker.C:
+		}
+
+		scanned, err := scan(root, stats.IsDir())
+		if err != nil {
+			logEvent(watchEvent{Event: "failed", Path: root, Error: err.Error()})
+			continue
+		}
+		now := time.Now()
+		for path, info := range scanned {
+			f, ok := files[path]
+			if !ok {
+				f = &watchedFile{}
+				files[path] = f
+			}
+			if !f.modTime.Equal(info.ModTime()) || f.size != info.Size() {
+				f.modTime, f.size, f.changed = info.ModTime(), info.Size(), now
+			}
+		}
+		for path, f := range files {
+			if _, ok := scanned[path]; !ok {
+				// Keep what was uploaded, in case the file comes back
+				f.modTime, f.size, f.changed = time.Time{}, -1, time.Time{}
+			}
+		}
+
+		// Upload in a stable order, so the log is easier to follow
+		var settled []string
+		for path, f := range files {
+			if !f.changed.IsZero() && now.Sub(f.changed) >= opts.Debounce {
+				settled = append(settled, path)
+			}
+		}
+		sort.Strings(settled)
+		for _, path := range settled {
+			if api.Context().Err() != nil {
+				break
+			}
+			uploadChange(root, path, files[path], opts)
+		}
+	}
+}
+
+// uploadChange uploads a settled file when its content has changed, then
+// deals with the version it supersedes
+func uploadChange(root, path string, f *watchedFile, opts WatchOptions) {
+	name := filepath.Base(path)
+	if rel, err := filepath.Rel(root, path); err == nil && rel != "." {
+		name = filepath.ToSlash(rel)
+	}
+
+	// A failure marks the file changed again, so it's retried once the
+	// debounce has passed rather than left out of date
+	f.changed = time.Time{}
+	hash, err := hashFile(path)
+	if err != nil {
+		// A file removed since the poll is forgotten on the next one
+		if !errors.Is(err, fs.ErrNotExist) {
+			logEvent(watchEvent{Event: "failed", Path: name, Error: err.Error()})
+			f.changed = time.Now()
+		}
+		return
+	}
+	if hash == f.hash {
+		return
+	}
+	if f.id == "" && opts.Replace != ReplaceKeep {
+		// The file may have been uploaded before the watch started
+		if f.id, f.cid, err = findPrevious(name, opts); err != nil {
+			logEvent(watchEvent{Event: "failed", Path: name, Error: fmt.Sprintf("failed to look up the previous version: %v", err)})
+		}
+		f.first = f.cid
+	}
+
+	result, err := upload(path, opts.GroupId, name, false, opts.Network)
+	if err != nil {
+		logEvent(watchEvent{Event: "failed", Path: name, Error: err.Error()})
+		f.changed = time.Now()
+		return
+	}
+	previousId, previousCid := f.id, f.cid
+	f.hash, f.id, f.cid = hash, result.Id, result.Cid
+	if f.first == "" {
+		f.first = result.Cid
+	}
+	logEvent(watchEvent{Event: "uploaded", Path: name, Id: result.Id, Cid: result.Cid, Size: result.Size, Previous: previousCid})
+	if err := runHooks(result, path, opts.Network); err != nil {
+		logEvent(watchEvent{Event: "failed", Path: name, Id: result.Id, Cid: result.Cid, Error: err.Error()})
+	}
+
+	if previousId == "" || previousCid == result.Cid {
+		return
+	}
+	switch opts.Replace {
+	case ReplaceDelete:
+		if err := api.Client().DeleteFile(api.Context(), opts.Network, previousId); err != nil {
+			logEvent(watchEvent{Event: "failed", Path: name, Id: previousId, Cid: previousCid, Error: fmt.Sprintf("failed to delete the previous version: %v", err)})
+			return
+		}
+		logEvent(watchEvent{Event: "deleted", Path: name, Id: previousId, Cid: previousCid})
+	case ReplaceSwap:
+		// Swapping the first CID keeps every link handed out during the
+		// watch pointing at the latest version
+		if _, err := api.Client().AddSwap(api.Context(), opts.Network, f.first, result.Cid); err != nil {
+			logEvent(watchEvent{Event: "failed", Path: name, Cid: f.first, Error: fmt.Sprintf("failed to swap to the new version: %v", err)})
+			return
+		}
+		logEvent(watchEvent{Event: "swapped", Path: name, Cid: result.Cid, Previous: f.first})
+	}
+}
+
+// findPrevious returns the newest file already uploaded with a name, to the
+// watch's group if it has one, or "" when there is none
+func findPrevious(name string, opts WatchOptions) (string, string, error) {
+	listOpts := pinata.ListFilesOptions{Network: opts.Network, Name: name, GroupId: opts.GroupId}
+	for {
+		list, err := api.Client().ListFiles(api.Context(), listOpts)
+		if err != nil {
+			return "", "", err
+		}
+		// The name filter also matches longer names
+		for _, file := range list.Files {
+			if file.Name == name {
+				return file.Id, file.Cid, nil
+			}
+		}
+		if list.NextPageToken == "" || len(list.Files) == 0 {
+			return "", "", nil
+		}
+		listOpts.PageToken = list.NextPageToken
+	}
+}
+
+// scan returns the files to watch by path: the root itself when it's a
+// file, or the files under it that aren't hidden
+func scan(root string, isDir bool) (map[string]fs.FileInfo, error) {
+	files := map[string]fs.FileInfo{}
+	if !isDir {
+		info, err := os.Stat(root)
+		if errors.Is(err, fs.ErrNotExist) {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		files[root] = info
+		return files, nil
+	}
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Files can disappear between listing a folder and reading them
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if path != root && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		files[path] = info
+		return nil
+	})
+	return files, err
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func logEvent(event watchEvent) {
+	event.Time = time.Now().UTC().Format(time.RFC3339)
+	line, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	fmt.Println(string(line))
+}
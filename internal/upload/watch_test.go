@@ -0,0 +1,131 @@
+package uploads
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/PinataCloud/ipfs-cli/internal/api"
+	"github.com/PinataCloud/ipfs-cli/internal/car"
+	"github.com/PinataCloud/ipfs-cli/internal/devserver"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+)
+
+// useFlakyDevServer points the API client at a dev server that fails the
+// first failures uploads with a 500, and returns the number of uploads it
+// was sent
+func useFlakyDevServer(t *testing.T, failures int32) *atomic.Int32 {
+	t.Helper()
+	var uploads atomic.Int32
+	handler := devserver.New(devserver.Options{}).Handler()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Path == "/v3/files" && uploads.Add(1) <= failures {
+			http.Error(w, `{"error": "try again later"}`, http.StatusInternalServerError)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("PINATA_CONFIG_DIR", t.TempDir())
+	t.Setenv("PINATA_JWT", "dev")
+
+	client := api.Client()
+	saved := *client
+	client.APIHost, client.UploadsHost, client.AgentsHost = server.URL, server.URL, server.URL
+	client.Retries = 0
+	t.Cleanup(func() {
+		client.APIHost, client.UploadsHost, client.AgentsHost = saved.APIHost, saved.UploadsHost, saved.AgentsHost
+		client.Retries = saved.Retries
+	})
+	return &uploads
+}
+
+func TestWatchRetriesFailedUpload(t *testing.T) {
+	uploads := useFlakyDevServer(t, 1)
+	root := t.TempDir()
+	data := []byte("quarterly numbers\n")
+	if err := os.WriteFile(filepath.Join(root, "report.txt"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	api.SetContext(ctx)
+	t.Cleanup(func() { api.SetContext(context.Background()) })
+
+	done := make(chan error, 1)
+	go func() {
+		done <- Watch(root, WatchOptions{
+			Network:  pinata.NetworkPublic,
+			Interval: 10 * time.Millisecond,
+			Initial:  true,
+		})
+	}()
+
+	// The first upload fails, and the file is sent again on a later poll
+	want := car.Sum(car.CodecRaw, data).String()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		list, err := api.Client().ListFiles(context.Background(), pinata.ListFilesOptions{Cid: want})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list.Files) == 1 && list.Files[0].Name == "report.txt" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("the file wasn't uploaded after %d attempts", uploads.Load())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Watch returned %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Watch didn't stop")
+	}
+	// Once uploaded, the unchanged file isn't sent again
+	if got := uploads.Load(); got != 2 {
+		t.Errorf("sent %d uploads, want 2", got)
+	}
+}
+
+func TestUploadChangeKeepsFailures(t *testing.T) {
+	useFlakyDevServer(t, 1)
+	root := t.TempDir()
+	path := filepath.Join(root, "notes.txt")
+	if err := os.WriteFile(path, []byte("draft"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	opts := WatchOptions{Network: pinata.NetworkPublic}
+	f := &watchedFile{changed: time.Now()}
+
+	uploadChange(root, path, f, opts)
+	if f.changed.IsZero() || f.id != "" || f.hash != "" {
+		t.Fatalf("a failed upload left %+v, want the file still changed", f)
+	}
+
+	uploadChange(root, path, f, opts)
+	if !f.changed.IsZero() || f.id == "" || f.cid != car.Sum(car.CodecRaw, []byte("draft")).String() {
+		t.Errorf("a successful upload left %+v", f)
+	}
+
+	// A file removed before its upload is left for the next poll to forget
+	f.changed = time.Now()
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	uploadChange(root, path, f, opts)
+	if !f.changed.IsZero() {
+		t.Errorf("a removed file is still marked changed")
+	}
+}
@@ -3,8 +3,11 @@ package utils
 import (
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
@@ -194,3 +197,71 @@ func MultiSelect(options []string) (string, error) {
 		return program.(model).choice, nil
 	}
 }
+
+// ParseLimit reads the --amount flag of the list commands. An empty value
+// leaves the page size to the API.
+func ParseLimit(amount string) (int, error) {
+	if amount == "" {
+		return 0, nil
+	}
+	limit, err := strconv.Atoi(amount)
+	if err != nil || limit < 0 {
+		return 0, exitcode.InvalidInputf("invalid amount %q: must be a positive number", amount)
+	}
+	return limit, nil
+}
+
+// ParseKeyValues reads repeated key=value flags into a map
+func ParseKeyValues(pairs []string) (map[string]string, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	keyValues := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, exitcode.InvalidInputf("invalid keyvalue %q: must be key=value", pair)
+		}
+		keyValues[key] = value
+	}
+	return keyValues, nil
+}
+
+// sizeUnits are the units ParseSize accepts, in bytes
+var sizeUnits = map[string]float64{
+	"":    1,
+	"b":   1,
+	"k":   1e3,
+	"kb":  1e3,
+	"kib": 1 << 10,
+	"m":   1e6,
+	"mb":  1e6,
+	"mib": 1 << 20,
+	"g":   1e9,
+	"gb":  1e9,
+	"gib": 1 << 30,
+}
+
+// ParseSize reads a size flag such as 50MB, 512KiB or 1048576, in bytes.
+// flag names the flag in errors.
+func ParseSize(flag string, value string) (int64, error) {
+	trimmed := strings.TrimSpace(value)
+	number := strings.TrimRightFunc(trimmed, func(r rune) bool {
+		return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+	})
+	unit, ok := sizeUnits[strings.ToLower(strings.TrimSpace(trimmed[len(number):]))]
+	size, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+	if !ok || err != nil || !(size*unit >= 1) || size*unit > 1<<62 {
+		return 0, exitcode.InvalidInputf("invalid --%s %q: must be a size such as 50MB or 512KiB", flag, value)
+	}
+	return int64(size * unit), nil
+}
+
+// ParseRate reads a rate flag such as 10MB/s, in bytes per second
+func ParseRate(flag string, value string) (int64, error) {
+	rate, err := ParseSize(flag, strings.TrimSuffix(strings.TrimSpace(value), "/s"))
+	if err != nil {
+		return 0, exitcode.InvalidInputf("invalid --%s %q: must be a rate such as 10MB/s or 512KiB/s", flag, value)
+	}
+	return rate, nil
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"1048576", 1048576},
+		{"1", 1},
+		{"50MB", 50_000_000},
+		{"50mb", 50_000_000},
+		{"50M", 50_000_000},
+		{"512KiB", 512 << 10},
+		{"512 kib", 512 << 10},
+		{"1.5KiB", 1536},
+		{"2GiB", 2 << 30},
+		{"1g", 1e9},
+		{" 10B ", 10},
+		{"1e3", 1000},
+	}
+	for _, tt := range tests {
+		got, err := ParseSize("chunk-size", tt.in)
+		if err != nil {
+			t.Errorf("ParseSize(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseSizeInvalid(t *testing.T) {
+	for _, in := range []string{"", "0", "-5MB", "0.5", "MB", "10TB", "ten", "NaN", "Inf", "1e30GiB", "5MB/s"} {
+		_, err := ParseSize("chunk-size", in)
+		if err == nil {
+			t.Errorf("ParseSize(%q) succeeded, want an error", in)
+			continue
+		}
+		if exitcode.For(err) != exitcode.InvalidInput {
+			t.Errorf("ParseSize(%q) gave exit code %d, want invalid input", in, exitcode.For(err))
+		}
+		if !strings.Contains(err.Error(), "--chunk-size") {
+			t.Errorf("ParseSize(%q) gave %q, want it to name the flag", in, err)
+		}
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"10MB/s", 10_000_000},
+		{"512KiB/s", 512 << 10},
+		{"1000", 1000},
+		{" 1MiB/s ", 1 << 20},
+	}
+	for _, tt := range tests {
+		got, err := ParseRate("limit-rate", tt.in)
+		if err != nil {
+			t.Errorf("ParseRate(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseRate(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "0/s", "fast", "10MB/h", "/s"} {
+		_, err := ParseRate("limit-rate", in)
+		if exitcode.For(err) != exitcode.InvalidInput || !strings.Contains(err.Error(), "must be a rate") {
+			t.Errorf("ParseRate(%q) gave %v, want an invalid rate", in, err)
+		}
+	}
+}
@@ -1,24 +1,38 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
-	"log"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
-	"pinata/internal/agents"
-	"pinata/internal/agents/chat"
-	"pinata/internal/auth"
-	"pinata/internal/config"
-	"pinata/internal/files"
-	"pinata/internal/gateways"
-	"pinata/internal/groups"
-	"pinata/internal/keys"
-	uploads "pinata/internal/upload"
+	"github.com/PinataCloud/ipfs-cli/internal/agents"
+	"github.com/PinataCloud/ipfs-cli/internal/agents/chat"
+	"github.com/PinataCloud/ipfs-cli/internal/api"
+	"github.com/PinataCloud/ipfs-cli/internal/auth"
+	"github.com/PinataCloud/ipfs-cli/internal/browse"
+	"github.com/PinataCloud/ipfs-cli/internal/car"
+	"github.com/PinataCloud/ipfs-cli/internal/completion"
+	"github.com/PinataCloud/ipfs-cli/internal/config"
+	"github.com/PinataCloud/ipfs-cli/internal/credentials"
+	"github.com/PinataCloud/ipfs-cli/internal/devserver"
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+	"github.com/PinataCloud/ipfs-cli/internal/files"
+	"github.com/PinataCloud/ipfs-cli/internal/gateways"
+	"github.com/PinataCloud/ipfs-cli/internal/groups"
+	"github.com/PinataCloud/ipfs-cli/internal/keys"
+	"github.com/PinataCloud/ipfs-cli/internal/pins"
+	"github.com/PinataCloud/ipfs-cli/internal/psa"
+	"github.com/PinataCloud/ipfs-cli/internal/refs"
+	uploads "github.com/PinataCloud/ipfs-cli/internal/upload"
+	"github.com/PinataCloud/ipfs-cli/internal/utils"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
 
 	"github.com/urfave/cli/v2"
 )
@@ -27,16 +41,127 @@ func main() {
 	app := &cli.App{
 		Name:  "pinata",
 		Usage: "The official Pinata IPFS CLI! To get started make an API key at https://app.pinata.cloud/keys, then authorize the CLI with the auth command with your JWT",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "profile",
+				EnvVars: []string{"PINATA_PROFILE"},
+				Usage:   "Use a named profile from the config file",
+			},
+			&cli.DurationFlag{
+				Name:    "timeout",
+				EnvVars: []string{"PINATA_TIMEOUT"},
+				Value:   api.DefaultTimeout,
+				Usage:   "Timeout for each API request, uploads excluded, 0 to disable",
+			},
+			&cli.IntFlag{
+				Name:    "retries",
+				EnvVars: []string{"PINATA_RETRIES"},
+				Value:   api.DefaultRetries,
+				Usage:   "Number of times to retry a request after a 429, 5xx or network error",
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				EnvVars: []string{"PINATA_OUTPUT"},
+				Value:   outputText,
+				Usage:   "Format for errors on stderr, and for the output of auth status and keys audit: text or json",
+			},
+			&cli.StringFlag{
+				Name:  "proxy",
+				Usage: "Proxy URL for all connections (http, https or socks5), overrides PINATA_PROXY and the profile",
+			},
+			&cli.StringFlag{
+				Name:      "ca-file",
+				Usage:     "PEM bundle of extra CA certificates to trust, overrides PINATA_CA_FILE and the profile",
+				TakesFile: true,
+			},
+			&cli.StringFlag{
+				Name:      "client-cert",
+				Usage:     "PEM client certificate for mutual TLS, overrides PINATA_CLIENT_CERT and the profile",
+				TakesFile: true,
+			},
+			&cli.StringFlag{
+				Name:      "client-key",
+				Usage:     "PEM private key for --client-cert, if it's not in the same file",
+				TakesFile: true,
+			},
+			&cli.BoolFlag{
+				Name:    "debug",
+				EnvVars: []string{"PINATA_DEBUG"},
+				Usage:   "Log each request and response to stderr, with credentials masked",
+			},
+			&cli.StringFlag{
+				Name:      "trace-file",
+				EnvVars:   []string{"PINATA_TRACE_FILE"},
+				Usage:     "Record requests, responses and WebSocket frames to a HAR file, with credentials masked",
+				TakesFile: true,
+			},
+		},
+		Before: configure,
 		Commands: []*cli.Command{
 			{
-				Name:      "auth",
-				Aliases:   []string{"a"},
-				Usage:     "Authorize the CLI with your Pinata JWT",
-				ArgsUsage: "[your Pinata JWT]",
+				Name:    "auth",
+				Aliases: []string{"a"},
+				Usage:   "Authorize the CLI with your Pinata JWT",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "jwt-stdin",
+						Usage: "Read the JWT from stdin instead of prompting for it",
+					},
+					&cli.StringFlag{
+						Name:  "jwt-file",
+						Usage: "Read the JWT from a file instead of prompting for it",
+					},
+					&cli.StringFlag{
+						Name:  "gateway",
+						Usage: "Gateway domain to use instead of picking one interactively",
+					},
+				},
 				Action: func(ctx *cli.Context) error {
-					err := auth.SaveJWT()
+					err := auth.SaveJWT(auth.LoginOptions{
+						JWTStdin: ctx.Bool("jwt-stdin"),
+						JWTFile:  ctx.String("jwt-file"),
+						Gateway:  ctx.String("gateway"),
+					})
 					return err
 				},
+				Subcommands: []*cli.Command{
+					{
+						Name:  "status",
+						Usage: "Show the active profile and decode the JWT claims with a validity check",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "offline",
+								Usage: "Only decode the JWT locally, skip the check against the API",
+							},
+						},
+						Action: func(ctx *cli.Context) error {
+							_, err := auth.Status(ctx.Bool("offline"), outputFormat == outputJSON)
+							return err
+						},
+					},
+					{
+						Name:  "encrypt",
+						Usage: "Move stored JWTs into a passphrase-encrypted credential store",
+						Description: `Moves the JWT of every profile out of the config file, and removes the legacy
+~/.pinata-files-cli file, into ~/.pinata/credentials.enc. The store is sealed
+with AES-256-GCM using a key derived from your passphrase. Set PINATA_PASSPHRASE
+to unlock it without a prompt, e.g. in CI.`,
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "require",
+								Usage: "Reject plaintext JWTs from now on. Use --require=false to allow them again",
+							},
+						},
+						Action: func(ctx *cli.Context) error {
+							var require *bool
+							if ctx.IsSet("require") {
+								value := ctx.Bool("require")
+								require = &value
+							}
+							return credentials.Migrate(require)
+						},
+					},
+				},
 			},
 			{
 				Name:      "upload",
@@ -65,19 +190,326 @@ func main() {
 						Aliases: []string{"net"},
 						Usage:   "Specify the network (public or private). Uses default if not specified",
 					},
+					&cli.StringFlag{
+						Name:  "watch",
+						Usage: "Keep running and upload each new or modified file in this folder, logging a JSON line per action",
+					},
+					&cli.StringFlag{
+						Name:  "replace",
+						Usage: "With --watch, what to do with the version a new upload supersedes, including one uploaded earlier with the same name: delete it, or swap its CID to the new one",
+					},
+					&cli.DurationFlag{
+						Name:  "interval",
+						Value: uploads.DefaultWatchInterval,
+						Usage: "With --watch, how often to check the files for changes",
+					},
+					&cli.DurationFlag{
+						Name:  "debounce",
+						Value: uploads.DefaultWatchDebounce,
+						Usage: "With --watch, how long a file must stay unchanged before it's uploaded",
+					},
+					&cli.BoolFlag{
+						Name:  "initial",
+						Usage: "With --watch, also upload the files already in the folder",
+					},
+					&cli.StringFlag{
+						Name:  "progress",
+						Usage: "Show progress as a bar, or as JSON line events with jsonl",
+					},
+					&cli.IntFlag{
+						Name:  "progress-fd",
+						Value: 2,
+						Usage: "File descriptor --progress jsonl writes to",
+					},
+					&cli.StringFlag{
+						Name:  "limit-rate",
+						Usage: "Cap the upload speed, e.g. 10MB/s",
+					},
+					&cli.StringFlag{
+						Name:  "chunk-size",
+						Usage: "Size of each request of a resumable upload (default: 50MiB)",
+					},
+					&cli.StringFlag{
+						Name:  "tus-threshold",
+						Usage: "Size above which files are sent as resumable uploads (default: 100MiB)",
+					},
+					&cli.StringFlag{
+						Name:  "on-success",
+						Usage: "Run a shell command after each upload, e.g. 'purge {cid}'. Overrides PINATA_ON_SUCCESS and the profile",
+					},
+					&cli.StringFlag{
+						Name:  "webhook",
+						Usage: "POST the upload response to a URL after each upload. Overrides PINATA_WEBHOOK and the profile",
+					},
+					&cli.BoolFlag{
+						Name:  "no-hooks",
+						Usage: "Skip the --on-success command and --webhook of the profile",
+					},
+					&cli.IntFlag{
+						Name:  "parallel",
+						Value: 1,
+						Usage: "Send resumable uploads as this many parts at once, when the server supports it. Each part holds one --chunk-size in memory",
+					},
 				},
 				Action: func(ctx *cli.Context) error {
+					if err := setTransfer(ctx); err != nil {
+						return err
+					}
+					if err := setHooks(ctx); err != nil {
+						return err
+					}
 					filePath := ctx.Args().First()
 					groupId := ctx.String("group")
 					name := ctx.String("name")
-					verbose := ctx.Bool("verbose")
+					verbose := ctx.Bool("verbose") || ctx.String("progress") == uploads.ProgressBar
 					network := ctx.String("network")
+					if err := uploads.SetProgress(ctx.String("progress"), ctx.Int("progress-fd")); err != nil {
+						return err
+					}
+					if ctx.IsSet("watch") {
+						if filePath != "" {
+							return exitcode.InvalidInputf("pass the folder to --watch instead of as an argument")
+						}
+						if ctx.IsSet("name") {
+							return exitcode.InvalidInputf("--name can't be used with --watch, files are named by their path in the folder")
+						}
+						return uploads.Watch(ctx.String("watch"), uploads.WatchOptions{
+							GroupId:  groupId,
+							Network:  network,
+							Interval: ctx.Duration("interval"),
+							Debounce: ctx.Duration("debounce"),
+							Replace:  ctx.String("replace"),
+							Initial:  ctx.Bool("initial"),
+						})
+					}
 					if filePath == "" {
-						return errors.New("no file path provided")
+						return exitcode.InvalidInputf("no file path provided")
 					}
 					_, err := uploads.Upload(filePath, groupId, name, verbose, network)
 					return err
 				},
+				Subcommands: []*cli.Command{
+					{
+						Name:      "json",
+						Usage:     "Upload a JSON document without writing it to a file first",
+						ArgsUsage: "[JSON document]",
+						Description: `Uploads JSON given as an argument, with --from-file or on stdin, as an
+application/json file. The document is validated first, and --canonicalize
+sorts its keys and removes whitespace so equal documents get the same CID.
+
+With --template, the input is CSV instead and a document is uploaded for each
+row. The template is a JSON skeleton whose strings may hold {{column}}
+placeholders, replaced by the row's cell as text, while a string that is
+exactly "{{{column}}}" is replaced by the cell as a JSON value, such as a
+number. --name and --keyvalue may hold placeholders too. Names default to the
+row number, e.g. 1.json.
+
+Examples:
+  pinata upload json '{"name": "Token #1", "image": "ipfs://..."}' --name 1.json
+  cat manifest.json | pinata upload json --canonicalize
+  pinata upload json --template nft.json --from-file tokens.csv --name '{{id}}.json'`,
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:    "from-file",
+								Aliases: []string{"f"},
+								Usage:   "Read the JSON, or the CSV with --template, from a file, or - for stdin",
+							},
+							&cli.StringFlag{
+								Name:    "name",
+								Aliases: []string{"n"},
+								Usage:   "Name of the file. Defaults to data.json or the name of the file read",
+							},
+							&cli.StringFlag{
+								Name:    "group",
+								Aliases: []string{"g"},
+								Usage:   "Upload to a specific group by passing in the groupId",
+							},
+							&cli.StringSliceFlag{
+								Name:    "keyvalue",
+								Aliases: []string{"kv"},
+								Usage:   "Add metadata to the file (format: key=value). Repeat for more",
+							},
+							&cli.BoolFlag{
+								Name:  "canonicalize",
+								Usage: "Sort object keys and remove insignificant whitespace before uploading",
+							},
+							&cli.StringFlag{
+								Name:  "template",
+								Usage: "Fill this JSON skeleton from each row of CSV input and upload a document per row",
+							},
+							&cli.BoolFlag{
+								Name:  "dry-run",
+								Usage: "Print the documents that would be uploaded instead of uploading them",
+							},
+							&cli.StringFlag{
+								Name:    "network",
+								Aliases: []string{"net"},
+								Usage:   "Specify the network (public or private). Uses default if not specified",
+							},
+							&cli.StringFlag{
+								Name:  "progress",
+								Usage: "Report the progress of each upload as JSON line events with jsonl",
+							},
+							&cli.IntFlag{
+								Name:  "progress-fd",
+								Value: 2,
+								Usage: "File descriptor --progress jsonl writes to",
+							},
+							&cli.StringFlag{
+								Name:  "limit-rate",
+								Usage: "Cap the upload speed, e.g. 10MB/s",
+							},
+							&cli.StringFlag{
+								Name:  "on-success",
+								Usage: "Run a shell command after each upload, e.g. 'purge {cid}'. Overrides PINATA_ON_SUCCESS and the profile",
+							},
+							&cli.StringFlag{
+								Name:  "webhook",
+								Usage: "POST the upload response to a URL after each upload. Overrides PINATA_WEBHOOK and the profile",
+							},
+							&cli.BoolFlag{
+								Name:  "no-hooks",
+								Usage: "Skip the --on-success command and --webhook of the profile",
+							},
+						},
+						Action: func(ctx *cli.Context) error {
+							keyValues, err := utils.ParseKeyValues(ctx.StringSlice("keyvalue"))
+							if err != nil {
+								return err
+							}
+							if err := uploads.SetProgress(ctx.String("progress"), ctx.Int("progress-fd")); err != nil {
+								return err
+							}
+							if err := setTransfer(ctx); err != nil {
+								return err
+							}
+							if err := setHooks(ctx); err != nil {
+								return err
+							}
+							_, err = uploads.UploadJSON(ctx.Args().First(), ctx.String("from-file"), uploads.JSONOptions{
+								Network:      ctx.String("network"),
+								Name:         ctx.String("name"),
+								GroupId:      ctx.String("group"),
+								KeyValues:    keyValues,
+								Canonicalize: ctx.Bool("canonicalize"),
+								Template:     ctx.String("template"),
+								DryRun:       ctx.Bool("dry-run"),
+							})
+							return err
+						},
+					},
+				},
+			},
+			{
+				Name:      "pin",
+				Usage:     "Pin content that's already on IPFS by its CID",
+				ArgsUsage: "[CID]",
+				Description: `Queues existing IPFS content, for example files pinned with another service,
+to be pinned on the public network. Pinata searches for the content and pins
+it once retrieved; follow the progress with 'pinata pin jobs --watch'.
+
+With --from-file, every CID in the file is queued. Each line holds a CID,
+optionally followed by a name, and lines starting with # are skipped. Use -
+to read the list from stdin.`,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "name",
+						Aliases: []string{"n"},
+						Usage:   "Name of the pinned file",
+					},
+					&cli.StringFlag{
+						Name:    "group",
+						Aliases: []string{"g"},
+						Usage:   "Add the pinned file to a public group",
+					},
+					&cli.StringSliceFlag{
+						Name:    "keyvalue",
+						Aliases: []string{"kv"},
+						Usage:   "Add metadata to the pinned file (format: key=value). Repeat for more",
+					},
+					&cli.StringFlag{
+						Name:    "from-file",
+						Aliases: []string{"f"},
+						Usage:   "Pin every CID listed in a file, or - for stdin",
+					},
+				},
+				Action: func(ctx *cli.Context) error {
+					cid := ctx.Args().First()
+					fromFile := ctx.String("from-file")
+					keyValues, err := utils.ParseKeyValues(ctx.StringSlice("keyvalue"))
+					if err != nil {
+						return err
+					}
+					opts := pinata.PinByCIDOptions{
+						Name:      ctx.String("name"),
+						GroupId:   ctx.String("group"),
+						KeyValues: keyValues,
+					}
+					switch {
+					case cid != "" && fromFile != "":
+						return exitcode.InvalidInputf("pass either a CID or --from-file, not both")
+					case fromFile != "":
+						_, err = pins.PinFromFile(fromFile, opts)
+					case cid != "":
+						_, err = pins.Pin(cid, opts)
+					default:
+						return exitcode.InvalidInputf("no CID provided")
+					}
+					return err
+				},
+				Subcommands: []*cli.Command{
+					{
+						Name:    "jobs",
+						Aliases: []string{"j"},
+						Usage:   "List the pin jobs still in the queue",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "cid",
+								Usage: "Filter jobs by CID",
+							},
+							&cli.StringFlag{
+								Name:    "status",
+								Aliases: []string{"s"},
+								Usage:   "Filter jobs by status, e.g. prechecking, searching, retrieving or expired",
+							},
+							&cli.StringFlag{
+								Name:    "amount",
+								Aliases: []string{"a"},
+								Usage:   "The number of jobs you would like to return",
+							},
+							&cli.StringFlag{
+								Name:    "token",
+								Aliases: []string{"t"},
+								Usage:   "Paginate through jobs using the pageToken",
+							},
+							&cli.BoolFlag{
+								Name:    "watch",
+								Aliases: []string{"w"},
+								Usage:   "Print each change of state until no job is pending. Fails if a job that was pending failed",
+							},
+							&cli.DurationFlag{
+								Name:  "interval",
+								Value: 5 * time.Second,
+								Usage: "How often --watch checks the queue",
+							},
+						},
+						Action: func(ctx *cli.Context) error {
+							opts := pinata.ListPinJobsOptions{
+								Cid:    ctx.String("cid"),
+								Status: ctx.String("status"),
+								Sort:   "ASC",
+							}
+							if ctx.Bool("watch") {
+								if ctx.Duration("interval") <= 0 {
+									return exitcode.InvalidInputf("--interval must be positive")
+								}
+								return pins.WatchJobs(opts, ctx.Duration("interval"))
+							}
+							_, err := pins.ListJobs(ctx.String("amount"), ctx.String("token"), opts)
+							return err
+						},
+					},
+				},
 			},
 			{
 				Name:    "groups",
@@ -100,7 +532,7 @@ func main() {
 							name := ctx.Args().First()
 							network := ctx.String("network")
 							if name == "" {
-								return errors.New("Group name required")
+								return exitcode.InvalidInputf("Group name required")
 							}
 							_, err := groups.CreateGroup(name, network)
 							return err
@@ -160,11 +592,11 @@ func main() {
 							},
 						},
 						Action: func(ctx *cli.Context) error {
-							groupId := ctx.Args().First()
+							groupId := refs.ArgsOf(ctx).First()
 							name := ctx.String("name")
 							network := ctx.String("network")
 							if groupId == "" {
-								return errors.New("no ID provided")
+								return exitcode.InvalidInputf("no ID provided")
 							}
 							_, err := groups.UpdateGroup(groupId, name, network)
 							return err
@@ -183,10 +615,10 @@ func main() {
 							},
 						},
 						Action: func(ctx *cli.Context) error {
-							groupId := ctx.Args().First()
+							groupId := refs.ArgsOf(ctx).First()
 							network := ctx.String("network")
 							if groupId == "" {
-								return errors.New("no ID provided")
+								return exitcode.InvalidInputf("no ID provided")
 							}
 							err := groups.DeleteGroup(groupId, network)
 							return err
@@ -205,10 +637,10 @@ func main() {
 							},
 						},
 						Action: func(ctx *cli.Context) error {
-							groupId := ctx.Args().First()
+							groupId := refs.ArgsOf(ctx).First()
 							network := ctx.String("network")
 							if groupId == "" {
-								return errors.New("no ID provided")
+								return exitcode.InvalidInputf("no ID provided")
 							}
 							_, err := groups.GetGroup(groupId, network)
 							return err
@@ -227,14 +659,14 @@ func main() {
 							},
 						},
 						Action: func(ctx *cli.Context) error {
-							groupId := ctx.Args().First()
-							fileId := ctx.Args().Get(1)
+							groupId := refs.ArgsOf(ctx).First()
+							fileId := refs.ArgsOf(ctx).Get(1)
 							network := ctx.String("network")
 							if groupId == "" {
-								return errors.New("no group id provided")
+								return exitcode.InvalidInputf("no group id provided")
 							}
 							if fileId == "" {
-								return errors.New("no file id provided")
+								return exitcode.InvalidInputf("no file id provided")
 							}
 							err := groups.AddFile(groupId, fileId, network)
 							return err
@@ -253,14 +685,14 @@ func main() {
 							},
 						},
 						Action: func(ctx *cli.Context) error {
-							groupId := ctx.Args().First()
-							fileId := ctx.Args().Get(1)
+							groupId := refs.ArgsOf(ctx).First()
+							fileId := refs.ArgsOf(ctx).Get(1)
 							network := ctx.String("network")
 							if groupId == "" {
-								return errors.New("no group id provided")
+								return exitcode.InvalidInputf("no group id provided")
 							}
 							if fileId == "" {
-								return errors.New("no file id provided")
+								return exitcode.InvalidInputf("no file id provided")
 							}
 							err := groups.RemoveFile(groupId, fileId, network)
 							return err
@@ -286,10 +718,10 @@ func main() {
 							},
 						},
 						Action: func(ctx *cli.Context) error {
-							fileId := ctx.Args().First()
+							fileId := refs.ArgsOf(ctx).First()
 							network := ctx.String("network")
 							if fileId == "" {
-								return errors.New("no file ID provided")
+								return exitcode.InvalidInputf("no file ID provided")
 							}
 							err := files.DeleteFile(fileId, network)
 							return err
@@ -298,9 +730,14 @@ func main() {
 					{
 						Name:      "get",
 						Aliases:   []string{"g"},
-						Usage:     "Get file info by ID",
-						ArgsUsage: "[ID of file]",
+						Usage:     "Get file info by ID, or download a CID's files with --output",
+						ArgsUsage: "[ID of file or CID]",
 						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:    "output",
+								Aliases: []string{"o"},
+								Usage:   "Fetch the CID as a verified CAR from the gateway and write its file or folder to this path",
+							},
 							&cli.StringFlag{
 								Name:    "network",
 								Aliases: []string{"net"},
@@ -308,15 +745,47 @@ func main() {
 							},
 						},
 						Action: func(ctx *cli.Context) error {
-							fileId := ctx.Args().First()
 							network := ctx.String("network")
+							if ctx.IsSet("output") {
+								// A CID isn't a reference, so use the argument as given
+								cid := ctx.Args().First()
+								if cid == "" {
+									return exitcode.InvalidInputf("no CID provided")
+								}
+								return files.GetCID(cid, ctx.String("output"), network)
+							}
+							fileId := refs.ArgsOf(ctx).First()
 							if fileId == "" {
-								return errors.New("no CID provided")
+								return exitcode.InvalidInputf("no CID provided")
 							}
 							_, err := files.GetFile(fileId, network)
 							return err
 						},
 					},
+					{
+						Name:      "ls",
+						Usage:     "List the files under a CID, fetched as a verified CAR from the gateway",
+						ArgsUsage: "[CID]",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "json",
+								Usage: "Print the entries as JSON instead of a tree",
+							},
+							&cli.StringFlag{
+								Name:    "network",
+								Aliases: []string{"net"},
+								Usage:   "Specify the network (public or private). Uses default if not specified",
+							},
+						},
+						Action: func(ctx *cli.Context) error {
+							cid := ctx.Args().First()
+							if cid == "" {
+								return exitcode.InvalidInputf("no CID provided")
+							}
+							_, err := files.ListCID(cid, ctx.String("network"), ctx.Bool("json"))
+							return err
+						},
+					},
 					{
 						Name:      "update",
 						Aliases:   []string{"u"},
@@ -335,11 +804,11 @@ func main() {
 							},
 						},
 						Action: func(ctx *cli.Context) error {
-							fileId := ctx.Args().First()
+							fileId := refs.ArgsOf(ctx).First()
 							name := ctx.String("name")
 							network := ctx.String("network")
 							if fileId == "" {
-								return errors.New("no ID provided")
+								return exitcode.InvalidInputf("no ID provided")
 							}
 							_, err := files.UpdateFile(fileId, name, network)
 							return err
@@ -419,6 +888,69 @@ func main() {
 					},
 				},
 			},
+			{
+				Name:  "car",
+				Usage: "Build, unpack and verify CAR files of UnixFS DAGs locally",
+				Subcommands: []*cli.Command{
+					{
+						Name:      "pack",
+						Usage:     "Build the DAG of a file or folder into a CAR, with the CID an upload of it gets",
+						ArgsUsage: "[path to file or folder]",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:    "output",
+								Aliases: []string{"o"},
+								Usage:   "Path of the CAR to write, or - for stdout. Defaults to the file or folder name with .car",
+							},
+						},
+						Action: func(ctx *cli.Context) error {
+							path := ctx.Args().First()
+							if path == "" {
+								return exitcode.InvalidInputf("no file or folder provided")
+							}
+							_, err := car.Pack(path, ctx.String("output"))
+							return err
+						},
+					},
+					{
+						Name:      "unpack",
+						Usage:     "Verify a CAR and write the file or folder it holds",
+						ArgsUsage: "[path to CAR]",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:    "output",
+								Aliases: []string{"o"},
+								Usage:   "Path to write to, which must not exist. Defaults to the root CID",
+							},
+							&cli.StringFlag{
+								Name:  "root",
+								Usage: "The root to unpack when the CAR has more than one",
+							},
+						},
+						Action: func(ctx *cli.Context) error {
+							path := ctx.Args().First()
+							if path == "" {
+								return exitcode.InvalidInputf("no CAR provided, use - for stdin")
+							}
+							_, err := car.Unpack(path, ctx.String("output"), ctx.String("root"))
+							return err
+						},
+					},
+					{
+						Name:      "inspect",
+						Usage:     "Verify every block of a CAR and list its roots, blocks and sizes",
+						ArgsUsage: "[path to CAR]",
+						Action: func(ctx *cli.Context) error {
+							path := ctx.Args().First()
+							if path == "" {
+								return exitcode.InvalidInputf("no CAR provided, use - for stdin")
+							}
+							_, err := car.Inspect(path)
+							return err
+						},
+					},
+				},
+			},
 			{
 				Name:    "swaps",
 				Aliases: []string{"s"},
@@ -441,7 +973,7 @@ func main() {
 							domain := ctx.Args().Get(1)
 							network := ctx.String("network")
 							if cid == "" {
-								return errors.New("No CID provided")
+								return exitcode.InvalidInputf("No CID provided")
 							}
 							_, err := files.GetSwapHistory(cid, domain, network)
 							return err
@@ -464,10 +996,10 @@ func main() {
 							swapCid := ctx.Args().Get(1)
 							network := ctx.String("network")
 							if cid == "" {
-								return errors.New("No CID provided")
+								return exitcode.InvalidInputf("No CID provided")
 							}
 							if swapCid == "" {
-								return errors.New("No swap CID provided")
+								return exitcode.InvalidInputf("No swap CID provided")
 							}
 							_, err := files.AddSwap(cid, swapCid, network)
 							return err
@@ -489,7 +1021,7 @@ func main() {
 							cid := ctx.Args().First()
 							network := ctx.String("network")
 							if cid == "" {
-								return errors.New("No CID provided")
+								return exitcode.InvalidInputf("No CID provided")
 							}
 							err := files.RemoveSwap(cid, network)
 							return err
@@ -518,20 +1050,20 @@ func main() {
 						Aliases:   []string{"o"},
 						Usage:     "Open a file in the browser",
 						ArgsUsage: "[CID of the file]",
-						Flags: []cli.Flag{
+						Flags: append([]cli.Flag{
 							&cli.StringFlag{
 								Name:    "network",
 								Aliases: []string{"net"},
 								Usage:   "Specify the network (public or private). Uses default if not specified",
 							},
-						},
+						}, linkOptionFlags()...),
 						Action: func(ctx *cli.Context) error {
 							cid := ctx.Args().First()
 							network := ctx.String("network")
 							if cid == "" {
-								return errors.New("No CID provided")
+								return exitcode.InvalidInputf("No CID provided")
 							}
-							err := gateways.OpenCID(cid, network)
+							err := gateways.OpenCID(cid, network, linkOptionsFromContext(ctx))
 							return err
 						},
 					},
@@ -540,18 +1072,18 @@ func main() {
 						Aliases:   []string{"l"},
 						Usage:     "Get either an IPFS link for a public file or a temporary access link for a Private IPFS file",
 						ArgsUsage: "[cid of the file, seconds the url is valid for]",
-						Flags: []cli.Flag{
+						Flags: append([]cli.Flag{
 							&cli.StringFlag{
 								Name:    "network",
 								Aliases: []string{"net"},
 								Usage:   "Specify the network (public or private). Uses default if not specified",
 							},
-						},
+						}, linkOptionFlags()...),
 						Action: func(ctx *cli.Context) error {
 							network := ctx.String("network")
 							cid := ctx.Args().First()
 							if cid == "" {
-								return errors.New("No CID provided")
+								return exitcode.InvalidInputf("No CID provided")
 							}
 							expires := ctx.Args().Get(1)
 
@@ -561,9 +1093,9 @@ func main() {
 
 							expiresInt, err := strconv.Atoi(expires)
 							if err != nil {
-								return errors.New("Invalid expire time")
+								return exitcode.InvalidInputf("Invalid expire time")
 							}
-							_, err = gateways.GetAccessLink(cid, expiresInt, network)
+							_, err = gateways.GetAccessLink(cid, expiresInt, network, linkOptionsFromContext(ctx))
 							return err
 						},
 					},
@@ -580,10 +1112,9 @@ func main() {
 						Usage:   "Create an API key with admin or scoped permissions",
 						Flags: []cli.Flag{
 							&cli.StringFlag{
-								Name:     "name",
-								Aliases:  []string{"n"},
-								Usage:    "Name of the API key",
-								Required: true,
+								Name:    "name",
+								Aliases: []string{"n"},
+								Usage:   "Name of the API key (required unless set in the policy file)",
 							},
 							&cli.BoolFlag{
 								Name:    "admin",
@@ -598,8 +1129,13 @@ func main() {
 							},
 							&cli.StringSliceFlag{
 								Name:    "endpoints",
-								Aliases: []string{"e"},
-								Usage:   "Optional array of endpoints the key is allowed to use",
+								Aliases: []string{"e", "scopes"},
+								Usage:   "Scopes the key is allowed to use, e.g. files:read, groups:write or pinByHash",
+							},
+							&cli.StringFlag{
+								Name:    "policy",
+								Aliases: []string{"p"},
+								Usage:   "Path to a JSON policy file with the key name, admin, max_uses and scopes",
 							},
 						},
 						Action: func(ctx *cli.Context) error {
@@ -607,6 +1143,22 @@ func main() {
 							admin := ctx.Bool("admin")
 							uses := ctx.Int("uses")
 							endpoints := ctx.StringSlice("endpoints")
+							if policyPath := ctx.String("policy"); policyPath != "" {
+								policy, err := keys.LoadPolicy(policyPath)
+								if err != nil {
+									return err
+								}
+								if name == "" {
+									name = policy.Name
+								}
+								if !ctx.IsSet("admin") {
+									admin = policy.Admin
+								}
+								if uses == 0 {
+									uses = policy.MaxUses
+								}
+								endpoints = append(policy.Scopes, endpoints...)
+							}
 							_, err := keys.CreateKey(name, admin, uses, endpoints)
 							return err
 						},
@@ -653,14 +1205,81 @@ func main() {
 						},
 					},
 					{
-						Name:      "revoke",
-						Aliases:   []string{"r"},
-						Usage:     "Revoke an API key",
+						Name:  "audit",
+						Usage: "Audit every active API key and exit with an error when a check fails",
+						Flags: []cli.Flag{
+							&cli.IntFlag{
+								Name:  "near-exhaustion",
+								Value: 90,
+								Usage: "Flag limited keys that have used at least this percentage of their max uses",
+							},
+							&cli.IntFlag{
+								Name:  "unused-days",
+								Value: 90,
+								Usage: "Flag keys that have never been used and are older than this many days. Set to 0 to disable",
+							},
+							&cli.StringSliceFlag{
+								Name:  "ignore",
+								Usage: "Report but don't fail on a check (near-exhaustion, unused, admin, duplicate-name)",
+							},
+						},
+						Action: func(ctx *cli.Context) error {
+							_, err := keys.AuditKeys(keys.AuditOptions{
+								ExhaustionPercent: ctx.Int("near-exhaustion"),
+								UnusedDays:        ctx.Int("unused-days"),
+								Ignore:            ctx.StringSlice("ignore"),
+								JSON:              outputFormat == outputJSON,
+							})
+							return err
+						},
+					},
+					{
+						Name:      "rotate",
+						Usage:     "Replace an API key with a new one that has the same scopes, then revoke the old key",
+						ArgsUsage: "[key ID or name]",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "target",
+								Value: keys.RotateTargetAuth,
+								Usage: "Where to write the new JWT: auth (the CLI auth store), file or stdout",
+							},
+							&cli.StringFlag{
+								Name:  "file",
+								Usage: "File to write the new JWT to when the target is 'file'",
+							},
+							&cli.StringFlag{
+								Name:    "name",
+								Aliases: []string{"n"},
+								Usage:   "Name for the new key. Defaults to the name of the old key",
+							},
+							&cli.DurationFlag{
+								Name:  "grace",
+								Usage: "How long to wait before revoking the old key, e.g. 10m",
+							},
+						},
+						Action: func(ctx *cli.Context) error {
+							key := ctx.Args().First()
+							if key == "" {
+								return exitcode.InvalidInputf("No key provided")
+							}
+							_, err := keys.RotateKey(key, keys.RotateOptions{
+								Name:   ctx.String("name"),
+								Target: ctx.String("target"),
+								File:   ctx.String("file"),
+								Grace:  ctx.Duration("grace"),
+							})
+							return err
+						},
+					},
+					{
+						Name:      "revoke",
+						Aliases:   []string{"r"},
+						Usage:     "Revoke an API key",
 						ArgsUsage: "[key]",
 						Action: func(ctx *cli.Context) error {
 							key := ctx.Args().First()
 							if key == "" {
-								return errors.New("No key provided")
+								return exitcode.InvalidInputf("No key provided")
 							}
 							err := keys.RevokeKey(key)
 							return err
@@ -692,132 +1311,247 @@ func main() {
 							return config.SetDefaultNetwork(network)
 						},
 					},
+					{
+						Name:    "profiles",
+						Aliases: []string{"p"},
+						Usage:   "Manage named profiles for multiple accounts and environments",
+						Subcommands: []*cli.Command{
+							{
+								Name:    "list",
+								Aliases: []string{"l"},
+								Usage:   "List profiles",
+								Action: func(ctx *cli.Context) error {
+									return config.ListProfiles()
+								},
+							},
+							{
+								Name:      "show",
+								Aliases:   []string{"s"},
+								Usage:     "Show the settings of a profile, defaults to the active profile",
+								ArgsUsage: "[profile name]",
+								Action: func(ctx *cli.Context) error {
+									return config.ShowProfile(ctx.Args().First())
+								},
+							},
+							{
+								Name:      "create",
+								Aliases:   []string{"c"},
+								Usage:     "Create a profile. Authorize it with 'pinata --profile [name] auth'",
+								ArgsUsage: "[profile name]",
+								Flags:     profileFlags(),
+								Action: func(ctx *cli.Context) error {
+									name := ctx.Args().First()
+									if name == "" {
+										return exitcode.InvalidInputf("no profile name provided")
+									}
+									if ctx.String("webhook") != "" {
+										if err := uploads.ValidateWebhook(ctx.String("webhook")); err != nil {
+											return err
+										}
+									}
+									profile := config.Profile{}
+									applyProfileFlags(ctx, &profile)
+									err := config.CreateProfile(name, profile)
+									if err != nil {
+										return err
+									}
+									fmt.Printf("Profile '%s' created\n", name)
+									return nil
+								},
+							},
+							{
+								Name:  "set",
+								Usage: "Update settings on the active profile",
+								Flags: profileFlags(),
+								Action: func(ctx *cli.Context) error {
+									if ctx.IsSet("network") {
+										if _, err := config.GetNetworkParam(ctx.String("network")); err != nil {
+											return err
+										}
+									}
+									if ctx.String("webhook") != "" {
+										if err := uploads.ValidateWebhook(ctx.String("webhook")); err != nil {
+											return err
+										}
+									}
+									err := config.UpdateActiveProfile(func(p *config.Profile) {
+										applyProfileFlags(ctx, p)
+									})
+									if err != nil {
+										return err
+									}
+									fmt.Println("Profile updated")
+									return nil
+								},
+							},
+							{
+								Name:      "use",
+								Aliases:   []string{"u"},
+								Usage:     "Set the current profile",
+								ArgsUsage: "[profile name]",
+								Action: func(ctx *cli.Context) error {
+									name := ctx.Args().First()
+									if name == "" {
+										return exitcode.InvalidInputf("no profile name provided")
+									}
+									err := config.UseProfile(name)
+									if err != nil {
+										return err
+									}
+									fmt.Printf("Now using profile '%s'\n", name)
+									return nil
+								},
+							},
+							{
+								Name:      "delete",
+								Aliases:   []string{"d"},
+								Usage:     "Delete a profile",
+								ArgsUsage: "[profile name]",
+								Action: func(ctx *cli.Context) error {
+									name := ctx.Args().First()
+									if name == "" {
+										return exitcode.InvalidInputf("no profile name provided")
+									}
+									err := config.DeleteProfile(name)
+									if err != nil {
+										return err
+									}
+									err = credentials.Delete(name)
+									if err != nil {
+										return err
+									}
+									fmt.Printf("Profile '%s' deleted\n", name)
+									return nil
+								},
+							},
+						},
+					},
 				},
 			},
 			{
 				Name:    "agents",
-			Aliases: []string{"ag"},
-			Usage:   "Interact with AI agents on Pinata",
-			Subcommands: []*cli.Command{
-				{
-					Name:    "list",
-					Aliases: []string{"l"},
-					Usage:   "List all agents",
-					Action: func(ctx *cli.Context) error {
-						_, err := agents.ListAgents()
-						return err
+				Aliases: []string{"ag"},
+				Usage:   "Interact with AI agents on Pinata",
+				Subcommands: []*cli.Command{
+					{
+						Name:    "list",
+						Aliases: []string{"l"},
+						Usage:   "List all agents",
+						Action: func(ctx *cli.Context) error {
+							_, err := agents.ListAgents()
+							return err
+						},
 					},
-				},
-				{
-					Name:    "create",
-					Aliases: []string{"c"},
-					Usage:   "Create a new agent",
-					Flags: []cli.Flag{
-						&cli.StringFlag{
-							Name:     "name",
-							Aliases:  []string{"n"},
-							Usage:    "Name of the agent (required)",
-							Required: true,
-						},
-						&cli.StringFlag{
-							Name:    "description",
-							Aliases: []string{"d"},
-							Usage:   "Agent personality description",
-						},
-						&cli.StringFlag{
-							Name:  "vibe",
-							Usage: "Agent vibe/tagline",
-						},
-						&cli.StringFlag{
-							Name:  "emoji",
-							Usage: "Agent emoji",
-						},
-						&cli.StringSliceFlag{
-							Name:  "skill",
-							Usage: "Skill CIDs to attach (can be specified multiple times)",
-						},
-						&cli.StringSliceFlag{
-							Name:  "secret",
-							Usage: "Secret IDs to attach (can be specified multiple times)",
-						},
-						&cli.StringFlag{
-							Name:    "template",
-							Aliases: []string{"t"},
-							Usage:   "Template ID to deploy from (uses template snapshot, skills, and defaults)",
-						},
-					},
-					Action: func(ctx *cli.Context) error {
-						name := ctx.String("name")
-						description := ctx.String("description")
-						vibe := ctx.String("vibe")
-						emoji := ctx.String("emoji")
-						skills := ctx.StringSlice("skill")
-						secrets := ctx.StringSlice("secret")
-						template := ctx.String("template")
-						_, err := agents.CreateAgent(name, description, vibe, emoji, template, skills, secrets)
-						return err
+					{
+						Name:    "create",
+						Aliases: []string{"c"},
+						Usage:   "Create a new agent",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:     "name",
+								Aliases:  []string{"n"},
+								Usage:    "Name of the agent (required)",
+								Required: true,
+							},
+							&cli.StringFlag{
+								Name:    "description",
+								Aliases: []string{"d"},
+								Usage:   "Agent personality description",
+							},
+							&cli.StringFlag{
+								Name:  "vibe",
+								Usage: "Agent vibe/tagline",
+							},
+							&cli.StringFlag{
+								Name:  "emoji",
+								Usage: "Agent emoji",
+							},
+							&cli.StringSliceFlag{
+								Name:  "skill",
+								Usage: "Skill CIDs to attach (can be specified multiple times)",
+							},
+							&cli.StringSliceFlag{
+								Name:  "secret",
+								Usage: "Secret IDs to attach (can be specified multiple times)",
+							},
+							&cli.StringFlag{
+								Name:    "template",
+								Aliases: []string{"t"},
+								Usage:   "Template ID to deploy from (uses template snapshot, skills, and defaults)",
+							},
+						},
+						Action: func(ctx *cli.Context) error {
+							name := ctx.String("name")
+							description := ctx.String("description")
+							vibe := ctx.String("vibe")
+							emoji := ctx.String("emoji")
+							skills := ctx.StringSlice("skill")
+							secrets := ctx.StringSlice("secret")
+							template := ctx.String("template")
+							_, err := agents.CreateAgent(name, description, vibe, emoji, template, skills, secrets)
+							return err
+						},
 					},
-				},
-				{
-					Name:      "get",
-					Aliases:   []string{"g"},
-					Usage:     "Get agent details",
-					ArgsUsage: "[agent ID]",
-					Action: func(ctx *cli.Context) error {
-						agentID := ctx.Args().First()
-						if agentID == "" {
-							return errors.New("no agent ID provided")
-						}
-						_, err := agents.GetAgent(agentID)
-						return err
+					{
+						Name:      "get",
+						Aliases:   []string{"g"},
+						Usage:     "Get agent details",
+						ArgsUsage: "[agent ID]",
+						Action: func(ctx *cli.Context) error {
+							agentID := refs.ArgsOf(ctx).First()
+							if agentID == "" {
+								return exitcode.InvalidInputf("no agent ID provided")
+							}
+							_, err := agents.GetAgent(agentID)
+							return err
+						},
 					},
-				},
-				{
-					Name:      "delete",
-					Aliases:   []string{"d"},
-					Usage:     "Delete an agent",
-					ArgsUsage: "[agent ID]",
-					Action: func(ctx *cli.Context) error {
-						agentID := ctx.Args().First()
-						if agentID == "" {
-							return errors.New("no agent ID provided")
-						}
-						return agents.DeleteAgent(agentID)
+					{
+						Name:      "delete",
+						Aliases:   []string{"d"},
+						Usage:     "Delete an agent",
+						ArgsUsage: "[agent ID]",
+						Action: func(ctx *cli.Context) error {
+							agentID := refs.ArgsOf(ctx).First()
+							if agentID == "" {
+								return exitcode.InvalidInputf("no agent ID provided")
+							}
+							return agents.DeleteAgent(agentID)
+						},
 					},
-				},
-				{
-					Name:      "restart",
-					Aliases:   []string{"r"},
-					Usage:     "Restart an agent",
-					ArgsUsage: "[agent ID]",
-					Action: func(ctx *cli.Context) error {
-						agentID := ctx.Args().First()
-						if agentID == "" {
-							return errors.New("no agent ID provided")
-						}
-						_, err := agents.RestartAgent(agentID)
-						return err
+					{
+						Name:      "restart",
+						Aliases:   []string{"r"},
+						Usage:     "Restart an agent",
+						ArgsUsage: "[agent ID]",
+						Action: func(ctx *cli.Context) error {
+							agentID := refs.ArgsOf(ctx).First()
+							if agentID == "" {
+								return exitcode.InvalidInputf("no agent ID provided")
+							}
+							_, err := agents.RestartAgent(agentID)
+							return err
+						},
 					},
-				},
-				{
-					Name:      "logs",
-					Usage:     "Get agent logs",
-					ArgsUsage: "[agent ID]",
-					Action: func(ctx *cli.Context) error {
-						agentID := ctx.Args().First()
-						if agentID == "" {
-							return errors.New("no agent ID provided")
-						}
-						_, err := agents.GetAgentLogs(agentID)
-						return err
+					{
+						Name:      "logs",
+						Usage:     "Get agent logs",
+						ArgsUsage: "[agent ID]",
+						Action: func(ctx *cli.Context) error {
+							agentID := refs.ArgsOf(ctx).First()
+							if agentID == "" {
+								return exitcode.InvalidInputf("no agent ID provided")
+							}
+							_, err := agents.GetAgentLogs(agentID)
+							return err
+						},
 					},
-				},
-				{
-					Name:      "chat",
-					Aliases:   []string{"c"},
-					Usage:     "Interactive chat with an agent",
-					ArgsUsage: "[agent ID] [optional prompt]",
-					Description: `Start an interactive chat session with an agent.
+					{
+						Name:      "chat",
+						Aliases:   []string{"c"},
+						Usage:     "Interactive chat with an agent",
+						ArgsUsage: "[agent ID] [optional prompt]",
+						Description: `Start an interactive chat session with an agent.
 
 The gateway URL and token are automatically fetched from the agent's configuration.
 
@@ -845,531 +1579,531 @@ Examples:
 
   # Filter JSONL with jq
   echo "hi" | pinata agents chat <id> | jq -c 'select(.type=="content_delta")'`,
-					Flags: []cli.Flag{
-						&cli.StringFlag{
-							Name:  "model",
-							Usage: "Model override",
-						},
-						&cli.BoolFlag{
-							Name:  "json",
-							Usage: "Force JSONL output (auto-enabled when stdout is not a TTY)",
-						},
-						&cli.BoolFlag{
-							Name:  "text",
-							Usage: "Force plain text output (simpler alternative to JSONL for pipes)",
-						},
-						&cli.BoolFlag{
-							Name:    "conversation",
-							Aliases: []string{"C"},
-							Usage:   "Multi-turn conversation mode (read messages from stdin line-by-line)",
-						},
-						&cli.StringFlag{
-							Name:  "session",
-							Usage: "Session key for conversation context (default: agent:main:cli)",
-						},
-						&cli.BoolFlag{
-							Name:    "yes",
-							Aliases: []string{"y"},
-							Usage:   "Auto-approve tool calls (default: true, tools run server-side)",
-							Hidden:  true,
-							Value:   true,
-						},
-						&cli.StringFlag{
-							Name:   "gateway",
-							Usage:  "Override gateway URL (auto-detected from agent)",
-							Hidden: true,
-						},
-						&cli.StringFlag{
-							Name:   "token",
-							Usage:  "Override API token (auto-detected from agent)",
-							Hidden: true,
-						},
-					},
-					Action: func(ctx *cli.Context) error {
-						agentID := ctx.Args().First()
-						if agentID == "" {
-							return errors.New("no agent ID provided")
-						}
-						gatewayURL := ctx.String("gateway")
-						token := ctx.String("token")
-						model := ctx.String("model")
-						jsonOutput := ctx.Bool("json")
-						textOutput := ctx.Bool("text")
-						conversationMode := ctx.Bool("conversation")
-						autoApprove := ctx.Bool("yes")
-						session := ctx.String("session")
-
-						// Get optional prompt from remaining args
-						prompt := ""
-						if ctx.Args().Len() > 1 {
-							prompt = strings.Join(ctx.Args().Slice()[1:], " ")
-						}
-
-						return chat.StartChat(agentID, gatewayURL, token, model, jsonOutput, textOutput, conversationMode, autoApprove, prompt, session)
-					},
-				},
-				{
-					Name:      "exec",
-					Usage:     "Execute a command in an agent container",
-					ArgsUsage: "[agent ID] [command]",
-					Flags: []cli.Flag{
-						&cli.StringFlag{
-							Name:  "cwd",
-							Usage: "Working directory for the command",
-						},
-					},
-					Action: func(ctx *cli.Context) error {
-						agentID := ctx.Args().First()
-						command := ctx.Args().Get(1)
-						cwd := ctx.String("cwd")
-						if agentID == "" {
-							return errors.New("no agent ID provided")
-						}
-						if command == "" {
-							return errors.New("no command provided")
-						}
-						_, err := agents.ExecCommand(agentID, command, cwd)
-						return err
-					},
-				},
-				{
-					Name:    "skills",
-					Aliases: []string{"sk"},
-					Usage:   "Manage agent skills",
-					Subcommands: []*cli.Command{
-						{
-							Name:    "list",
-							Aliases: []string{"l"},
-							Usage:   "List available skills in library",
-							Action: func(ctx *cli.Context) error {
-								_, err := agents.ListSkills()
-								return err
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "model",
+								Usage: "Model override",
 							},
-						},
-						{
-							Name:    "create",
-							Aliases: []string{"c"},
-							Usage:   "Create a new skill",
-							Flags: []cli.Flag{
-								&cli.StringFlag{
-									Name:     "cid",
-									Usage:    "Content ID of the skill (required)",
-									Required: true,
-								},
-								&cli.StringFlag{
-									Name:     "name",
-									Aliases:  []string{"n"},
-									Usage:    "Skill name (required)",
-									Required: true,
-								},
-								&cli.StringFlag{
-									Name:    "description",
-									Aliases: []string{"d"},
-									Usage:   "Skill description",
-								},
-								&cli.StringSliceFlag{
-									Name:  "env",
-									Usage: "Required environment variable names",
-								},
-								&cli.StringFlag{
-									Name:  "file-id",
-									Usage: "Pinata v3 file ID",
-								},
-							},
-							Action: func(ctx *cli.Context) error {
-								cid := ctx.String("cid")
-								name := ctx.String("name")
-								description := ctx.String("description")
-								envVars := ctx.StringSlice("env")
-								fileId := ctx.String("file-id")
-								_, err := agents.CreateSkill(cid, name, description, envVars, fileId)
-								return err
+							&cli.BoolFlag{
+								Name:  "json",
+								Usage: "Force JSONL output (auto-enabled when stdout is not a TTY)",
 							},
-						},
-						{
-							Name:      "delete",
-							Aliases:   []string{"d"},
-							Usage:     "Delete a skill from library",
-							ArgsUsage: "[skill CID]",
-							Action: func(ctx *cli.Context) error {
-								skillCid := ctx.Args().First()
-								if skillCid == "" {
-									return errors.New("no skill CID provided")
-								}
-								return agents.DeleteSkill(skillCid)
-							},
-						},
-						{
-							Name:      "attach",
-							Aliases:   []string{"a"},
-							Usage:     "Attach skills to an agent",
-							ArgsUsage: "[agent ID] [skill CID...]",
-							Action: func(ctx *cli.Context) error {
-								agentID := ctx.Args().First()
-								if agentID == "" {
-									return errors.New("no agent ID provided")
-								}
-								skillCids := ctx.Args().Tail()
-								if len(skillCids) == 0 {
-									return errors.New("no skill CIDs provided")
-								}
-								return agents.AttachSkills(agentID, skillCids)
-							},
-						},
-						{
-							Name:      "detach",
-							Usage:     "Detach a skill from an agent",
-							ArgsUsage: "[agent ID] [skill ID]",
-							Action: func(ctx *cli.Context) error {
-								agentID := ctx.Args().First()
-								skillID := ctx.Args().Get(1)
-								if agentID == "" {
-									return errors.New("no agent ID provided")
-								}
-								if skillID == "" {
-									return errors.New("no skill ID provided")
-								}
-								return agents.DetachSkill(agentID, skillID)
+							&cli.BoolFlag{
+								Name:  "text",
+								Usage: "Force plain text output (simpler alternative to JSONL for pipes)",
 							},
-						},
-					},
-				},
-				{
-					Name:    "secrets",
-					Aliases: []string{"sec"},
-					Usage:   "Manage secrets",
-					Subcommands: []*cli.Command{
-						{
-							Name:    "list",
-							Aliases: []string{"l"},
-							Usage:   "List all secrets",
-							Action: func(ctx *cli.Context) error {
-								_, err := agents.ListSecrets()
-								return err
+							&cli.BoolFlag{
+								Name:    "conversation",
+								Aliases: []string{"C"},
+								Usage:   "Multi-turn conversation mode (read messages from stdin line-by-line)",
 							},
-						},
-						{
-							Name:    "create",
-							Aliases: []string{"c"},
-							Usage:   "Create a new secret",
-							Flags: []cli.Flag{
-								&cli.StringFlag{
-									Name:     "name",
-									Aliases:  []string{"n"},
-									Usage:    "Secret name (e.g. ANTHROPIC_API_KEY)",
-									Required: true,
-								},
-								&cli.StringFlag{
-									Name:     "value",
-									Aliases:  []string{"v"},
-									Usage:    "Secret value",
-									Required: true,
-								},
-							},
-							Action: func(ctx *cli.Context) error {
-								name := ctx.String("name")
-								value := ctx.String("value")
-								_, err := agents.CreateSecret(name, value)
-								return err
+							&cli.StringFlag{
+								Name:  "session",
+								Usage: "Session key for conversation context (default: agent:main:cli)",
 							},
-						},
-						{
-							Name:      "update",
-							Aliases:   []string{"u"},
-							Usage:     "Update a secret value",
-							ArgsUsage: "[secret ID]",
-							Flags: []cli.Flag{
-								&cli.StringFlag{
-									Name:     "value",
-									Aliases:  []string{"v"},
-									Usage:    "New secret value",
-									Required: true,
-								},
-							},
-							Action: func(ctx *cli.Context) error {
-								secretID := ctx.Args().First()
-								value := ctx.String("value")
-								if secretID == "" {
-									return errors.New("no secret ID provided")
-								}
-								return agents.UpdateSecret(secretID, value)
-							},
-						},
-						{
-							Name:      "delete",
-							Aliases:   []string{"d"},
-							Usage:     "Delete a secret",
-							ArgsUsage: "[secret ID]",
-							Action: func(ctx *cli.Context) error {
-								secretID := ctx.Args().First()
-								if secretID == "" {
-									return errors.New("no secret ID provided")
-								}
-								return agents.DeleteSecret(secretID)
-							},
-						},
-						{
-							Name:      "attach",
-							Aliases:   []string{"a"},
-							Usage:     "Attach secrets to an agent",
-							ArgsUsage: "[agent ID] [secret ID...]",
-							Action: func(ctx *cli.Context) error {
-								agentID := ctx.Args().First()
-								if agentID == "" {
-									return errors.New("no agent ID provided")
-								}
-								secretIds := ctx.Args().Tail()
-								if len(secretIds) == 0 {
-									return errors.New("no secret IDs provided")
-								}
-								return agents.AttachSecrets(agentID, secretIds)
-							},
-						},
-						{
-							Name:      "detach",
-							Usage:     "Detach a secret from an agent",
-							ArgsUsage: "[agent ID] [secret ID]",
-							Action: func(ctx *cli.Context) error {
-								agentID := ctx.Args().First()
-								secretID := ctx.Args().Get(1)
-								if agentID == "" {
-									return errors.New("no agent ID provided")
-								}
-								if secretID == "" {
-									return errors.New("no secret ID provided")
-								}
-								return agents.DetachSecret(agentID, secretID)
+							&cli.BoolFlag{
+								Name:    "yes",
+								Aliases: []string{"y"},
+								Usage:   "Auto-approve tool calls (default: true, tools run server-side)",
+								Hidden:  true,
+								Value:   true,
 							},
+							&cli.StringFlag{
+								Name:   "gateway",
+								Usage:  "Override gateway URL (auto-detected from agent)",
+								Hidden: true,
+							},
+							&cli.StringFlag{
+								Name:   "token",
+								Usage:  "Override API token (auto-detected from agent)",
+								Hidden: true,
+							},
+						},
+						Action: func(ctx *cli.Context) error {
+							agentID := refs.ArgsOf(ctx).First()
+							if agentID == "" {
+								return exitcode.InvalidInputf("no agent ID provided")
+							}
+							gatewayURL := ctx.String("gateway")
+							token := ctx.String("token")
+							model := ctx.String("model")
+							jsonOutput := ctx.Bool("json")
+							textOutput := ctx.Bool("text")
+							conversationMode := ctx.Bool("conversation")
+							autoApprove := ctx.Bool("yes")
+							session := ctx.String("session")
+
+							// Get optional prompt from remaining args
+							prompt := ""
+							if refs.ArgsOf(ctx).Len() > 1 {
+								prompt = strings.Join(refs.ArgsOf(ctx).Slice()[1:], " ")
+							}
+
+							return chat.StartChat(agentID, gatewayURL, token, model, jsonOutput, textOutput, conversationMode, autoApprove, prompt, session)
 						},
 					},
-				},
-				{
-					Name:    "channels",
-					Aliases: []string{"ch"},
-					Usage:   "Manage agent channels",
-					Subcommands: []*cli.Command{
-						{
-							Name:      "status",
-							Aliases:   []string{"s"},
-							Usage:     "Get channel configuration status",
-							ArgsUsage: "[agent ID]",
-							Action: func(ctx *cli.Context) error {
-								agentID := ctx.Args().First()
-								if agentID == "" {
-									return errors.New("no agent ID provided")
-								}
-								_, err := agents.GetChannelStatus(agentID)
-								return err
+					{
+						Name:      "exec",
+						Usage:     "Execute a command in an agent container",
+						ArgsUsage: "[agent ID] [command]",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "cwd",
+								Usage: "Working directory for the command",
 							},
 						},
-						{
-							Name:      "configure",
-							Aliases:   []string{"c"},
-							Usage:     "Configure a channel (telegram, slack, discord, whatsapp)",
-							ArgsUsage: "[agent ID] [channel]",
-							Flags: []cli.Flag{
-								&cli.StringFlag{
-									Name:  "bot-token",
-									Usage: "Bot token",
+						Action: func(ctx *cli.Context) error {
+							agentID := refs.ArgsOf(ctx).First()
+							command := refs.ArgsOf(ctx).Get(1)
+							cwd := ctx.String("cwd")
+							if agentID == "" {
+								return exitcode.InvalidInputf("no agent ID provided")
+							}
+							if command == "" {
+								return exitcode.InvalidInputf("no command provided")
+							}
+							_, err := agents.ExecCommand(agentID, command, cwd)
+							return err
+						},
+					},
+					{
+						Name:    "skills",
+						Aliases: []string{"sk"},
+						Usage:   "Manage agent skills",
+						Subcommands: []*cli.Command{
+							{
+								Name:    "list",
+								Aliases: []string{"l"},
+								Usage:   "List available skills in library",
+								Action: func(ctx *cli.Context) error {
+									_, err := agents.ListSkills()
+									return err
 								},
-								&cli.StringFlag{
-									Name:  "app-token",
-									Usage: "App token (Slack only)",
+							},
+							{
+								Name:    "create",
+								Aliases: []string{"c"},
+								Usage:   "Create a new skill",
+								Flags: []cli.Flag{
+									&cli.StringFlag{
+										Name:     "cid",
+										Usage:    "Content ID of the skill (required)",
+										Required: true,
+									},
+									&cli.StringFlag{
+										Name:     "name",
+										Aliases:  []string{"n"},
+										Usage:    "Skill name (required)",
+										Required: true,
+									},
+									&cli.StringFlag{
+										Name:    "description",
+										Aliases: []string{"d"},
+										Usage:   "Skill description",
+									},
+									&cli.StringSliceFlag{
+										Name:  "env",
+										Usage: "Required environment variable names",
+									},
+									&cli.StringFlag{
+										Name:  "file-id",
+										Usage: "Pinata v3 file ID",
+									},
 								},
-								&cli.StringFlag{
-									Name:  "dm-policy",
-									Usage: "DM policy: open or pairing",
+								Action: func(ctx *cli.Context) error {
+									cid := ctx.String("cid")
+									name := ctx.String("name")
+									description := ctx.String("description")
+									envVars := ctx.StringSlice("env")
+									fileId := ctx.String("file-id")
+									_, err := agents.CreateSkill(cid, name, description, envVars, fileId)
+									return err
 								},
-								&cli.StringSliceFlag{
-									Name:  "allow-from",
-									Usage: "Allowed user IDs/phone numbers",
+							},
+							{
+								Name:      "delete",
+								Aliases:   []string{"d"},
+								Usage:     "Delete a skill from library",
+								ArgsUsage: "[skill CID]",
+								Action: func(ctx *cli.Context) error {
+									skillCid := refs.ArgsOf(ctx).First()
+									if skillCid == "" {
+										return exitcode.InvalidInputf("no skill CID provided")
+									}
+									return agents.DeleteSkill(skillCid)
 								},
 							},
-							Action: func(ctx *cli.Context) error {
-								agentID := ctx.Args().First()
-								channel := ctx.Args().Get(1)
-								if agentID == "" {
-									return errors.New("no agent ID provided")
-								}
-								if channel == "" {
-									return errors.New("no channel provided (telegram, slack, discord, whatsapp)")
-								}
-								botToken := ctx.String("bot-token")
-								appToken := ctx.String("app-token")
-								dmPolicy := ctx.String("dm-policy")
-								allowFrom := ctx.StringSlice("allow-from")
-								return agents.ConfigureChannel(agentID, channel, botToken, appToken, dmPolicy, allowFrom)
-							},
-						},
-						{
-							Name:      "remove",
-							Aliases:   []string{"r"},
-							Usage:     "Remove a channel configuration",
-							ArgsUsage: "[agent ID] [channel]",
-							Action: func(ctx *cli.Context) error {
-								agentID := ctx.Args().First()
-								channel := ctx.Args().Get(1)
-								if agentID == "" {
-									return errors.New("no agent ID provided")
-								}
-								if channel == "" {
-									return errors.New("no channel provided")
-								}
-								return agents.RemoveChannel(agentID, channel)
+							{
+								Name:      "attach",
+								Aliases:   []string{"a"},
+								Usage:     "Attach skills to an agent",
+								ArgsUsage: "[agent ID] [skill CID...]",
+								Action: func(ctx *cli.Context) error {
+									agentID := refs.ArgsOf(ctx).First()
+									if agentID == "" {
+										return exitcode.InvalidInputf("no agent ID provided")
+									}
+									skillCids := refs.ArgsOf(ctx).Tail()
+									if len(skillCids) == 0 {
+										return exitcode.InvalidInputf("no skill CIDs provided")
+									}
+									return agents.AttachSkills(agentID, skillCids)
+								},
+							},
+							{
+								Name:      "detach",
+								Usage:     "Detach a skill from an agent",
+								ArgsUsage: "[agent ID] [skill ID]",
+								Action: func(ctx *cli.Context) error {
+									agentID := refs.ArgsOf(ctx).First()
+									skillID := refs.ArgsOf(ctx).Get(1)
+									if agentID == "" {
+										return exitcode.InvalidInputf("no agent ID provided")
+									}
+									if skillID == "" {
+										return exitcode.InvalidInputf("no skill ID provided")
+									}
+									return agents.DetachSkill(agentID, skillID)
+								},
 							},
 						},
 					},
-				},
-				{
-					Name:    "devices",
-					Aliases: []string{"dev"},
-					Usage:   "Manage agent devices",
-					Subcommands: []*cli.Command{
-						{
-							Name:      "list",
-							Aliases:   []string{"l"},
-							Usage:     "List pending and paired devices",
-							ArgsUsage: "[agent ID]",
-							Action: func(ctx *cli.Context) error {
-								agentID := ctx.Args().First()
-								if agentID == "" {
-									return errors.New("no agent ID provided")
-								}
-								_, err := agents.ListDevices(agentID)
-								return err
+					{
+						Name:    "secrets",
+						Aliases: []string{"sec"},
+						Usage:   "Manage secrets",
+						Subcommands: []*cli.Command{
+							{
+								Name:    "list",
+								Aliases: []string{"l"},
+								Usage:   "List all secrets",
+								Action: func(ctx *cli.Context) error {
+									_, err := agents.ListSecrets()
+									return err
+								},
 							},
-						},
-						{
-							Name:      "approve",
-							Aliases:   []string{"a"},
-							Usage:     "Approve a device pairing request",
-							ArgsUsage: "[agent ID] [request ID]",
-							Action: func(ctx *cli.Context) error {
-								agentID := ctx.Args().First()
-								requestID := ctx.Args().Get(1)
-								if agentID == "" {
-									return errors.New("no agent ID provided")
-								}
-								if requestID == "" {
-									return errors.New("no request ID provided")
-								}
-								return agents.ApproveDevice(agentID, requestID)
+							{
+								Name:    "create",
+								Aliases: []string{"c"},
+								Usage:   "Create a new secret",
+								Flags: []cli.Flag{
+									&cli.StringFlag{
+										Name:     "name",
+										Aliases:  []string{"n"},
+										Usage:    "Secret name (e.g. ANTHROPIC_API_KEY)",
+										Required: true,
+									},
+									&cli.StringFlag{
+										Name:     "value",
+										Aliases:  []string{"v"},
+										Usage:    "Secret value",
+										Required: true,
+									},
+								},
+								Action: func(ctx *cli.Context) error {
+									name := ctx.String("name")
+									value := ctx.String("value")
+									_, err := agents.CreateSecret(name, value)
+									return err
+								},
 							},
-						},
-						{
-							Name:      "approve-all",
-							Usage:     "Approve all pending device requests",
-							ArgsUsage: "[agent ID]",
-							Action: func(ctx *cli.Context) error {
-								agentID := ctx.Args().First()
-								if agentID == "" {
-									return errors.New("no agent ID provided")
-								}
-								_, err := agents.ApproveAllDevices(agentID)
-								return err
+							{
+								Name:      "update",
+								Aliases:   []string{"u"},
+								Usage:     "Update a secret value",
+								ArgsUsage: "[secret ID]",
+								Flags: []cli.Flag{
+									&cli.StringFlag{
+										Name:     "value",
+										Aliases:  []string{"v"},
+										Usage:    "New secret value",
+										Required: true,
+									},
+								},
+								Action: func(ctx *cli.Context) error {
+									secretID := refs.ArgsOf(ctx).First()
+									value := ctx.String("value")
+									if secretID == "" {
+										return exitcode.InvalidInputf("no secret ID provided")
+									}
+									return agents.UpdateSecret(secretID, value)
+								},
 							},
-						},
-					},
-				},
-				{
-					Name:    "snapshots",
-					Aliases: []string{"snap"},
-					Usage:   "Manage agent snapshots",
-					Subcommands: []*cli.Command{
-						{
-							Name:      "list",
-							Aliases:   []string{"l"},
-							Usage:     "List agent snapshots",
-							ArgsUsage: "[agent ID]",
-							Action: func(ctx *cli.Context) error {
-								agentID := ctx.Args().First()
-								if agentID == "" {
-									return errors.New("no agent ID provided")
-								}
-								_, err := agents.ListSnapshots(agentID)
-								return err
+							{
+								Name:      "delete",
+								Aliases:   []string{"d"},
+								Usage:     "Delete a secret",
+								ArgsUsage: "[secret ID]",
+								Action: func(ctx *cli.Context) error {
+									secretID := refs.ArgsOf(ctx).First()
+									if secretID == "" {
+										return exitcode.InvalidInputf("no secret ID provided")
+									}
+									return agents.DeleteSecret(secretID)
+								},
 							},
-						},
-						{
-							Name:      "create",
-							Aliases:   []string{"c"},
-							Usage:     "Create a snapshot",
-							ArgsUsage: "[agent ID]",
-							Action: func(ctx *cli.Context) error {
-								agentID := ctx.Args().First()
-								if agentID == "" {
-									return errors.New("no agent ID provided")
-								}
-								_, err := agents.CreateSnapshot(agentID)
-								return err
+							{
+								Name:      "attach",
+								Aliases:   []string{"a"},
+								Usage:     "Attach secrets to an agent",
+								ArgsUsage: "[agent ID] [secret ID...]",
+								Action: func(ctx *cli.Context) error {
+									agentID := refs.ArgsOf(ctx).First()
+									if agentID == "" {
+										return exitcode.InvalidInputf("no agent ID provided")
+									}
+									secretIds := refs.ArgsOf(ctx).Tail()
+									if len(secretIds) == 0 {
+										return exitcode.InvalidInputf("no secret IDs provided")
+									}
+									return agents.AttachSecrets(agentID, secretIds)
+								},
+							},
+							{
+								Name:      "detach",
+								Usage:     "Detach a secret from an agent",
+								ArgsUsage: "[agent ID] [secret ID]",
+								Action: func(ctx *cli.Context) error {
+									agentID := refs.ArgsOf(ctx).First()
+									secretID := refs.ArgsOf(ctx).Get(1)
+									if agentID == "" {
+										return exitcode.InvalidInputf("no agent ID provided")
+									}
+									if secretID == "" {
+										return exitcode.InvalidInputf("no secret ID provided")
+									}
+									return agents.DetachSecret(agentID, secretID)
+								},
 							},
 						},
-						{
-							Name:      "status",
-							Aliases:   []string{"s"},
-							Usage:     "Get sync status",
-							ArgsUsage: "[agent ID]",
-							Action: func(ctx *cli.Context) error {
-								agentID := ctx.Args().First()
-								if agentID == "" {
-									return errors.New("no agent ID provided")
-								}
-								_, err := agents.GetSyncStatus(agentID)
-								return err
+					},
+					{
+						Name:    "channels",
+						Aliases: []string{"ch"},
+						Usage:   "Manage agent channels",
+						Subcommands: []*cli.Command{
+							{
+								Name:      "status",
+								Aliases:   []string{"s"},
+								Usage:     "Get channel configuration status",
+								ArgsUsage: "[agent ID]",
+								Action: func(ctx *cli.Context) error {
+									agentID := refs.ArgsOf(ctx).First()
+									if agentID == "" {
+										return exitcode.InvalidInputf("no agent ID provided")
+									}
+									_, err := agents.GetChannelStatus(agentID)
+									return err
+								},
+							},
+							{
+								Name:      "configure",
+								Aliases:   []string{"c"},
+								Usage:     "Configure a channel (telegram, slack, discord, whatsapp)",
+								ArgsUsage: "[agent ID] [channel]",
+								Flags: []cli.Flag{
+									&cli.StringFlag{
+										Name:  "bot-token",
+										Usage: "Bot token",
+									},
+									&cli.StringFlag{
+										Name:  "app-token",
+										Usage: "App token (Slack only)",
+									},
+									&cli.StringFlag{
+										Name:  "dm-policy",
+										Usage: "DM policy: open or pairing",
+									},
+									&cli.StringSliceFlag{
+										Name:  "allow-from",
+										Usage: "Allowed user IDs/phone numbers",
+									},
+								},
+								Action: func(ctx *cli.Context) error {
+									agentID := refs.ArgsOf(ctx).First()
+									channel := refs.ArgsOf(ctx).Get(1)
+									if agentID == "" {
+										return exitcode.InvalidInputf("no agent ID provided")
+									}
+									if channel == "" {
+										return exitcode.InvalidInputf("no channel provided (telegram, slack, discord, whatsapp)")
+									}
+									botToken := ctx.String("bot-token")
+									appToken := ctx.String("app-token")
+									dmPolicy := ctx.String("dm-policy")
+									allowFrom := ctx.StringSlice("allow-from")
+									return agents.ConfigureChannel(agentID, channel, botToken, appToken, dmPolicy, allowFrom)
+								},
+							},
+							{
+								Name:      "remove",
+								Aliases:   []string{"r"},
+								Usage:     "Remove a channel configuration",
+								ArgsUsage: "[agent ID] [channel]",
+								Action: func(ctx *cli.Context) error {
+									agentID := refs.ArgsOf(ctx).First()
+									channel := refs.ArgsOf(ctx).Get(1)
+									if agentID == "" {
+										return exitcode.InvalidInputf("no agent ID provided")
+									}
+									if channel == "" {
+										return exitcode.InvalidInputf("no channel provided")
+									}
+									return agents.RemoveChannel(agentID, channel)
+								},
 							},
 						},
-						{
-							Name:      "reset",
-							Aliases:   []string{"r"},
-							Usage:     "Reset to a snapshot",
-							ArgsUsage: "[agent ID] [snapshot CID]",
-							Action: func(ctx *cli.Context) error {
-								agentID := ctx.Args().First()
-								snapshotCid := ctx.Args().Get(1)
-								if agentID == "" {
-									return errors.New("no agent ID provided")
-								}
-								if snapshotCid == "" {
-									return errors.New("no snapshot CID provided")
-								}
-								_, err := agents.ResetSnapshot(agentID, snapshotCid)
-								return err
+					},
+					{
+						Name:    "devices",
+						Aliases: []string{"dev"},
+						Usage:   "Manage agent devices",
+						Subcommands: []*cli.Command{
+							{
+								Name:      "list",
+								Aliases:   []string{"l"},
+								Usage:     "List pending and paired devices",
+								ArgsUsage: "[agent ID]",
+								Action: func(ctx *cli.Context) error {
+									agentID := refs.ArgsOf(ctx).First()
+									if agentID == "" {
+										return exitcode.InvalidInputf("no agent ID provided")
+									}
+									_, err := agents.ListDevices(agentID)
+									return err
+								},
+							},
+							{
+								Name:      "approve",
+								Aliases:   []string{"a"},
+								Usage:     "Approve a device pairing request",
+								ArgsUsage: "[agent ID] [request ID]",
+								Action: func(ctx *cli.Context) error {
+									agentID := refs.ArgsOf(ctx).First()
+									requestID := refs.ArgsOf(ctx).Get(1)
+									if agentID == "" {
+										return exitcode.InvalidInputf("no agent ID provided")
+									}
+									if requestID == "" {
+										return exitcode.InvalidInputf("no request ID provided")
+									}
+									return agents.ApproveDevice(agentID, requestID)
+								},
+							},
+							{
+								Name:      "approve-all",
+								Usage:     "Approve all pending device requests",
+								ArgsUsage: "[agent ID]",
+								Action: func(ctx *cli.Context) error {
+									agentID := refs.ArgsOf(ctx).First()
+									if agentID == "" {
+										return exitcode.InvalidInputf("no agent ID provided")
+									}
+									_, err := agents.ApproveAllDevices(agentID)
+									return err
+								},
 							},
 						},
 					},
-				},
-				{
-					Name:    "tasks",
-					Aliases: []string{"t"},
-					Usage:   "Manage agent cron jobs/tasks",
-					Subcommands: []*cli.Command{
-						{
-							Name:      "list",
-							Aliases:   []string{"l"},
-							Usage:     "List tasks",
-							ArgsUsage: "[agent ID]",
-							Flags: []cli.Flag{
-								&cli.BoolFlag{
-									Name:  "include-disabled",
-									Usage: "Include disabled tasks",
-								},
-							},
-							Action: func(ctx *cli.Context) error {
-								agentID := ctx.Args().First()
-								if agentID == "" {
-									return errors.New("no agent ID provided")
-								}
-								includeDisabled := ctx.Bool("include-disabled")
-								_, err := agents.ListTasks(agentID, includeDisabled)
-								return err
+					{
+						Name:    "snapshots",
+						Aliases: []string{"snap"},
+						Usage:   "Manage agent snapshots",
+						Subcommands: []*cli.Command{
+							{
+								Name:      "list",
+								Aliases:   []string{"l"},
+								Usage:     "List agent snapshots",
+								ArgsUsage: "[agent ID]",
+								Action: func(ctx *cli.Context) error {
+									agentID := refs.ArgsOf(ctx).First()
+									if agentID == "" {
+										return exitcode.InvalidInputf("no agent ID provided")
+									}
+									_, err := agents.ListSnapshots(agentID)
+									return err
+								},
+							},
+							{
+								Name:      "create",
+								Aliases:   []string{"c"},
+								Usage:     "Create a snapshot",
+								ArgsUsage: "[agent ID]",
+								Action: func(ctx *cli.Context) error {
+									agentID := refs.ArgsOf(ctx).First()
+									if agentID == "" {
+										return exitcode.InvalidInputf("no agent ID provided")
+									}
+									_, err := agents.CreateSnapshot(agentID)
+									return err
+								},
+							},
+							{
+								Name:      "status",
+								Aliases:   []string{"s"},
+								Usage:     "Get sync status",
+								ArgsUsage: "[agent ID]",
+								Action: func(ctx *cli.Context) error {
+									agentID := refs.ArgsOf(ctx).First()
+									if agentID == "" {
+										return exitcode.InvalidInputf("no agent ID provided")
+									}
+									_, err := agents.GetSyncStatus(agentID)
+									return err
+								},
+							},
+							{
+								Name:      "reset",
+								Aliases:   []string{"r"},
+								Usage:     "Reset to a snapshot",
+								ArgsUsage: "[agent ID] [snapshot CID]",
+								Action: func(ctx *cli.Context) error {
+									agentID := refs.ArgsOf(ctx).First()
+									snapshotCid := refs.ArgsOf(ctx).Get(1)
+									if agentID == "" {
+										return exitcode.InvalidInputf("no agent ID provided")
+									}
+									if snapshotCid == "" {
+										return exitcode.InvalidInputf("no snapshot CID provided")
+									}
+									_, err := agents.ResetSnapshot(agentID, snapshotCid)
+									return err
+								},
 							},
 						},
-						{
-							Name:      "create",
-							Aliases:   []string{"c"},
-							Usage:     "Create a new task",
-							ArgsUsage: "[agent ID]",
-							Description: `Create a new cron job for an agent.
+					},
+					{
+						Name:    "tasks",
+						Aliases: []string{"t"},
+						Usage:   "Manage agent cron jobs/tasks",
+						Subcommands: []*cli.Command{
+							{
+								Name:      "list",
+								Aliases:   []string{"l"},
+								Usage:     "List tasks",
+								ArgsUsage: "[agent ID]",
+								Flags: []cli.Flag{
+									&cli.BoolFlag{
+										Name:  "include-disabled",
+										Usage: "Include disabled tasks",
+									},
+								},
+								Action: func(ctx *cli.Context) error {
+									agentID := refs.ArgsOf(ctx).First()
+									if agentID == "" {
+										return exitcode.InvalidInputf("no agent ID provided")
+									}
+									includeDisabled := ctx.Bool("include-disabled")
+									_, err := agents.ListTasks(agentID, includeDisabled)
+									return err
+								},
+							},
+							{
+								Name:      "create",
+								Aliases:   []string{"c"},
+								Usage:     "Create a new task",
+								ArgsUsage: "[agent ID]",
+								Description: `Create a new cron job for an agent.
 
 Schedule types:
   --at       Run once at a specific time (ISO 8601 format)
@@ -1389,162 +2123,162 @@ Examples:
 
   # Run once at a specific time
   pinata agents tasks create <agent-id> --name "one-time" --at "2026-04-01T12:00:00Z" --system-event "Do task"`,
-							Flags: []cli.Flag{
-								&cli.StringFlag{
-									Name:     "name",
-									Aliases:  []string{"n"},
-									Usage:    "Task name (required)",
-									Required: true,
-								},
-								&cli.StringFlag{
-									Name:  "description",
-									Usage: "Task description",
-								},
-								&cli.BoolFlag{
-									Name:  "disabled",
-									Usage: "Create task in disabled state",
-								},
-								&cli.StringFlag{
-									Name:  "at",
-									Usage: "Run once at this time (ISO 8601)",
-								},
-								&cli.StringFlag{
-									Name:  "every",
-									Usage: "Run every interval (e.g., 1h, 30m)",
-								},
-								&cli.StringFlag{
-									Name:  "cron",
-									Usage: "Cron expression (e.g., '0 9 * * *')",
-								},
-								&cli.StringFlag{
-									Name:  "tz",
-									Usage: "Timezone for cron schedule",
-								},
-								&cli.StringFlag{
-									Name:  "system-event",
-									Usage: "System event payload text",
-								},
-								&cli.StringFlag{
-									Name:  "agent-turn",
-									Usage: "Agent turn message",
-								},
-								&cli.StringFlag{
-									Name:  "model",
-									Usage: "Model override for agent turn",
-								},
-								&cli.IntFlag{
-									Name:  "timeout",
-									Usage: "Timeout in seconds",
-								},
-								&cli.StringFlag{
-									Name:  "session",
-									Usage: "Session target: main or isolated",
-									Value: "main",
-								},
-							},
-							Action: func(ctx *cli.Context) error {
-								agentID := ctx.Args().First()
-								if agentID == "" {
-									return errors.New("no agent ID provided")
-								}
+								Flags: []cli.Flag{
+									&cli.StringFlag{
+										Name:     "name",
+										Aliases:  []string{"n"},
+										Usage:    "Task name (required)",
+										Required: true,
+									},
+									&cli.StringFlag{
+										Name:  "description",
+										Usage: "Task description",
+									},
+									&cli.BoolFlag{
+										Name:  "disabled",
+										Usage: "Create task in disabled state",
+									},
+									&cli.StringFlag{
+										Name:  "at",
+										Usage: "Run once at this time (ISO 8601)",
+									},
+									&cli.StringFlag{
+										Name:  "every",
+										Usage: "Run every interval (e.g., 1h, 30m)",
+									},
+									&cli.StringFlag{
+										Name:  "cron",
+										Usage: "Cron expression (e.g., '0 9 * * *')",
+									},
+									&cli.StringFlag{
+										Name:  "tz",
+										Usage: "Timezone for cron schedule",
+									},
+									&cli.StringFlag{
+										Name:  "system-event",
+										Usage: "System event payload text",
+									},
+									&cli.StringFlag{
+										Name:  "agent-turn",
+										Usage: "Agent turn message",
+									},
+									&cli.StringFlag{
+										Name:  "model",
+										Usage: "Model override for agent turn",
+									},
+									&cli.IntFlag{
+										Name:  "timeout",
+										Usage: "Timeout in seconds",
+									},
+									&cli.StringFlag{
+										Name:  "session",
+										Usage: "Session target: main or isolated",
+										Value: "main",
+									},
+								},
+								Action: func(ctx *cli.Context) error {
+									agentID := refs.ArgsOf(ctx).First()
+									if agentID == "" {
+										return exitcode.InvalidInputf("no agent ID provided")
+									}
 
-								// Parse schedule
-								var schedule agents.TaskSchedule
-								atTime := ctx.String("at")
-								every := ctx.String("every")
-								cronExpr := ctx.String("cron")
+									// Parse schedule
+									var schedule pinata.TaskSchedule
+									atTime := ctx.String("at")
+									every := ctx.String("every")
+									cronExpr := ctx.String("cron")
 
-								scheduleCount := 0
-								if atTime != "" {
-									scheduleCount++
-								}
-								if every != "" {
-									scheduleCount++
-								}
-								if cronExpr != "" {
-									scheduleCount++
-								}
+									scheduleCount := 0
+									if atTime != "" {
+										scheduleCount++
+									}
+									if every != "" {
+										scheduleCount++
+									}
+									if cronExpr != "" {
+										scheduleCount++
+									}
 
-								if scheduleCount == 0 {
-									return errors.New("specify one of --at, --every, or --cron")
-								}
-								if scheduleCount > 1 {
-									return errors.New("specify only one of --at, --every, or --cron")
-								}
+									if scheduleCount == 0 {
+										return exitcode.InvalidInputf("specify one of --at, --every, or --cron")
+									}
+									if scheduleCount > 1 {
+										return exitcode.InvalidInputf("specify only one of --at, --every, or --cron")
+									}
 
-								if atTime != "" {
-									schedule.Kind = agents.ScheduleKindAt
-									schedule.At = atTime
-								} else if every != "" {
-									schedule.Kind = agents.ScheduleKindEvery
-									// Parse duration string to milliseconds
-									dur, err := time.ParseDuration(every)
-									if err != nil {
-										return fmt.Errorf("invalid duration: %w", err)
+									if atTime != "" {
+										schedule.Kind = pinata.ScheduleKindAt
+										schedule.At = atTime
+									} else if every != "" {
+										schedule.Kind = pinata.ScheduleKindEvery
+										// Parse duration string to milliseconds
+										dur, err := time.ParseDuration(every)
+										if err != nil {
+											return exitcode.InvalidInputf("invalid duration: %w", err)
+										}
+										schedule.EveryMs = int(dur.Milliseconds())
+									} else if cronExpr != "" {
+										schedule.Kind = pinata.ScheduleKindCron
+										schedule.Expr = cronExpr
 									}
-									schedule.EveryMs = int(dur.Milliseconds())
-								} else if cronExpr != "" {
-									schedule.Kind = agents.ScheduleKindCron
-									schedule.Expr = cronExpr
-								}
 
-								if tz := ctx.String("tz"); tz != "" {
-									schedule.Tz = tz
-								}
+									if tz := ctx.String("tz"); tz != "" {
+										schedule.Tz = tz
+									}
 
-								// Parse payload
-								var payload agents.TaskPayload
-								systemEvent := ctx.String("system-event")
-								agentTurn := ctx.String("agent-turn")
+									// Parse payload
+									var payload pinata.TaskPayload
+									systemEvent := ctx.String("system-event")
+									agentTurn := ctx.String("agent-turn")
 
-								if systemEvent == "" && agentTurn == "" {
-									return errors.New("specify either --system-event or --agent-turn")
-								}
-								if systemEvent != "" && agentTurn != "" {
-									return errors.New("specify only one of --system-event or --agent-turn")
-								}
+									if systemEvent == "" && agentTurn == "" {
+										return exitcode.InvalidInputf("specify either --system-event or --agent-turn")
+									}
+									if systemEvent != "" && agentTurn != "" {
+										return exitcode.InvalidInputf("specify only one of --system-event or --agent-turn")
+									}
 
-								if systemEvent != "" {
-									payload.Kind = agents.PayloadKindSystemEvent
-									payload.Text = systemEvent
-								} else {
-									payload.Kind = agents.PayloadKindAgentTurn
-									payload.Message = agentTurn
-								}
+									if systemEvent != "" {
+										payload.Kind = pinata.PayloadKindSystemEvent
+										payload.Text = systemEvent
+									} else {
+										payload.Kind = pinata.PayloadKindAgentTurn
+										payload.Message = agentTurn
+									}
 
-								if model := ctx.String("model"); model != "" {
-									payload.Model = model
-								}
-								if timeout := ctx.Int("timeout"); timeout > 0 {
-									payload.TimeoutSeconds = timeout
-								}
+									if model := ctx.String("model"); model != "" {
+										payload.Model = model
+									}
+									if timeout := ctx.Int("timeout"); timeout > 0 {
+										payload.TimeoutSeconds = timeout
+									}
 
-								body := agents.CreateTaskBody{
-									Name:        ctx.String("name"),
-									Description: ctx.String("description"),
-									Enabled:     !ctx.Bool("disabled"),
-									Schedule:    schedule,
-									Payload:     payload,
-								}
+									body := pinata.CreateTaskBody{
+										Name:        ctx.String("name"),
+										Description: ctx.String("description"),
+										Enabled:     !ctx.Bool("disabled"),
+										Schedule:    schedule,
+										Payload:     payload,
+									}
 
-								if session := ctx.String("session"); session != "" {
-									if session == "main" {
-										body.SessionTarget = agents.SessionTargetMain
-									} else if session == "isolated" {
-										body.SessionTarget = agents.SessionTargetIsolated
+									if session := ctx.String("session"); session != "" {
+										if session == "main" {
+											body.SessionTarget = pinata.SessionTargetMain
+										} else if session == "isolated" {
+											body.SessionTarget = pinata.SessionTargetIsolated
+										}
 									}
-								}
 
-								_, err := agents.CreateTask(agentID, body)
-								return err
+									_, err := agents.CreateTask(agentID, body)
+									return err
+								},
 							},
-						},
-						{
-							Name:      "update",
-							Aliases:   []string{"u"},
-							Usage:     "Update an existing task",
-							ArgsUsage: "[agent ID] [job ID]",
-							Description: `Update an existing cron job. Only specified fields are changed.
+							{
+								Name:      "update",
+								Aliases:   []string{"u"},
+								Usage:     "Update an existing task",
+								ArgsUsage: "[agent ID] [job ID]",
+								Description: `Update an existing cron job. Only specified fields are changed.
 
 Examples:
   # Change task name
@@ -1555,243 +2289,243 @@ Examples:
 
   # Update payload message
   pinata agents tasks update <agent-id> <job-id> --system-event "New message"`,
-							Flags: []cli.Flag{
-								&cli.StringFlag{
-									Name:    "name",
-									Aliases: []string{"n"},
-									Usage:   "New task name",
-								},
-								&cli.StringFlag{
-									Name:  "description",
-									Usage: "New task description",
-								},
-								&cli.StringFlag{
-									Name:  "at",
-									Usage: "Run once at this time (ISO 8601)",
-								},
-								&cli.StringFlag{
-									Name:  "every",
-									Usage: "Run every interval (e.g., 1h, 30m)",
-								},
-								&cli.StringFlag{
-									Name:  "cron",
-									Usage: "Cron expression (e.g., '0 9 * * *')",
-								},
-								&cli.StringFlag{
-									Name:  "tz",
-									Usage: "Timezone for cron schedule",
-								},
-								&cli.StringFlag{
-									Name:  "system-event",
-									Usage: "System event payload text",
-								},
-								&cli.StringFlag{
-									Name:  "agent-turn",
-									Usage: "Agent turn message",
-								},
-								&cli.StringFlag{
-									Name:  "model",
-									Usage: "Model override for agent turn",
-								},
-								&cli.IntFlag{
-									Name:  "timeout",
-									Usage: "Timeout in seconds",
-								},
-							},
-							Action: func(ctx *cli.Context) error {
-								agentID := ctx.Args().First()
-								jobID := ctx.Args().Get(1)
-								if agentID == "" {
-									return errors.New("no agent ID provided")
-								}
-								if jobID == "" {
-									return errors.New("no job ID provided")
-								}
-
-								body := agents.UpdateTaskBody{}
+								Flags: []cli.Flag{
+									&cli.StringFlag{
+										Name:    "name",
+										Aliases: []string{"n"},
+										Usage:   "New task name",
+									},
+									&cli.StringFlag{
+										Name:  "description",
+										Usage: "New task description",
+									},
+									&cli.StringFlag{
+										Name:  "at",
+										Usage: "Run once at this time (ISO 8601)",
+									},
+									&cli.StringFlag{
+										Name:  "every",
+										Usage: "Run every interval (e.g., 1h, 30m)",
+									},
+									&cli.StringFlag{
+										Name:  "cron",
+										Usage: "Cron expression (e.g., '0 9 * * *')",
+									},
+									&cli.StringFlag{
+										Name:  "tz",
+										Usage: "Timezone for cron schedule",
+									},
+									&cli.StringFlag{
+										Name:  "system-event",
+										Usage: "System event payload text",
+									},
+									&cli.StringFlag{
+										Name:  "agent-turn",
+										Usage: "Agent turn message",
+									},
+									&cli.StringFlag{
+										Name:  "model",
+										Usage: "Model override for agent turn",
+									},
+									&cli.IntFlag{
+										Name:  "timeout",
+										Usage: "Timeout in seconds",
+									},
+								},
+								Action: func(ctx *cli.Context) error {
+									agentID := refs.ArgsOf(ctx).First()
+									jobID := refs.ArgsOf(ctx).Get(1)
+									if agentID == "" {
+										return exitcode.InvalidInputf("no agent ID provided")
+									}
+									if jobID == "" {
+										return exitcode.InvalidInputf("no job ID provided")
+									}
 
-								if name := ctx.String("name"); name != "" {
-									body.Name = name
-								}
-								if desc := ctx.String("description"); desc != "" {
-									body.Description = desc
-								}
+									body := pinata.UpdateTaskBody{}
 
-								// Parse schedule if any schedule flag is set
-								atTime := ctx.String("at")
-								every := ctx.String("every")
-								cronExpr := ctx.String("cron")
+									if name := ctx.String("name"); name != "" {
+										body.Name = name
+									}
+									if desc := ctx.String("description"); desc != "" {
+										body.Description = desc
+									}
 
-								if atTime != "" || every != "" || cronExpr != "" {
-									schedule := &agents.TaskSchedule{}
+									// Parse schedule if any schedule flag is set
+									atTime := ctx.String("at")
+									every := ctx.String("every")
+									cronExpr := ctx.String("cron")
+
+									if atTime != "" || every != "" || cronExpr != "" {
+										schedule := &pinata.TaskSchedule{}
+
+										if atTime != "" {
+											schedule.Kind = pinata.ScheduleKindAt
+											schedule.At = atTime
+										} else if every != "" {
+											schedule.Kind = pinata.ScheduleKindEvery
+											dur, err := time.ParseDuration(every)
+											if err != nil {
+												return exitcode.InvalidInputf("invalid duration: %w", err)
+											}
+											schedule.EveryMs = int(dur.Milliseconds())
+										} else if cronExpr != "" {
+											schedule.Kind = pinata.ScheduleKindCron
+											schedule.Expr = cronExpr
+										}
 
-									if atTime != "" {
-										schedule.Kind = agents.ScheduleKindAt
-										schedule.At = atTime
-									} else if every != "" {
-										schedule.Kind = agents.ScheduleKindEvery
-										dur, err := time.ParseDuration(every)
-										if err != nil {
-											return fmt.Errorf("invalid duration: %w", err)
+										if tz := ctx.String("tz"); tz != "" {
+											schedule.Tz = tz
 										}
-										schedule.EveryMs = int(dur.Milliseconds())
-									} else if cronExpr != "" {
-										schedule.Kind = agents.ScheduleKindCron
-										schedule.Expr = cronExpr
-									}
 
-									if tz := ctx.String("tz"); tz != "" {
-										schedule.Tz = tz
+										body.Schedule = schedule
 									}
 
-									body.Schedule = schedule
-								}
+									// Parse payload if any payload flag is set
+									systemEvent := ctx.String("system-event")
+									agentTurn := ctx.String("agent-turn")
 
-								// Parse payload if any payload flag is set
-								systemEvent := ctx.String("system-event")
-								agentTurn := ctx.String("agent-turn")
+									if systemEvent != "" || agentTurn != "" {
+										payload := &pinata.TaskPayload{}
 
-								if systemEvent != "" || agentTurn != "" {
-									payload := &agents.TaskPayload{}
+										if systemEvent != "" {
+											payload.Kind = pinata.PayloadKindSystemEvent
+											payload.Text = systemEvent
+										} else {
+											payload.Kind = pinata.PayloadKindAgentTurn
+											payload.Message = agentTurn
+										}
 
-									if systemEvent != "" {
-										payload.Kind = agents.PayloadKindSystemEvent
-										payload.Text = systemEvent
-									} else {
-										payload.Kind = agents.PayloadKindAgentTurn
-										payload.Message = agentTurn
-									}
+										if model := ctx.String("model"); model != "" {
+											payload.Model = model
+										}
+										if timeout := ctx.Int("timeout"); timeout > 0 {
+											payload.TimeoutSeconds = timeout
+										}
 
-									if model := ctx.String("model"); model != "" {
-										payload.Model = model
+										body.Payload = payload
 									}
-									if timeout := ctx.Int("timeout"); timeout > 0 {
-										payload.TimeoutSeconds = timeout
-									}
-
-									body.Payload = payload
-								}
 
-								_, err := agents.UpdateTask(agentID, jobID, body)
-								return err
+									_, err := agents.UpdateTask(agentID, jobID, body)
+									return err
+								},
 							},
-						},
-						{
-							Name:      "delete",
-							Aliases:   []string{"d"},
-							Usage:     "Delete a task",
-							ArgsUsage: "[agent ID] [job ID]",
-							Action: func(ctx *cli.Context) error {
-								agentID := ctx.Args().First()
-								jobID := ctx.Args().Get(1)
-								if agentID == "" {
-									return errors.New("no agent ID provided")
-								}
-								if jobID == "" {
-									return errors.New("no job ID provided")
-								}
-								return agents.DeleteTask(agentID, jobID)
+							{
+								Name:      "delete",
+								Aliases:   []string{"d"},
+								Usage:     "Delete a task",
+								ArgsUsage: "[agent ID] [job ID]",
+								Action: func(ctx *cli.Context) error {
+									agentID := refs.ArgsOf(ctx).First()
+									jobID := refs.ArgsOf(ctx).Get(1)
+									if agentID == "" {
+										return exitcode.InvalidInputf("no agent ID provided")
+									}
+									if jobID == "" {
+										return exitcode.InvalidInputf("no job ID provided")
+									}
+									return agents.DeleteTask(agentID, jobID)
+								},
 							},
-						},
-						{
-							Name:      "toggle",
-							Usage:     "Enable or disable a task",
-							ArgsUsage: "[agent ID] [job ID]",
-							Flags: []cli.Flag{
-								&cli.BoolFlag{
-									Name:  "enable",
-									Usage: "Enable the task",
+							{
+								Name:      "toggle",
+								Usage:     "Enable or disable a task",
+								ArgsUsage: "[agent ID] [job ID]",
+								Flags: []cli.Flag{
+									&cli.BoolFlag{
+										Name:  "enable",
+										Usage: "Enable the task",
+									},
+									&cli.BoolFlag{
+										Name:  "disable",
+										Usage: "Disable the task",
+									},
 								},
-								&cli.BoolFlag{
-									Name:  "disable",
-									Usage: "Disable the task",
+								Action: func(ctx *cli.Context) error {
+									agentID := refs.ArgsOf(ctx).First()
+									jobID := refs.ArgsOf(ctx).Get(1)
+									if agentID == "" {
+										return exitcode.InvalidInputf("no agent ID provided")
+									}
+									if jobID == "" {
+										return exitcode.InvalidInputf("no job ID provided")
+									}
+									enable := ctx.Bool("enable")
+									disable := ctx.Bool("disable")
+									if enable == disable {
+										return exitcode.InvalidInputf("specify either --enable or --disable")
+									}
+									return agents.ToggleTask(agentID, jobID, enable)
 								},
 							},
-							Action: func(ctx *cli.Context) error {
-								agentID := ctx.Args().First()
-								jobID := ctx.Args().Get(1)
-								if agentID == "" {
-									return errors.New("no agent ID provided")
-								}
-								if jobID == "" {
-									return errors.New("no job ID provided")
-								}
-								enable := ctx.Bool("enable")
-								disable := ctx.Bool("disable")
-								if enable == disable {
-									return errors.New("specify either --enable or --disable")
-								}
-								return agents.ToggleTask(agentID, jobID, enable)
-							},
-						},
-						{
-							Name:      "run",
-							Usage:     "Run a task immediately",
-							ArgsUsage: "[agent ID] [job ID]",
-							Action: func(ctx *cli.Context) error {
-								agentID := ctx.Args().First()
-								jobID := ctx.Args().Get(1)
-								if agentID == "" {
-									return errors.New("no agent ID provided")
-								}
-								if jobID == "" {
-									return errors.New("no job ID provided")
-								}
-								return agents.RunTask(agentID, jobID)
-							},
-						},
-						{
-							Name:      "history",
-							Usage:     "View task run history",
-							ArgsUsage: "[agent ID] [job ID]",
-							Flags: []cli.Flag{
-								&cli.IntFlag{
-									Name:  "limit",
-									Usage: "Number of runs to return",
-									Value: 10,
+							{
+								Name:      "run",
+								Usage:     "Run a task immediately",
+								ArgsUsage: "[agent ID] [job ID]",
+								Action: func(ctx *cli.Context) error {
+									agentID := refs.ArgsOf(ctx).First()
+									jobID := refs.ArgsOf(ctx).Get(1)
+									if agentID == "" {
+										return exitcode.InvalidInputf("no agent ID provided")
+									}
+									if jobID == "" {
+										return exitcode.InvalidInputf("no job ID provided")
+									}
+									return agents.RunTask(agentID, jobID)
 								},
 							},
-							Action: func(ctx *cli.Context) error {
-								agentID := ctx.Args().First()
-								jobID := ctx.Args().Get(1)
-								if agentID == "" {
-									return errors.New("no agent ID provided")
-								}
-								if jobID == "" {
-									return errors.New("no job ID provided")
-								}
-								limit := ctx.Int("limit")
-								_, err := agents.GetTaskHistory(agentID, jobID, limit)
-								return err
+							{
+								Name:      "history",
+								Usage:     "View task run history",
+								ArgsUsage: "[agent ID] [job ID]",
+								Flags: []cli.Flag{
+									&cli.IntFlag{
+										Name:  "limit",
+										Usage: "Number of runs to return",
+										Value: 10,
+									},
+								},
+								Action: func(ctx *cli.Context) error {
+									agentID := refs.ArgsOf(ctx).First()
+									jobID := refs.ArgsOf(ctx).Get(1)
+									if agentID == "" {
+										return exitcode.InvalidInputf("no agent ID provided")
+									}
+									if jobID == "" {
+										return exitcode.InvalidInputf("no job ID provided")
+									}
+									limit := ctx.Int("limit")
+									_, err := agents.GetTaskHistory(agentID, jobID, limit)
+									return err
+								},
 							},
 						},
 					},
-				},
-				{
-					Name:    "ports",
-					Aliases: []string{"p"},
-					Usage:   "Manage agent port forwarding",
-					Subcommands: []*cli.Command{
-						{
-							Name:      "list",
-							Aliases:   []string{"l"},
-							Usage:     "List port forwarding rules",
-							ArgsUsage: "[agent ID]",
-							Action: func(ctx *cli.Context) error {
-								agentID := ctx.Args().First()
-								if agentID == "" {
-									return errors.New("no agent ID provided")
-								}
-								_, err := agents.ListPorts(agentID)
-								return err
+					{
+						Name:    "ports",
+						Aliases: []string{"p"},
+						Usage:   "Manage agent port forwarding",
+						Subcommands: []*cli.Command{
+							{
+								Name:      "list",
+								Aliases:   []string{"l"},
+								Usage:     "List port forwarding rules",
+								ArgsUsage: "[agent ID]",
+								Action: func(ctx *cli.Context) error {
+									agentID := refs.ArgsOf(ctx).First()
+									if agentID == "" {
+										return exitcode.InvalidInputf("no agent ID provided")
+									}
+									_, err := agents.ListPorts(agentID)
+									return err
+								},
 							},
-						},
-						{
-							Name:      "set",
-							Aliases:   []string{"s"},
-							Usage:     "Set port forwarding rules",
-							ArgsUsage: "[agent ID] [port:pathPrefix] [port:pathPrefix] ...",
-							Description: `Replace all port forwarding rules for this agent.
+							{
+								Name:      "set",
+								Aliases:   []string{"s"},
+								Usage:     "Set port forwarding rules",
+								ArgsUsage: "[agent ID] [port:pathPrefix] [port:pathPrefix] ...",
+								Description: `Replace all port forwarding rules for this agent.
 
 Each mapping is specified as port:pathPrefix (e.g., 8080:/api).
 Up to 10 rules can be configured.
@@ -1805,57 +2539,57 @@ Examples:
 
   # Clear all port forwarding rules
   pinata agents ports set <agent-id>`,
-							Action: func(ctx *cli.Context) error {
-								agentID := ctx.Args().First()
-								if agentID == "" {
-									return errors.New("no agent ID provided")
-								}
-								var mappings []agents.PortForwarding
-								for _, arg := range ctx.Args().Tail() {
-									parts := strings.SplitN(arg, ":", 2)
-									if len(parts) != 2 {
-										return fmt.Errorf("invalid port mapping: %s (expected port:pathPrefix)", arg)
+								Action: func(ctx *cli.Context) error {
+									agentID := refs.ArgsOf(ctx).First()
+									if agentID == "" {
+										return exitcode.InvalidInputf("no agent ID provided")
 									}
-									port, err := strconv.Atoi(parts[0])
-									if err != nil {
-										return fmt.Errorf("invalid port number: %s", parts[0])
+									var mappings []pinata.PortForwarding
+									for _, arg := range refs.ArgsOf(ctx).Tail() {
+										parts := strings.SplitN(arg, ":", 2)
+										if len(parts) != 2 {
+											return exitcode.InvalidInputf("invalid port mapping: %s (expected port:pathPrefix)", arg)
+										}
+										port, err := strconv.Atoi(parts[0])
+										if err != nil {
+											return exitcode.InvalidInputf("invalid port number: %s", parts[0])
+										}
+										mappings = append(mappings, pinata.PortForwarding{
+											Port:       port,
+											PathPrefix: parts[1],
+										})
 									}
-									mappings = append(mappings, agents.PortForwarding{
-										Port:       port,
-										PathPrefix: parts[1],
-									})
-								}
-								_, err := agents.UpdatePorts(agentID, mappings)
-								return err
+									_, err := agents.UpdatePorts(agentID, mappings)
+									return err
+								},
 							},
 						},
 					},
-				},
-				{
-					Name:    "domains",
-					Aliases: []string{"dom"},
-					Usage:   "Manage custom domains (beta)",
-					Subcommands: []*cli.Command{
-						{
-							Name:      "list",
-							Aliases:   []string{"l"},
-							Usage:     "List custom domains",
-							ArgsUsage: "[agent ID]",
-							Action: func(ctx *cli.Context) error {
-								agentID := ctx.Args().First()
-								if agentID == "" {
-									return errors.New("no agent ID provided")
-								}
-								_, err := agents.ListDomains(agentID)
-								return err
+					{
+						Name:    "domains",
+						Aliases: []string{"dom"},
+						Usage:   "Manage custom domains (beta)",
+						Subcommands: []*cli.Command{
+							{
+								Name:      "list",
+								Aliases:   []string{"l"},
+								Usage:     "List custom domains",
+								ArgsUsage: "[agent ID]",
+								Action: func(ctx *cli.Context) error {
+									agentID := refs.ArgsOf(ctx).First()
+									if agentID == "" {
+										return exitcode.InvalidInputf("no agent ID provided")
+									}
+									_, err := agents.ListDomains(agentID)
+									return err
+								},
 							},
-						},
-						{
-							Name:      "add",
-							Aliases:   []string{"a"},
-							Usage:     "Register a custom domain",
-							ArgsUsage: "[agent ID]",
-							Description: `Register a subdomain or custom domain for this agent.
+							{
+								Name:      "add",
+								Aliases:   []string{"a"},
+								Usage:     "Register a custom domain",
+								ArgsUsage: "[agent ID]",
+								Description: `Register a subdomain or custom domain for this agent.
 
 Use --subdomain for a *.apps.pinata.cloud subdomain, or --domain for your own domain.
 Max 5 domains per agent. Port 18789 is reserved.
@@ -1869,533 +2603,967 @@ Examples:
 
   # Add authentication protection
   pinata agents domains add <agent-id> --subdomain myapp --port 8080 --protected`,
-							Flags: []cli.Flag{
-								&cli.StringFlag{
-									Name:  "subdomain",
-									Usage: "Subdomain name (e.g., 'myapp' for myapp.apps.pinata.cloud)",
-								},
-								&cli.StringFlag{
-									Name:  "domain",
-									Usage: "Custom domain (e.g., 'api.example.com')",
-								},
-								&cli.IntFlag{
-									Name:     "port",
-									Usage:    "Target container port",
-									Required: true,
+								Flags: []cli.Flag{
+									&cli.StringFlag{
+										Name:  "subdomain",
+										Usage: "Subdomain name (e.g., 'myapp' for myapp.apps.pinata.cloud)",
+									},
+									&cli.StringFlag{
+										Name:  "domain",
+										Usage: "Custom domain (e.g., 'api.example.com')",
+									},
+									&cli.IntFlag{
+										Name:     "port",
+										Usage:    "Target container port",
+										Required: true,
+									},
+									&cli.BoolFlag{
+										Name:  "protected",
+										Usage: "Require authentication",
+									},
 								},
-								&cli.BoolFlag{
-									Name:  "protected",
-									Usage: "Require authentication",
-								},
-							},
-							Action: func(ctx *cli.Context) error {
-								agentID := ctx.Args().First()
-								if agentID == "" {
-									return errors.New("no agent ID provided")
-								}
-								subdomain := ctx.String("subdomain")
-								customDomain := ctx.String("domain")
-								port := ctx.Int("port")
-								protected := ctx.Bool("protected")
+								Action: func(ctx *cli.Context) error {
+									agentID := refs.ArgsOf(ctx).First()
+									if agentID == "" {
+										return exitcode.InvalidInputf("no agent ID provided")
+									}
+									subdomain := ctx.String("subdomain")
+									customDomain := ctx.String("domain")
+									port := ctx.Int("port")
+									protected := ctx.Bool("protected")
 
-								if subdomain == "" && customDomain == "" {
-									return errors.New("specify either --subdomain or --domain")
-								}
-								if subdomain != "" && customDomain != "" {
-									return errors.New("specify only one of --subdomain or --domain")
-								}
+									if subdomain == "" && customDomain == "" {
+										return exitcode.InvalidInputf("specify either --subdomain or --domain")
+									}
+									if subdomain != "" && customDomain != "" {
+										return exitcode.InvalidInputf("specify only one of --subdomain or --domain")
+									}
 
-								_, err := agents.CreateDomain(agentID, subdomain, customDomain, port, protected)
-								return err
-							},
-						},
-						{
-							Name:      "update",
-							Aliases:   []string{"u"},
-							Usage:     "Update a custom domain",
-							ArgsUsage: "[agent ID] [domain ID]",
-							Flags: []cli.Flag{
-								&cli.StringFlag{
-									Name:  "subdomain",
-									Usage: "New subdomain name",
-								},
-								&cli.StringFlag{
-									Name:  "domain",
-									Usage: "New custom domain",
-								},
-								&cli.IntFlag{
-									Name:  "port",
-									Usage: "New target port",
-								},
-								&cli.BoolFlag{
-									Name:  "protected",
-									Usage: "Enable authentication",
-								},
-								&cli.BoolFlag{
-									Name:  "no-protected",
-									Usage: "Disable authentication",
+									_, err := agents.CreateDomain(agentID, subdomain, customDomain, port, protected)
+									return err
 								},
 							},
-							Action: func(ctx *cli.Context) error {
-								agentID := ctx.Args().First()
-								domainID := ctx.Args().Get(1)
-								if agentID == "" {
-									return errors.New("no agent ID provided")
-								}
-								if domainID == "" {
-									return errors.New("no domain ID provided")
-								}
+							{
+								Name:      "update",
+								Aliases:   []string{"u"},
+								Usage:     "Update a custom domain",
+								ArgsUsage: "[agent ID] [domain ID]",
+								Flags: []cli.Flag{
+									&cli.StringFlag{
+										Name:  "subdomain",
+										Usage: "New subdomain name",
+									},
+									&cli.StringFlag{
+										Name:  "domain",
+										Usage: "New custom domain",
+									},
+									&cli.IntFlag{
+										Name:  "port",
+										Usage: "New target port",
+									},
+									&cli.BoolFlag{
+										Name:  "protected",
+										Usage: "Enable authentication",
+									},
+									&cli.BoolFlag{
+										Name:  "no-protected",
+										Usage: "Disable authentication",
+									},
+								},
+								Action: func(ctx *cli.Context) error {
+									agentID := refs.ArgsOf(ctx).First()
+									domainID := refs.ArgsOf(ctx).Get(1)
+									if agentID == "" {
+										return exitcode.InvalidInputf("no agent ID provided")
+									}
+									if domainID == "" {
+										return exitcode.InvalidInputf("no domain ID provided")
+									}
 
-								subdomain := ctx.String("subdomain")
-								customDomain := ctx.String("domain")
+									subdomain := ctx.String("subdomain")
+									customDomain := ctx.String("domain")
 
-								var targetPort *int
-								if ctx.IsSet("port") {
-									p := ctx.Int("port")
-									targetPort = &p
-								}
+									var targetPort *int
+									if ctx.IsSet("port") {
+										p := ctx.Int("port")
+										targetPort = &p
+									}
 
-								var protected *bool
-								if ctx.IsSet("protected") {
-									p := true
-									protected = &p
-								} else if ctx.IsSet("no-protected") {
-									p := false
-									protected = &p
-								}
+									var protected *bool
+									if ctx.IsSet("protected") {
+										p := true
+										protected = &p
+									} else if ctx.IsSet("no-protected") {
+										p := false
+										protected = &p
+									}
 
-								_, err := agents.UpdateDomain(agentID, domainID, subdomain, customDomain, targetPort, protected)
-								return err
+									_, err := agents.UpdateDomain(agentID, domainID, subdomain, customDomain, targetPort, protected)
+									return err
+								},
 							},
-						},
-						{
-							Name:      "delete",
-							Aliases:   []string{"d"},
-							Usage:     "Remove a custom domain",
-							ArgsUsage: "[agent ID] [domain ID]",
-							Action: func(ctx *cli.Context) error {
-								agentID := ctx.Args().First()
-								domainID := ctx.Args().Get(1)
-								if agentID == "" {
-									return errors.New("no agent ID provided")
-								}
-								if domainID == "" {
-									return errors.New("no domain ID provided")
-								}
-								_, err := agents.DeleteDomain(agentID, domainID)
-								return err
+							{
+								Name:      "delete",
+								Aliases:   []string{"d"},
+								Usage:     "Remove a custom domain",
+								ArgsUsage: "[agent ID] [domain ID]",
+								Action: func(ctx *cli.Context) error {
+									agentID := refs.ArgsOf(ctx).First()
+									domainID := refs.ArgsOf(ctx).Get(1)
+									if agentID == "" {
+										return exitcode.InvalidInputf("no agent ID provided")
+									}
+									if domainID == "" {
+										return exitcode.InvalidInputf("no domain ID provided")
+									}
+									_, err := agents.DeleteDomain(agentID, domainID)
+									return err
+								},
 							},
 						},
 					},
-				},
-				{
-					Name:  "files",
-					Usage: "Agent file operations",
-					Subcommands: []*cli.Command{
-						{
-							Name:      "read",
-							Aliases:   []string{"r"},
-							Usage:     "Read a file from agent container",
-							ArgsUsage: "[agent ID] [file path]",
-							Action: func(ctx *cli.Context) error {
-								agentID := ctx.Args().First()
-								filePath := ctx.Args().Get(1)
-								if agentID == "" {
-									return errors.New("no agent ID provided")
-								}
-								if filePath == "" {
-									return errors.New("no file path provided")
-								}
-								_, err := agents.ReadFile(agentID, filePath)
-								return err
+					{
+						Name:  "files",
+						Usage: "Agent file operations",
+						Subcommands: []*cli.Command{
+							{
+								Name:      "read",
+								Aliases:   []string{"r"},
+								Usage:     "Read a file from agent container",
+								ArgsUsage: "[agent ID] [file path]",
+								Action: func(ctx *cli.Context) error {
+									agentID := refs.ArgsOf(ctx).First()
+									filePath := refs.ArgsOf(ctx).Get(1)
+									if agentID == "" {
+										return exitcode.InvalidInputf("no agent ID provided")
+									}
+									if filePath == "" {
+										return exitcode.InvalidInputf("no file path provided")
+									}
+									_, err := agents.ReadFile(agentID, filePath)
+									return err
+								},
 							},
 						},
 					},
-				},
-				{
-					Name:    "templates",
-					Aliases: []string{"tpl"},
-					Usage:   "Browse and manage agent templates",
-					Subcommands: []*cli.Command{
-						{
-							Name:    "list",
-							Aliases: []string{"l"},
-							Usage:   "List available templates",
-							Flags: []cli.Flag{
-								&cli.StringFlag{
-									Name:    "category",
-									Aliases: []string{"c"},
-									Usage:   "Filter by category",
-								},
-								&cli.BoolFlag{
-									Name:  "featured",
-									Usage: "Show only featured templates",
-								},
-							},
-							Action: func(ctx *cli.Context) error {
-								category := ctx.String("category")
-								featured := ctx.Bool("featured")
-								_, err := agents.ListTemplates(category, featured)
-								return err
+					{
+						Name:    "templates",
+						Aliases: []string{"tpl"},
+						Usage:   "Browse and manage agent templates",
+						Subcommands: []*cli.Command{
+							{
+								Name:    "list",
+								Aliases: []string{"l"},
+								Usage:   "List available templates",
+								Flags: []cli.Flag{
+									&cli.StringFlag{
+										Name:    "category",
+										Aliases: []string{"c"},
+										Usage:   "Filter by category",
+									},
+									&cli.BoolFlag{
+										Name:  "featured",
+										Usage: "Show only featured templates",
+									},
+								},
+								Action: func(ctx *cli.Context) error {
+									category := ctx.String("category")
+									featured := ctx.Bool("featured")
+									_, err := agents.ListTemplates(category, featured)
+									return err
+								},
 							},
-						},
-						{
-							Name:      "get",
-							Aliases:   []string{"g"},
-							Usage:     "Get template details by slug",
-							ArgsUsage: "[slug]",
-							Action: func(ctx *cli.Context) error {
-								slug := ctx.Args().First()
-								if slug == "" {
-									return errors.New("no template slug provided")
-								}
-								_, err := agents.GetTemplate(slug)
-								return err
+							{
+								Name:      "get",
+								Aliases:   []string{"g"},
+								Usage:     "Get template details by slug",
+								ArgsUsage: "[slug]",
+								Action: func(ctx *cli.Context) error {
+									slug := ctx.Args().First()
+									if slug == "" {
+										return exitcode.InvalidInputf("no template slug provided")
+									}
+									_, err := agents.GetTemplate(slug)
+									return err
+								},
 							},
-						},
-						{
-							Name:  "mine",
-							Usage: "List templates you have submitted",
-							Action: func(ctx *cli.Context) error {
-								_, err := agents.ListTemplatesBySubmitter()
-								return err
+							{
+								Name:  "mine",
+								Usage: "List templates you have submitted",
+								Action: func(ctx *cli.Context) error {
+									_, err := agents.ListTemplatesBySubmitter()
+									return err
+								},
 							},
-						},
-						{
-							Name:      "validate",
-							Aliases:   []string{"v"},
-							Usage:     "Validate a git repo for template submission",
-							ArgsUsage: "[git URL]",
-							Flags: []cli.Flag{
-								&cli.StringFlag{
-									Name:    "branch",
-									Aliases: []string{"b"},
-									Usage:   "Branch to validate (default: main)",
+							{
+								Name:      "validate",
+								Aliases:   []string{"v"},
+								Usage:     "Validate a git repo for template submission",
+								ArgsUsage: "[git URL]",
+								Flags: []cli.Flag{
+									&cli.StringFlag{
+										Name:    "branch",
+										Aliases: []string{"b"},
+										Usage:   "Branch to validate (default: main)",
+									},
+								},
+								Action: func(ctx *cli.Context) error {
+									gitURL := ctx.Args().First()
+									if gitURL == "" {
+										return exitcode.InvalidInputf("no git URL provided")
+									}
+									branch := ctx.String("branch")
+									_, err := agents.ValidateTemplate(gitURL, branch)
+									return err
 								},
 							},
-							Action: func(ctx *cli.Context) error {
-								gitURL := ctx.Args().First()
-								if gitURL == "" {
-									return errors.New("no git URL provided")
-								}
-								branch := ctx.String("branch")
-								_, err := agents.ValidateTemplate(gitURL, branch)
-								return err
+							{
+								Name:      "submit",
+								Aliases:   []string{"s"},
+								Usage:     "Submit a new template from a git repo",
+								ArgsUsage: "[git URL]",
+								Flags: []cli.Flag{
+									&cli.StringFlag{
+										Name:    "branch",
+										Aliases: []string{"b"},
+										Usage:   "Branch to submit from (default: main)",
+									},
+								},
+								Action: func(ctx *cli.Context) error {
+									gitURL := ctx.Args().First()
+									if gitURL == "" {
+										return exitcode.InvalidInputf("no git URL provided")
+									}
+									branch := ctx.String("branch")
+									_, err := agents.SubmitTemplate(gitURL, branch)
+									return err
+								},
 							},
-						},
-						{
-							Name:      "submit",
-							Aliases:   []string{"s"},
-							Usage:     "Submit a new template from a git repo",
-							ArgsUsage: "[git URL]",
-							Flags: []cli.Flag{
-								&cli.StringFlag{
-									Name:    "branch",
-									Aliases: []string{"b"},
-									Usage:   "Branch to submit from (default: main)",
+							{
+								Name:      "update",
+								Aliases:   []string{"u"},
+								Usage:     "Update an existing template submission (re-pull from repo)",
+								ArgsUsage: "[template ID]",
+								Flags: []cli.Flag{
+									&cli.StringFlag{
+										Name:  "git-url",
+										Usage: "New git URL (optional, uses existing if omitted)",
+									},
+									&cli.StringFlag{
+										Name:    "branch",
+										Aliases: []string{"b"},
+										Usage:   "Branch to pull from (default: main)",
+									},
+								},
+								Action: func(ctx *cli.Context) error {
+									templateID := ctx.Args().First()
+									if templateID == "" {
+										return exitcode.InvalidInputf("no template ID provided")
+									}
+									gitURL := ctx.String("git-url")
+									branch := ctx.String("branch")
+									_, err := agents.UpdateTemplate(templateID, gitURL, branch)
+									return err
 								},
 							},
-							Action: func(ctx *cli.Context) error {
-								gitURL := ctx.Args().First()
-								if gitURL == "" {
-									return errors.New("no git URL provided")
-								}
-								branch := ctx.String("branch")
-								_, err := agents.SubmitTemplate(gitURL, branch)
-								return err
+							{
+								Name:      "delete",
+								Aliases:   []string{"d"},
+								Usage:     "Archive a template submission",
+								ArgsUsage: "[template ID]",
+								Action: func(ctx *cli.Context) error {
+									templateID := ctx.Args().First()
+									if templateID == "" {
+										return exitcode.InvalidInputf("no template ID provided")
+									}
+									_, err := agents.DeleteTemplate(templateID)
+									return err
+								},
+							},
+							{
+								Name:      "branches",
+								Usage:     "List branches for a git repository",
+								ArgsUsage: "[git URL]",
+								Action: func(ctx *cli.Context) error {
+									gitURL := ctx.Args().First()
+									if gitURL == "" {
+										return exitcode.InvalidInputf("no git URL provided")
+									}
+									_, err := agents.ListBranches(gitURL)
+									return err
+								},
 							},
 						},
-						{
-							Name:      "update",
-							Aliases:   []string{"u"},
-							Usage:     "Update an existing template submission (re-pull from repo)",
-							ArgsUsage: "[template ID]",
-							Flags: []cli.Flag{
-								&cli.StringFlag{
-									Name:  "git-url",
-									Usage: "New git URL (optional, uses existing if omitted)",
+					},
+					{
+						Name:    "clawhub",
+						Aliases: []string{"hub"},
+						Usage:   "Browse and install skills from ClawHub",
+						Subcommands: []*cli.Command{
+							{
+								Name:    "list",
+								Aliases: []string{"l"},
+								Usage:   "Browse ClawHub skills",
+								Flags: []cli.Flag{
+									&cli.StringFlag{
+										Name:    "category",
+										Aliases: []string{"c"},
+										Usage:   "Filter by category",
+									},
+									&cli.StringFlag{
+										Name:    "sort",
+										Aliases: []string{"s"},
+										Usage:   "Sort by: popular, newest, name",
+									},
+									&cli.BoolFlag{
+										Name:  "featured",
+										Usage: "Show only featured skills",
+									},
+									&cli.StringFlag{
+										Name:  "cursor",
+										Usage: "Pagination cursor",
+									},
 								},
-								&cli.StringFlag{
-									Name:    "branch",
-									Aliases: []string{"b"},
-									Usage:   "Branch to pull from (default: main)",
+								Action: func(ctx *cli.Context) error {
+									category := ctx.String("category")
+									sort := ctx.String("sort")
+									featured := ctx.Bool("featured")
+									cursor := ctx.String("cursor")
+									_, err := agents.ListHubSkills(category, sort, featured, cursor)
+									return err
 								},
 							},
-							Action: func(ctx *cli.Context) error {
-								templateID := ctx.Args().First()
-								if templateID == "" {
-									return errors.New("no template ID provided")
-								}
-								gitURL := ctx.String("git-url")
-								branch := ctx.String("branch")
-								_, err := agents.UpdateTemplate(templateID, gitURL, branch)
-								return err
+							{
+								Name:      "get",
+								Aliases:   []string{"g"},
+								Usage:     "Get ClawHub skill details by slug",
+								ArgsUsage: "[slug]",
+								Action: func(ctx *cli.Context) error {
+									slug := ctx.Args().First()
+									if slug == "" {
+										return exitcode.InvalidInputf("no skill slug provided")
+									}
+									_, err := agents.GetHubSkill(slug)
+									return err
+								},
 							},
-						},
-						{
-							Name:      "delete",
-							Aliases:   []string{"d"},
-							Usage:     "Archive a template submission",
-							ArgsUsage: "[template ID]",
-							Action: func(ctx *cli.Context) error {
-								templateID := ctx.Args().First()
-								if templateID == "" {
-									return errors.New("no template ID provided")
-								}
-								_, err := agents.DeleteTemplate(templateID)
-								return err
+							{
+								Name:      "install",
+								Aliases:   []string{"i"},
+								Usage:     "Install a ClawHub skill to your library",
+								ArgsUsage: "[hub-skill-id]",
+								Action: func(ctx *cli.Context) error {
+									hubSkillID := ctx.Args().First()
+									if hubSkillID == "" {
+										return exitcode.InvalidInputf("no hub skill ID provided")
+									}
+									_, err := agents.InstallHubSkill(hubSkillID)
+									return err
+								},
 							},
 						},
-						{
-							Name:      "branches",
-							Usage:     "List branches for a git repository",
-							ArgsUsage: "[git URL]",
-							Action: func(ctx *cli.Context) error {
-								gitURL := ctx.Args().First()
-								if gitURL == "" {
-									return errors.New("no git URL provided")
-								}
-								_, err := agents.ListBranches(gitURL)
-								return err
+					},
+					{
+						Name:    "config",
+						Aliases: []string{"cfg"},
+						Usage:   "Manage agent configuration",
+						Subcommands: []*cli.Command{
+							{
+								Name:      "get",
+								Aliases:   []string{"g"},
+								Usage:     "Get agent openclaw config",
+								ArgsUsage: "[agent ID]",
+								Action: func(ctx *cli.Context) error {
+									agentID := refs.ArgsOf(ctx).First()
+									if agentID == "" {
+										return exitcode.InvalidInputf("no agent ID provided")
+									}
+									_, err := agents.GetConfig(agentID)
+									return err
+								},
+							},
+							{
+								Name:      "set",
+								Aliases:   []string{"s"},
+								Usage:     "Set agent openclaw config (JSON)",
+								ArgsUsage: "[agent ID] [json config]",
+								Action: func(ctx *cli.Context) error {
+									agentID := refs.ArgsOf(ctx).First()
+									configJSON := refs.ArgsOf(ctx).Get(1)
+									if agentID == "" {
+										return exitcode.InvalidInputf("no agent ID provided")
+									}
+									if configJSON == "" {
+										return exitcode.InvalidInputf("no config JSON provided")
+									}
+									var configData interface{}
+									if err := json.Unmarshal([]byte(configJSON), &configData); err != nil {
+										return exitcode.InvalidInputf("invalid JSON: %w", err)
+									}
+									return agents.SetConfig(agentID, configData)
+								},
+							},
+							{
+								Name:      "validate",
+								Aliases:   []string{"v"},
+								Usage:     "Validate agent openclaw config",
+								ArgsUsage: "[agent ID]",
+								Action: func(ctx *cli.Context) error {
+									agentID := refs.ArgsOf(ctx).First()
+									if agentID == "" {
+										return exitcode.InvalidInputf("no agent ID provided")
+									}
+									_, err := agents.ValidateConfig(agentID)
+									return err
+								},
 							},
 						},
 					},
-				},
-				{
-					Name:    "clawhub",
-					Aliases: []string{"hub"},
-					Usage:   "Browse and install skills from ClawHub",
-					Subcommands: []*cli.Command{
-						{
-							Name:    "list",
-							Aliases: []string{"l"},
-							Usage:   "Browse ClawHub skills",
-							Flags: []cli.Flag{
-								&cli.StringFlag{
-									Name:    "category",
-									Aliases: []string{"c"},
-									Usage:   "Filter by category",
-								},
-								&cli.StringFlag{
-									Name:    "sort",
-									Aliases: []string{"s"},
-									Usage:   "Sort by: popular, newest, name",
-								},
-								&cli.BoolFlag{
-									Name:  "featured",
-									Usage: "Show only featured skills",
-								},
-								&cli.StringFlag{
-									Name:  "cursor",
-									Usage: "Pagination cursor",
-								},
-							},
-							Action: func(ctx *cli.Context) error {
-								category := ctx.String("category")
-								sort := ctx.String("sort")
-								featured := ctx.Bool("featured")
-								cursor := ctx.String("cursor")
-								_, err := agents.ListHubSkills(category, sort, featured, cursor)
-								return err
+					{
+						Name:    "update",
+						Aliases: []string{"up"},
+						Usage:   "Manage agent openclaw updates",
+						Subcommands: []*cli.Command{
+							{
+								Name:      "check",
+								Aliases:   []string{"c"},
+								Usage:     "Check for openclaw updates",
+								ArgsUsage: "[agent ID]",
+								Action: func(ctx *cli.Context) error {
+									agentID := refs.ArgsOf(ctx).First()
+									if agentID == "" {
+										return exitcode.InvalidInputf("no agent ID provided")
+									}
+									_, err := agents.CheckUpdate(agentID)
+									return err
+								},
 							},
-						},
-						{
-							Name:      "get",
-							Aliases:   []string{"g"},
-							Usage:     "Get ClawHub skill details by slug",
-							ArgsUsage: "[slug]",
-							Action: func(ctx *cli.Context) error {
-								slug := ctx.Args().First()
-								if slug == "" {
-									return errors.New("no skill slug provided")
-								}
-								_, err := agents.GetHubSkill(slug)
-								return err
+							{
+								Name:      "apply",
+								Aliases:   []string{"a"},
+								Usage:     "Apply openclaw update",
+								ArgsUsage: "[agent ID]",
+								Flags: []cli.Flag{
+									&cli.StringFlag{
+										Name:  "tag",
+										Usage: "Specific version or tag to install (e.g., 'latest', '0.3.0', 'beta')",
+									},
+								},
+								Action: func(ctx *cli.Context) error {
+									agentID := refs.ArgsOf(ctx).First()
+									if agentID == "" {
+										return exitcode.InvalidInputf("no agent ID provided")
+									}
+									tag := ctx.String("tag")
+									_, err := agents.ApplyUpdate(agentID, tag)
+									return err
+								},
 							},
 						},
-						{
-							Name:      "install",
-							Aliases:   []string{"i"},
-							Usage:     "Install a ClawHub skill to your library",
-							ArgsUsage: "[hub-skill-id]",
-							Action: func(ctx *cli.Context) error {
-								hubSkillID := ctx.Args().First()
-								if hubSkillID == "" {
-									return errors.New("no hub skill ID provided")
-								}
-								_, err := agents.InstallHubSkill(hubSkillID)
-								return err
-							},
+					},
+					{
+						Name:      "versions",
+						Aliases:   []string{"ver"},
+						Usage:     "List available agent versions",
+						ArgsUsage: "[agent ID]",
+						Action: func(ctx *cli.Context) error {
+							agentID := refs.ArgsOf(ctx).First()
+							if agentID == "" {
+								return exitcode.InvalidInputf("no agent ID provided")
+							}
+							_, err := agents.GetAvailableVersions(agentID)
+							return err
 						},
 					},
-				},
-				{
-					Name:    "config",
-					Aliases: []string{"cfg"},
-					Usage:   "Manage agent configuration",
-					Subcommands: []*cli.Command{
-						{
-							Name:      "get",
-							Aliases:   []string{"g"},
-							Usage:     "Get agent openclaw config",
-							ArgsUsage: "[agent ID]",
-							Action: func(ctx *cli.Context) error {
-								agentID := ctx.Args().First()
-								if agentID == "" {
-									return errors.New("no agent ID provided")
-								}
-								_, err := agents.GetConfig(agentID)
-								return err
+					{
+						Name:      "auth",
+						Usage:     "Authenticate with a provider and store the credential as a secret",
+						ArgsUsage: "[provider: anthropic, openai, openrouter, venice]",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "oauth",
+								Usage: "Use OAuth browser flow instead of API key (openai only)",
 							},
+							// &cli.BoolFlag{
+							// 	Name:  "setup-token",
+							// 	Usage: "Store an Anthropic setup token instead of an API key (anthropic only)",
+							// },
 						},
-						{
-							Name:      "set",
-							Aliases:   []string{"s"},
-							Usage:     "Set agent openclaw config (JSON)",
-							ArgsUsage: "[agent ID] [json config]",
-							Action: func(ctx *cli.Context) error {
-								agentID := ctx.Args().First()
-								configJSON := ctx.Args().Get(1)
-								if agentID == "" {
-									return errors.New("no agent ID provided")
-								}
-								if configJSON == "" {
-									return errors.New("no config JSON provided")
-								}
-								var configData interface{}
-								if err := json.Unmarshal([]byte(configJSON), &configData); err != nil {
-									return fmt.Errorf("invalid JSON: %w", err)
-								}
-								return agents.SetConfig(agentID, configData)
-							},
-						},
-						{
-							Name:      "validate",
-							Aliases:   []string{"v"},
-							Usage:     "Validate agent openclaw config",
-							ArgsUsage: "[agent ID]",
-							Action: func(ctx *cli.Context) error {
-								agentID := ctx.Args().First()
-								if agentID == "" {
-									return errors.New("no agent ID provided")
+						Action: func(ctx *cli.Context) error {
+							provider := ctx.Args().First()
+							switch provider {
+							case "anthropic":
+								// if ctx.Bool("setup-token") {
+								// 	return agents.CredentialLogin("Anthropic setup token (run 'claude setup-token' to generate one)", "ANTHROPIC_SETUP_TOKEN")
+								// }
+								return agents.CredentialLogin("Anthropic API key", "ANTHROPIC_API_KEY")
+							case "openai":
+								if ctx.Bool("oauth") {
+									_, err := agents.CodexOAuthLogin()
+									return err
 								}
-								_, err := agents.ValidateConfig(agentID)
-								return err
-							},
+								return agents.CredentialLogin("OpenAI API key", "OPENAI_API_KEY")
+							case "openrouter":
+								return agents.CredentialLogin("OpenRouter API key", "OPENROUTER_API_KEY")
+							case "venice":
+								return agents.CredentialLogin("Venice AI API key", "VENICE_API_KEY")
+							default:
+								return exitcode.InvalidInputf("unsupported provider: %q\navailable: anthropic, openai, openrouter, venice", provider)
+							}
+						},
+					},
+					{
+						Name:      "feedback",
+						Usage:     "Submit feedback or feature request",
+						ArgsUsage: "[message]",
+						Action: func(ctx *cli.Context) error {
+							message := strings.Join(ctx.Args().Slice(), " ")
+							if message == "" {
+								return exitcode.InvalidInputf("no feedback message provided")
+							}
+							return agents.SubmitFeedback(message)
 						},
 					},
 				},
-				{
-					Name:    "update",
-					Aliases: []string{"up"},
-					Usage:   "Manage agent openclaw updates",
-					Subcommands: []*cli.Command{
-						{
-							Name:      "check",
-							Aliases:   []string{"c"},
-							Usage:     "Check for openclaw updates",
-							ArgsUsage: "[agent ID]",
-							Action: func(ctx *cli.Context) error {
-								agentID := ctx.Args().First()
-								if agentID == "" {
-									return errors.New("no agent ID provided")
-								}
-								_, err := agents.CheckUpdate(agentID)
-								return err
+			},
+			{
+				Name:  "psa",
+				Usage: "Bridge the IPFS Pinning Service API to Pinata",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "serve",
+						Usage: "Serve the Pinning Service API so tools like 'ipfs pin remote' can pin to Pinata",
+						Description: `Serves the /pins endpoints of the IPFS Pinning Service API and translates them
+into Pinata calls made with the active profile: pins are public files, and
+pins in progress are pin-by-CID jobs, with meta stored as keyvalues. Callers
+authenticate with a bearer token, generated at startup unless given.
+
+Examples:
+  pinata psa serve --listen :5050 --token "$PSA_TOKEN"
+  ipfs pin remote service add pinata http://127.0.0.1:5050 "$PSA_TOKEN"
+  ipfs pin remote add --service=pinata --name=site bafy...`,
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "listen",
+								Value: psa.DefaultAddr,
+								Usage: "Address to listen on, e.g. :5050 for every interface",
 							},
-						},
-						{
-							Name:      "apply",
-							Aliases:   []string{"a"},
-							Usage:     "Apply openclaw update",
-							ArgsUsage: "[agent ID]",
-							Flags: []cli.Flag{
-								&cli.StringFlag{
-									Name:  "tag",
-									Usage: "Specific version or tag to install (e.g., 'latest', '0.3.0', 'beta')",
-								},
+							&cli.StringFlag{
+								Name:    "token",
+								EnvVars: []string{"PINATA_PSA_TOKEN"},
+								Usage:   "Bearer token callers must send. A random one is generated if not given",
 							},
-							Action: func(ctx *cli.Context) error {
-								agentID := ctx.Args().First()
-								if agentID == "" {
-									return errors.New("no agent ID provided")
-								}
-								tag := ctx.String("tag")
-								_, err := agents.ApplyUpdate(agentID, tag)
-								return err
+							&cli.StringSliceFlag{
+								Name:  "delegate",
+								Usage: "Multiaddr reported for callers to connect to. Repeat for more (default: /dnsaddr/bitswap.pinata.cloud)",
 							},
 						},
+						Action: func(ctx *cli.Context) error {
+							token := ctx.String("token")
+							if token == "" {
+								token = psa.NewToken()
+							}
+							server := psa.New(api.Client(), psa.Options{
+								Token:     token,
+								Delegates: ctx.StringSlice("delegate"),
+							})
+							return server.ListenAndServe(ctx.Context, ctx.String("listen"), func(baseURL string) {
+								fmt.Fprintf(os.Stderr, "Pinning Service API listening on %s, press Ctrl-C to stop\n\n", baseURL)
+								if !ctx.IsSet("token") {
+									fmt.Fprintf(os.Stderr, "  Access token: %s\n\n", token)
+								}
+								fmt.Fprintf(os.Stderr, "  ipfs pin remote service add pinata %s <token>\n", baseURL)
+							})
+						},
 					},
 				},
-				{
-					Name:      "versions",
-					Aliases:   []string{"ver"},
-					Usage:     "List available agent versions",
-					ArgsUsage: "[agent ID]",
-					Action: func(ctx *cli.Context) error {
-						agentID := ctx.Args().First()
-						if agentID == "" {
-							return errors.New("no agent ID provided")
-						}
-						_, err := agents.GetAvailableVersions(agentID)
-						return err
+			},
+			{
+				Name:  "dev-server",
+				Usage: "Run an in-memory fake of the Pinata APIs for offline testing",
+				Description: "Serves the files, groups, swaps, keys, gateways and upload endpoints, signed links and a minimal agents API " +
+					"with an echoing chat gateway. Point PINATA_API_HOST, PINATA_UPLOADS_HOST and PINATA_AGENTS_HOST at it. " +
+					"Nothing is saved when it stops.",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "addr",
+						Value: devserver.DefaultAddr,
+						Usage: "Address to listen on",
 					},
-				},
-				{
-					Name:      "auth",
-					Usage:     "Authenticate with a provider and store the credential as a secret",
-					ArgsUsage: "[provider: anthropic, openai, openrouter, venice]",
-					Flags: []cli.Flag{
-						&cli.BoolFlag{
-							Name:  "oauth",
-							Usage: "Use OAuth browser flow instead of API key (openai only)",
-						},
-						// &cli.BoolFlag{
-						// 	Name:  "setup-token",
-						// 	Usage: "Store an Anthropic setup token instead of an API key (anthropic only)",
-						// },
-					},
-					Action: func(ctx *cli.Context) error {
-						provider := ctx.Args().First()
-						switch provider {
-						case "anthropic":
-							// if ctx.Bool("setup-token") {
-							// 	return agents.CredentialLogin("Anthropic setup token (run 'claude setup-token' to generate one)", "ANTHROPIC_SETUP_TOKEN")
-							// }
-							return agents.CredentialLogin("Anthropic API key", "ANTHROPIC_API_KEY")
-						case "openai":
-							if ctx.Bool("oauth") {
-								_, err := agents.CodexOAuthLogin()
-								return err
-							}
-							return agents.CredentialLogin("OpenAI API key", "OPENAI_API_KEY")
-						case "openrouter":
-							return agents.CredentialLogin("OpenRouter API key", "OPENROUTER_API_KEY")
-						case "venice":
-							return agents.CredentialLogin("Venice AI API key", "VENICE_API_KEY")
-						default:
-							return fmt.Errorf("unsupported provider: %q\navailable: anthropic, openai, openrouter, venice", provider)
-						}
+					&cli.StringFlag{
+						Name:    "jwt",
+						EnvVars: []string{"PINATA_DEV_SERVER_JWT"},
+						Usage:   "Only accept this JWT and those of keys created on the server, instead of any token",
+					},
+					&cli.StringFlag{
+						Name:  "gateway",
+						Value: "dev-server",
+						Usage: "Gateway domain reported by the gateways endpoint",
 					},
 				},
-				{
-					Name:      "feedback",
-					Usage:     "Submit feedback or feature request",
-					ArgsUsage: "[message]",
-					Action: func(ctx *cli.Context) error {
-						message := strings.Join(ctx.Args().Slice(), " ")
-						if message == "" {
-							return errors.New("no feedback message provided")
+				Action: func(ctx *cli.Context) error {
+					server := devserver.New(devserver.Options{
+						JWT:           ctx.String("jwt"),
+						GatewayDomain: ctx.String("gateway"),
+					})
+					return server.ListenAndServe(ctx.Context, ctx.String("addr"), func(baseURL string) {
+						jwt := ctx.String("jwt")
+						if jwt == "" {
+							jwt = "dev"
 						}
-						return agents.SubmitFeedback(message)
+						fmt.Fprintf(os.Stderr, "Pinata dev server listening on %s, press Ctrl-C to stop\n\n", baseURL)
+						fmt.Fprintf(os.Stderr, "  export PINATA_API_HOST=%s\n", baseURL)
+						fmt.Fprintf(os.Stderr, "  export PINATA_UPLOADS_HOST=%s\n", baseURL)
+						fmt.Fprintf(os.Stderr, "  export PINATA_AGENTS_HOST=%s\n", baseURL)
+						fmt.Fprintf(os.Stderr, "  export PINATA_JWT=%s\n", jwt)
+					})
+				},
+			},
+			{
+				Name:      "completion",
+				Usage:     "Print a shell completion script",
+				ArgsUsage: "[bash, zsh or fish]",
+				Description: `Completes commands, flags and the IDs of agents, groups, files, secrets, skills,
+tasks and domains, with their names as descriptions in zsh and fish. IDs are
+listed for the active profile and network, or those given with --profile and
+--network, and cached for a minute.
+
+Examples:
+  # bash, in ~/.bashrc
+  source <(pinata completion bash)
+
+  # zsh, in ~/.zshrc after compinit
+  source <(pinata completion zsh)
+
+  # fish
+  pinata completion fish > ~/.config/fish/completions/pinata.fish`,
+				Action: func(ctx *cli.Context) error {
+					script, err := completion.Script(ctx.Args().First())
+					if err != nil {
+						return err
+					}
+					fmt.Print(script)
+					return nil
+				},
+			},
+			{
+				Name:  "browse",
+				Usage: "Explore your files and groups in a full-screen browser",
+				Description: `Lists files or groups with a detail pane for the selected one, loading more
+as you scroll. Press ? for the key bindings: search, filters, copying the CID
+or a signed link, opening in the gateway, renaming, editing keyvalues, adding
+to or removing from a group and deleting.`,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "network",
+						Aliases: []string{"net"},
+						Usage:   "Specify the network (public or private). Uses default if not specified",
 					},
 				},
+				Action: func(ctx *cli.Context) error {
+					return browse.Run(ctx.String("network"))
+				},
 			},
 		},
-	},
+	}
+
+	// Cancel requests in flight on Ctrl-C instead of waiting for them
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	app.OnUsageError = onUsageError
+	setUsageErrorHandler(app.Commands)
+	refs.Install(app.Commands, referenceArgs)
+	completion.Install(app, configure)
+
+	err := app.RunContext(ctx, os.Args)
+	if traceErr := api.FlushTrace(); traceErr != nil {
+		fmt.Fprintf(os.Stderr, "failed to write the trace file: %s\n", traceErr)
+	}
+	if err != nil {
+		stop()
+		exitWithError(classifyError(err))
+	}
+}
+
+// referenceArgs declares the arguments of each command that take a name or
+// prefix in place of an ID, and those that shell completion fills in
+var referenceArgs = refs.Commands{
+	"groups update":              {{Kind: refs.Group}},
+	"groups delete":              {{Kind: refs.Group}},
+	"groups get":                 {{Kind: refs.Group}},
+	"groups add":                 {{Kind: refs.Group}, {Kind: refs.File}},
+	"groups remove":              {{Kind: refs.Group}, {Kind: refs.File}},
+	"files delete":               {{Kind: refs.File}},
+	"files get":                  {{Kind: refs.File}},
+	"files update":               {{Kind: refs.File}},
+	"config network":             {{Kind: completion.Network}},
+	"config profiles show":       {{Kind: completion.Profile}},
+	"config profiles create":     {{Kind: completion.Profile}},
+	"config profiles use":        {{Kind: completion.Profile}},
+	"config profiles delete":     {{Kind: completion.Profile}},
+	"agents get":                 {{Kind: refs.Agent}},
+	"agents delete":              {{Kind: refs.Agent}},
+	"agents restart":             {{Kind: refs.Agent}},
+	"agents logs":                {{Kind: refs.Agent}},
+	"agents chat":                {{Kind: refs.Agent}},
+	"agents exec":                {{Kind: refs.Agent}},
+	"agents skills delete":       {{Kind: refs.Skill}},
+	"agents skills attach":       {{Kind: refs.Agent}, {Kind: refs.Skill, Repeats: true}},
+	"agents skills detach":       {{Kind: refs.Agent}, {Kind: refs.AttachedSkill}},
+	"agents secrets update":      {{Kind: refs.Secret}},
+	"agents secrets delete":      {{Kind: refs.Secret}},
+	"agents secrets attach":      {{Kind: refs.Agent}, {Kind: refs.Secret, Repeats: true}},
+	"agents secrets detach":      {{Kind: refs.Agent}, {Kind: refs.Secret}},
+	"agents channels status":     {{Kind: refs.Agent}},
+	"agents channels configure":  {{Kind: refs.Agent}},
+	"agents channels remove":     {{Kind: refs.Agent}},
+	"agents devices list":        {{Kind: refs.Agent}},
+	"agents devices approve":     {{Kind: refs.Agent}},
+	"agents devices approve-all": {{Kind: refs.Agent}},
+	"agents snapshots list":      {{Kind: refs.Agent}},
+	"agents snapshots create":    {{Kind: refs.Agent}},
+	"agents snapshots status":    {{Kind: refs.Agent}},
+	"agents snapshots reset":     {{Kind: refs.Agent}},
+	"agents tasks list":          {{Kind: refs.Agent}},
+	"agents tasks create":        {{Kind: refs.Agent}},
+	"agents tasks update":        {{Kind: refs.Agent}, {Kind: refs.Task}},
+	"agents tasks delete":        {{Kind: refs.Agent}, {Kind: refs.Task}},
+	"agents tasks toggle":        {{Kind: refs.Agent}, {Kind: refs.Task}},
+	"agents tasks run":           {{Kind: refs.Agent}, {Kind: refs.Task}},
+	"agents tasks history":       {{Kind: refs.Agent}, {Kind: refs.Task}},
+	"agents ports list":          {{Kind: refs.Agent}},
+	"agents ports set":           {{Kind: refs.Agent}},
+	"agents domains list":        {{Kind: refs.Agent}},
+	"agents domains add":         {{Kind: refs.Agent}},
+	"agents domains update":      {{Kind: refs.Agent}, {Kind: refs.Domain}},
+	"agents domains delete":      {{Kind: refs.Agent}, {Kind: refs.Domain}},
+	"agents files read":          {{Kind: refs.Agent}},
+	"agents config get":          {{Kind: refs.Agent}},
+	"agents config set":          {{Kind: refs.Agent}},
+	"agents config validate":     {{Kind: refs.Agent}},
+	"agents update check":        {{Kind: refs.Agent}},
+	"agents update apply":        {{Kind: refs.Agent}},
+	"agents versions":            {{Kind: refs.Agent}},
+}
+
+// configure applies the global flags. Shell completion skips Before, so it
+// calls this itself when it needs the API.
+func configure(ctx *cli.Context) error {
+	outputFormat = ctx.String("output")
+	if outputFormat != outputText && outputFormat != outputJSON {
+		return exitcode.InvalidInputf("invalid output format %q: must be text or json", outputFormat)
+	}
+	config.SetProfileOverride(ctx.String("profile"))
+	api.SetContext(ctx.Context)
+	api.Configure(ctx.Duration("timeout"), ctx.Int("retries"))
+	err := api.ConfigureTransport(api.TransportOptions{
+		Proxy:      flagOr(ctx, "proxy", config.GetProxy),
+		CAFile:     flagOr(ctx, "ca-file", config.GetCAFile),
+		ClientCert: flagOr(ctx, "client-cert", config.GetClientCert),
+		ClientKey:  flagOr(ctx, "client-key", config.GetClientKey),
+	})
+	if err != nil {
+		return err
+	}
+	api.EnableTracing(ctx.Bool("debug"), ctx.String("trace-file"))
+	return nil
+}
+
+// linkOptionFlags returns the image optimization and download flags shared by
+// the gateway link and open commands
+func linkOptionFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.IntFlag{
+			Name:  "width",
+			Usage: "Resize images to the given width in pixels",
+		},
+		&cli.IntFlag{
+			Name:  "height",
+			Usage: "Resize images to the given height in pixels",
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "Convert images to another format (auto, webp, avif, jpeg, png)",
+		},
+		&cli.IntFlag{
+			Name:  "quality",
+			Usage: "Image quality from 1 to 100",
+		},
+		&cli.StringFlag{
+			Name:  "fit",
+			Usage: "How images are resized to fit the width and height (scale-down, contain, cover, crop, pad)",
+		},
+		&cli.StringFlag{
+			Name:  "download-as",
+			Usage: "Force the browser to download the file with the given filename",
+		},
+	}
+}
+
+func linkOptionsFromContext(ctx *cli.Context) gateways.LinkOptions {
+	return gateways.LinkOptions{
+		Width:      ctx.Int("width"),
+		Height:     ctx.Int("height"),
+		Format:     ctx.String("format"),
+		Quality:    ctx.Int("quality"),
+		Fit:        ctx.String("fit"),
+		DownloadAs: ctx.String("download-as"),
+	}
 }
 
-	if err := app.Run(os.Args); err != nil {
-		log.Fatal(err)
+// setTransfer applies the upload tuning flags. Flags a command doesn't have
+// are left at their defaults.
+func setTransfer(ctx *cli.Context) error {
+	var opts uploads.TransferOptions
+	var err error
+	if ctx.String("limit-rate") != "" {
+		if opts.RateLimit, err = utils.ParseRate("limit-rate", ctx.String("limit-rate")); err != nil {
+			return err
+		}
+	}
+	if ctx.String("chunk-size") != "" {
+		if opts.ChunkSize, err = utils.ParseSize("chunk-size", ctx.String("chunk-size")); err != nil {
+			return err
+		}
+	}
+	if ctx.String("tus-threshold") != "" {
+		if opts.TUSThreshold, err = utils.ParseSize("tus-threshold", ctx.String("tus-threshold")); err != nil {
+			return err
+		}
+	}
+	opts.Parallel = ctx.Int("parallel")
+	return uploads.SetTransfer(opts)
+}
+
+// setHooks applies the --on-success and --webhook flags, which fall back to
+// the environment and profile unless --no-hooks is set
+func setHooks(ctx *cli.Context) error {
+	if ctx.Bool("no-hooks") {
+		return uploads.SetHooks(uploads.Hooks{})
+	}
+	return uploads.SetHooks(uploads.Hooks{
+		OnSuccess: flagOr(ctx, "on-success", config.GetOnSuccess),
+		Webhook:   flagOr(ctx, "webhook", config.GetWebhook),
+	})
+}
+
+// profileFlags returns the flags used to create or update a profile
+func profileFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    "network",
+			Aliases: []string{"net"},
+			Usage:   "Default network (public or private)",
+		},
+		&cli.StringFlag{
+			Name:  "gateway",
+			Usage: "Gateway domain, e.g. example.mypinata.cloud",
+		},
+		&cli.StringFlag{
+			Name:  "api-host",
+			Usage: "API host, e.g. api.pinata.cloud, or a URL such as http://127.0.0.1:8787",
+		},
+		&cli.StringFlag{
+			Name:  "uploads-host",
+			Usage: "Uploads host, e.g. uploads.pinata.cloud, or a URL",
+		},
+		&cli.StringFlag{
+			Name:  "agents-host",
+			Usage: "Agents API host, e.g. agents.pinata.cloud, or a URL",
+		},
+		&cli.StringFlag{
+			Name:  "model",
+			Usage: "Default model for agent chat",
+		},
+		&cli.StringFlag{
+			Name:  "proxy",
+			Usage: "Proxy URL for all connections (http, https or socks5)",
+		},
+		&cli.StringFlag{
+			Name:      "ca-file",
+			Usage:     "PEM bundle of extra CA certificates to trust",
+			TakesFile: true,
+		},
+		&cli.StringFlag{
+			Name:      "client-cert",
+			Usage:     "PEM client certificate for mutual TLS",
+			TakesFile: true,
+		},
+		&cli.StringFlag{
+			Name:      "client-key",
+			Usage:     "PEM private key for the client certificate, if it's not in the same file",
+			TakesFile: true,
+		},
+		&cli.StringFlag{
+			Name:  "on-success",
+			Usage: "Shell command run after each upload, with placeholders such as {cid}",
+		},
+		&cli.StringFlag{
+			Name:  "webhook",
+			Usage: "URL each upload response is POSTed to, with placeholders such as {cid}",
+		},
+	}
+}
+
+func applyProfileFlags(ctx *cli.Context, profile *config.Profile) {
+	if ctx.IsSet("network") {
+		profile.Network = ctx.String("network")
+	}
+	if ctx.IsSet("gateway") {
+		profile.Gateway = ctx.String("gateway")
+	}
+	if ctx.IsSet("api-host") {
+		profile.APIHost = ctx.String("api-host")
+	}
+	if ctx.IsSet("uploads-host") {
+		profile.UploadsHost = ctx.String("uploads-host")
+	}
+	if ctx.IsSet("agents-host") {
+		profile.AgentsHost = ctx.String("agents-host")
+	}
+	if ctx.IsSet("model") {
+		profile.ChatModel = ctx.String("model")
+	}
+	if ctx.IsSet("on-success") {
+		profile.OnSuccess = ctx.String("on-success")
+	}
+	if ctx.IsSet("webhook") {
+		profile.Webhook = ctx.String("webhook")
+	}
+	// The connection flags also exist globally, so only the ones given to
+	// this command are saved. Paths are stored absolute so they work from
+	// any directory.
+	local := ctx.LocalFlagNames()
+	if slices.Contains(local, "proxy") {
+		profile.Proxy = ctx.String("proxy")
+	}
+	if slices.Contains(local, "ca-file") {
+		profile.CAFile = absPath(ctx.String("ca-file"))
+	}
+	if slices.Contains(local, "client-cert") {
+		profile.ClientCert = absPath(ctx.String("client-cert"))
+	}
+	if slices.Contains(local, "client-key") {
+		profile.ClientKey = absPath(ctx.String("client-key"))
+	}
+}
+
+// flagOr returns a global flag if it was given, or the value from the
+// environment or active profile
+func flagOr(ctx *cli.Context, name string, fallback func() string) string {
+	if value := ctx.String(name); value != "" {
+		return value
+	}
+	return fallback()
+}
+
+func absPath(path string) string {
+	if path == "" {
+		return ""
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
 	}
+	return path
 }
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/PinataCloud/ipfs-cli/internal/car"
+	"github.com/PinataCloud/ipfs-cli/internal/devserver"
+	"github.com/PinataCloud/ipfs-cli/internal/exitcode"
+	"github.com/PinataCloud/ipfs-cli/pkg/pinata"
+)
+
+// cliArgsEnv makes the test binary run the CLI with the newline separated
+// arguments it holds, so commands are tested end to end in a process of
+// their own
+const cliArgsEnv = "PINATA_TEST_CLI_ARGS"
+
+func TestMain(m *testing.M) {
+	if args, ok := os.LookupEnv(cliArgsEnv); ok {
+		os.Args = append([]string{"pinata"}, strings.Split(args, "\n")...)
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// testCLI runs commands against a dev server, with a config directory of
+// its own
+type testCLI struct {
+	t      *testing.T
+	server *httptest.Server
+	config string
+}
+
+func newCLI(t *testing.T) *testCLI {
+	t.Helper()
+	server := httptest.NewServer(devserver.New(devserver.Options{}).Handler())
+	t.Cleanup(server.Close)
+	return &testCLI{t: t, server: server, config: t.TempDir()}
+}
+
+// run runs the CLI and returns its stdout, stderr and exit code
+func (c *testCLI) run(args ...string) (string, string, int) {
+	c.t.Helper()
+	cmd := exec.Command(os.Args[0])
+	// Settings of the environment running the tests are left out
+	for _, env := range os.Environ() {
+		if !strings.HasPrefix(env, "PINATA_") {
+			cmd.Env = append(cmd.Env, env)
+		}
+	}
+	cmd.Env = append(cmd.Env,
+		cliArgsEnv+"="+strings.Join(args, "\n"),
+		"PINATA_API_HOST="+c.server.URL,
+		"PINATA_UPLOADS_HOST="+c.server.URL,
+		"PINATA_AGENTS_HOST="+c.server.URL,
+		"PINATA_JWT=dev",
+		"PINATA_CONFIG_DIR="+c.config,
+	)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	var exit *exec.ExitError
+	if err != nil && !errors.As(err, &exit) {
+		c.t.Fatal(err)
+	}
+	return stdout.String(), stderr.String(), cmd.ProcessState.ExitCode()
+}
+
+// ok runs the CLI and fails the test unless it succeeds
+func (c *testCLI) ok(args ...string) string {
+	c.t.Helper()
+	stdout, stderr, code := c.run(args...)
+	if code != 0 {
+		c.t.Fatalf("pinata %s exited with %d: %s", strings.Join(args, " "), code, stderr)
+	}
+	return stdout
+}
+
+func (c *testCLI) upload(args ...string) pinata.UploadResult {
+	c.t.Helper()
+	var result pinata.UploadResult
+	if err := json.Unmarshal([]byte(c.ok(append([]string{"upload"}, args...)...)), &result); err != nil {
+		c.t.Fatal(err)
+	}
+	return result
+}
+
+func writeTestFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCLIUploadListDelete(t *testing.T) {
+	c := newCLI(t)
+	data := []byte("uploaded by the CLI\n")
+	uploaded := c.upload("--name", "notes.txt", writeTestFile(t, "a.txt", data))
+	if want := car.Sum(car.CodecRaw, data).String(); uploaded.Cid != want || uploaded.Name != "notes.txt" {
+		t.Errorf("uploaded %+v, want CID %s", uploaded, want)
+	}
+
+	var list pinata.FileList
+	if err := json.Unmarshal([]byte(c.ok("files", "list", "--name", "notes.txt")), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Files) != 1 || list.Files[0].Id != uploaded.Id {
+		t.Errorf("listed %+v", list.Files)
+	}
+
+	c.ok("files", "delete", uploaded.Id)
+	_, stderr, code := c.run("files", "get", uploaded.Id)
+	if code != exitcode.NotFound {
+		t.Errorf("getting a deleted file exited with %d, want %d: %s", code, exitcode.NotFound, stderr)
+	}
+}
+
+func TestCLIUploadTUSParallel(t *testing.T) {
+	c := newCLI(t)
+	data := bytes.Repeat([]byte("resumable"), 8000)
+	uploaded := c.upload("--tus-threshold", "1KB", "--chunk-size", "8KB", "--parallel", "3", writeTestFile(t, "big.bin", data))
+	if want := car.Sum(car.CodecRaw, data).String(); uploaded.Cid != want || uploaded.Size != len(data) {
+		t.Errorf("uploaded %+v, want CID %s", uploaded, want)
+	}
+
+	_, stderr, code := c.run("upload", "--parallel", "0", writeTestFile(t, "small.txt", data[:10]))
+	if code != exitcode.InvalidInput || !strings.Contains(stderr, "--parallel") {
+		t.Errorf("--parallel 0 exited with %d: %s", code, stderr)
+	}
+}
+
+func TestCLISignedLink(t *testing.T) {
+	c := newCLI(t)
+	data := []byte("for your eyes only")
+	uploaded := c.upload("--network", "private", writeTestFile(t, "secret.txt", data))
+
+	c.ok("gateways", "set", "example.mypinata.cloud")
+	link, err := url.Parse(strings.TrimSpace(c.ok("gateways", "link", "--network", "private", uploaded.Cid, "60")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.Host != "example.mypinata.cloud" || link.Query().Get("X-Expires") != "60" {
+		t.Fatalf("link is %s", link)
+	}
+
+	// The dev server accepts signed links whatever host they were made for
+	resp, err := http.Get(c.server.URL + link.RequestURI())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !bytes.Equal(body, data) {
+		t.Errorf("link gave %d %q", resp.StatusCode, body)
+	}
+}
@@ -0,0 +1,664 @@
+package pinata
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+const agentsAPIVersion = "v0"
+
+// agentURL builds the URL of an Agents API endpoint under the given
+// collection, such as "agents", "skills" or "secrets".
+func (c *Client) agentURL(collection, path string) string {
+	return c.agentsURL("/%s/%s%s", agentsAPIVersion, collection, path)
+}
+
+// agentDo sends a JSON request to the Agents API and decodes the response.
+func (c *Client) agentDo(ctx context.Context, method, collection, path string, body, result interface{}) error {
+	return c.call(ctx, method, c.agentURL(collection, path), body, result)
+}
+
+// --- Agents ---
+
+// ListAgents retrieves all agents for the authenticated user.
+func (c *Client) ListAgents(ctx context.Context) ([]Agent, error) {
+	var response AgentListResponse
+	err := c.agentDo(ctx, http.MethodGet, "agents", "", nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return response.Agents, nil
+}
+
+// CreateAgent creates a new agent.
+func (c *Client) CreateAgent(ctx context.Context, body CreateAgentBody) (*CreateAgentResponse, error) {
+	var response CreateAgentResponse
+	err := c.agentDo(ctx, http.MethodPost, "agents", "", body, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetAgent retrieves detailed information about a specific agent, including
+// the gateway token used to chat with it.
+func (c *Client) GetAgent(ctx context.Context, agentID string) (*AgentDetailResponse, error) {
+	var response AgentDetailResponse
+	err := c.agentDo(ctx, http.MethodGet, "agents", "/"+url.PathEscape(agentID), nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// DeleteAgent deletes an agent by ID.
+func (c *Client) DeleteAgent(ctx context.Context, agentID string) (*DeleteAgentResponse, error) {
+	var response DeleteAgentResponse
+	err := c.agentDo(ctx, http.MethodDelete, "agents", "/"+url.PathEscape(agentID), nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// RestartAgent restarts an agent by ID.
+func (c *Client) RestartAgent(ctx context.Context, agentID string) (*RestartResponse, error) {
+	var response RestartResponse
+	err := c.agentDo(ctx, http.MethodPost, "agents", "/"+url.PathEscape(agentID)+"/restart", nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// AgentLogs retrieves the logs of a specific agent.
+func (c *Client) AgentLogs(ctx context.Context, agentID string) (string, error) {
+	var response LogsResponse
+	err := c.agentDo(ctx, http.MethodGet, "agents", "/"+url.PathEscape(agentID)+"/logs", nil, &response)
+	if err != nil {
+		return "", err
+	}
+	return response.Logs, nil
+}
+
+// --- Skills ---
+
+// ListSkills retrieves all skills in the user's library.
+func (c *Client) ListSkills(ctx context.Context) ([]Skill, error) {
+	var response SkillListResponse
+	err := c.agentDo(ctx, http.MethodGet, "skills", "", nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return response.Skills, nil
+}
+
+// CreateSkill adds a skill to the user's library.
+func (c *Client) CreateSkill(ctx context.Context, body CreateSkillBody) (*CreateSkillResponse, error) {
+	var response CreateSkillResponse
+	err := c.agentDo(ctx, http.MethodPost, "skills", "", body, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// DeleteSkill deletes a skill by its CID.
+func (c *Client) DeleteSkill(ctx context.Context, skillCid string) (*DeleteSkillResponse, error) {
+	var response DeleteSkillResponse
+	err := c.agentDo(ctx, http.MethodDelete, "skills", "/"+url.PathEscape(skillCid), nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// AttachSkills attaches skills to an agent.
+func (c *Client) AttachSkills(ctx context.Context, agentID string, skillCids []string) (*AddSkillsResponse, error) {
+	var response AddSkillsResponse
+	err := c.agentDo(ctx, http.MethodPost, "agents", "/"+url.PathEscape(agentID)+"/skills", AddSkillsBody{SkillCids: skillCids}, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// DetachSkill detaches a skill from an agent.
+func (c *Client) DetachSkill(ctx context.Context, agentID, skillID string) error {
+	return c.agentDo(ctx, http.MethodDelete, "agents", "/"+url.PathEscape(agentID)+"/skills/"+url.PathEscape(skillID), nil, nil)
+}
+
+// --- Secrets ---
+
+// ListSecrets retrieves all secrets and the agents they're attached to.
+func (c *Client) ListSecrets(ctx context.Context) ([]SecretWithAgents, error) {
+	var response SecretListResponse
+	err := c.agentDo(ctx, http.MethodGet, "secrets", "", nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return response.Secrets, nil
+}
+
+// CreateSecret creates a new secret.
+func (c *Client) CreateSecret(ctx context.Context, name, value string) (*CreateSecretResponse, error) {
+	var response CreateSecretResponse
+	err := c.agentDo(ctx, http.MethodPost, "secrets", "", CreateSecretBody{Name: name, Value: value}, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// UpdateSecret updates an existing secret's value.
+func (c *Client) UpdateSecret(ctx context.Context, secretID, value string) error {
+	return c.agentDo(ctx, http.MethodPut, "secrets", "/"+url.PathEscape(secretID), UpdateSecretBody{Value: value}, nil)
+}
+
+// UpsertSecret updates the secret with the given name, or creates it if
+// there is none.
+func (c *Client) UpsertSecret(ctx context.Context, name, value string) error {
+	secrets, err := c.ListSecrets(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list secrets: %w", err)
+	}
+	for _, s := range secrets {
+		if s.Name == name {
+			return c.UpdateSecret(ctx, s.ID, value)
+		}
+	}
+	_, err = c.CreateSecret(ctx, name, value)
+	return err
+}
+
+// DeleteSecret deletes a secret by ID.
+func (c *Client) DeleteSecret(ctx context.Context, secretID string) error {
+	return c.agentDo(ctx, http.MethodDelete, "secrets", "/"+url.PathEscape(secretID), nil, nil)
+}
+
+// AttachSecrets attaches secrets to an agent.
+func (c *Client) AttachSecrets(ctx context.Context, agentID string, secretIds []string) (*AddSecretsResponse, error) {
+	var response AddSecretsResponse
+	err := c.agentDo(ctx, http.MethodPost, "agents", "/"+url.PathEscape(agentID)+"/secrets", AddSecretsBody{SecretIds: secretIds}, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// DetachSecret detaches a secret from an agent.
+func (c *Client) DetachSecret(ctx context.Context, agentID, secretID string) error {
+	return c.agentDo(ctx, http.MethodDelete, "agents", "/"+url.PathEscape(agentID)+"/secrets/"+url.PathEscape(secretID), nil, nil)
+}
+
+// --- Channels ---
+
+// ChannelStatus retrieves the channel configuration status for an agent.
+func (c *Client) ChannelStatus(ctx context.Context, agentID string) (*ChannelStatusResponse, error) {
+	var response ChannelStatusResponse
+	err := c.agentDo(ctx, http.MethodGet, "agents", "/"+url.PathEscape(agentID)+"/channels", nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ConfigureChannel configures a channel for an agent. channel must be one of
+// telegram, slack, discord or whatsapp.
+func (c *Client) ConfigureChannel(ctx context.Context, agentID, channel string, body ConfigureChannelBody) (*ConfigureChannelResponse, error) {
+	var response ConfigureChannelResponse
+	err := c.agentDo(ctx, http.MethodPost, "agents", "/"+url.PathEscape(agentID)+"/channels/"+url.PathEscape(channel), body, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// RemoveChannel removes a channel configuration from an agent.
+func (c *Client) RemoveChannel(ctx context.Context, agentID, channel string) error {
+	return c.agentDo(ctx, http.MethodDelete, "agents", "/"+url.PathEscape(agentID)+"/channels/"+url.PathEscape(channel), nil, nil)
+}
+
+// --- Devices ---
+
+// ListDevices retrieves the paired and pending devices of an agent.
+func (c *Client) ListDevices(ctx context.Context, agentID string) (*DeviceListResponse, error) {
+	var response DeviceListResponse
+	err := c.agentDo(ctx, http.MethodGet, "agents", "/"+url.PathEscape(agentID)+"/devices", nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ApproveDevice approves a pending device pairing request.
+func (c *Client) ApproveDevice(ctx context.Context, agentID, requestID string) (*ApproveDeviceResponse, error) {
+	var response ApproveDeviceResponse
+	err := c.agentDo(ctx, http.MethodPost, "agents", "/"+url.PathEscape(agentID)+"/devices/"+url.PathEscape(requestID)+"/approve", nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ApproveAllDevices approves all pending device pairing requests.
+func (c *Client) ApproveAllDevices(ctx context.Context, agentID string) (*ApproveAllResponse, error) {
+	var response ApproveAllResponse
+	err := c.agentDo(ctx, http.MethodPost, "agents", "/"+url.PathEscape(agentID)+"/devices/approve-all", nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// --- Snapshots ---
+
+// ListSnapshots retrieves all snapshots of an agent.
+func (c *Client) ListSnapshots(ctx context.Context, agentID string) (*AgentSnapshotsResponse, error) {
+	var response AgentSnapshotsResponse
+	err := c.agentDo(ctx, http.MethodGet, "agents", "/"+url.PathEscape(agentID)+"/snapshots", nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// CreateSnapshot triggers a snapshot sync for an agent.
+func (c *Client) CreateSnapshot(ctx context.Context, agentID string) (*StorageSyncResponse, error) {
+	var response StorageSyncResponse
+	err := c.agentDo(ctx, http.MethodPost, "agents", "/"+url.PathEscape(agentID)+"/snapshots/sync", nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// SyncStatus retrieves the storage sync status of an agent.
+func (c *Client) SyncStatus(ctx context.Context, agentID string) (*StorageStatusResponse, error) {
+	var response StorageStatusResponse
+	err := c.agentDo(ctx, http.MethodGet, "agents", "/"+url.PathEscape(agentID)+"/snapshots/sync", nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ResetSnapshot resets an agent to a specific snapshot.
+func (c *Client) ResetSnapshot(ctx context.Context, agentID, snapshotCid string) (*ResetSnapshotResponse, error) {
+	var response ResetSnapshotResponse
+	err := c.agentDo(ctx, http.MethodPost, "agents", "/"+url.PathEscape(agentID)+"/snapshots/reset", ResetSnapshotBody{SnapshotCid: snapshotCid}, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// --- Port forwarding ---
+
+// ListPorts retrieves the port forwarding rules of an agent.
+func (c *Client) ListPorts(ctx context.Context, agentID string) (*PortForwardingResponse, error) {
+	var response PortForwardingResponse
+	err := c.agentDo(ctx, http.MethodGet, "agents", "/"+url.PathEscape(agentID)+"/port-forwarding", nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// UpdatePorts replaces the port forwarding rules of an agent.
+func (c *Client) UpdatePorts(ctx context.Context, agentID string, mappings []PortForwarding) (*UpdatePortForwardingResponse, error) {
+	var response UpdatePortForwardingResponse
+	err := c.agentDo(ctx, http.MethodPut, "agents", "/"+url.PathEscape(agentID)+"/port-forwarding", UpdatePortForwardingBody{Mappings: mappings}, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// --- Console ---
+
+// ExecCommand executes a command on the agent's console.
+func (c *Client) ExecCommand(ctx context.Context, agentID, command, cwd string) (*ExecResponse, error) {
+	var response ExecResponse
+	err := c.agentDo(ctx, http.MethodPost, "agents", "/"+url.PathEscape(agentID)+"/console/exec", ExecRequest{Command: command, Cwd: cwd}, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ReadAgentFile reads a file from the agent's filesystem.
+func (c *Client) ReadAgentFile(ctx context.Context, agentID, filePath string) ([]byte, error) {
+	resp, err := c.Send(ctx, &Request{
+		Method: http.MethodGet,
+		URL:    c.agentURL("agents", "/"+url.PathEscape(agentID)+"/files?path="+url.QueryEscape(filePath)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	return content, nil
+}
+
+// --- Tasks ---
+
+// ListTasks retrieves the tasks (cron jobs) of an agent.
+func (c *Client) ListTasks(ctx context.Context, agentID string, includeDisabled bool) (interface{}, error) {
+	var result interface{}
+	path := fmt.Sprintf("/%s/tasks?includeDisabled=%t", url.PathEscape(agentID), includeDisabled)
+	err := c.agentDo(ctx, http.MethodGet, "agents", path, nil, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CreateTask creates a task (cron job) for an agent.
+func (c *Client) CreateTask(ctx context.Context, agentID string, body CreateTaskBody) (interface{}, error) {
+	var result interface{}
+	err := c.agentDo(ctx, http.MethodPost, "agents", "/"+url.PathEscape(agentID)+"/tasks", body, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// UpdateTask updates a task (cron job) of an agent.
+func (c *Client) UpdateTask(ctx context.Context, agentID, jobID string, body UpdateTaskBody) (interface{}, error) {
+	var result interface{}
+	err := c.agentDo(ctx, http.MethodPut, "agents", "/"+url.PathEscape(agentID)+"/tasks/"+url.PathEscape(jobID), body, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DeleteTask deletes a task (cron job) of an agent.
+func (c *Client) DeleteTask(ctx context.Context, agentID, jobID string) error {
+	return c.agentDo(ctx, http.MethodDelete, "agents", "/"+url.PathEscape(agentID)+"/tasks/"+url.PathEscape(jobID), nil, nil)
+}
+
+// ToggleTask enables or disables a task (cron job) of an agent.
+func (c *Client) ToggleTask(ctx context.Context, agentID, jobID string, enabled bool) error {
+	return c.agentDo(ctx, http.MethodPost, "agents", "/"+url.PathEscape(agentID)+"/tasks/"+url.PathEscape(jobID)+"/toggle", ToggleTaskBody{Enabled: enabled}, nil)
+}
+
+// RunTask triggers a task (cron job) to run immediately.
+func (c *Client) RunTask(ctx context.Context, agentID, jobID string) error {
+	return c.agentDo(ctx, http.MethodPost, "agents", "/"+url.PathEscape(agentID)+"/tasks/"+url.PathEscape(jobID)+"/run", nil, nil)
+}
+
+// TaskHistory retrieves up to limit past runs of a task.
+func (c *Client) TaskHistory(ctx context.Context, agentID, jobID string, limit int) (interface{}, error) {
+	var result interface{}
+	path := fmt.Sprintf("/%s/tasks/%s/runs?limit=%d", url.PathEscape(agentID), url.PathEscape(jobID), limit)
+	err := c.agentDo(ctx, http.MethodGet, "agents", path, nil, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// --- Config and updates ---
+
+// AgentConfig retrieves the OpenClaw config of an agent.
+func (c *Client) AgentConfig(ctx context.Context, agentID string) (*ConfigResponse, error) {
+	var response ConfigResponse
+	err := c.agentDo(ctx, http.MethodGet, "agents", "/"+url.PathEscape(agentID)+"/config", nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// SetAgentConfig replaces the OpenClaw config of an agent.
+func (c *Client) SetAgentConfig(ctx context.Context, agentID string, config interface{}) (map[string]interface{}, error) {
+	var response map[string]interface{}
+	err := c.agentDo(ctx, http.MethodPut, "agents", "/"+url.PathEscape(agentID)+"/config", UpdateConfigBody{Config: config}, &response)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// ValidateAgentConfig validates the OpenClaw config of an agent.
+func (c *Client) ValidateAgentConfig(ctx context.Context, agentID string) (*ValidateConfigResponse, error) {
+	var response ValidateConfigResponse
+	err := c.agentDo(ctx, http.MethodPost, "agents", "/"+url.PathEscape(agentID)+"/config/validate", nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// CheckAgentUpdate checks whether an OpenClaw update is available for an agent.
+func (c *Client) CheckAgentUpdate(ctx context.Context, agentID string) (*UpdateCheckResponse, error) {
+	var response UpdateCheckResponse
+	err := c.agentDo(ctx, http.MethodGet, "agents", "/"+url.PathEscape(agentID)+"/update", nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ApplyAgentUpdate updates an agent to the given OpenClaw tag, or the latest
+// when tag is empty.
+func (c *Client) ApplyAgentUpdate(ctx context.Context, agentID, tag string) (*UpdateApplyResponse, error) {
+	var body *UpdateApplyBody
+	if tag != "" {
+		body = &UpdateApplyBody{Tag: tag}
+	}
+
+	var response UpdateApplyResponse
+	err := c.agentDo(ctx, http.MethodPost, "agents", "/"+url.PathEscape(agentID)+"/update", body, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// AgentVersions retrieves the OpenClaw versions an agent can be updated to.
+func (c *Client) AgentVersions(ctx context.Context, agentID string) (*VersionsResponse, error) {
+	var response VersionsResponse
+	err := c.agentDo(ctx, http.MethodGet, "agents", "/"+url.PathEscape(agentID)+"/available-agent-versions", nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// --- Custom domains ---
+
+// ListDomains retrieves the custom domains of an agent.
+func (c *Client) ListDomains(ctx context.Context, agentID string) (*CustomDomainListResponse, error) {
+	var response CustomDomainListResponse
+	err := c.agentDo(ctx, http.MethodGet, "agents", "/"+url.PathEscape(agentID)+"/domains", nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// CreateDomain registers a custom domain for an agent.
+func (c *Client) CreateDomain(ctx context.Context, agentID string, body CreateCustomDomainBody) (*CreateCustomDomainResponse, error) {
+	var response CreateCustomDomainResponse
+	err := c.agentDo(ctx, http.MethodPost, "agents", "/"+url.PathEscape(agentID)+"/domains", body, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// UpdateDomain updates a custom domain mapping.
+func (c *Client) UpdateDomain(ctx context.Context, agentID, domainID string, body UpdateCustomDomainBody) (*UpdateCustomDomainResponse, error) {
+	var response UpdateCustomDomainResponse
+	err := c.agentDo(ctx, http.MethodPut, "agents", "/"+url.PathEscape(agentID)+"/domains/"+url.PathEscape(domainID), body, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// DeleteDomain removes a custom domain mapping.
+func (c *Client) DeleteDomain(ctx context.Context, agentID, domainID string) (*DeleteCustomDomainResponse, error) {
+	var response DeleteCustomDomainResponse
+	err := c.agentDo(ctx, http.MethodDelete, "agents", "/"+url.PathEscape(agentID)+"/domains/"+url.PathEscape(domainID), nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// --- Templates ---
+
+// ListTemplatesOptions filters ListTemplates.
+type ListTemplatesOptions struct {
+	Category string
+	Featured bool
+	// SubmittedByMe lists the user's own submissions instead of published
+	// templates
+	SubmittedByMe bool
+}
+
+// ListTemplates retrieves templates.
+func (c *Client) ListTemplates(ctx context.Context, opts ListTemplatesOptions) (*TemplateListResponse, error) {
+	query := url.Values{}
+	setParam(query, "category", opts.Category)
+	if opts.Featured {
+		query.Set("featured", "true")
+	}
+	if opts.SubmittedByMe {
+		query.Set("submittedBy", "me")
+	}
+
+	var response TemplateListResponse
+	err := c.agentDo(ctx, http.MethodGet, "templates", encodeQuery(query), nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetTemplate retrieves a template by its slug.
+func (c *Client) GetTemplate(ctx context.Context, slug string) (*TemplateDetailResponse, error) {
+	var response TemplateDetailResponse
+	err := c.agentDo(ctx, http.MethodGet, "templates", "/"+url.PathEscape(slug), nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ValidateTemplate validates a git repository for template submission.
+func (c *Client) ValidateTemplate(ctx context.Context, body SubmitTemplateBody) (*ValidateTemplateResponse, error) {
+	var response ValidateTemplateResponse
+	err := c.agentDo(ctx, http.MethodPost, "templates", "/validate", body, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// SubmitTemplate submits a template from a git repository.
+func (c *Client) SubmitTemplate(ctx context.Context, body SubmitTemplateBody) (*SubmitTemplateResponse, error) {
+	var response SubmitTemplateResponse
+	err := c.agentDo(ctx, http.MethodPost, "templates", "", body, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// UpdateTemplate updates a template submission by pulling from its repository
+// again.
+func (c *Client) UpdateTemplate(ctx context.Context, templateID string, body SubmitTemplateBody) (*SubmitTemplateResponse, error) {
+	var response SubmitTemplateResponse
+	err := c.agentDo(ctx, http.MethodPut, "templates", "/"+url.PathEscape(templateID), body, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// DeleteTemplate archives a template submission.
+func (c *Client) DeleteTemplate(ctx context.Context, templateID string) (*DeleteTemplateResponse, error) {
+	var response DeleteTemplateResponse
+	err := c.agentDo(ctx, http.MethodDelete, "templates", "/"+url.PathEscape(templateID), nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ListBranches lists the branches of a public git repository.
+func (c *Client) ListBranches(ctx context.Context, gitURL string) (*BranchesResponse, error) {
+	var response BranchesResponse
+	err := c.agentDo(ctx, http.MethodPost, "templates", "/branches", BranchesBody{GitURL: gitURL}, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// --- ClawHub ---
+
+// ListHubSkillsOptions filters and pages ListHubSkills.
+type ListHubSkillsOptions struct {
+	Category string
+	Sort     string
+	Featured bool
+	Cursor   string
+}
+
+// ListHubSkills retrieves skills from ClawHub.
+func (c *Client) ListHubSkills(ctx context.Context, opts ListHubSkillsOptions) (*HubSkillListResponse, error) {
+	query := url.Values{}
+	setParam(query, "category", opts.Category)
+	setParam(query, "sort", opts.Sort)
+	if opts.Featured {
+		query.Set("featured", "true")
+	}
+	setParam(query, "cursor", opts.Cursor)
+
+	var response HubSkillListResponse
+	err := c.agentDo(ctx, http.MethodGet, "clawhub", encodeQuery(query), nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetHubSkill retrieves a ClawHub skill by slug.
+func (c *Client) GetHubSkill(ctx context.Context, slug string) (*HubSkillDetailResponse, error) {
+	var response HubSkillDetailResponse
+	err := c.agentDo(ctx, http.MethodGet, "clawhub", "/"+url.PathEscape(slug), nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// InstallHubSkill installs a ClawHub skill into the user's library.
+func (c *Client) InstallHubSkill(ctx context.Context, hubSkillID string) (*InstallHubSkillResponse, error) {
+	var response InstallHubSkillResponse
+	err := c.agentDo(ctx, http.MethodPost, "clawhub", "/"+url.PathEscape(hubSkillID)+"/install", nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// --- Feedback ---
+
+// SubmitFeedback sends feedback to the Pinata team.
+func (c *Client) SubmitFeedback(ctx context.Context, message string) error {
+	return c.agentDo(ctx, http.MethodPost, "feedback", "", FeedbackBody{Message: message}, nil)
+}
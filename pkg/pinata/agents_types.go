@@ -1,5 +1,4 @@
-// Package agents provides type definitions for the Pinata Agents API.
-package agents
+package pinata
 
 // AgentStatus represents the possible statuses of an agent.
 type AgentStatus string
@@ -0,0 +1,431 @@
+package pinata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// WebSocket timeouts
+	wsConnectTimeout = 30 * time.Second
+	wsReadTimeout    = 60 * time.Second
+
+	// DefaultChatSession is the session used when ChatRequest.Session is empty
+	DefaultChatSession = "agent:main:cli"
+)
+
+// ChatEventType is the type of a ChatEvent
+type ChatEventType string
+
+const (
+	ChatEventToken      ChatEventType = "token"
+	ChatEventToolCall   ChatEventType = "tool_call"
+	ChatEventToolResult ChatEventType = "tool_result"
+	ChatEventDone       ChatEventType = "done"
+	ChatEventError      ChatEventType = "error"
+)
+
+// ChatEvent is an event from an agent's reply stream
+type ChatEvent struct {
+	Type       ChatEventType
+	Token      string          // For token events
+	ToolCall   *ChatToolCall   // For tool_call events
+	ToolResult *ChatToolResult // For tool_result events
+	Err        error           // For error events
+}
+
+// ChatToolCall is a tool the agent started running
+type ChatToolCall struct {
+	ID        string
+	Name      string
+	Arguments map[string]interface{}
+}
+
+// ChatToolResult is the outcome of a tool call
+type ChatToolResult struct {
+	ToolCallID string
+	Content    string
+	IsError    bool
+}
+
+// ChatRequest is a message sent to an agent
+type ChatRequest struct {
+	// GatewayToken authenticates with the agent's gateway, see GetAgent
+	GatewayToken string
+	// Session keeps separate conversations apart, defaults to
+	// DefaultChatSession
+	Session string
+	Message string
+}
+
+// OpenClawMessage is a frame of the OpenClaw gateway protocol
+type OpenClawMessage struct {
+	Type    string          `json:"type"`              // "req", "res", "event"
+	ID      string          `json:"id,omitempty"`      // Request/response ID
+	Method  string          `json:"method,omitempty"`  // For requests
+	Params  json.RawMessage `json:"params,omitempty"`  // For requests
+	Event   string          `json:"event,omitempty"`   // For events
+	Payload json.RawMessage `json:"payload,omitempty"` // For events/responses
+	OK      *bool           `json:"ok,omitempty"`      // For responses
+	Error   *OpenClawError  `json:"error,omitempty"`   // For error responses
+}
+
+// OpenClawError represents an error in the OpenClaw protocol.
+type OpenClawError struct {
+	Code    interface{} `json:"code"` // Can be string or int
+	Message string      `json:"message"`
+}
+
+// ConnectChallenge is the server's challenge payload
+type ConnectChallenge struct {
+	Nonce string `json:"nonce"`
+	TS    int64  `json:"ts"`
+}
+
+// ConnectParams for the connect request
+type ConnectParams struct {
+	MinProtocol int            `json:"minProtocol"`
+	MaxProtocol int            `json:"maxProtocol"`
+	Client      ConnectClient  `json:"client"`
+	Role        string         `json:"role"`
+	Scopes      []string       `json:"scopes"`
+	Caps        []string       `json:"caps"`
+	Commands    []string       `json:"commands"`
+	Permissions map[string]any `json:"permissions"`
+	Auth        ConnectAuth    `json:"auth"`
+}
+
+// ConnectClient identifies the client
+type ConnectClient struct {
+	ID       string `json:"id"`
+	Version  string `json:"version"`
+	Platform string `json:"platform"`
+	Mode     string `json:"mode"`
+}
+
+// ConnectAuth contains authentication details.
+type ConnectAuth struct {
+	Token string `json:"token"`
+}
+
+// ChatSendParams for the chat.send method
+type ChatSendParams struct {
+	Message        string `json:"message"`
+	IdempotencyKey string `json:"idempotencyKey"`
+	SessionKey     string `json:"sessionKey"`
+}
+
+// AgentEventPayload represents an agent event payload
+type AgentEventPayload struct {
+	Stream     string                 `json:"stream"` // "assistant", "lifecycle", "tool", etc.
+	RunID      string                 `json:"runId"`
+	SessionKey string                 `json:"sessionKey"`
+	Seq        int                    `json:"seq"`
+	TS         int64                  `json:"ts"`
+	Data       map[string]interface{} `json:"data"`
+}
+
+// ChatEventPayload represents a chat event payload
+type ChatEventPayload struct {
+	State      string                 `json:"state"` // "delta", "final"
+	RunID      string                 `json:"runId"`
+	SessionKey string                 `json:"sessionKey"`
+	Seq        int                    `json:"seq"`
+	Message    map[string]interface{} `json:"message"`
+}
+
+// ChatGatewayURL returns the WebSocket URL of an agent's gateway
+func ChatGatewayURL(agentID string) string {
+	return fmt.Sprintf("wss://%s.agents.pinata.cloud", agentID)
+}
+
+// chatURL returns the gateway URL StreamChat connects to. An agents host given
+// with a scheme, such as a local dev server, serves every agent's gateway
+// under /v0/agents/<id>/gateway instead of a subdomain.
+func (c *Client) chatURL(agentID string) string {
+	if !strings.Contains(c.AgentsHost, "://") {
+		return ChatGatewayURL(agentID)
+	}
+	// http becomes ws and https becomes wss
+	base := "ws" + strings.TrimPrefix(baseURL(c.AgentsHost), "http")
+	return base + "/v0/agents/" + url.PathEscape(agentID) + "/gateway"
+}
+
+// StreamChat sends a message to an agent over its gateway WebSocket and
+// streams the reply. The channel ends with a done or error event, or is closed
+// without one when ctx is cancelled.
+func (c *Client) StreamChat(ctx context.Context, agentID string, req ChatRequest) <-chan ChatEvent {
+	events := make(chan ChatEvent, 100)
+
+	go func() {
+		defer close(events)
+		err := c.streamChat(ctx, agentID, req, events)
+		if err != nil && ctx.Err() == nil {
+			events <- ChatEvent{Type: ChatEventError, Err: err}
+		}
+	}()
+
+	return events
+}
+
+// streamChat runs a chat exchange, sending events until the reply is done.
+// Errors are returned rather than sent.
+func (c *Client) streamChat(ctx context.Context, agentID string, req ChatRequest, events chan<- ChatEvent) error {
+	if req.Message == "" {
+		return errors.New("no user message found")
+	}
+
+	gatewayURL := c.chatURL(agentID)
+	header := http.Header{}
+	header.Set("Origin", "https://agents.pinata.cloud")
+	if req.GatewayToken != "" {
+		header.Set("Authorization", "Bearer "+req.GatewayToken)
+	}
+
+	dialer := c.Dialer
+	if dialer == nil {
+		dialer = &websocket.Dialer{
+			Proxy:            http.ProxyFromEnvironment,
+			HandshakeTimeout: wsConnectTimeout,
+		}
+	}
+
+	started := time.Now()
+	conn, resp, err := dialer.DialContext(ctx, gatewayURL, header)
+	var tracer FrameTracer
+	if c.TraceWebSocket != nil {
+		tracer = c.TraceWebSocket(gatewayURL, header, resp, started, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer conn.Close()
+
+	// Close the connection when the context is cancelled to unblock reads
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	frames := &frameConn{conn: conn, tracer: tracer}
+
+	// Step 1: Wait for connect.challenge event
+	var challengeMsg OpenClawMessage
+	if err := frames.read(&challengeMsg); err != nil {
+		return fmt.Errorf("failed to read challenge: %w", err)
+	}
+	if challengeMsg.Type != "event" || challengeMsg.Event != "connect.challenge" {
+		if challengeMsg.Type == "event" && challengeMsg.Event == "error" {
+			return fmt.Errorf("server error: %s", string(challengeMsg.Payload))
+		}
+		return fmt.Errorf("expected connect.challenge, got: type=%s event=%s", challengeMsg.Type, challengeMsg.Event)
+	}
+
+	// Step 2: Send connect request
+	err = frames.write(OpenClawMessage{
+		Type:   "req",
+		ID:     "connect-1",
+		Method: "connect",
+		Params: mustMarshal(ConnectParams{
+			MinProtocol: 3,
+			MaxProtocol: 3,
+			Client: ConnectClient{
+				ID:       "cli",
+				Version:  "1.0.0",
+				Platform: "darwin",
+				Mode:     "cli",
+			},
+			Role:        "operator",
+			Scopes:      []string{"operator.read", "operator.write"},
+			Caps:        []string{},
+			Commands:    []string{},
+			Permissions: map[string]any{},
+			Auth:        ConnectAuth{Token: req.GatewayToken},
+		}),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send connect: %w", err)
+	}
+
+	// Step 3: Wait for connect response
+	var connectResp OpenClawMessage
+	if err := frames.read(&connectResp); err != nil {
+		return fmt.Errorf("failed to read connect response: %w", err)
+	}
+	if connectResp.Type != "res" || connectResp.ID != "connect-1" {
+		return fmt.Errorf("unexpected response: type=%s id=%s", connectResp.Type, connectResp.ID)
+	}
+	if connectResp.OK != nil && !*connectResp.OK {
+		return openClawError(connectResp.Error, "connect failed")
+	}
+
+	// Step 4: Send chat.send request
+	sessionKey := req.Session
+	if sessionKey == "" {
+		sessionKey = DefaultChatSession
+	}
+	err = frames.write(OpenClawMessage{
+		Type:   "req",
+		ID:     "chat-1",
+		Method: "chat.send",
+		Params: mustMarshal(ChatSendParams{
+			Message:        req.Message,
+			IdempotencyKey: uuid.New().String(),
+			SessionKey:     sessionKey,
+		}),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+
+	// Step 5: Read streaming responses
+	for {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		var msg OpenClawMessage
+		if err := frames.read(&msg); err != nil {
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				return nil
+			}
+			// A quiet agent is still working, keep waiting
+			if netErr, ok := err.(interface{ Timeout() bool }); ok && netErr.Timeout() {
+				continue
+			}
+			return fmt.Errorf("read error: %w", err)
+		}
+
+		switch msg.Type {
+		case "event":
+			done, err := handleChatEvent(msg, events)
+			if err != nil || done {
+				return err
+			}
+
+		case "res":
+			// Response to our chat request, events follow when it's accepted
+			if msg.ID == "chat-1" && msg.OK != nil && !*msg.OK {
+				return openClawError(msg.Error, "request failed")
+			}
+		}
+	}
+}
+
+// handleChatEvent turns an OpenClaw event into chat events. It reports
+// whether the reply is complete.
+func handleChatEvent(msg OpenClawMessage, events chan<- ChatEvent) (bool, error) {
+	switch msg.Event {
+	case "agent":
+		var payload AgentEventPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return false, nil
+		}
+
+		switch payload.Stream {
+		case "assistant":
+			// Text delta
+			if delta, ok := payload.Data["delta"].(string); ok {
+				events <- ChatEvent{Type: ChatEventToken, Token: delta}
+			}
+
+		case "tool":
+			phase, _ := payload.Data["phase"].(string)
+			toolID, _ := payload.Data["id"].(string)
+			switch phase {
+			case "start":
+				toolName, _ := payload.Data["name"].(string)
+				args, _ := payload.Data["input"].(map[string]interface{})
+				events <- ChatEvent{
+					Type:     ChatEventToolCall,
+					ToolCall: &ChatToolCall{ID: toolID, Name: toolName, Arguments: args},
+				}
+			case "end":
+				result, _ := payload.Data["result"].(string)
+				isError, _ := payload.Data["isError"].(bool)
+				events <- ChatEvent{
+					Type:       ChatEventToolResult,
+					ToolResult: &ChatToolResult{ToolCallID: toolID, Content: result, IsError: isError},
+				}
+			}
+
+		case "lifecycle":
+			if phase, _ := payload.Data["phase"].(string); phase == "end" {
+				events <- ChatEvent{Type: ChatEventDone}
+				return true, nil
+			}
+
+		case "error":
+			errMsg := "agent error"
+			if reason, ok := payload.Data["reason"].(string); ok {
+				errMsg = reason
+			}
+			return true, errors.New(errMsg)
+		}
+
+	case "chat":
+		var payload ChatEventPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return false, nil
+		}
+		if payload.State == "final" {
+			events <- ChatEvent{Type: ChatEventDone}
+			return true, nil
+		}
+
+	case "error":
+		return true, errors.New("server error")
+	}
+
+	// tick, health and presence events are ignored
+	return false, nil
+}
+
+func openClawError(err *OpenClawError, fallback string) error {
+	if err != nil && err.Message != "" {
+		return errors.New(err.Message)
+	}
+	return errors.New(fallback)
+}
+
+// frameConn reads and writes OpenClaw frames, passing them to the tracer
+type frameConn struct {
+	conn   *websocket.Conn
+	tracer FrameTracer
+}
+
+func (f *frameConn) read(v interface{}) error {
+	// Each read has a deadline so a dead connection is noticed
+	f.conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
+	_, data, err := f.conn.ReadMessage()
+	if err != nil {
+		return err
+	}
+	if f.tracer != nil {
+		f.tracer.Received(data)
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (f *frameConn) write(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if f.tracer != nil {
+		f.tracer.Sent(data)
+	}
+	return f.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+func mustMarshal(v interface{}) json.RawMessage {
+	data, _ := json.Marshal(v)
+	return data
+}
@@ -0,0 +1,384 @@
+// Package pinata is a Go client for the Pinata Files, Groups, Keys, Gateways
+// and Agents APIs. It's the same client the pinata CLI is built on.
+//
+//	client := pinata.New(pinata.StaticToken(os.Getenv("PINATA_JWT")))
+//	files, err := client.ListFiles(ctx, pinata.ListFilesOptions{Limit: 10})
+//
+// Every method takes a context, returns typed results and reports responses
+// outside the 2xx range as an *Error and failed connections as a
+// *NetworkError. Requests that are safe to repeat are retried with backoff.
+package pinata
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	DefaultAPIHost     = "api.pinata.cloud"
+	DefaultUploadsHost = "uploads.pinata.cloud"
+	DefaultAgentsHost  = "agents.pinata.cloud"
+
+	DefaultTimeout = 60 * time.Second
+	DefaultRetries = 3
+
+	minBackoff = 500 * time.Millisecond
+	maxBackoff = 30 * time.Second
+	// maxRetryAfter caps the wait requested by a Retry-After header so a
+	// misbehaving server can't stall the client indefinitely
+	maxRetryAfter = 5 * time.Minute
+)
+
+// TokenSource supplies the JWT sent with authenticated requests. It's called
+// for every request, so it may return a refreshed token.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// TokenFunc adapts a function to a TokenSource
+type TokenFunc func(ctx context.Context) (string, error)
+
+func (f TokenFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// StaticToken returns a TokenSource that always returns jwt
+func StaticToken(jwt string) TokenSource {
+	return TokenFunc(func(context.Context) (string, error) {
+		return jwt, nil
+	})
+}
+
+// FrameTracer observes the frames of an agent chat WebSocket
+type FrameTracer interface {
+	Sent(data []byte)
+	Received(data []byte)
+}
+
+// Client sends requests to the Pinata APIs. Its fields may be changed before
+// it's first used.
+type Client struct {
+	HTTPClient *http.Client
+	Tokens     TokenSource
+
+	// Hosts of each API. A bare host name is reached over https, a value
+	// with a scheme such as http://localhost:8080 is used as is.
+	APIHost     string
+	UploadsHost string
+	AgentsHost  string
+
+	// Network is used by methods that are given an empty network, defaults
+	// to public
+	Network string
+
+	// Timeout applies to each attempt, including reading the response body.
+	// Zero disables it. Uploads and streams are never timed out.
+	Timeout time.Duration
+	// Retries is the number of extra attempts made after a transient failure
+	Retries int
+	// OnRetry, when set, is called before waiting to retry a failed attempt.
+	// attempt is the number of the next attempt, out of attempts.
+	OnRetry func(err error, wait time.Duration, attempt, attempts int)
+
+	// Dialer opens agent chat WebSockets. A dialer using the environment's
+	// proxy is used when it's nil.
+	Dialer *websocket.Dialer
+	// TraceWebSocket, when set, is called once the chat WebSocket handshake
+	// has finished, successfully or not. The FrameTracer it returns, if any,
+	// sees every frame.
+	TraceWebSocket func(url string, header http.Header, resp *http.Response, started time.Time, err error) FrameTracer
+}
+
+// New returns a client for the production APIs with the default timeout and
+// retries
+func New(tokens TokenSource) *Client {
+	return &Client{
+		HTTPClient:  &http.Client{},
+		Tokens:      tokens,
+		APIHost:     DefaultAPIHost,
+		UploadsHost: DefaultUploadsHost,
+		AgentsHost:  DefaultAgentsHost,
+		Network:     NetworkPublic,
+		Timeout:     DefaultTimeout,
+		Retries:     DefaultRetries,
+	}
+}
+
+// WithToken returns a copy of the client that authenticates with jwt
+func (c *Client) WithToken(jwt string) *Client {
+	clone := *c
+	clone.Tokens = StaticToken(jwt)
+	return &clone
+}
+
+// Request describes a single API call
+type Request struct {
+	Method string
+	URL    string
+	// JSON is marshalled as the request body when set
+	JSON interface{}
+	// Body is sent as-is when JSON is not set. It's kept in memory so the
+	// request can be replayed on retry.
+	Body        []byte
+	ContentType string
+	Header      http.Header
+	// WrapBody can observe the body as it's sent, e.g. to draw a progress bar
+	WrapBody func(io.Reader) io.Reader
+	// Token overrides the client's token source
+	Token string
+	// NoAuth sends the request without an Authorization header
+	NoAuth bool
+	// NoTimeout disables the per-attempt timeout, for uploads and streams
+	NoTimeout bool
+	// Idempotent allows a POST or PATCH to be retried on any transient
+	// failure. GET, HEAD, PUT, DELETE and OPTIONS always are.
+	Idempotent bool
+}
+
+// Do sends a request and decodes the JSON response into result when it isn't
+// nil. Responses outside the 2xx range are returned as an *Error.
+func (c *Client) Do(ctx context.Context, req *Request, result interface{}) error {
+	resp, err := c.Send(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if result == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	err = json.NewDecoder(resp.Body).Decode(result)
+	if err != nil {
+		return errors.Join(err, errors.New("failed to decode response"))
+	}
+	return nil
+}
+
+// Send sends a request, retrying transient failures, and returns the
+// response for the caller to read and close. Responses outside the 2xx range
+// are returned as an *Error and transport failures as a *NetworkError.
+func (c *Client) Send(ctx context.Context, req *Request) (*http.Response, error) {
+	body := req.Body
+	contentType := req.ContentType
+	if req.JSON != nil {
+		payload, err := json.Marshal(req.JSON)
+		if err != nil {
+			return nil, errors.Join(err, errors.New("failed to marshal request body"))
+		}
+		body = payload
+		contentType = "application/json"
+	}
+	if contentType == "" {
+		contentType = "application/json"
+	}
+
+	token := req.Token
+	if token == "" && !req.NoAuth {
+		jwt, err := c.token(ctx)
+		if err != nil {
+			return nil, err
+		}
+		token = jwt
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, req, body, contentType, token)
+		if err == nil {
+			return resp, nil
+		}
+		if attempt >= c.Retries || !retryable(req, err) {
+			return nil, err
+		}
+
+		wait := backoff(attempt)
+		var apiErr *Error
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+			wait = min(apiErr.RetryAfter, maxRetryAfter)
+		}
+		if c.OnRetry != nil {
+			c.OnRetry(err, wait, attempt+2, c.Retries+1)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (c *Client) token(ctx context.Context) (string, error) {
+	if c.Tokens == nil {
+		return "", errors.New("no token source configured")
+	}
+	return c.Tokens.Token(ctx)
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return c.HTTPClient
+}
+
+// send makes a single attempt
+func (c *Client) send(parent context.Context, req *Request, body []byte, contentType string, token string) (*http.Response, error) {
+	ctx, cancel := parent, context.CancelFunc(func() {})
+	if c.Timeout > 0 && !req.NoTimeout {
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+		if req.WrapBody != nil {
+			reqBody = req.WrapBody(reqBody)
+		}
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, req.URL, reqBody)
+	if err != nil {
+		cancel()
+		return nil, errors.Join(err, errors.New("failed to create the request"))
+	}
+	if body != nil {
+		httpReq.ContentLength = int64(len(body))
+	}
+	for key, values := range req.Header {
+		for _, value := range values {
+			httpReq.Header.Add(key, value)
+		}
+	}
+	httpReq.Header.Set("Content-Type", contentType)
+	if token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.httpClient().Do(httpReq)
+	if err != nil {
+		cancel()
+		// Report the caller's cancellation as is rather than as a network error
+		if ctxErr := parent.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, &NetworkError{Method: req.Method, URL: req.URL, Err: err}
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer cancel()
+		defer resp.Body.Close()
+		return nil, newError(req.Method, req.URL, resp)
+	}
+
+	// Keep the timeout running until the caller has read the body
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// retryable reports whether a failed attempt may be repeated. Requests that
+// may have changed something on the server are only retried when the server
+// says it didn't process them.
+func retryable(req *Request, err error) bool {
+	idempotent := req.Idempotent
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		idempotent = true
+	}
+
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.Status == http.StatusTooManyRequests, apiErr.Status == http.StatusServiceUnavailable:
+			return true
+		case apiErr.Status >= 500:
+			return idempotent
+		}
+		return false
+	}
+
+	var netErr *NetworkError
+	if errors.As(err, &netErr) {
+		return idempotent
+	}
+	return false
+}
+
+// backoff returns an exponential delay with full jitter for the given attempt
+func backoff(attempt int) time.Duration {
+	ceiling := minBackoff << attempt
+	if ceiling <= 0 || ceiling > maxBackoff {
+		ceiling = maxBackoff
+	}
+	return minBackoff/2 + rand.N(ceiling-minBackoff/2)
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as a date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
+
+// baseURL returns the URL for a host, which is https unless it has a scheme
+func baseURL(host string) string {
+	if strings.Contains(host, "://") {
+		return strings.TrimSuffix(host, "/")
+	}
+	return "https://" + host
+}
+
+func (c *Client) apiURL(format string, args ...interface{}) string {
+	return baseURL(c.APIHost) + fmt.Sprintf(format, args...)
+}
+
+func (c *Client) uploadsURL(format string, args ...interface{}) string {
+	return baseURL(c.UploadsHost) + fmt.Sprintf(format, args...)
+}
+
+func (c *Client) agentsURL(format string, args ...interface{}) string {
+	return baseURL(c.AgentsHost) + fmt.Sprintf(format, args...)
+}
+
+// get and friends are shorthands for the common JSON requests
+func (c *Client) get(ctx context.Context, url string, result interface{}) error {
+	return c.Do(ctx, &Request{Method: http.MethodGet, URL: url}, result)
+}
+
+func (c *Client) call(ctx context.Context, method, url string, body interface{}, result interface{}) error {
+	return c.Do(ctx, &Request{Method: method, URL: url, JSON: body}, result)
+}
@@ -0,0 +1,253 @@
+package pinata
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// failingServer answers the first failures requests with status and the
+// rest with 200, counting the attempts it saw
+func failingServer(t *testing.T, status, failures int, header http.Header) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		if int(attempts.Add(1)) <= failures {
+			for key, values := range header {
+				w.Header()[key] = values
+			}
+			w.WriteHeader(status)
+			io.WriteString(w, `{"error": "try again"}`)
+			return
+		}
+		io.WriteString(w, `{"ok": true}`)
+	}))
+	t.Cleanup(server.Close)
+	return server, &attempts
+}
+
+func testClient(retries int) *Client {
+	client := New(StaticToken("test"))
+	client.Retries = retries
+	return client
+}
+
+func TestRetryableStatuses(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		idempotent bool
+		status     int
+		attempts   int32
+	}{
+		{"GET 429", http.MethodGet, false, http.StatusTooManyRequests, 2},
+		{"POST 429", http.MethodPost, false, http.StatusTooManyRequests, 2},
+		{"POST 503", http.MethodPost, false, http.StatusServiceUnavailable, 2},
+		{"GET 500", http.MethodGet, false, http.StatusInternalServerError, 2},
+		{"idempotent POST 500", http.MethodPost, true, http.StatusInternalServerError, 2},
+		// A POST may have been processed before the server failed
+		{"POST 500", http.MethodPost, false, http.StatusInternalServerError, 1},
+		{"PATCH 504", http.MethodPatch, false, http.StatusGatewayTimeout, 1},
+		{"GET 400", http.MethodGet, false, http.StatusBadRequest, 1},
+		{"GET 401", http.MethodGet, false, http.StatusUnauthorized, 1},
+		{"GET 404", http.MethodGet, false, http.StatusNotFound, 1},
+		{"PUT 409", http.MethodPut, false, http.StatusConflict, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			// The server never recovers, so the client gives up after its
+			// retries
+			server, attempts := failingServer(t, tt.status, 100, nil)
+			_, err := testClient(1).Send(context.Background(), &Request{
+				Method:     tt.method,
+				URL:        server.URL,
+				Body:       []byte(`{"name": "x"}`),
+				Idempotent: tt.idempotent,
+			})
+			if StatusCode(err) != tt.status {
+				t.Errorf("got %v, want a %d", err, tt.status)
+			}
+			if got := attempts.Load(); got != tt.attempts {
+				t.Errorf("made %d attempts, want %d", got, tt.attempts)
+			}
+		})
+	}
+}
+
+func TestRetrySucceeds(t *testing.T) {
+	var body []string
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = append(body, string(data))
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, `{"ok": true}`)
+	}))
+	t.Cleanup(server.Close)
+
+	var retries []string
+	client := testClient(3)
+	client.OnRetry = func(err error, wait time.Duration, attempt, total int) {
+		if wait < minBackoff/2 || wait > maxBackoff {
+			t.Errorf("waited %v", wait)
+		}
+		retries = append(retries, strconv.Itoa(attempt)+"/"+strconv.Itoa(total))
+	}
+	var result struct{ OK bool }
+	err := client.Do(context.Background(), &Request{Method: http.MethodPost, URL: server.URL, JSON: map[string]string{"name": "x"}}, &result)
+	if err != nil || !result.OK {
+		t.Fatalf("got %+v, %v", result, err)
+	}
+	if len(retries) != 2 || retries[0] != "2/4" || retries[1] != "3/4" {
+		t.Errorf("OnRetry saw attempts %v, want 2/4 and 3/4", retries)
+	}
+	// The body is sent again in full
+	for i, b := range body {
+		if b != `{"name":"x"}` {
+			t.Errorf("attempt %d sent %q", i+1, b)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter string
+		min, max   time.Duration
+	}{
+		{"seconds", "1", time.Second, time.Second},
+		// Dates have a precision of a second
+		{"date", time.Now().Add(2 * time.Second).UTC().Format(http.TimeFormat), time.Millisecond, 2 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			server, attempts := failingServer(t, http.StatusTooManyRequests, 1, http.Header{"Retry-After": {tt.retryAfter}})
+			client := testClient(1)
+			var waited time.Duration
+			client.OnRetry = func(err error, wait time.Duration, attempt, total int) {
+				waited = wait
+			}
+
+			started := time.Now()
+			resp, err := client.Send(context.Background(), &Request{Method: http.MethodGet, URL: server.URL})
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if waited < tt.min || waited > tt.max {
+				t.Errorf("Retry-After %s waited %v, want between %v and %v", tt.retryAfter, waited, tt.min, tt.max)
+			}
+			if elapsed := time.Since(started); elapsed < waited {
+				t.Errorf("retried after %v, before the %v asked for", elapsed, waited)
+			}
+			if attempts.Load() != 2 {
+				t.Errorf("made %d attempts, want 2", attempts.Load())
+			}
+		})
+	}
+}
+
+func TestRetryAfterIsCapped(t *testing.T) {
+	server, _ := failingServer(t, http.StatusTooManyRequests, 1, http.Header{"Retry-After": {"86400"}})
+	ctx, cancel := context.WithCancel(context.Background())
+	client := testClient(1)
+	var waited time.Duration
+	client.OnRetry = func(err error, wait time.Duration, attempt, total int) {
+		waited = wait
+		cancel()
+	}
+
+	_, err := client.Send(ctx, &Request{Method: http.MethodGet, URL: server.URL})
+	if waited != maxRetryAfter {
+		t.Errorf("waited %v, want the cap of %v", waited, maxRetryAfter)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want the cancellation", err)
+	}
+}
+
+func TestBackoffRespectsContext(t *testing.T) {
+	server, attempts := failingServer(t, http.StatusServiceUnavailable, 100, http.Header{"Retry-After": {"60"}})
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	_, err := testClient(3).Send(ctx, &Request{Method: http.MethodGet, URL: server.URL})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want the deadline", err)
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("returned after %v, the backoff wasn't interrupted", elapsed)
+	}
+	if attempts.Load() != 1 {
+		t.Errorf("made %d attempts, want 1", attempts.Load())
+	}
+}
+
+func TestRetryNetworkErrors(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	for method, want := range map[string]int{http.MethodGet: 1, http.MethodPost: 0} {
+		t.Run(method, func(t *testing.T) {
+			t.Parallel()
+			client := testClient(1)
+			retries := 0
+			client.OnRetry = func(error, time.Duration, int, int) { retries++ }
+			_, err := client.Send(context.Background(), &Request{Method: method, URL: url})
+			var netErr *NetworkError
+			if !errors.As(err, &netErr) {
+				t.Errorf("got %v, want a network error", err)
+			}
+			if retries != want {
+				t.Errorf("retried %d times, want %d", retries, want)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value    string
+		min, max time.Duration
+	}{
+		{"", 0, 0},
+		{"0", 0, 0},
+		{"-5", 0, 0},
+		{"120", 2 * time.Minute, 2 * time.Minute},
+		{"soon", 0, 0},
+		{"1.5", 0, 0},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, 0},
+		{time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), 59 * time.Minute, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value); got < tt.min || got > tt.max {
+			t.Errorf("parseRetryAfter(%q) = %v, want between %v and %v", tt.value, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt := range 70 {
+		ceiling := min(maxBackoff, minBackoff<<min(attempt, 20))
+		for range 50 {
+			if got := backoff(attempt); got < minBackoff/2 || got >= ceiling {
+				t.Fatalf("backoff(%d) = %v, want between %v and %v", attempt, got, minBackoff/2, ceiling)
+			}
+		}
+	}
+}
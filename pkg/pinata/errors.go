@@ -0,0 +1,208 @@
+package pinata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Error categories returned by the ErrorCode method of *Error and
+// *NetworkError
+const (
+	CodeError        = "error"
+	CodeInvalidInput = "invalid_input"
+	CodeUnauthorized = "unauthorized"
+	CodeNotFound     = "not_found"
+	CodeRateLimited  = "rate_limited"
+	CodeNetwork      = "network_error"
+	CodeServer       = "server_error"
+	CodeConflict     = "conflict"
+)
+
+// maxErrorBody limits how much of an error response is kept
+const maxErrorBody = 64 * 1024
+
+// Error is a response outside the 2xx range
+type Error struct {
+	Method string
+	URL    string
+	Status int
+	// Message is the error reported by the server, if it could be found in
+	// the body
+	Message string
+	// Body is the raw response body
+	Body      string
+	RequestID string
+	// RetryAfter is the wait requested by the server, if any
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("server returned an error %d", e.Status)
+	if text := http.StatusText(e.Status); text != "" {
+		msg += " " + text
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.RequestID != "" {
+		msg += fmt.Sprintf(" (request ID %s)", e.RequestID)
+	}
+	return msg
+}
+
+// ErrorCode sorts the error by its HTTP status
+func (e *Error) ErrorCode() string {
+	switch {
+	case e.Status == http.StatusBadRequest, e.Status == http.StatusUnprocessableEntity:
+		return CodeInvalidInput
+	case e.Status == http.StatusUnauthorized, e.Status == http.StatusForbidden:
+		return CodeUnauthorized
+	case e.Status == http.StatusNotFound:
+		return CodeNotFound
+	case e.Status == http.StatusConflict:
+		return CodeConflict
+	case e.Status == http.StatusTooManyRequests:
+		return CodeRateLimited
+	case e.Status >= 500:
+		return CodeServer
+	}
+	return CodeError
+}
+
+// NetworkError is a request that got no response
+type NetworkError struct {
+	Method string
+	URL    string
+	Err    error
+}
+
+func (e *NetworkError) Error() string {
+	host := e.URL
+	if u, err := url.Parse(e.URL); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	if errors.Is(e.Err, context.DeadlineExceeded) {
+		return fmt.Sprintf("request to %s timed out", host)
+	}
+	return fmt.Sprintf("failed to reach %s: %s", host, unwrapURLError(e.Err))
+}
+
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
+func (e *NetworkError) ErrorCode() string {
+	return CodeNetwork
+}
+
+// StatusCode returns the HTTP status of an *Error anywhere in err's chain, or
+// 0 if there is none
+func StatusCode(err error) int {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Status
+	}
+	return 0
+}
+
+func newError(method, rawURL string, resp *http.Response) *Error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	body := string(data)
+	return &Error{
+		Method:     method,
+		URL:        rawURL,
+		Status:     resp.StatusCode,
+		Message:    errorMessage(body),
+		Body:       body,
+		RequestID:  RequestID(resp.Header),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+}
+
+// errorMessage finds the error in the body of a response. The Pinata APIs use
+// {"error": "..."}, {"error": {"reason": "...", "details": "..."}},
+// {"error": {"message": "..."}} and {"message": "..."}.
+func errorMessage(body string) string {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return ""
+	}
+
+	var parsed struct {
+		Error   json.RawMessage `json:"error"`
+		Message string          `json:"message"`
+		Details string          `json:"details"`
+	}
+	if err := json.Unmarshal([]byte(body), &parsed); err != nil {
+		// Plain text, unless it's an HTML error page from a proxy
+		if strings.HasPrefix(body, "<") {
+			return ""
+		}
+		return truncate(body, 500)
+	}
+
+	if len(parsed.Error) > 0 {
+		var text string
+		if err := json.Unmarshal(parsed.Error, &text); err == nil && text != "" {
+			return joinDetails(text, parsed.Details)
+		}
+		var nested struct {
+			Reason  string `json:"reason"`
+			Message string `json:"message"`
+			Details string `json:"details"`
+		}
+		if err := json.Unmarshal(parsed.Error, &nested); err == nil {
+			if nested.Message != "" {
+				return joinDetails(nested.Message, nested.Details)
+			}
+			if nested.Reason != "" {
+				return joinDetails(nested.Reason, nested.Details)
+			}
+		}
+	}
+	if parsed.Message != "" {
+		return joinDetails(parsed.Message, parsed.Details)
+	}
+	return truncate(body, 500)
+}
+
+func joinDetails(msg, details string) string {
+	if details == "" || details == msg {
+		return msg
+	}
+	return msg + " (" + details + ")"
+}
+
+// RequestID returns the ID the Pinata APIs or their CDN gave a response, for
+// support requests
+func RequestID(header http.Header) string {
+	for _, name := range []string{"X-Request-Id", "X-Amzn-Requestid", "Cf-Ray"} {
+		if value := header.Get(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
+
+// unwrapURLError drops the "Get \"https://...\":" prefix added by net/http,
+// since NetworkError already names the host
+func unwrapURLError(err error) error {
+	if urlErr, ok := err.(*url.Error); ok {
+		return urlErr.Err
+	}
+	return err
+}
@@ -0,0 +1,179 @@
+package pinata
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ListFilesOptions filters and pages ListFiles. Empty fields are ignored.
+type ListFilesOptions struct {
+	Network    string
+	Name       string
+	Cid        string
+	GroupId    string
+	MimeType   string
+	KeyValues  map[string]string
+	CidPending bool
+	Limit      int
+	PageToken  string
+}
+
+// network resolves an empty network to the client's default
+func (c *Client) network(network string) (string, error) {
+	if network == "" {
+		network = c.Network
+	}
+	switch network {
+	case "":
+		return NetworkPublic, nil
+	case NetworkPublic, NetworkPrivate:
+		return network, nil
+	}
+	return "", fmt.Errorf("invalid network: %s. Must be either 'public' or 'private'", network)
+}
+
+// ListFiles lists files, newest first
+func (c *Client) ListFiles(ctx context.Context, opts ListFilesOptions) (*FileList, error) {
+	network, err := c.network(opts.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	setParam(query, "name", opts.Name)
+	setParam(query, "cid", opts.Cid)
+	setParam(query, "group", opts.GroupId)
+	setParam(query, "mimeType", opts.MimeType)
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	setParam(query, "pageToken", opts.PageToken)
+	if opts.CidPending {
+		query.Set("cidPending", "true")
+	}
+	for key, value := range opts.KeyValues {
+		query.Set(fmt.Sprintf("keyvalues[%s]", key), value)
+	}
+
+	var response ListResponse
+	err = c.get(ctx, c.apiURL("/v3/files/%s%s", network, encodeQuery(query)), &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// GetFile returns a file by ID
+func (c *Client) GetFile(ctx context.Context, network, id string) (*File, error) {
+	network, err := c.network(network)
+	if err != nil {
+		return nil, err
+	}
+
+	var response GetFileResponse
+	err = c.get(ctx, c.apiURL("/v3/files/%s/%s", network, url.PathEscape(id)), &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// UpdateFile renames a file
+func (c *Client) UpdateFile(ctx context.Context, network, id, name string) (*File, error) {
+	network, err := c.network(network)
+	if err != nil {
+		return nil, err
+	}
+
+	var response GetFileResponse
+	err = c.call(ctx, http.MethodPut, c.apiURL("/v3/files/%s/%s", network, url.PathEscape(id)), FileUpdateBody{Name: name}, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// SetFileKeyValues adds or changes key-values on a file. Keys that aren't
+// given keep their values.
+func (c *Client) SetFileKeyValues(ctx context.Context, network, id string, keyValues map[string]string) (*File, error) {
+	network, err := c.network(network)
+	if err != nil {
+		return nil, err
+	}
+
+	var response GetFileResponse
+	err = c.call(ctx, http.MethodPut, c.apiURL("/v3/files/%s/%s", network, url.PathEscape(id)), FileKeyValuesBody{KeyValues: keyValues}, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// DeleteFile deletes a file by ID
+func (c *Client) DeleteFile(ctx context.Context, network, id string) error {
+	network, err := c.network(network)
+	if err != nil {
+		return err
+	}
+	return c.call(ctx, http.MethodDelete, c.apiURL("/v3/files/%s/%s", network, url.PathEscape(id)), nil, nil)
+}
+
+// SwapHistory returns the CIDs a CID has been swapped for on a gateway domain
+func (c *Client) SwapHistory(ctx context.Context, network, cid, domain string) ([]Swap, error) {
+	network, err := c.network(network)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	setParam(query, "domain", domain)
+
+	var response GetSwapHistoryResponse
+	err = c.get(ctx, c.apiURL("/v3/files/%s/swap/%s%s", network, url.PathEscape(cid), encodeQuery(query)), &response)
+	if err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// AddSwap makes gateways serve swapCid in place of cid
+func (c *Client) AddSwap(ctx context.Context, network, cid, swapCid string) (*Swap, error) {
+	network, err := c.network(network)
+	if err != nil {
+		return nil, err
+	}
+
+	var response AddSwapResponse
+	err = c.call(ctx, http.MethodPut, c.apiURL("/v3/files/%s/swap/%s", network, url.PathEscape(cid)), AddSwapBody{SwapCid: swapCid}, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// RemoveSwap removes the swap for a CID
+func (c *Client) RemoveSwap(ctx context.Context, network, cid string) error {
+	network, err := c.network(network)
+	if err != nil {
+		return err
+	}
+	return c.call(ctx, http.MethodDelete, c.apiURL("/v3/files/%s/swap/%s", network, url.PathEscape(cid)), nil, nil)
+}
+
+func setParam(query url.Values, key, value string) {
+	if value != "" {
+		query.Set(key, value)
+	}
+}
+
+// encodeQuery returns the query string with its leading ?, or nothing when
+// it's empty
+func encodeQuery(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+	return "?" + query.Encode()
+}
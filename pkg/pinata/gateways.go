@@ -0,0 +1,45 @@
+package pinata
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ListGateways returns the account's dedicated gateways. Domain is the
+// subdomain of mypinata.cloud.
+func (c *Client) ListGateways(ctx context.Context) ([]Gateway, error) {
+	var response GetGatewaysResponse
+	err := c.get(ctx, c.apiURL("/v3/ipfs/gateways"), &response)
+	if err != nil {
+		return nil, err
+	}
+	return response.Data.Rows, nil
+}
+
+// SignURL returns a temporary link to a gateway URL of a private file, such
+// as https://example.mypinata.cloud/files/<cid>, valid for expires seconds
+func (c *Client) SignURL(ctx context.Context, gatewayURL string, expires int) (string, error) {
+	body := GetSignedURLBody{
+		URL:     gatewayURL,
+		Expires: expires,
+		Date:    time.Now().Unix(),
+		Method:  http.MethodGet,
+	}
+
+	var response GetSignedURLResponse
+	err := c.Do(ctx, &Request{
+		Method: http.MethodPost,
+		URL:    c.apiURL("/v3/files/private/download_link"),
+		JSON:   body,
+		// Signing a link changes nothing on the server
+		Idempotent: true,
+	}, &response)
+	if err != nil {
+		return "", err
+	}
+
+	link := strings.ReplaceAll(response.Data, "\\u0026", "&")
+	return strings.Trim(link, "\""), nil
+}
@@ -0,0 +1,110 @@
+package pinata
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ListGroupsOptions filters and pages ListGroups. Empty fields are ignored.
+type ListGroupsOptions struct {
+	Network   string
+	Name      string
+	Limit     int
+	PageToken string
+}
+
+// ListGroups lists groups
+func (c *Client) ListGroups(ctx context.Context, opts ListGroupsOptions) (*GroupList, error) {
+	network, err := c.network(opts.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	setParam(query, "name", opts.Name)
+	setParam(query, "pageToken", opts.PageToken)
+
+	var response GroupListResponse
+	err = c.get(ctx, c.apiURL("/v3/groups/%s%s", network, encodeQuery(query)), &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// GetGroup returns a group by ID
+func (c *Client) GetGroup(ctx context.Context, network, id string) (*Group, error) {
+	network, err := c.network(network)
+	if err != nil {
+		return nil, err
+	}
+
+	var response GroupResponse
+	err = c.get(ctx, c.apiURL("/v3/groups/%s/%s", network, url.PathEscape(id)), &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// CreateGroup creates a group
+func (c *Client) CreateGroup(ctx context.Context, network, name string) (*Group, error) {
+	network, err := c.network(network)
+	if err != nil {
+		return nil, err
+	}
+
+	var response GroupResponse
+	err = c.call(ctx, http.MethodPost, c.apiURL("/v3/groups/%s", network), GroupCreateBody{Name: name}, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// UpdateGroup renames a group
+func (c *Client) UpdateGroup(ctx context.Context, network, id, name string) (*Group, error) {
+	network, err := c.network(network)
+	if err != nil {
+		return nil, err
+	}
+
+	var response GroupResponse
+	err = c.call(ctx, http.MethodPut, c.apiURL("/v3/groups/%s/%s", network, url.PathEscape(id)), GroupCreateBody{Name: name}, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// DeleteGroup deletes a group. The files in it are kept.
+func (c *Client) DeleteGroup(ctx context.Context, network, id string) error {
+	network, err := c.network(network)
+	if err != nil {
+		return err
+	}
+	return c.call(ctx, http.MethodDelete, c.apiURL("/v3/groups/%s/%s", network, url.PathEscape(id)), nil, nil)
+}
+
+// AddFileToGroup adds a file to a group
+func (c *Client) AddFileToGroup(ctx context.Context, network, groupId, fileId string) error {
+	network, err := c.network(network)
+	if err != nil {
+		return err
+	}
+	return c.call(ctx, http.MethodPut, c.apiURL("/v3/groups/%s/%s/ids/%s", network, url.PathEscape(groupId), url.PathEscape(fileId)), nil, nil)
+}
+
+// RemoveFileFromGroup removes a file from a group
+func (c *Client) RemoveFileFromGroup(ctx context.Context, network, groupId, fileId string) error {
+	network, err := c.network(network)
+	if err != nil {
+		return err
+	}
+	return c.call(ctx, http.MethodDelete, c.apiURL("/v3/groups/%s/%s/ids/%s", network, url.PathEscape(groupId), url.PathEscape(fileId)), nil, nil)
+}
@@ -0,0 +1,61 @@
+package pinata
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// ListKeysOptions filters and pages ListKeys
+type ListKeysOptions struct {
+	Name       string
+	Revoked    bool
+	LimitedUse bool
+	Exhausted  bool
+	Offset     string
+}
+
+// ListKeys lists API keys
+func (c *Client) ListKeys(ctx context.Context, opts ListKeysOptions) (*KeyList, error) {
+	query := url.Values{}
+	setParam(query, "name", opts.Name)
+	if opts.Revoked {
+		query.Set("revoked", "true")
+	}
+	if opts.LimitedUse {
+		query.Set("limitedUse", "true")
+	}
+	if opts.Exhausted {
+		query.Set("exhausted", "true")
+	}
+	setParam(query, "offset", opts.Offset)
+
+	var response KeyList
+	err := c.get(ctx, c.apiURL("/v3/pinata/keys%s", encodeQuery(query)), &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// CreateKey creates an API key. The JWT and secret in the response can't be
+// retrieved again.
+func (c *Client) CreateKey(ctx context.Context, body CreateKeyBody) (*CreateKeyResponse, error) {
+	var response CreateKeyResponse
+	err := c.call(ctx, http.MethodPost, c.apiURL("/v3/pinata/keys"), body, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// RevokeKey revokes an API key by its key ID
+func (c *Client) RevokeKey(ctx context.Context, id string) error {
+	return c.call(ctx, http.MethodPut, c.apiURL("/v3/pinata/keys/%s", url.PathEscape(id)), nil, nil)
+}
+
+// TestAuthentication checks that the client's JWT is accepted. A rejected JWT
+// is returned as an *Error with a 401 or 403 status.
+func (c *Client) TestAuthentication(ctx context.Context) error {
+	return c.get(ctx, c.apiURL("/data/testAuthentication"), nil)
+}
@@ -0,0 +1,95 @@
+package pinata
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Statuses of a pin job. The first three mean the job is still working, the
+// rest that it failed.
+const (
+	PinStatusPrechecking   = "prechecking"
+	PinStatusSearching     = "searching"
+	PinStatusRetrieving    = "retrieving"
+	PinStatusExpired       = "expired"
+	PinStatusOverFreeLimit = "over_free_limit"
+	PinStatusOverMaxSize   = "over_max_size"
+	PinStatusInvalidObject = "invalid_object"
+	PinStatusBadHostNode   = "bad_host_node"
+)
+
+// Pending reports whether the job is still looking for or fetching its
+// content
+func (j PinJob) Pending() bool {
+	switch j.Status {
+	case PinStatusPrechecking, PinStatusSearching, PinStatusRetrieving:
+		return true
+	}
+	return false
+}
+
+// PinByCIDOptions names and organizes content pinned by CID. Empty fields
+// are ignored.
+type PinByCIDOptions struct {
+	Name      string
+	GroupId   string
+	KeyValues map[string]string
+	// HostNodes are multiaddrs of peers known to have the content, which
+	// speeds up finding it
+	HostNodes []string
+}
+
+// PinByCID queues content already on IPFS to be pinned to the public
+// network. The returned job tracks the progress.
+func (c *Client) PinByCID(ctx context.Context, cid string, opts PinByCIDOptions) (*PinJob, error) {
+	body := PinByCIDBody{
+		Cid:       cid,
+		Name:      opts.Name,
+		GroupId:   opts.GroupId,
+		KeyValues: opts.KeyValues,
+		HostNodes: opts.HostNodes,
+	}
+
+	var response PinByCIDResponse
+	err := c.call(ctx, http.MethodPost, c.uploadsURL("/v3/files/public/pin_by_cid"), body, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// ListPinJobsOptions filters and pages ListPinJobs. Empty fields are ignored.
+type ListPinJobsOptions struct {
+	Cid    string
+	Status string
+	// Sort is ASC or DESC by the time jobs were queued
+	Sort      string
+	Limit     int
+	PageToken string
+}
+
+// ListPinJobs lists the pin jobs still in the queue
+func (c *Client) ListPinJobs(ctx context.Context, opts ListPinJobsOptions) (*PinJobList, error) {
+	query := url.Values{}
+	setParam(query, "cid", opts.Cid)
+	setParam(query, "status", opts.Status)
+	setParam(query, "sort", opts.Sort)
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	setParam(query, "pageToken", opts.PageToken)
+
+	var response PinJobListResponse
+	err := c.get(ctx, c.apiURL("/v3/files/public/pin_by_cid%s", encodeQuery(query)), &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// CancelPinJob removes a job from the queue
+func (c *Client) CancelPinJob(ctx context.Context, id string) error {
+	return c.call(ctx, http.MethodDelete, c.apiURL("/v3/files/public/pin_by_cid/%s", url.PathEscape(id)), nil, nil)
+}
@@ -0,0 +1,123 @@
+package pinata
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// rateLimiter spreads the bytes of an upload over time so they don't go
+// faster than rate bytes per second. It's shared by the requests of an
+// upload, so parallel requests share the rate.
+type rateLimiter struct {
+	rate float64
+
+	mu sync.Mutex
+	// next is when the bytes sent so far have all been paid for
+	next time.Time
+}
+
+// newRateLimiter returns a limiter for rate bytes per second, or nil when
+// rate isn't positive
+func newRateLimiter(rate int64) *rateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &rateLimiter{rate: float64(rate)}
+}
+
+// wait blocks until n more bytes fit in the rate, or ctx is done
+func (l *rateLimiter) wait(ctx context.Context, n int) error {
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	l.next = l.next.Add(time.Duration(float64(n) / l.rate * float64(time.Second)))
+	delay := l.next.Sub(now)
+	l.mu.Unlock()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// reader returns r limited to the rate, or r itself for a nil limiter. Reads
+// fail once ctx is done.
+func (l *rateLimiter) reader(ctx context.Context, r io.Reader) io.Reader {
+	if l == nil {
+		return r
+	}
+	// Small reads keep the pace even, as each waits a tenth of a second at
+	// most
+	size := min(max(int(l.rate/10), 512), 64<<10)
+	return &limitedReader{ctx: ctx, r: r, limiter: l, size: size}
+}
+
+type limitedReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *rateLimiter
+	size    int
+}
+
+func (lr *limitedReader) Read(p []byte) (int, error) {
+	if len(p) > lr.size {
+		p = p[:lr.size]
+	}
+	n, err := lr.r.Read(p)
+	if n > 0 {
+		if waitErr := lr.limiter.wait(lr.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+// limitedTransport limits the bodies of the requests it sends, for uploads
+// whose requests are made by another library. That library doesn't take a
+// context, so requests without one are sent with ctx.
+type limitedTransport struct {
+	ctx     context.Context
+	base    http.RoundTripper
+	limiter *rateLimiter
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Context() == context.Background() {
+		req = req.Clone(t.ctx)
+	} else {
+		req = req.Clone(req.Context())
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		body := req.Body
+		req.Body = struct {
+			io.Reader
+			io.Closer
+		}{t.limiter.reader(t.ctx, body), body}
+	}
+	return t.base.RoundTrip(req)
+}
+
+// client returns an HTTP client like base whose requests are sent with ctx
+// and whose request bodies are limited to the rate, or base itself for a nil
+// limiter
+func (l *rateLimiter) client(ctx context.Context, base *http.Client) *http.Client {
+	if l == nil {
+		return base
+	}
+	transport := base.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	limited := *base
+	limited.Transport = &limitedTransport{ctx: ctx, base: transport, limiter: l}
+	return &limited
+}
@@ -0,0 +1,219 @@
+package pinata
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const tusVersion = "1.0.0"
+
+// tusConcatenation reports whether the uploads endpoint supports the TUS
+// concatenation extension, which parallel uploads need
+func (c *Client) tusConcatenation(ctx context.Context) (bool, error) {
+	resp, err := c.Send(ctx, &Request{
+		Method: http.MethodOptions,
+		URL:    c.uploadsURL("/v3/files"),
+		Header: http.Header{"Tus-Resumable": {tusVersion}},
+	})
+	if err != nil {
+		// Servers without TUS discovery don't support the extension either
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return false, ctxErr
+		}
+		return false, nil
+	}
+	resp.Body.Close()
+	for _, extension := range strings.Split(resp.Header.Get("Tus-Extension"), ",") {
+		if strings.TrimSpace(extension) == "concatenation" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// uploadTUSParallel splits a file into parts, sends them as partial TUS
+// uploads at once, then asks the server to concatenate them. It returns the
+// ID of the file made. Each part holds one chunk in memory while it's sent,
+// so this uses up to parts times the chunk size.
+func (c *Client) uploadTUSParallel(ctx context.Context, f *os.File, size int64, parts int, metadata map[string]string, opts UploadOptions, limiter *rateLimiter) (string, error) {
+	endpoint := c.uploadsURL("/v3/files")
+	partSize := (size + int64(parts) - 1) / int64(parts)
+
+	type part struct {
+		location  string
+		url       string
+		offset    int64
+		length    int64
+		committed int64
+	}
+	var all []*part
+	for offset := int64(0); offset < size; offset += partSize {
+		p := &part{offset: offset, length: min(partSize, size-offset)}
+		location, err := c.tusCreate(ctx, endpoint, http.Header{
+			"Upload-Length": {strconv.FormatInt(p.length, 10)},
+			"Upload-Concat": {"partial"},
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to create upload: %w", err)
+		}
+		p.location = location
+		if p.url, err = resolveURL(endpoint, location); err != nil {
+			return "", err
+		}
+		all = append(all, p)
+	}
+
+	// Progress adds up what the parts have committed so far
+	var mu sync.Mutex
+	committed := int64(0)
+	report := func(p *part, offset int64) {
+		mu.Lock()
+		defer mu.Unlock()
+		committed += offset - p.committed
+		p.committed = offset
+		if opts.Progress != nil {
+			opts.Progress(committed, size)
+		}
+		if opts.Chunk != nil {
+			opts.Chunk(committed, size)
+		}
+	}
+	if opts.Progress != nil {
+		opts.Progress(0, size)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errs := make([]error, len(all))
+	var wg sync.WaitGroup
+	for i, p := range all {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := c.tusSend(ctx, f, p.url, p.offset, p.length, opts.chunkSize(), limiter, func(offset int64) {
+				report(p, offset)
+			})
+			if err != nil {
+				errs[i] = err
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		// Parts cancelled because another failed report the failure itself
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return "", fmt.Errorf("failed during upload: %w", err)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	locations := make([]string, len(all))
+	for i, p := range all {
+		locations[i] = p.location
+	}
+	location, err := c.tusCreate(ctx, endpoint, http.Header{
+		"Upload-Concat":   {"final;" + strings.Join(locations, " ")},
+		"Upload-Metadata": {encodeTUSMetadata(metadata)},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to concatenate the upload: %w", err)
+	}
+	return tusFileId(location), nil
+}
+
+// tusCreate creates a TUS upload and returns its location
+func (c *Client) tusCreate(ctx context.Context, endpoint string, header http.Header) (string, error) {
+	header.Set("Tus-Resumable", tusVersion)
+	resp, err := c.Send(ctx, &Request{
+		Method: http.MethodPost,
+		URL:    endpoint,
+		Header: header,
+	})
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return "", errors.New("the server didn't return the upload's location")
+	}
+	return location, nil
+}
+
+// tusSend sends length bytes of f from start to an upload, in chunks of
+// chunkSize, calling committed with the upload's offset after each
+func (c *Client) tusSend(ctx context.Context, f *os.File, uploadURL string, start, length, chunkSize int64, limiter *rateLimiter, committed func(offset int64)) error {
+	buf := make([]byte, min(chunkSize, length))
+	for offset := int64(0); offset < length; {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		chunk := buf[:min(chunkSize, length-offset)]
+		if _, err := f.ReadAt(chunk, start+offset); err != nil {
+			return err
+		}
+		resp, err := c.Send(ctx, &Request{
+			Method:      http.MethodPatch,
+			URL:         uploadURL,
+			Body:        chunk,
+			ContentType: "application/offset+octet-stream",
+			Header: http.Header{
+				"Tus-Resumable": {tusVersion},
+				"Upload-Offset": {strconv.FormatInt(offset, 10)},
+			},
+			WrapBody: func(r io.Reader) io.Reader {
+				return limiter.reader(ctx, r)
+			},
+			NoTimeout: true,
+		})
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		newOffset, err := strconv.ParseInt(resp.Header.Get("Upload-Offset"), 10, 64)
+		if err != nil || newOffset <= offset || newOffset > length {
+			return fmt.Errorf("invalid Upload-Offset %q in the response", resp.Header.Get("Upload-Offset"))
+		}
+		offset = newOffset
+		committed(offset)
+	}
+	return nil
+}
+
+// encodeTUSMetadata encodes an Upload-Metadata header, a comma separated
+// list of keys and base64 values
+func encodeTUSMetadata(metadata map[string]string) string {
+	var pairs []string
+	for key, value := range metadata {
+		pairs = append(pairs, key+" "+base64.StdEncoding.EncodeToString([]byte(value)))
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// resolveURL resolves a location returned by the server against the URL of
+// the request
+func resolveURL(base, location string) (string, error) {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	locationURL, err := url.Parse(location)
+	if err != nil {
+		return "", fmt.Errorf("invalid upload location %q: %w", location, err)
+	}
+	return baseURL.ResolveReference(locationURL).String(), nil
+}
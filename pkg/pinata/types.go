@@ -1,28 +1,29 @@
-package types
-
-type UploadResponse struct {
-	Data struct {
-		Id            string            `json:"id"`
-		Name          string            `json:"name"`
-		Cid           string            `json:"cid"`
-		Size          int               `json:"size"`
-		CreatedAt     string            `json:"created_at"`
-		NumberOfFiles int               `json:"number_of_files"`
-		MimeType      string            `json:"mime_type"`
-		GroupId       *string           `json:"group_id"`
-		KeyValues     map[string]string `json:"keyvalues"`
-		Vectorized    bool              `json:"vectorized"`
-		Network       string            `json:"network,omitempty"`
-		IsDuplicate   bool              `json:"is_duplicate,omitempty"`
-	} `json:"data"`
+package pinata
+
+// Networks files and groups can be stored on
+const (
+	NetworkPublic  = "public"
+	NetworkPrivate = "private"
+)
+
+// UploadResult is a file or folder that was uploaded
+type UploadResult struct {
+	Id            string            `json:"id"`
+	Name          string            `json:"name"`
+	Cid           string            `json:"cid"`
+	Size          int               `json:"size"`
+	CreatedAt     string            `json:"created_at"`
+	NumberOfFiles int               `json:"number_of_files"`
+	MimeType      string            `json:"mime_type"`
+	GroupId       *string           `json:"group_id"`
+	KeyValues     map[string]string `json:"keyvalues"`
+	Vectorized    bool              `json:"vectorized"`
+	Network       string            `json:"network,omitempty"`
+	IsDuplicate   bool              `json:"is_duplicate,omitempty"`
 }
 
-type Options struct {
-	GroupId string `json:"group_id"`
-}
-
-type Metadata struct {
-	Name string `json:"name"`
+type UploadResponse struct {
+	Data UploadResult `json:"data"`
 }
 
 type File struct {
@@ -41,36 +42,40 @@ type FileUpdateBody struct {
 	Name string `json:"name"`
 }
 
+type FileKeyValuesBody struct {
+	KeyValues map[string]string `json:"keyvalues"`
+}
+
 type GetFileResponse struct {
 	Data File `json:"data"`
 }
 
-type ListFilesData struct {
+type FileList struct {
 	Files         []File `json:"files"`
 	NextPageToken string `json:"next_page_token"`
 }
 
 type ListResponse struct {
-	Data ListFilesData `json:"data"`
+	Data FileList `json:"data"`
 }
 
-type GroupResponseItem struct {
+type Group struct {
 	Id        string `json:"id"`
 	Name      string `json:"name"`
 	CreatedAt string `json:"created_at"`
 }
 
+type GroupList struct {
+	Groups        []Group `json:"groups"`
+	NextPageToken string  `json:"next_page_token"`
+}
+
 type GroupListResponse struct {
-	Data struct {
-		Groups        []GroupResponseItem `json:"groups"`
-		NextPageToken string              `json:"next_page_token"`
-	} `json:"data"`
+	Data GroupList `json:"data"`
 }
 
-type GroupCreateResponse struct {
-	Data struct {
-		GroupResponseItem
-	} `json:"data"`
+type GroupResponse struct {
+	Data Group `json:"data"`
 }
 
 type GroupCreateBody struct {
@@ -89,21 +94,24 @@ type GetSignedURLResponse struct {
 	Data string `json:"data"`
 }
 
-type GetGatewayItem struct {
+type Gateway struct {
 	Domain string `json:"domain"`
 }
 
 type GetGatewaysResponse struct {
 	Data struct {
-		Rows []GetGatewayItem
+		Rows []Gateway
 	} `json:"data"`
 }
 
+// Swap maps a CID to another one on a gateway
+type Swap struct {
+	MappedCid string `json:"mapped_cid"`
+	CreatedAt string `json:"created_at"`
+}
+
 type GetSwapHistoryResponse struct {
-	Data []struct {
-		MappedCid string `json:"mapped_cid"`
-		CreatedAt string `json:"created_at"`
-	} `json:"data"`
+	Data []Swap `json:"data"`
 }
 
 type AddSwapBody struct {
@@ -111,10 +119,7 @@ type AddSwapBody struct {
 }
 
 type AddSwapResponse struct {
-	Data struct {
-		MappedCid string `json:"mapped_cid"`
-		CreatedAt string `json:"created_at"`
-	} `json:"data"`
+	Data Swap `json:"data"`
 }
 
 type CreateKeyResponse struct {
@@ -123,7 +128,7 @@ type CreateKeyResponse struct {
 	PinataAPISecret string `json:"pinata_api_secret"`
 }
 
-type CreatKeyBody struct {
+type CreateKeyBody struct {
 	KeyName     string      `json:"keyName"`
 	Permissions Permissions `json:"permissions"`
 	MaxUses     int         `json:"maxUses,omitempty"`
@@ -132,6 +137,7 @@ type CreatKeyBody struct {
 type Permissions struct {
 	Admin     bool      `json:"admin,omitempty"`
 	Endpoints Endpoints `json:"endpoints,omitempty"`
+	Resources []string  `json:"resources,omitempty"`
 }
 
 type Endpoints struct {
@@ -155,12 +161,12 @@ type PinningEndpoints struct {
 	UserPinPolicy bool `json:"userPinPolicy,omitempty"`
 }
 
-type KeyListResponse struct {
-	Keys  []KeyItem `json:"keys"`
-	Count int       `json:"count"`
+type KeyList struct {
+	Keys  []Key `json:"keys"`
+	Count int   `json:"count"`
 }
 
-type KeyItem struct {
+type Key struct {
 	ID        string      `json:"id"`
 	Name      string      `json:"name"`
 	Key       string      `json:"key"`
@@ -183,3 +189,38 @@ type PinataMetadata struct {
 	Name      string            `json:"name"`
 	KeyValues map[string]string `json:"keyvalues,omitempty"`
 }
+
+// PinJob is a request to pin content that's already on IPFS. Jobs leave the
+// queue once the content is pinned, and fail with a status such as expired
+// when it can't be found.
+type PinJob struct {
+	Id         string                 `json:"id"`
+	Cid        string                 `json:"cid"`
+	Name       string                 `json:"name"`
+	Status     string                 `json:"status"`
+	DateQueued string                 `json:"date_queued"`
+	KeyValues  map[string]interface{} `json:"keyvalues"`
+	HostNodes  []string               `json:"host_nodes,omitempty"`
+	GroupId    *string                `json:"group_id,omitempty"`
+}
+
+type PinJobList struct {
+	Jobs          []PinJob `json:"jobs"`
+	NextPageToken string   `json:"next_page_token"`
+}
+
+type PinByCIDBody struct {
+	Cid       string            `json:"cid"`
+	Name      string            `json:"name,omitempty"`
+	GroupId   string            `json:"group_id,omitempty"`
+	KeyValues map[string]string `json:"keyvalues,omitempty"`
+	HostNodes []string          `json:"host_nodes,omitempty"`
+}
+
+type PinByCIDResponse struct {
+	Data PinJob `json:"data"`
+}
+
+type PinJobListResponse struct {
+	Data PinJobList `json:"data"`
+}
@@ -0,0 +1,472 @@
+package pinata
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/eventials/go-tus"
+)
+
+const (
+	// TUSThreshold is the size above which Upload sends a file with the
+	// resumable TUS protocol
+	TUSThreshold = 100 * 1024 * 1024
+	// TUSChunkSize is the size of each TUS request
+	TUSChunkSize = 50*1024*1024 + 1
+)
+
+// ErrFolderPrivate is returned when uploading a folder to the private
+// network, which doesn't support folders
+var ErrFolderPrivate = errors.New("folders are not supported on the private network")
+
+// UploadOptions controls how a file or folder is stored
+type UploadOptions struct {
+	Network string
+	// Name defaults to the name of the file or folder
+	Name      string
+	GroupId   string
+	KeyValues map[string]string
+	// Progress, when set, is called with the bytes sent so far and the total
+	// as the upload is sent. It may be called again from zero if the upload
+	// is retried.
+	Progress func(sent, total int64)
+	// Chunk, when set, is called with the bytes the server has committed
+	// after each chunk of a TUS upload
+	Chunk func(committed, total int64)
+	// RateLimit caps the upload to this many bytes per second when positive
+	RateLimit int64
+	// TUSThreshold overrides the size above which Upload uses TUS
+	TUSThreshold int64
+	// ChunkSize overrides TUSChunkSize
+	ChunkSize int64
+	// Parallel sends a TUS upload as this many partial uploads at once,
+	// which the server concatenates. It's only used when the server supports
+	// the TUS concatenation extension. Each part buffers one chunk, so this
+	// uses up to Parallel times ChunkSize of memory.
+	Parallel int
+}
+
+// UsesTUS reports whether Upload sends a file of size bytes with TUS
+func (o UploadOptions) UsesTUS(size int64) bool {
+	threshold := o.TUSThreshold
+	if threshold <= 0 {
+		threshold = TUSThreshold
+	}
+	return size > threshold
+}
+
+func (o UploadOptions) chunkSize() int64 {
+	if o.ChunkSize <= 0 {
+		return TUSChunkSize
+	}
+	return o.ChunkSize
+}
+
+// Upload uploads a file or folder. Folders are uploaded with UploadFolder,
+// files larger than TUSThreshold, or opts.TUSThreshold, with UploadTUS and
+// any other file with UploadFile.
+func (c *Client) Upload(ctx context.Context, path string, opts UploadOptions) (*UploadResult, error) {
+	stats, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if stats.IsDir() {
+		return c.UploadFolder(ctx, path, opts)
+	}
+	if opts.UsesTUS(stats.Size()) {
+		return c.UploadTUS(ctx, path, opts)
+	}
+	return c.UploadFile(ctx, path, opts)
+}
+
+// UploadFile uploads a file in a single request
+func (c *Client) UploadFile(ctx context.Context, path string, opts UploadOptions) (*UploadResult, error) {
+	network, err := c.network(opts.Network)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	err = writeFormFiles(writer, path, stats, false)
+	if err != nil {
+		return nil, err
+	}
+	err = writeUploadFields(writer, network, nameOr(opts.Name, stats.Name()), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var response UploadResponse
+	err = c.Do(ctx, uploadRequest(ctx, c.uploadsURL("/v3/files"), body, writer.FormDataContentType(), opts), &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// UploadJSON uploads a JSON document as an application/json file. The name
+// defaults to data.json, and the file sent always has a .json extension.
+func (c *Client) UploadJSON(ctx context.Context, data []byte, opts UploadOptions) (*UploadResult, error) {
+	network, err := c.network(opts.Network)
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid(data) {
+		return nil, errors.New("invalid JSON document")
+	}
+
+	name := nameOr(opts.Name, "data.json")
+	filename := name
+	if !strings.EqualFold(filepath.Ext(filename), ".json") {
+		filename += ".json"
+	}
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{"name": "file", "filename": filename}))
+	header.Set("Content-Type", "application/json")
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(data); err != nil {
+		return nil, err
+	}
+	err = writeUploadFields(writer, network, name, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var response UploadResponse
+	err = c.Do(ctx, uploadRequest(ctx, c.uploadsURL("/v3/files"), body, writer.FormDataContentType(), opts), &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// writeUploadFields adds the fields of a v3 upload to a multipart form and
+// closes it
+func writeUploadFields(writer *multipart.Writer, network, name string, opts UploadOptions) error {
+	fields := map[string]string{
+		"network":  network,
+		"group_id": opts.GroupId,
+		"name":     name,
+	}
+	if len(opts.KeyValues) > 0 {
+		keyValues, err := json.Marshal(opts.KeyValues)
+		if err != nil {
+			return err
+		}
+		fields["keyvalues"] = string(keyValues)
+	}
+	return writeFields(writer, fields)
+}
+
+// UploadFolder uploads a folder and its contents to the public network as a
+// single CID
+func (c *Client) UploadFolder(ctx context.Context, path string, opts UploadOptions) (*UploadResult, error) {
+	network, err := c.network(opts.Network)
+	if err != nil {
+		return nil, err
+	}
+	if network == NetworkPrivate {
+		return nil, ErrFolderPrivate
+	}
+	stats, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	err = writeFormFiles(writer, path, stats, true)
+	if err != nil {
+		return nil, err
+	}
+
+	optionsBytes, err := json.Marshal(PinataOptions{
+		CidVersion: 1,
+		GroupId:    opts.GroupId,
+	})
+	if err != nil {
+		return nil, err
+	}
+	metadataBytes, err := json.Marshal(PinataMetadata{
+		Name:      nameOr(opts.Name, stats.Name()),
+		KeyValues: opts.KeyValues,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = writeFields(writer, map[string]string{
+		"pinataOptions":  string(optionsBytes),
+		"pinataMetadata": string(metadataBytes),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Folders use the pinning endpoint, which has its own response format
+	var pinningResponse struct {
+		ID            string            `json:"ID"`
+		Name          string            `json:"Name"`
+		IpfsHash      string            `json:"IpfsHash"`
+		PinSize       int               `json:"PinSize"`
+		Timestamp     string            `json:"Timestamp"`
+		NumberOfFiles int               `json:"NumberOfFiles"`
+		MimeType      string            `json:"MimeType"`
+		GroupId       *string           `json:"GroupId"`
+		Keyvalues     map[string]string `json:"Keyvalues"`
+		IsDuplicate   bool              `json:"isDuplicate"`
+	}
+	err = c.Do(ctx, uploadRequest(ctx, c.apiURL("/pinning/pinFileToIPFS"), body, writer.FormDataContentType(), opts), &pinningResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &UploadResult{
+		Id:            pinningResponse.ID,
+		Name:          pinningResponse.Name,
+		Cid:           pinningResponse.IpfsHash,
+		Size:          pinningResponse.PinSize,
+		CreatedAt:     pinningResponse.Timestamp,
+		NumberOfFiles: pinningResponse.NumberOfFiles,
+		MimeType:      pinningResponse.MimeType,
+		GroupId:       pinningResponse.GroupId,
+		KeyValues:     pinningResponse.Keyvalues,
+		Network:       NetworkPublic,
+		IsDuplicate:   pinningResponse.IsDuplicate,
+	}
+	if opts.GroupId != "" {
+		groupId := opts.GroupId
+		result.GroupId = &groupId
+	}
+	return result, nil
+}
+
+// UploadTUS uploads a file in chunks of TUSChunkSize, or opts.ChunkSize, with
+// the TUS protocol. The context is checked between chunks.
+func (c *Client) UploadTUS(ctx context.Context, path string, opts UploadOptions) (*UploadResult, error) {
+	network, err := c.network(opts.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+	stats, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := map[string]string{
+		"filename": nameOr(opts.Name, filepath.Base(path)),
+		"network":  network,
+	}
+	if opts.GroupId != "" {
+		metadata["group_id"] = opts.GroupId
+	}
+	if len(opts.KeyValues) > 0 {
+		keyValues, err := json.Marshal(opts.KeyValues)
+		if err != nil {
+			return nil, err
+		}
+		metadata["keyvalues"] = string(keyValues)
+	}
+
+	limiter := newRateLimiter(opts.RateLimit)
+	parts := min(int64(opts.Parallel), (stats.Size()+opts.chunkSize()-1)/opts.chunkSize())
+	if parts > 1 {
+		supported, err := c.tusConcatenation(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if supported {
+			fileId, err := c.uploadTUSParallel(ctx, f, stats.Size(), int(parts), metadata, opts, limiter)
+			if err != nil {
+				return nil, err
+			}
+			return c.tusResult(ctx, network, fileId)
+		}
+	}
+
+	jwt, err := c.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	client, err := tus.NewClient(c.uploadsURL("/v3/files"), &tus.Config{
+		ChunkSize:  opts.chunkSize(),
+		Resume:     false,
+		Header:     http.Header{"Authorization": {"Bearer " + jwt}},
+		HttpClient: limiter.client(ctx, c.httpClient()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create TUS client: %w", err)
+	}
+
+	uploader, err := client.CreateUpload(tus.NewUpload(f, stats.Size(), metadata, ""))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload: %w", err)
+	}
+
+	if opts.Progress != nil {
+		opts.Progress(0, stats.Size())
+	}
+	for uploader.Offset() < stats.Size() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		err = uploader.UploadChunck()
+		if err != nil {
+			return nil, fmt.Errorf("failed during upload: %w", err)
+		}
+		if opts.Progress != nil {
+			opts.Progress(uploader.Offset(), stats.Size())
+		}
+		if opts.Chunk != nil {
+			opts.Chunk(uploader.Offset(), stats.Size())
+		}
+	}
+
+	return c.tusResult(ctx, network, tusFileId(uploader.Url()))
+}
+
+// tusResult fetches the file a TUS upload made
+func (c *Client) tusResult(ctx context.Context, network, fileId string) (*UploadResult, error) {
+	var response UploadResponse
+	err := c.get(ctx, c.apiURL("/v3/files/%s/%s", network, fileId), &response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch upload response: %w", err)
+	}
+	return &response.Data, nil
+}
+
+// tusFileId returns the file ID in a TUS upload URL, which ends in
+// /<file id>/upload
+func tusFileId(uploadURL string) string {
+	urlParts := strings.Split(uploadURL, "/")
+	return urlParts[len(urlParts)-2]
+}
+
+// uploadRequest builds the request for a multipart upload. Uploads have no
+// timeout and may be retried on any transient failure, since sending the same
+// content again yields the same CID.
+func uploadRequest(ctx context.Context, url string, body *bytes.Buffer, contentType string, opts UploadOptions) *Request {
+	req := &Request{
+		Method:      http.MethodPost,
+		URL:         url,
+		Body:        body.Bytes(),
+		ContentType: contentType,
+		NoTimeout:   true,
+		Idempotent:  true,
+	}
+	limiter := newRateLimiter(opts.RateLimit)
+	progress := opts.Progress
+	if limiter != nil || progress != nil {
+		total := int64(body.Len())
+		req.WrapBody = func(r io.Reader) io.Reader {
+			r = limiter.reader(ctx, r)
+			if progress == nil {
+				return r
+			}
+			progress(0, total)
+			return &progressReader{r: r, total: total, progress: progress}
+		}
+	}
+	return req
+}
+
+type progressReader struct {
+	r        io.Reader
+	sent     int64
+	total    int64
+	progress func(sent, total int64)
+}
+
+func (pr *progressReader) Read(p []byte) (int, error) {
+	n, err := pr.r.Read(p)
+	if n > 0 {
+		pr.sent += int64(n)
+		pr.progress(pr.sent, pr.total)
+	}
+	return n, err
+}
+
+// writeFormFiles adds a file, or every file in a folder under the folder's
+// name, to a multipart form. Folder paths always use forward slashes.
+func writeFormFiles(writer *multipart.Writer, path string, stats os.FileInfo, slashPaths bool) error {
+	if !stats.IsDir() {
+		return writeFormFile(writer, path, filepath.Base(path))
+	}
+	return filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		relPath, err := filepath.Rel(path, file)
+		if err != nil {
+			return err
+		}
+		name := filepath.Join(stats.Name(), relPath)
+		if slashPaths && runtime.GOOS == "windows" {
+			name = strings.ReplaceAll(name, "\\", "/")
+		}
+		return writeFormFile(writer, file, name)
+	})
+}
+
+func writeFormFile(writer *multipart.Writer, path, name string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	part, err := writer.CreateFormFile("file", name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(part, file)
+	return err
+}
+
+// writeFields adds the non-empty fields to a multipart form and closes it
+func writeFields(writer *multipart.Writer, fields map[string]string) error {
+	for key, value := range fields {
+		if value == "" {
+			continue
+		}
+		if err := writer.WriteField(key, value); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+func nameOr(name, fallback string) string {
+	if name == "" {
+		return fallback
+	}
+	return name
+}